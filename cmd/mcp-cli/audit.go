@@ -0,0 +1,78 @@
+// cmd/mcp-cli/audit.go - audit log subcommand
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+)
+
+// runAudit prints audit log entries matching the flags, most recent first
+func runAudit(args []string, cfg *config.Config) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	dataDir := flags.String("data-dir", cfg.Storage.DataDir, "data directory holding the audit log")
+	action := flags.String("action", "", "only entries for this action")
+	id := flags.String("id", "", "only entries naming this memory ID or base ID")
+	client := flags.String("client", "", "only entries from clients whose name contains this text")
+	tool := flags.String("tool", "", "only entries made by this tool")
+	since := flags.String("since", "", "only entries at or after this RFC 3339 time")
+	until := flags.String("until", "", "only entries before this RFC 3339 time")
+	limit := flags.Int("limit", memory.DefaultAuditLimit, "maximum number of entries")
+	verify := flags.Bool("verify", false, "verify the hash chain and exit")
+	asJSON := flags.Bool("json", false, "print entries as JSON lines")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	auditLog, err := memory.OpenAuditLog(*dataDir, &cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	if *verify {
+		checked, err := auditLog.Verify()
+		if err != nil {
+			return fmt.Errorf("verification failed after %d intact entries: %w", checked, err)
+		}
+		fmt.Printf("Verified the hash chain of %d entries\n", checked)
+		return nil
+	}
+
+	query := memory.AuditQuery{Action: *action, ID: *id, Client: *client, Tool: *tool, Limit: *limit}
+	if *since != "" {
+		if query.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if *until != "" {
+		if query.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+
+	entries, err := auditLog.Query(query)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, entry := range entries {
+		if *asJSON {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("%6d  %s  %-11s  %-24s  %-16s  %s  %s\n",
+			entry.Seq, entry.Time.Format(time.RFC3339), entry.Action, entry.Client, entry.Tool,
+			strings.Join(entry.IDs, ","), entry.Detail)
+	}
+	return nil
+}
@@ -21,6 +21,14 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// The audit subcommand reads the audit log and exits
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := runAudit(os.Args[2:], cfg); err != nil {
+			log.Fatalf("audit: %v", err)
+		}
+		return
+	}
+
 	// Initialize logger
 	logger := logger.New(cfg.Logging.Level, cfg.Logging.Format)
 	logger.Info("Starting MCP Memory Server (CLI mode)", "version", "1.0.0")
@@ -33,6 +41,11 @@ func main() {
 
 	// Initialize MCP server
 	mcpServer := mcp.NewServer(memoryStore, logger)
+	for _, name := range cfg.MCP.DisabledTools {
+		if !mcpServer.UnregisterTool(name) {
+			logger.Warn("Cannot disable unknown tool", "tool", name)
+		}
+	}
 
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -22,6 +22,7 @@ func main() {
 	port := flag.Int("port", 9000, "Web server port")
 	host := flag.String("host", "localhost", "Web server host")
 	dataDir := flag.String("data-dir", "", "MCP memory data directory (auto-detected if not specified)")
+	pollInterval := flag.Duration("poll-interval", memory.DefaultWatchInterval, "How often to scan for memory changes where filesystem notifications are unavailable")
 	flag.Parse()
 
 	// Load configuration to get default data directory
@@ -48,9 +49,15 @@ func main() {
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to initialize read-only memory store")
 	}
+	defer memoryStore.Close()
+
+	// Apply memory changes as they happen so the dashboard updates live
+	if err := memoryStore.Watch(*pollInterval); err != nil {
+		logger.WithError(err).Warn("Failed to watch for memory changes")
+	}
 
 	// Initialize reporting server
-	reportingServer := reporting.NewServer(*host, *port, memoryStore, logger)
+	reportingServer := reporting.NewServer(*host, *port, memoryStore, memoryDataDir, logger)
 
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
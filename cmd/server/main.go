@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,6 +13,9 @@ import (
 	"mcp-memory-server/internal/config"
 	"mcp-memory-server/internal/mcp"
 	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/internal/metrics"
+	"mcp-memory-server/internal/replication"
+	"mcp-memory-server/internal/webhook"
 	"mcp-memory-server/pkg/logger"
 )
 
@@ -34,6 +38,11 @@ func main() {
 
 	// Initialize MCP server
 	mcpServer := mcp.NewServer(memoryStore, logger)
+	for _, name := range cfg.MCP.DisabledTools {
+		if !mcpServer.UnregisterTool(name) {
+			logger.Warn("Cannot disable unknown tool", "tool", name)
+		}
+	}
 
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -61,6 +70,71 @@ func main() {
 		close(shutdownComplete)
 	}()
 
+	// Webhooks and replication keep state in the data directory, so only the
+	// process writing to it runs them
+	readOnly := memoryStore.ReadOnly() != nil
+	if readOnly && (len(cfg.Webhook.Targets) > 0 || cfg.Sync.Enabled()) {
+		logger.Warn("Webhooks and replication are disabled while another process writes to the data directory")
+	}
+
+	// Deliver memory events to webhooks
+	if len(cfg.Webhook.Targets) > 0 && !readOnly {
+		dispatcher, err := webhook.NewDispatcher(&cfg.Webhook, memoryStore.Events(), cfg.Storage.DataDir, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to initialize webhooks")
+		}
+		go func() {
+			if err := dispatcher.Run(ctx); err != nil {
+				logger.WithError(err).Error("Webhook dispatcher failed")
+			}
+		}()
+	}
+
+	// Exchange changes with other instances
+	if cfg.Sync.Enabled() && !readOnly {
+		replicator, err := replication.NewReplicator(&cfg.Sync, memoryStore, cfg.Storage.DataDir, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to initialize replication")
+		}
+		if cfg.Sync.Listen != "" {
+			syncServer := &http.Server{Addr: cfg.Sync.Listen, Handler: replicator.Handler()}
+			go func() {
+				logger.Info("Serving sync protocol", "addr", cfg.Sync.Listen, "node", replicator.Node())
+				if err := syncServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.WithError(err).Error("Sync server failed")
+				}
+			}()
+			go func() {
+				<-ctx.Done()
+				syncServer.Close()
+			}()
+		}
+		if len(cfg.Sync.Peers) > 0 {
+			go func() {
+				if err := replicator.Run(ctx); err != nil {
+					logger.WithError(err).Error("Replication failed")
+				}
+			}()
+		}
+	}
+
+	// Serve Prometheus metrics
+	if cfg.Metrics.Listen != "" {
+		mux := http.NewServeMux()
+		mux.Handle(metrics.Path, metrics.Handler())
+		metricsServer := &http.Server{Addr: cfg.Metrics.Listen, Handler: mux}
+		go func() {
+			logger.Info("Serving metrics", "addr", cfg.Metrics.Listen, "path", metrics.Path)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.WithError(err).Error("Metrics server failed")
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			metricsServer.Close()
+		}()
+	}
+
 	// Start MCP server
 	logger.Info("MCP Memory Server ready", "data_dir", cfg.Storage.DataDir)
 	if err := mcpServer.Run(ctx); err != nil {
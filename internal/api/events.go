@@ -0,0 +1,186 @@
+// internal/api/events.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// EventReset tells a subscriber that events after its sequence number have
+// expired. It should resynchronize from /memories and resume after LastSeq.
+type EventReset struct {
+	Type    string `json:"type"` // always "reset"
+	LastSeq uint64 `json:"last_seq"`
+}
+
+const (
+	eventResetType = "reset"
+	eventKeepAlive = 15 * time.Second // interval of SSE comments and WebSocket pings on an idle feed
+)
+
+// subscribeEvents subscribes after the sequence number a client resumes
+// from. When the events it missed have expired it subscribes from the latest
+// event instead and returns the reset to send first.
+func (s *Server) subscribeEvents(after uint64) ([]memory.Event, *memory.Subscription, *EventReset, error) {
+	backlog, sub, err := s.store.Events().Subscribe(after)
+	if !errors.Is(err, memory.ErrEventsExpired) {
+		return backlog, sub, nil, err
+	}
+	lastSeq, sub, err := s.store.Events().SubscribeLatest()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return nil, sub, &EventReset{Type: eventResetType, LastSeq: lastSeq}, nil
+}
+
+// eventCursor returns the sequence number a client resumes after, from the
+// after query parameter or the Last-Event-ID header EventSource sends on
+// reconnecting. A client that gives neither gets only new events.
+func (s *Server) eventCursor(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("after")
+	if value == "" {
+		value = r.Header.Get("Last-Event-ID")
+	}
+	if value == "" {
+		return s.store.Events().LastSeq(), nil
+	}
+	after, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event sequence number: %s", value)
+	}
+	return after, nil
+}
+
+// handleEvents streams memory events as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	after, err := s.eventCursor(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	backlog, sub, reset, err := s.subscribeEvents(after)
+	if err != nil {
+		http.Error(w, "Event feed unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if reset != nil {
+		data, _ := json.Marshal(reset)
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", reset.LastSeq, eventResetType, data)
+	}
+	for _, event := range backlog {
+		writeSSEEvent(w, event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind or the store closed; the client
+				// reconnects with Last-Event-ID and catches up
+				return
+			}
+			writeSSEEvent(w, event)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event memory.Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+}
+
+// handleEventsWebSocket streams memory events as JSON WebSocket messages
+func (s *Server) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	after, err := s.eventCursor(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	backlog, sub, reset, err := s.subscribeEvents(after)
+	if err != nil {
+		http.Error(w, "Event feed unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.logger.Warn("WebSocket upgrade failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(closed)
+	}()
+
+	send := func(v interface{}) bool {
+		data, _ := json.Marshal(v)
+		return conn.WriteText(data) == nil
+	}
+	if reset != nil && !send(reset) {
+		return
+	}
+	for _, event := range backlog {
+		if !send(event) {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok || !send(event) {
+				return
+			}
+		case <-keepAlive.C:
+			if conn.Ping() != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
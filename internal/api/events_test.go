@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/pkg/logger"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "api-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := &config.StorageConfig{
+		MaxStorageSize: 10 * 1024 * 1024,
+		MaxFileSize:    1024 * 1024,
+		TrashRetention: time.Hour,
+		EventHistory:   2,
+	}
+	log := logger.New("error", "text")
+	store, err := memory.NewStore(dir, cfg, log)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewServer(store, log)
+}
+
+// readSSE reads one event from a Server-Sent Events stream
+func readSSE(t *testing.T, reader *bufio.Reader) (id, eventType, data string) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && eventType != "":
+			return id, eventType, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventsServerSentEvents(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(s.handleEvents))
+	defer ts.Close()
+
+	first, _ := s.store.Store("Deploy freeze starts Friday", "", "ops", nil, nil)
+	s.store.Store("Deploy freeze ends Monday", "", "ops", nil, nil)
+
+	// Resume after the first event and receive the second from the history
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	if id, eventType, _ := readSSE(t, reader); id != "2" || eventType != memory.EventCreated {
+		t.Errorf("First streamed event = %s %s, want 2 created", id, eventType)
+	}
+
+	if err := s.store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	id, eventType, data := readSSE(t, reader)
+	var event memory.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Invalid event data %q: %v", data, err)
+	}
+	if id != "3" || eventType != memory.EventDeleted || event.ID != first.ID {
+		t.Errorf("Live event = %s %s %+v, want 3 deleted %s", id, eventType, event, first.ID)
+	}
+
+	// With only two events kept, resuming after none sends a reset
+	resp2, err := http.Get(ts.URL + "?after=0")
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp2.Body.Close()
+	if id, eventType, data := readSSE(t, bufio.NewReader(resp2.Body)); eventType != "reset" || id != "3" || !strings.Contains(data, `"last_seq":3`) {
+		t.Errorf("Expired resume = %s %s %s, want a reset at 3", id, eventType, data)
+	}
+
+	if resp, err := http.Get(ts.URL + "?after=x"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Invalid after should be rejected, got %v, %v", resp, err)
+	}
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(s.handleEventsWebSocket))
+	defer ts.Close()
+
+	s.store.Store("On-call rotates weekly", "", "", nil, nil)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := "GET /?after=0 HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatalf("Handshake failed: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected handshake response %d %v", resp.StatusCode, resp.Header)
+	}
+
+	readMessage := func() memory.Event {
+		t.Helper()
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(reader, head); err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		if head[0] != 0x81 || head[1]&0x80 != 0 || head[1]&0x7F == 127 {
+			t.Fatalf("Unexpected frame header %x", head)
+		}
+		length := int(head[1])
+		if length == 126 {
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(reader, ext); err != nil {
+				t.Fatalf("Failed to read frame: %v", err)
+			}
+			length = int(binary.BigEndian.Uint16(ext))
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		var event memory.Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			t.Fatalf("Invalid message %q: %v", payload, err)
+		}
+		return event
+	}
+
+	if event := readMessage(); event.Seq != 1 || event.Type != memory.EventCreated {
+		t.Errorf("Backlog message = %+v", event)
+	}
+	stored, _ := s.store.Store("On-call rotates weekly", "", "", nil, nil)
+	if event := readMessage(); event.Seq != 2 || event.Type != memory.EventVersioned || event.ID != stored.ID {
+		t.Errorf("Live message = %+v", event)
+	}
+
+	// A masked close frame ends the stream
+	conn.Write([]byte{0x88, 0x80, 1, 2, 3, 4})
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("Connection should close cleanly: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// internal/api/pagination.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// ListOptionsFromQuery reads list options from URL query parameters
+// (category, tags, metadata.<key>, limit, offset, cursor, sort, order) so
+// every HTTP interface paginates the same way. The limit must be positive;
+// it defaults to defaultLimit.
+func ListOptionsFromQuery(values url.Values, defaultLimit int) (*memory.ListOptions, error) {
+	opts := &memory.ListOptions{
+		Category: values.Get("category"),
+		Limit:    defaultLimit,
+		Cursor:   values.Get("cursor"),
+		Sort:     memory.SortField(values.Get("sort")),
+		Order:    values.Get("order"),
+	}
+
+	for _, tag := range values["tags"] {
+		for _, t := range strings.Split(tag, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				opts.Tags = append(opts.Tags, t)
+			}
+		}
+	}
+
+	for name := range values {
+		if key := strings.TrimPrefix(name, "metadata."); key != name && key != "" {
+			if opts.Metadata == nil {
+				opts.Metadata = make(map[string]string)
+			}
+			opts.Metadata[key] = values.Get(name)
+		}
+	}
+
+	// A limit of 0 means no limit to the store, which an HTTP client must
+	// not be able to ask for
+	if l := values.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid limit %q", l)
+		}
+		opts.Limit = limit
+	}
+
+	if o := values.Get("offset"); o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid offset %q", o)
+		}
+		opts.Offset = offset
+	}
+
+	return opts, nil
+}
+
+// WritePageHeaders describes a page in HTTP response headers so list
+// endpoints can keep returning a plain JSON array
+func WritePageHeaders(h http.Header, page *memory.Page) {
+	h.Set("X-Total-Count", strconv.Itoa(page.Total))
+	h.Set("X-Offset", strconv.Itoa(page.Offset))
+	h.Set("X-Sort", fmt.Sprintf("%s %s", page.Sort, page.Order))
+	if page.NextCursor != "" {
+		h.Set("X-Next-Cursor", page.NextCursor)
+	}
+}
+
+// QueryErrorStatus is the HTTP status for an error from SearchPage or
+// ListPage: a bad query, filter or cursor is the client's fault, anything
+// else the server's
+func QueryErrorStatus(err error) int {
+	if memory.IsQueryError(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
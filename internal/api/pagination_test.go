@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/memory"
+)
+
+func TestListOptionsFromQuery(t *testing.T) {
+	values := url.Values{
+		"category": {"decision"},
+		"tags":     {"go, api", "db"},
+		"limit":    {"15"},
+		"offset":   {"30"},
+		"sort":     {"updated"},
+		"order":    {"asc"},
+
+		"metadata.repo": {"api"},
+	}
+
+	opts, err := ListOptionsFromQuery(values, 20)
+	if err != nil {
+		t.Fatalf("ListOptionsFromQuery failed: %v", err)
+	}
+	if opts.Category != "decision" || opts.Limit != 15 || opts.Offset != 30 || opts.Sort != memory.SortByUpdated || opts.Order != memory.SortAsc {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+	if len(opts.Metadata) != 1 || opts.Metadata["repo"] != "api" {
+		t.Errorf("Unexpected metadata: %v", opts.Metadata)
+	}
+	if len(opts.Tags) != 3 || opts.Tags[0] != "go" || opts.Tags[1] != "api" || opts.Tags[2] != "db" {
+		t.Errorf("Unexpected tags: %v", opts.Tags)
+	}
+
+	defaults, err := ListOptionsFromQuery(url.Values{}, 20)
+	if err != nil {
+		t.Fatalf("ListOptionsFromQuery failed: %v", err)
+	}
+	if defaults.Limit != 20 {
+		t.Errorf("Expected default limit 20, got %d", defaults.Limit)
+	}
+
+	for _, bad := range []url.Values{
+		{"limit": {"-1"}},
+		{"limit": {"0"}}, // would list the whole store
+		{"offset": {"abc"}},
+	} {
+		if _, err := ListOptionsFromQuery(bad, 20); err == nil {
+			t.Errorf("Expected %v to be rejected", bad)
+		}
+	}
+}
+
+func TestQueryErrorStatus(t *testing.T) {
+	_, syntaxErr := memory.ParseQuery("(unclosed")
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{syntaxErr, http.StatusBadRequest},
+		{memory.ErrInvalidCursor, http.StatusBadRequest},
+		{errors.New("disk full"), http.StatusInternalServerError},
+	} {
+		if got := QueryErrorStatus(tc.err); got != tc.want {
+			t.Errorf("QueryErrorStatus(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRecallRejectsNegativeOffset(t *testing.T) {
+	s := newTestServer(t)
+	s.store.Store("Deploys happen on Tuesdays", "", "ops", nil, nil)
+
+	for _, body := range []string{
+		`{"query": "deploys", "offset": -1}`,
+		`{"query": "deploys", "offset": -1, "cursor": "not a cursor"}`,
+	} {
+		rec := httptest.NewRecorder()
+		s.handleRecall(rec, httptest.NewRequest(http.MethodPost, "/recall", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /recall %s = %d, want 400", body, rec.Code)
+		}
+	}
+}
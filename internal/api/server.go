@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/internal/metrics"
 	"mcp-memory-server/pkg/logger"
 )
 
@@ -21,31 +22,66 @@ func NewServer(store *memory.Store, logger *logger.Logger) *Server {
 	}
 }
 
+// storeFor returns the store as the client of an HTTP request, so the
+// operations it leads to are audited under its route
+func (s *Server) storeFor(r *http.Request) *memory.Store {
+	return s.store.As(memory.Caller{Client: "http-api", Tool: r.URL.Path})
+}
+
 type RememberRequest struct {
-	Content  string   `json:"content"`
-	Summary  string   `json:"summary,omitempty"`
-	Category string   `json:"category,omitempty"`
-	Tags     []string `json:"tags,omitempty"`
+	Content    string            `json:"content"`
+	Summary    string            `json:"summary,omitempty"`
+	Category   string            `json:"category,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Importance float64           `json:"importance,omitempty"` // 0-1
 }
 
 type RememberResponse struct {
-	Success bool   `json:"success"`
-	ID      string `json:"id"`
-	Message string `json:"message"`
+	Success    bool            `json:"success"`
+	ID         string          `json:"id"`
+	Message    string          `json:"message"`
+	MergedInto string          `json:"merged_into,omitempty"` // base ID of the memory a near-duplicate was merged into
+	Duplicates []SimilarMemory `json:"duplicates,omitempty"`
+	Related    []SimilarMemory `json:"related,omitempty"`
+}
+
+// SimilarMemory identifies an existing memory similar to a stored one
+type SimilarMemory struct {
+	ID         string  `json:"id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// RestoreRequest names a deleted memory to restore
+type RestoreRequest struct {
+	ID string `json:"id"`
 }
 
 type RecallRequest struct {
-	Query    string   `json:"query"`
-	Category string   `json:"category,omitempty"`
-	Tags     []string `json:"tags,omitempty"`
-	Limit    int      `json:"limit,omitempty"`
+	Query     string            `json:"query"`
+	Category  string            `json:"category,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Limit     int               `json:"limit,omitempty"`
+	Offset    int               `json:"offset,omitempty"`
+	Cursor    string            `json:"cursor,omitempty"`
+	Sort      string            `json:"sort,omitempty"`
+	Order     string            `json:"order,omitempty"`
+	Stemming  *bool             `json:"stemming,omitempty"`  // default true
+	Fuzziness string            `json:"fuzziness,omitempty"` // auto, 0, 1 or 2 (default auto)
 }
 
 func (s *Server) Start(port string) error {
-	http.HandleFunc("/remember", s.handleRemember)
-	http.HandleFunc("/recall", s.handleRecall)
-	http.HandleFunc("/stats", s.handleStats)
-	http.HandleFunc("/health", s.handleHealth)
+	http.HandleFunc("/remember", metrics.InstrumentFunc("/remember", s.handleRemember))
+	http.HandleFunc("/recall", metrics.InstrumentFunc("/recall", s.handleRecall))
+	http.HandleFunc("/memories", metrics.InstrumentFunc("/memories", s.handleMemories))
+	http.HandleFunc("/stats", metrics.InstrumentFunc("/stats", s.handleStats))
+	http.HandleFunc("/health", metrics.InstrumentFunc("/health", s.handleHealth))
+	http.HandleFunc("/trash", metrics.InstrumentFunc("/trash", s.handleTrash))
+	http.HandleFunc("/trash/restore", metrics.InstrumentFunc("/trash/restore", s.handleRestore))
+	http.HandleFunc("/events", metrics.InstrumentFunc("/events", s.handleEvents))
+	http.HandleFunc("/events/ws", metrics.InstrumentFunc("/events/ws", s.handleEventsWebSocket))
+	http.Handle(metrics.Path, metrics.Handler())
 
 	s.logger.Info("Starting API server", map[string]interface{}{
 		"port": port,
@@ -72,7 +108,19 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Store memory using the async store
-	mem, err := s.store.Store(req.Content, req.Summary, req.Category, req.Tags, nil)
+	if _, err := memory.ValidateImportance(req.Importance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.storeFor(r).Remember(memory.MemoryInput{
+		Content:    req.Content,
+		Summary:    req.Summary,
+		Category:   req.Category,
+		Tags:       req.Tags,
+		Metadata:   req.Metadata,
+		Importance: req.Importance,
+	})
 	if err != nil {
 		s.logger.Error("Failed to store memory", map[string]interface{}{
 			"error": err.Error(),
@@ -82,15 +130,26 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := RememberResponse{
-		Success: true,
-		ID:      mem.ID,
-		Message: "Memory stored successfully",
+		Success:    true,
+		ID:         result.Memory.ID,
+		Message:    "Memory stored successfully",
+		MergedInto: result.MergedInto,
+		Duplicates: similarMemories(result.Duplicates),
+		Related:    similarMemories(result.Related),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+func similarMemories(matches []memory.SimilarMemory) []SimilarMemory {
+	similar := make([]SimilarMemory, 0, len(matches))
+	for _, match := range matches {
+		similar = append(similar, SimilarMemory{ID: match.Memory.ID, Similarity: match.Similarity})
+	}
+	return similar
+}
+
 func (s *Server) handleRecall(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -111,29 +170,71 @@ func (s *Server) handleRecall(w http.ResponseWriter, r *http.Request) {
 	if req.Limit == 0 {
 		req.Limit = 10
 	}
+	if req.Offset < 0 {
+		http.Error(w, "Offset must not be negative", http.StatusBadRequest)
+		return
+	}
 
 	searchQuery := &memory.SearchQuery{
 		Query:    req.Query,
 		Category: req.Category,
 		Tags:     req.Tags,
+		Metadata: req.Metadata,
 		Limit:    req.Limit,
+		Offset:   req.Offset,
+		Cursor:   req.Cursor,
+		Sort:     memory.SortField(req.Sort),
+		Order:    req.Order,
 	}
-	
-	memories, err := s.store.Search(searchQuery)
+
+	match := memory.DefaultMatchOptions()
+	if req.Stemming != nil {
+		match.Stemming = *req.Stemming
+	}
+	fuzziness, err := memory.ParseFuzziness(req.Fuzziness)
 	if err != nil {
-		s.logger.Error("Failed to search memories", map[string]interface{}{
-			"error": err.Error(),
-		})
-		http.Error(w, "Failed to search memories", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	match.Fuzziness = fuzziness
+	searchQuery.Match = &match
+
+	page, err := s.storeFor(r).SearchPage(searchQuery)
+	if err != nil {
+		http.Error(w, err.Error(), QueryErrorStatus(err))
+		return
+	}
+
+	WritePageHeaders(w.Header(), page)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Memories)
+}
+
+func (s *Server) handleMemories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts, err := ListOptionsFromQuery(r.URL.Query(), 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.storeFor(r).ListPage(opts)
+	if err != nil {
+		http.Error(w, err.Error(), QueryErrorStatus(err))
 		return
 	}
 
+	WritePageHeaders(w.Header(), page)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(memories)
+	json.NewEncoder(w).Encode(page.Memories)
 }
 
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	stats := s.store.GetStats()
+	stats := s.storeFor(r).GetStats()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
@@ -143,4 +244,57 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "ok",
 	})
-}
\ No newline at end of file
+}
+
+// handleTrash lists the trash on GET and empties it on DELETE, which
+// requires confirm=true
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.storeFor(r).Trash())
+	case http.MethodDelete:
+		if r.URL.Query().Get("confirm") != "true" {
+			http.Error(w, "confirm=true is required to empty the trash", http.StatusBadRequest)
+			return
+		}
+		purged, err := s.storeFor(r).EmptyTrash()
+		if err != nil {
+			s.logger.Error("Failed to empty trash", map[string]interface{}{
+				"error": err.Error(),
+			})
+			http.Error(w, "Failed to empty trash", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"purged": purged})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" {
+		http.Error(w, "ID is required", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := s.storeFor(r).Restore(req.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restored)
+}
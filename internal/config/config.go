@@ -3,9 +3,12 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
@@ -14,8 +17,18 @@ type Config struct {
 	Logging LoggingConfig `json:"logging"`
 	Search  SearchConfig  `json:"search"`
 	Web     WebConfig     `json:"web"`
+	MCP     MCPConfig     `json:"mcp"`
+	Webhook WebhookConfig `json:"webhook"`
+	Sync    SyncConfig    `json:"sync"`
+	Metrics MetricsConfig `json:"metrics"`
 }
 
+// Quota policies: what happens when a category or namespace exceeds its quota
+const (
+	QuotaPolicyEvict  = "evict"  // evict the least valuable memories of the category or namespace
+	QuotaPolicyReject = "reject" // refuse new memories until space is freed
+)
+
 // StorageConfig holds data storage configuration
 type StorageConfig struct {
 	DataDir        string `json:"data_dir"`
@@ -34,6 +47,47 @@ type StorageConfig struct {
 	// Encryption configuration
 	EnableEncryption  bool   `json:"enable_encryption"`  // Enable AES-256-GCM encryption
 	EncryptionKeyPath string `json:"encryption_key_path"` // Path to encryption key file
+	
+	// Duplicate detection configuration
+	DuplicateThreshold  float64 `json:"duplicate_threshold"`   // Similarity (0-1) at which a new memory is flagged as a near-duplicate
+	AutoMergeDuplicates bool    `json:"auto_merge_duplicates"` // Store near-duplicates as a new version of the memory they duplicate
+	
+	// Consolidation configuration
+	ConsolidationInterval time.Duration `json:"consolidation_interval"` // How often to propose consolidations of overlapping memories (0 disables)
+	
+	// Decay model configuration (ranking and eviction)
+	DecayHalfLife    time.Duration `json:"decay_half_life"`   // Time after which the recency of an unaccessed memory halves
+	RecencyWeight    float64       `json:"recency_weight"`    // Weight of recency in the retention score
+	FrequencyWeight  float64       `json:"frequency_weight"`  // Weight of access frequency in the retention score
+	ImportanceWeight float64       `json:"importance_weight"` // Weight of caller-set importance in the retention score
+	
+	// Protection configuration
+	EvictProtected bool `json:"evict_protected"` // Let storage cleanup evict protected memories
+	
+	// Quota configuration
+	CategoryQuotas  map[string]int64 `json:"category_quotas"`  // Bytes per category; "*" applies to each category not listed
+	NamespaceQuotas map[string]int64 `json:"namespace_quotas"` // Bytes per namespace (the "namespace" metadata value); "*" applies to each namespace not listed
+	QuotaPolicy     string           `json:"quota_policy"`     // "evict" a category's least valuable memories when it exceeds its quota, or "reject" new ones
+	
+	// Trash configuration
+	TrashRetention time.Duration `json:"trash_retention"` // How long deleted memories stay restorable (0 deletes immediately)
+	
+	// Audit configuration
+	EnableAudit bool `json:"enable_audit"` // Record stores, deletes, evictions and accesses in a hash-chained audit log
+
+	// Change feed
+	EventHistory int `json:"event_history"` // Recent memory events kept for subscribers resuming after a disconnect
+
+	// Multi-process access
+	WriterLock bool `json:"writer_lock"` // Open a data directory read-only while another process is writing to it
+
+	// Git-backed storage
+	GitStorage      bool          `json:"git_storage"`       // Keep memories as Markdown in a git working tree, committing each change
+	GitRemote       string        `json:"git_remote"`        // Repository to pull from and push to, e.g. the path of a bare repository
+	GitBranch       string        `json:"git_branch"`        // Branch to commit to
+	GitSyncInterval time.Duration `json:"git_sync_interval"` // How often to pull and push (0 syncs only on request)
+	GitAuthorName   string        `json:"git_author_name"`   // Author of the commits
+	GitAuthorEmail  string        `json:"git_author_email"`
 }
 
 // LoggingConfig holds logging configuration
@@ -56,6 +110,47 @@ type WebConfig struct {
 	Host    string `json:"host"`
 }
 
+// MCPConfig holds MCP protocol configuration
+type MCPConfig struct {
+	DisabledTools []string `json:"disabled_tools"` // Tools removed from the registry at startup
+}
+
+// WebhookConfig holds outgoing webhook configuration
+type WebhookConfig struct {
+	Targets        []WebhookTarget `json:"targets"`
+	MaxAttempts    int             `json:"max_attempts"`    // Deliveries failing this many times go to the dead-letter list
+	InitialBackoff time.Duration   `json:"initial_backoff"` // Delay before the first retry, doubling after each failure
+	MaxBackoff     time.Duration   `json:"max_backoff"`     // Longest delay between retries
+	Timeout        time.Duration   `json:"timeout"`         // Timeout of one delivery request
+}
+
+// WebhookTarget is an endpoint notified of memory events. Empty filters
+// match everything; a memory matches the tag filter with any of the tags.
+type WebhookTarget struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`     // HMAC-SHA256 signing secret
+	Events     []string `json:"events"`     // created, versioned, deleted, evicted, restored
+	Categories []string `json:"categories"`
+	Tags       []string `json:"tags"`
+}
+
+// SyncConfig holds replication configuration. Instances serve their change
+// log on Listen and exchange changes with each of Peers every Interval.
+type SyncConfig struct {
+	NodeID   string        `json:"node_id"`  // Name of this instance in vector clocks (generated when empty)
+	Listen   string        `json:"listen"`   // Address to serve the sync protocol on (disabled when empty)
+	Peers    []string      `json:"peers"`    // Base URLs of the instances to sync with
+	Interval time.Duration `json:"interval"` // How often to sync with each peer
+	Token    string        `json:"token"`    // Shared bearer token required on sync requests (required to listen beyond loopback)
+	Timeout  time.Duration `json:"timeout"`  // Timeout of one sync request
+}
+
+// MetricsConfig holds the Prometheus endpoint configuration
+type MetricsConfig struct {
+	Listen string `json:"listen"` // Address to serve /metrics on (disabled when empty)
+}
+
 // Load loads configuration from environment variables with sensible defaults
 func Load() (*Config, error) {
 	homeDir, err := os.UserHomeDir()
@@ -77,6 +172,27 @@ func Load() (*Config, error) {
 			CompressionLevel:  getEnvInt("MCP_COMPRESSION_LEVEL", 6),                   // Default gzip level (1-9, 6 is balanced)
 			EnableEncryption:  getEnvBool("MCP_ENABLE_ENCRYPTION", false),              // Encryption disabled by default
 			EncryptionKeyPath: getEnvString("MCP_ENCRYPTION_KEY_PATH", filepath.Join(homeDir, ".mcp-memory", "encryption.key")),
+			DuplicateThreshold:  getEnvFloat("MCP_DUPLICATE_THRESHOLD", 0.8),        // Flag memories 80% similar to an existing one
+			AutoMergeDuplicates: getEnvBool("MCP_AUTO_MERGE_DUPLICATES", false),     // Keep near-duplicates as separate memories by default
+			ConsolidationInterval: getEnvDuration("MCP_CONSOLIDATION_INTERVAL", 0),  // Consolidation proposals only on request by default
+			DecayHalfLife:    getEnvDuration("MCP_DECAY_HALF_LIFE", 30*24*time.Hour),   // Recency halves every 30 days
+			RecencyWeight:    getEnvFloat("MCP_RECENCY_WEIGHT", 0.4),
+			FrequencyWeight:  getEnvFloat("MCP_FREQUENCY_WEIGHT", 0.3),
+			ImportanceWeight: getEnvFloat("MCP_IMPORTANCE_WEIGHT", 0.3),
+			EvictProtected:   getEnvBool("MCP_EVICT_PROTECTED", false),             // Protected memories survive cleanup by default
+			CategoryQuotas:   getEnvQuotas("MCP_CATEGORY_QUOTAS"),                  // e.g. "logs=104857600,*=1073741824"
+			NamespaceQuotas:  getEnvQuotas("MCP_NAMESPACE_QUOTAS"),
+			QuotaPolicy:      getEnvString("MCP_QUOTA_POLICY", QuotaPolicyEvict),
+			TrashRetention:   getEnvDuration("MCP_TRASH_RETENTION", 7*24*time.Hour), // Deleted memories are restorable for a week
+			EnableAudit:      getEnvBool("MCP_ENABLE_AUDIT", true),                 // Audit log enabled by default
+			EventHistory:     getEnvInt("MCP_EVENT_HISTORY", 1000),                 // Keep the last 1000 change events
+			WriterLock:       getEnvBool("MCP_WRITER_LOCK", true),                  // One writer per data directory by default
+			GitStorage:       getEnvBool("MCP_GIT_STORAGE", false),
+			GitRemote:        getEnvString("MCP_GIT_REMOTE", ""),
+			GitBranch:        getEnvString("MCP_GIT_BRANCH", "main"),
+			GitSyncInterval:  getEnvDuration("MCP_GIT_SYNC_INTERVAL", 0),
+			GitAuthorName:    getEnvString("MCP_GIT_AUTHOR_NAME", "MCP Memory Server"),
+			GitAuthorEmail:   getEnvString("MCP_GIT_AUTHOR_EMAIL", "mcp-memory@localhost"),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnvString("MCP_LOG_LEVEL", "info"),
@@ -92,6 +208,44 @@ func Load() (*Config, error) {
 			Port:    getEnvInt("MCP_WEB_PORT", 9000),
 			Host:    getEnvString("MCP_WEB_HOST", "localhost"),
 		},
+		MCP: MCPConfig{
+			DisabledTools: getEnvStringList("MCP_DISABLED_TOOLS", nil),
+		},
+		Metrics: MetricsConfig{
+			Listen: getEnvString("MCP_METRICS_ADDR", ""),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:    getEnvInt("MCP_WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff: getEnvDuration("MCP_WEBHOOK_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:     getEnvDuration("MCP_WEBHOOK_MAX_BACKOFF", 10*time.Minute),
+			Timeout:        getEnvDuration("MCP_WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+	}
+
+	// Git storage commits each change once its file is written
+	if cfg.Storage.GitStorage {
+		cfg.Storage.EnableAsync = false
+	}
+
+	cfg.Sync = SyncConfig{
+		NodeID:   getEnvString("MCP_SYNC_NODE_ID", ""),
+		Listen:   getEnvString("MCP_SYNC_ADDR", ""),
+		Peers:    getEnvStringList("MCP_SYNC_PEERS", nil),
+		Interval: getEnvDuration("MCP_SYNC_INTERVAL", 30*time.Second),
+		Token:    getEnvString("MCP_SYNC_TOKEN", ""),
+		Timeout:  getEnvDuration("MCP_SYNC_TIMEOUT", 30*time.Second),
+	}
+
+	// A single webhook target can be configured from the environment
+	if url := getEnvString("MCP_WEBHOOK_URL", ""); url != "" {
+		cfg.Webhook.Targets = append(cfg.Webhook.Targets, WebhookTarget{
+			Name:       "default",
+			URL:        url,
+			Secret:     getEnvString("MCP_WEBHOOK_SECRET", ""),
+			Events:     getEnvStringList("MCP_WEBHOOK_EVENTS", nil),
+			Categories: getEnvStringList("MCP_WEBHOOK_CATEGORIES", nil),
+			Tags:       getEnvStringList("MCP_WEBHOOK_TAGS", nil),
+		})
 	}
 
 	// Validate configuration
@@ -135,13 +289,144 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max storage size must be positive, got %d", c.Storage.MaxStorageSize)
 	}
 	
+	if c.Storage.DuplicateThreshold <= 0 || c.Storage.DuplicateThreshold > 1 {
+		return fmt.Errorf("duplicate threshold must be greater than 0 and at most 1, got %g", c.Storage.DuplicateThreshold)
+	}
+	
+	if c.Storage.ConsolidationInterval < 0 {
+		return fmt.Errorf("consolidation interval cannot be negative, got %s", c.Storage.ConsolidationInterval)
+	}
+	
+	if c.Storage.GitStorage && c.Storage.EnableEncryption {
+		return fmt.Errorf("git storage keeps memories readable and cannot be combined with encryption")
+	}
+	
+	if c.Storage.GitSyncInterval < 0 {
+		return fmt.Errorf("git sync interval cannot be negative, got %s", c.Storage.GitSyncInterval)
+	}
+	
+	if c.Storage.QuotaPolicy != QuotaPolicyEvict && c.Storage.QuotaPolicy != QuotaPolicyReject {
+		return fmt.Errorf("quota policy must be %q or %q, got %q", QuotaPolicyEvict, QuotaPolicyReject, c.Storage.QuotaPolicy)
+	}
+	
+	for scope, quotas := range map[string]map[string]int64{"category": c.Storage.CategoryQuotas, "namespace": c.Storage.NamespaceQuotas} {
+		for name, quota := range quotas {
+			if quota <= 0 {
+				return fmt.Errorf("%s quota for %q must be positive, got %d", scope, name, quota)
+			}
+		}
+	}
+	
+	if c.Storage.EventHistory < 0 {
+		return fmt.Errorf("event history cannot be negative, got %d", c.Storage.EventHistory)
+	}
+
+	if c.Storage.TrashRetention < 0 {
+		return fmt.Errorf("trash retention cannot be negative, got %s", c.Storage.TrashRetention)
+	}
+	
+	if c.Storage.DecayHalfLife <= 0 {
+		return fmt.Errorf("decay half-life must be positive, got %s", c.Storage.DecayHalfLife)
+	}
+	
+	if c.Storage.RecencyWeight < 0 || c.Storage.FrequencyWeight < 0 || c.Storage.ImportanceWeight < 0 {
+		return fmt.Errorf("decay weights cannot be negative")
+	}
+	
+	if c.Storage.RecencyWeight+c.Storage.FrequencyWeight+c.Storage.ImportanceWeight == 0 {
+		return fmt.Errorf("at least one decay weight must be positive")
+	}
+	
 	if c.Storage.MaxFileSize > c.Storage.MaxStorageSize {
 		return fmt.Errorf("max file size (%d) cannot exceed max storage size (%d)", c.Storage.MaxFileSize, c.Storage.MaxStorageSize)
 	}
 	
+	if err := c.Webhook.Validate(); err != nil {
+		return err
+	}
+	
+	if err := c.Sync.Validate(); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+// Validate validates the webhook configuration
+func (c *WebhookConfig) Validate() error {
+	if len(c.Targets) == 0 {
+		return nil
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("webhook max attempts must be at least 1, got %d", c.MaxAttempts)
+	}
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("webhook backoff must be positive with max backoff at least the initial backoff")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("webhook timeout must be positive, got %s", c.Timeout)
+	}
+
+	names := make(map[string]bool)
+	for _, target := range c.Targets {
+		if target.Name == "" || names[target.Name] {
+			return fmt.Errorf("webhook targets need unique names, got %q", target.Name)
+		}
+		names[target.Name] = true
+		if !strings.HasPrefix(target.URL, "http://") && !strings.HasPrefix(target.URL, "https://") {
+			return fmt.Errorf("webhook %s: URL must be http or https, got %q", target.Name, target.URL)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether the instance serves or pulls changes
+func (c *SyncConfig) Enabled() bool {
+	return c.Listen != "" || len(c.Peers) > 0
+}
+
+// Validate validates the sync configuration
+func (c *SyncConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	for _, r := range c.NodeID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("sync node ID may only contain letters, digits, '-' and '_', got %q", c.NodeID)
+		}
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("sync interval must be positive, got %s", c.Interval)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("sync timeout must be positive, got %s", c.Timeout)
+	}
+	for _, peer := range c.Peers {
+		if !strings.HasPrefix(peer, "http://") && !strings.HasPrefix(peer, "https://") {
+			return fmt.Errorf("sync peer URL must be http or https, got %q", peer)
+		}
+	}
+	// Anyone who can reach the sync address can push changes without a token
+	if c.Listen != "" && c.Token == "" && !isLoopbackAddr(c.Listen) {
+		return fmt.Errorf("sync token is required to listen on %q, set one or listen on a loopback address", c.Listen)
+	}
 	return nil
 }
 
+// isLoopbackAddr reports whether a listen address only accepts local
+// connections. An empty host listens on every interface.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // Helper functions for environment variable parsing
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -168,6 +453,54 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if str := os.Getenv(key); str != "" {
+		if val, err := strconv.ParseFloat(str, 64); err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if str := os.Getenv(key); str != "" {
+		if val, err := time.ParseDuration(str); err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+func getEnvStringList(key string, defaultValue []string) []string {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, value := range strings.Split(str, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvQuotas parses comma-separated name=bytes pairs
+func getEnvQuotas(key string) map[string]int64 {
+	quotas := make(map[string]int64)
+	for _, pair := range getEnvStringList(key, nil) {
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		if val, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			quotas[strings.TrimSpace(name)] = val
+		}
+	}
+	return quotas
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if str := os.Getenv(key); str != "" {
 		return str == "true" || str == "1"
@@ -0,0 +1,109 @@
+// internal/mcp/audit.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+type auditLogArgs struct {
+	Action string `json:"action"`
+	ID     string `json:"id"`
+	Client string `json:"client"`
+	Tool   string `json:"tool"`
+	Since  string `json:"since"`
+	Until  string `json:"until"`
+	Limit  int    `json:"limit"`
+	Verify bool   `json:"verify"`
+}
+
+func (s *Server) handleAuditLog(store *memory.Store, args auditLogArgs) (string, error) {
+	log := store.AuditLog()
+	if log == nil {
+		return "", fmt.Errorf("the audit log is disabled (set MCP_ENABLE_AUDIT=true to enable it)")
+	}
+
+	query := memory.AuditQuery{
+		Action: args.Action,
+		ID:     args.ID,
+		Client: args.Client,
+		Tool:   args.Tool,
+		Limit:  args.Limit,
+	}
+	if args.Since != "" {
+		since, err := parseDateTime(args.Since)
+		if err != nil {
+			return "", fmt.Errorf("invalid date format for since: %s (use ISO 8601 format)", args.Since)
+		}
+		query.Since = since
+	}
+	if args.Until != "" {
+		until, err := parseDateTime(args.Until)
+		if err != nil {
+			return "", fmt.Errorf("invalid date format for until: %s (use ISO 8601 format)", args.Until)
+		}
+		query.Until = until
+	}
+
+	var result strings.Builder
+	if args.Verify {
+		checked, err := log.Verify()
+		if err != nil {
+			result.WriteString(fmt.Sprintf("**Verification failed** after %d intact entries: %s\n\n", checked, err))
+		} else {
+			result.WriteString(fmt.Sprintf("Verified the hash chain of %d entries.\n\n", checked))
+		}
+	}
+
+	entries, err := log.Query(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to query audit log: %w", err)
+	}
+	if len(entries) == 0 {
+		result.WriteString("No audit entries found.")
+		return result.String(), nil
+	}
+
+	result.WriteString(fmt.Sprintf("Found %d audit entries:\n\n", len(entries)))
+	for _, entry := range entries {
+		result.WriteString(formatAuditEntry(entry))
+		result.WriteString("\n")
+	}
+	return result.String(), nil
+}
+
+// formatAuditEntry renders an entry on one line
+func formatAuditEntry(entry memory.AuditEntry) string {
+	line := fmt.Sprintf("#%d %s **%s**", entry.Seq, entry.Time.Format("2006-01-02 15:04:05"), entry.Action)
+	if len(entry.IDs) > 0 {
+		line += " " + strings.Join(entry.IDs, ", ")
+	}
+	if entry.Detail != "" {
+		line += fmt.Sprintf(" (%s)", entry.Detail)
+	}
+	line += " by " + entry.Client
+	if entry.Tool != "" {
+		line += " via " + entry.Tool
+	}
+	if len(entry.ArgsDigest) >= 12 {
+		line += " args " + entry.ArgsDigest[:12]
+	}
+	return line
+}
+
+// clientName identifies the connected client for the audit log
+func (s *Server) clientName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name, _ := s.clientInfo["name"].(string)
+	if name == "" {
+		return "unknown"
+	}
+	if version, _ := s.clientInfo["version"].(string); version != "" {
+		name += "/" + version
+	}
+	return name
+}
@@ -0,0 +1,230 @@
+// internal/mcp/budget.go
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// Verbosity levels for recall output, cheapest first
+const (
+	VerbosityIDs     = "ids"
+	VerbositySummary = "summary"
+	VerbosityFull    = "full"
+)
+
+// minTruncatedTokens is the smallest content excerpt worth returning when a
+// memory has to be cut down to fit the remaining budget
+const minTruncatedTokens = 40
+
+// maxSummaryRunes caps generated summaries for memories without one
+const maxSummaryRunes = 200
+
+// estimateTokens approximates how many tokens a model tokenizer produces for
+// text. Four characters per token is the usual rule of thumb for English and
+// errs on the generous side for code.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// summarizeMemory returns the stored summary, or the first sentence of the
+// content capped at maxSummaryRunes when none was provided
+func summarizeMemory(m *memory.Memory) string {
+	if m.Summary != "" {
+		return m.Summary
+	}
+
+	text := strings.Join(strings.Fields(m.Content), " ")
+	return truncateRunes(firstSentence(text), maxSummaryRunes)
+}
+
+// firstSentence returns text up to the first sentence end: a '.', '!' or '?'
+// followed by a space and an uppercase letter, so abbreviations like "e.g."
+// and versions like "v1.2" don't cut it short. text must be whitespace
+// normalized.
+func firstSentence(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		if (r == '.' || r == '!' || r == '?') && i+2 < len(runes) && runes[i+1] == ' ' && unicode.IsUpper(runes[i+2]) {
+			return string(runes[:i+1])
+		}
+	}
+	return text
+}
+
+// truncateRunes shortens text to at most n runes, marking the cut with an ellipsis
+func truncateRunes(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimRight(string(runes[:n]), " ") + "..."
+}
+
+// renderMemory formats one recall result at the given verbosity
+func renderMemory(n int, m *memory.Memory, verbosity string) string {
+	switch verbosity {
+	case VerbosityIDs:
+		return fmt.Sprintf("%d. %s\n", n, m.ID)
+	case VerbositySummary:
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("%d. **%s** (ID: %s)\n", n, summarizeMemory(m), m.ID))
+		if m.Category != "" {
+			b.WriteString(fmt.Sprintf("   Category: %s\n", m.Category))
+		}
+		if len(m.Tags) > 0 {
+			b.WriteString(fmt.Sprintf("   Tags: %s\n", strings.Join(m.Tags, ", ")))
+		}
+		return b.String()
+	default:
+		return renderFullMemory(n, m, m.Content)
+	}
+}
+
+// renderFullMemory formats a memory with all its fields and the given content
+func renderFullMemory(n int, m *memory.Memory, content string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("## Memory %d (ID: %s)\n", n, m.ID))
+	if m.Category != "" {
+		b.WriteString(fmt.Sprintf("**Category:** %s\n", m.Category))
+	}
+	if len(m.Tags) > 0 {
+		b.WriteString(fmt.Sprintf("**Tags:** %s\n", strings.Join(m.Tags, ", ")))
+	}
+	if m.Summary != "" {
+		b.WriteString(fmt.Sprintf("**Summary:** %s\n", m.Summary))
+	}
+	if len(m.Metadata) > 0 {
+		b.WriteString(fmt.Sprintf("**Metadata:** %s\n", formatMetadata(m.Metadata)))
+	}
+	if len(m.Links) > 0 {
+		b.WriteString(fmt.Sprintf("**Links:** %s\n", formatLinks(m.Links)))
+	}
+	if m.Importance > 0 {
+		b.WriteString(fmt.Sprintf("**Importance:** %.2f\n", m.Importance))
+	}
+	if m.Pinned || m.Protected {
+		b.WriteString(fmt.Sprintf("**Flags:** %s\n", formatFlags(m)))
+	}
+	b.WriteString(fmt.Sprintf("**Created:** %s\n", m.CreatedAt.Format("2006-01-02 15:04:05")))
+	b.WriteString(fmt.Sprintf("**Content:**\n%s\n\n", content))
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// formatMetadata renders metadata as key=value pairs in key order
+func formatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + metadata[key]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// renderTruncatedMemory renders a full memory whose content is cut so the
+// whole entry fits in budget tokens. It reports false when not even a
+// minimal excerpt would fit.
+func renderTruncatedMemory(n int, m *memory.Memory, budget int) (string, bool) {
+	overhead := estimateTokens(renderFullMemory(n, m, ""))
+	available := budget - overhead
+	if available < minTruncatedTokens {
+		return "", false
+	}
+
+	// Leave room for the ellipsis
+	content := truncateRunes(m.Content, (available-1)*4)
+	return renderFullMemory(n, m, content), true
+}
+
+// recallPage is the part of a result page that fits the token budget
+type recallPage struct {
+	Entries    []string
+	Tokens     int      // estimated tokens across all entries
+	Truncated  []string // IDs whose content was cut to fit
+	Downgraded []string // IDs shown at a lower verbosity than requested
+	Next       int      // index of the first memory not shown, or -1 when all were shown
+}
+
+// fitToBudget renders memories in order while the rendered size stays within
+// maxTokens (0 means unlimited). first is the display number of memories[0].
+// Results that do not fit at the requested verbosity are truncated or shown at
+// a cheaper verbosity before the page is cut short. At least one entry is
+// always returned so a continuation cursor makes progress.
+func fitToBudget(memories []*memory.Memory, first, maxTokens int, verbosity string) recallPage {
+	page := recallPage{Next: -1}
+
+	i := 0
+	for ; i < len(memories); i++ {
+		m := memories[i]
+		n := first + i
+		remaining := maxTokens - page.Tokens
+
+		entry := renderMemory(n, m, verbosity)
+		if maxTokens > 0 && estimateTokens(entry) > remaining {
+			fitted := ""
+			if verbosity == VerbosityFull {
+				if truncated, ok := renderTruncatedMemory(n, m, remaining); ok {
+					fitted = truncated
+					page.Truncated = append(page.Truncated, m.ID)
+				}
+			}
+			if fitted == "" {
+				for _, cheaper := range cheaperVerbosities(verbosity) {
+					candidate := renderMemory(n, m, cheaper)
+					if estimateTokens(candidate) <= remaining || (len(page.Entries) == 0 && cheaper == VerbosityIDs) {
+						fitted = candidate
+						page.Downgraded = append(page.Downgraded, m.ID)
+						break
+					}
+				}
+			}
+			if fitted == "" {
+				break
+			}
+			entry = fitted
+		}
+
+		page.Entries = append(page.Entries, entry)
+		page.Tokens += estimateTokens(entry)
+	}
+
+	if i < len(memories) {
+		page.Next = i
+	}
+	return page
+}
+
+// cheaperVerbosities lists the verbosity levels below v, most detailed first
+func cheaperVerbosities(v string) []string {
+	switch v {
+	case VerbosityFull:
+		return []string{VerbositySummary, VerbosityIDs}
+	case VerbositySummary:
+		return []string{VerbosityIDs}
+	default:
+		return []string{VerbosityIDs}
+	}
+}
+
+// formatFlags lists the pinned and protected flags set on a memory
+func formatFlags(m *memory.Memory) string {
+	var flags []string
+	if m.Pinned {
+		flags = append(flags, "pinned")
+	}
+	if m.Protected {
+		flags = append(flags, "protected")
+	}
+	return strings.Join(flags, ", ")
+}
@@ -0,0 +1,124 @@
+// internal/mcp/budget_test.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/memory"
+)
+
+func budgetTestMemories(n int, contentLen int) []*memory.Memory {
+	memories := make([]*memory.Memory, n)
+	for i := range memories {
+		memories[i] = &memory.Memory{
+			ID:        fmt.Sprintf("mem%02d-v1", i),
+			Content:   strings.Repeat("word ", contentLen/5),
+			Category:  "test",
+			CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return memories
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := estimateTokens(""); got != 0 {
+		t.Errorf("Expected 0 tokens for empty text, got %d", got)
+	}
+	if got := estimateTokens("abcd"); got != 1 {
+		t.Errorf("Expected 1 token for 4 chars, got %d", got)
+	}
+	if got := estimateTokens("héllo wörld"); got != 3 {
+		t.Errorf("Expected tokens to be counted by rune, got %d", got)
+	}
+}
+
+func TestSummarizeMemory(t *testing.T) {
+	m := &memory.Memory{Content: "First   sentence here. Second sentence."}
+	if got := summarizeMemory(m); got != "First sentence here." {
+		t.Errorf("Expected first sentence, got %q", got)
+	}
+
+	// Abbreviations and version numbers are not sentence ends
+	for content, want := range map[string]string{
+		"Use a queue, e.g. a broker like NATS. Then scale out.": "Use a queue, e.g. a broker like NATS.",
+		"Upgrade to v1.2 before Friday. It fixes the leak.":     "Upgrade to v1.2 before Friday.",
+		"Done! next steps are unclear":                          "Done! next steps are unclear",
+	} {
+		if got := summarizeMemory(&memory.Memory{Content: content}); got != want {
+			t.Errorf("summarizeMemory(%q) = %q, want %q", content, got, want)
+		}
+	}
+
+	m.Summary = "Explicit"
+	if got := summarizeMemory(m); got != "Explicit" {
+		t.Errorf("Expected stored summary, got %q", got)
+	}
+
+	long := &memory.Memory{Content: strings.Repeat("x", 500)}
+	if got := summarizeMemory(long); len(got) > maxSummaryRunes+3 {
+		t.Errorf("Expected summary capped at %d runes, got %d", maxSummaryRunes, len(got))
+	}
+}
+
+func TestFitToBudgetUnlimited(t *testing.T) {
+	memories := budgetTestMemories(5, 100)
+
+	page := fitToBudget(memories, 1, 0, VerbosityFull)
+	if len(page.Entries) != 5 || page.Next != -1 {
+		t.Errorf("Expected all 5 entries and nothing left, got %d entries, next %d", len(page.Entries), page.Next)
+	}
+	if !strings.HasPrefix(page.Entries[0], "## Memory 1 ") {
+		t.Errorf("Expected numbering to start at 1, got %q", page.Entries[0])
+	}
+
+	page = fitToBudget(memories, 11, 0, VerbosityIDs)
+	if !strings.HasPrefix(page.Entries[0], "11. ") {
+		t.Errorf("Expected numbering to start at 11, got %q", page.Entries[0])
+	}
+}
+
+func TestFitToBudgetStaysWithinBudget(t *testing.T) {
+	memories := budgetTestMemories(60, 400)
+	budget := 300
+
+	page := fitToBudget(memories, 1, budget, VerbosityFull)
+	if page.Tokens > budget {
+		t.Errorf("Page used %d tokens, budget was %d", page.Tokens, budget)
+	}
+	if len(page.Entries) == 0 || page.Next <= 0 {
+		t.Fatalf("Expected a partial page, got %d entries, next %d", len(page.Entries), page.Next)
+	}
+	if page.Next != len(page.Entries) {
+		t.Errorf("Next index %d does not follow the %d entries shown", page.Next, len(page.Entries))
+	}
+	if len(page.Truncated)+len(page.Downgraded) == 0 {
+		t.Error("Expected some results to be truncated or downgraded to fit")
+	}
+}
+
+func TestFitToBudgetAlwaysProgresses(t *testing.T) {
+	memories := budgetTestMemories(3, 1000)
+
+	page := fitToBudget(memories, 1, 1, VerbosityFull)
+	if len(page.Entries) != 1 {
+		t.Fatalf("Expected exactly one entry for a tiny budget, got %d", len(page.Entries))
+	}
+	if !strings.Contains(page.Entries[0], memories[0].ID) || page.Next != 1 {
+		t.Errorf("Expected first result as an id with next at 1, got %q next %d", page.Entries[0], page.Next)
+	}
+}
+
+func TestFitToBudgetVerbosity(t *testing.T) {
+	memories := budgetTestMemories(3, 400)
+
+	ids := fitToBudget(memories, 1, 0, VerbosityIDs)
+	summaries := fitToBudget(memories, 1, 0, VerbositySummary)
+	full := fitToBudget(memories, 1, 0, VerbosityFull)
+
+	if !(ids.Tokens < summaries.Tokens && summaries.Tokens < full.Tokens) {
+		t.Errorf("Expected ids < summary < full, got %d, %d, %d", ids.Tokens, summaries.Tokens, full.Tokens)
+	}
+}
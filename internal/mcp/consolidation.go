@@ -0,0 +1,129 @@
+// internal/mcp/consolidation.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+type proposeConsolidationsArgs struct {
+	Threshold      float64 `json:"threshold"`
+	MinClusterSize int     `json:"min_cluster_size"`
+	MaxClusterSize int     `json:"max_cluster_size"`
+	Category       string  `json:"category"`
+}
+
+func (s *Server) handleProposeConsolidations(store *memory.Store, args proposeConsolidationsArgs) (string, error) {
+	proposals, err := store.ProposeConsolidations(memory.ConsolidationOptions{
+		Threshold:      args.Threshold,
+		MinClusterSize: args.MinClusterSize,
+		MaxClusterSize: args.MaxClusterSize,
+		Category:       args.Category,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to propose consolidations: %w", err)
+	}
+
+	if len(proposals) == 0 {
+		return "No overlapping memories found to consolidate.", nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Proposed %d consolidation(s):\n\n", len(proposals)))
+	writeProposalList(&result, proposals)
+	result.WriteString("\nReview one with list_consolidations, then apply_consolidation or reject_consolidation.")
+	return result.String(), nil
+}
+
+type listConsolidationsArgs struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (s *Server) handleListConsolidations(store *memory.Store, args listConsolidationsArgs) (string, error) {
+	if args.ID != "" {
+		proposal, err := store.GetConsolidationProposal(args.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get consolidation: %w", err)
+		}
+		return formatProposal(proposal), nil
+	}
+
+	status := args.Status
+	switch status {
+	case "":
+		status = memory.ProposalPending
+	case "all":
+		status = ""
+	}
+
+	proposals := store.ConsolidationProposals(status)
+	if len(proposals) == 0 {
+		if status == "" {
+			return "No consolidation proposals. Run propose_consolidations to create some.", nil
+		}
+		return fmt.Sprintf("No %s consolidation proposals.", status), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d consolidation proposal(s):\n\n", len(proposals)))
+	writeProposalList(&result, proposals)
+	return result.String(), nil
+}
+
+type consolidationIDArgs struct {
+	ID string `json:"id"`
+}
+
+func (s *Server) handleApplyConsolidation(store *memory.Store, args consolidationIDArgs) (string, error) {
+	merged, err := store.ApplyConsolidation(args.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply consolidation: %w", err)
+	}
+	return fmt.Sprintf("Consolidation %s applied. New memory ID: %s (supersedes its sources, which are kept).", args.ID, merged.ID), nil
+}
+
+func (s *Server) handleRejectConsolidation(store *memory.Store, args consolidationIDArgs) (string, error) {
+	if err := store.RejectConsolidation(args.ID); err != nil {
+		return "", fmt.Errorf("failed to reject consolidation: %w", err)
+	}
+	return fmt.Sprintf("Consolidation %s rejected.", args.ID), nil
+}
+
+// writeProposalList writes one numbered entry per proposal
+func writeProposalList(result *strings.Builder, proposals []*memory.ConsolidationProposal) {
+	for i, proposal := range proposals {
+		result.WriteString(fmt.Sprintf("%d. **%s** (ID: %s, %s, %d memories, %.0f%% similar)\n",
+			i+1, truncateRunes(proposal.Summary, maxSummaryRunes), proposal.ID, proposal.Status,
+			len(proposal.SourceIDs), proposal.Similarity*100))
+		if len(proposal.Keywords) > 0 {
+			result.WriteString(fmt.Sprintf("   Keywords: %s\n", strings.Join(proposal.Keywords, ", ")))
+		}
+		result.WriteString(fmt.Sprintf("   Sources: %s\n", strings.Join(proposal.SourceIDs, ", ")))
+	}
+}
+
+// formatProposal renders a proposal in full for review
+func formatProposal(proposal *memory.ConsolidationProposal) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("## Consolidation %s (%s)\n\n", proposal.ID, proposal.Status))
+	result.WriteString(fmt.Sprintf("**Summary:** %s\n", proposal.Summary))
+	if proposal.Category != "" {
+		result.WriteString(fmt.Sprintf("**Category:** %s\n", proposal.Category))
+	}
+	if len(proposal.Tags) > 0 {
+		result.WriteString(fmt.Sprintf("**Tags:** %s\n", strings.Join(proposal.Tags, ", ")))
+	}
+	if len(proposal.Keywords) > 0 {
+		result.WriteString(fmt.Sprintf("**Keywords:** %s\n", strings.Join(proposal.Keywords, ", ")))
+	}
+	result.WriteString(fmt.Sprintf("**Similarity:** %.0f%%\n", proposal.Similarity*100))
+	result.WriteString(fmt.Sprintf("**Sources:** %s\n", strings.Join(proposal.SourceIDs, ", ")))
+	if proposal.MergedID != "" {
+		result.WriteString(fmt.Sprintf("**Merged into:** %s\n", proposal.MergedID))
+	}
+	result.WriteString(fmt.Sprintf("\n**Merged content:**\n\n%s\n", proposal.Content))
+	return result.String()
+}
@@ -0,0 +1,71 @@
+// internal/mcp/flags.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// CoreContextURI is the resource holding every pinned memory
+const CoreContextURI = "memory://core-context"
+
+type pinMemoryArgs struct {
+	ID     string `json:"id"`
+	Pinned *bool  `json:"pinned"`
+}
+
+func (s *Server) handlePinMemory(store *memory.Store, args pinMemoryArgs) (string, error) {
+	pinned := args.Pinned == nil || *args.Pinned
+	if _, err := store.SetPinned(args.ID, pinned); err != nil {
+		return "", fmt.Errorf("failed to pin memory: %w", err)
+	}
+
+	if pinned {
+		return fmt.Sprintf("Memory %s is pinned and included in the core context (%s).", args.ID, CoreContextURI), nil
+	}
+	return fmt.Sprintf("Memory %s is no longer pinned.", args.ID), nil
+}
+
+type protectMemoryArgs struct {
+	ID        string `json:"id"`
+	Protected *bool  `json:"protected"`
+}
+
+func (s *Server) handleProtectMemory(store *memory.Store, args protectMemoryArgs) (string, error) {
+	protected := args.Protected == nil || *args.Protected
+	if _, err := store.SetProtected(args.ID, protected); err != nil {
+		return "", fmt.Errorf("failed to protect memory: %w", err)
+	}
+
+	if protected {
+		return fmt.Sprintf("Memory %s is protected. Deleting it requires override_protection.", args.ID), nil
+	}
+	return fmt.Sprintf("Memory %s is no longer protected.", args.ID), nil
+}
+
+// coreContextResource describes the core context in resources/list
+func coreContextResource() map[string]interface{} {
+	return map[string]interface{}{
+		"uri":         CoreContextURI,
+		"name":        "Core context",
+		"description": "Pinned memories that should always be in context",
+		"mimeType":    "text/markdown",
+	}
+}
+
+// renderCoreContext renders every pinned memory in full
+func (s *Server) renderCoreContext() string {
+	pinned := s.store.PinnedMemories()
+	if len(pinned) == 0 {
+		return "No memories are pinned. Pin one with pin_memory to add it to the core context."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# Core context (%d pinned memories)\n\n", len(pinned)))
+	for i, m := range pinned {
+		result.WriteString(renderFullMemory(i+1, m, m.Content))
+	}
+	return result.String()
+}
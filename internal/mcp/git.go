@@ -0,0 +1,53 @@
+// internal/mcp/git.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// gitSyncTool pulls team changes into a git-backed store and pushes local ones
+func (s *Server) gitSyncTool() *Tool {
+	return NewTool("git_sync",
+		"Pull memories shared through the git remote, merge them with local changes and push the result. "+
+			"Edits of the same memory version on both sides are kept, the remote one as a sibling version",
+		objectSchema(map[string]interface{}{
+			"pull_only": booleanProp("Merge remote changes without pushing local ones"),
+		}),
+		s.handleGitSync)
+}
+
+type gitSyncArgs struct {
+	PullOnly bool `json:"pull_only"`
+}
+
+func (s *Server) handleGitSync(store *memory.Store, args gitSyncArgs) (string, error) {
+	sync := store.GitSync
+	if args.PullOnly {
+		sync = store.GitPull
+	}
+	result, err := sync()
+	if err != nil {
+		return "", fmt.Errorf("failed to sync memories: %w", err)
+	}
+
+	var out strings.Builder
+	if !result.Merged {
+		out.WriteString("Already up to date with the remote.")
+	} else {
+		out.WriteString(fmt.Sprintf("Merged remote changes: %d memory versions updated, %d removed.",
+			len(result.Updated), len(result.Removed)))
+	}
+	if len(result.Conflicts) > 0 {
+		out.WriteString(fmt.Sprintf("\nConflicting edits kept as siblings: %s", strings.Join(result.Conflicts, ", ")))
+	}
+	if result.Pushed {
+		out.WriteString("\nLocal changes pushed.")
+	}
+	if len(result.Head) >= 8 {
+		out.WriteString(fmt.Sprintf("\nNow at commit %s.", result.Head[:8]))
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,55 @@
+// internal/mcp/metrics.go
+package mcp
+
+import (
+	"errors"
+	"time"
+
+	"mcp-memory-server/internal/metrics"
+)
+
+// Protocol metrics, served on /metrics
+var (
+	requestsTotal = metrics.Default.NewCounter("mcp_requests_total",
+		"MCP requests by JSON-RPC method.", "method")
+	requestDuration = metrics.Default.NewHistogram("mcp_request_duration_seconds",
+		"Latency of MCP requests by JSON-RPC method.", metrics.DefaultBuckets, "method")
+	toolCallsTotal = metrics.Default.NewCounter("mcp_tool_calls_total",
+		"MCP tool calls by tool and outcome.", "tool", "status")
+	toolCallDuration = metrics.Default.NewHistogram("mcp_tool_call_duration_seconds",
+		"Latency of MCP tool calls by tool.", metrics.DefaultBuckets, "tool")
+)
+
+// knownMethods bounds the method label; anything else counts as unknown
+var knownMethods = map[string]bool{
+	"initialize":       true,
+	"ping":             true,
+	"logging/setLevel": true,
+	"tools/list":       true,
+	"tools/call":       true,
+	"resources/list":   true,
+	"resources/read":   true,
+}
+
+// observeRequest records a request that started at start
+func observeRequest(method string, start time.Time) {
+	if !knownMethods[method] {
+		method = "unknown"
+	}
+	requestsTotal.Inc(method)
+	requestDuration.ObserveSince(start, method)
+}
+
+// observeToolCall records a call to a registered tool that started at start
+func observeToolCall(tool string, start time.Time, err error) {
+	status := "ok"
+	var argErr *ArgumentError
+	switch {
+	case errors.As(err, &argErr):
+		status = "invalid_arguments"
+	case err != nil:
+		status = "error"
+	}
+	toolCallsTotal.Inc(tool, status)
+	toolCallDuration.ObserveSince(start, tool)
+}
@@ -0,0 +1,63 @@
+// internal/mcp/metrics_test.go
+package mcp
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/metrics"
+)
+
+// sample scrapes the default registry and returns the value of one series,
+// or 0 when it has not been recorded yet
+func sample(t *testing.T, series string) float64 {
+	t.Helper()
+	var b strings.Builder
+	metrics.Default.WriteTo(&b)
+	for _, line := range strings.Split(b.String(), "\n") {
+		if value, found := strings.CutPrefix(line, series+" "); found {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("Bad sample %q: %v", line, err)
+			}
+			return v
+		}
+	}
+	return 0
+}
+
+func TestToolCallsAreMeasured(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	ok := `mcp_tool_calls_total{tool="remember",status="ok"}`
+	invalid := `mcp_tool_calls_total{tool="remember",status="invalid_arguments"}`
+	latency := `mcp_tool_call_duration_seconds_count{tool="remember"}`
+	calls := `mcp_requests_total{method="tools/call"}`
+	okBefore, invalidBefore, latencyBefore, callsBefore := sample(t, ok), sample(t, invalid), sample(t, latency), sample(t, calls)
+
+	c.toolText("remember", map[string]interface{}{"content": "Metrics are scraped every 15 seconds"})
+	c.call("tools/call", map[string]interface{}{"name": "remember", "arguments": map[string]interface{}{}})
+	// Requests are measured once answered; the next response follows that
+	c.call("ping", nil)
+
+	if got := sample(t, ok) - okBefore; got != 1 {
+		t.Errorf("Successful calls = %g, want 1", got)
+	}
+	if got := sample(t, invalid) - invalidBefore; got != 1 {
+		t.Errorf("Calls with invalid arguments = %g, want 1", got)
+	}
+	if got := sample(t, latency) - latencyBefore; got != 2 {
+		t.Errorf("Latency observations = %g, want 2", got)
+	}
+	if got := sample(t, calls) - callsBefore; got != 2 {
+		t.Errorf("tools/call requests = %g, want 2", got)
+	}
+	if sample(t, `mcp_memory_operation_duration_seconds_count{operation="remember"}`) == 0 {
+		t.Error("The store should measure the remember it served")
+	}
+	if sample(t, `mcp_memory_index_entries{index="memories"}`) == 0 {
+		t.Error("Index sizes should be collected on scrape")
+	}
+}
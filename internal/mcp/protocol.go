@@ -0,0 +1,51 @@
+// internal/mcp/protocol.go
+package mcp
+
+// JSON-RPC 2.0 error codes used by the MCP transport
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// LatestProtocolVersion is the newest MCP spec revision this server implements
+const LatestProtocolVersion = "2025-06-18"
+
+// SupportedProtocolVersions lists every MCP spec revision this server can speak, newest first
+var SupportedProtocolVersions = []string{
+	"2025-06-18",
+	"2025-03-26",
+	"2024-11-05",
+}
+
+// negotiateProtocolVersion picks the protocol version to answer initialize with.
+// Per the spec, the server echoes the client's version when it supports it and
+// otherwise proposes the latest version it knows; the client decides whether to continue.
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range SupportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return LatestProtocolVersion
+}
+
+// versionAtLeast reports whether version is the same as or newer than min.
+// MCP versions are ISO dates, so lexical comparison orders them correctly.
+func versionAtLeast(version, min string) bool {
+	return version >= min
+}
+
+// logLevelSeverity maps MCP (RFC 5424) log levels to an ordering, lowest first
+var logLevelSeverity = map[string]int{
+	"debug":     0,
+	"info":      1,
+	"notice":    2,
+	"warning":   3,
+	"error":     4,
+	"critical":  5,
+	"alert":     6,
+	"emergency": 7,
+}
@@ -0,0 +1,95 @@
+// internal/mcp/relations.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+type linkMemoriesArgs struct {
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id"`
+	Type     string `json:"type"`
+}
+
+func (s *Server) handleLinkMemories(store *memory.Store, args linkMemoriesArgs) (string, error) {
+	created, err := store.Link(args.SourceID, args.TargetID, args.Type)
+	if err != nil {
+		return "", fmt.Errorf("failed to link memories: %w", err)
+	}
+
+	if !created {
+		return fmt.Sprintf("Link %s -[%s]-> %s already exists.", args.SourceID, args.Type, args.TargetID), nil
+	}
+	return fmt.Sprintf("Linked %s -[%s]-> %s.", args.SourceID, args.Type, args.TargetID), nil
+}
+
+type unlinkMemoriesArgs struct {
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id"`
+	Type     string `json:"type"`
+}
+
+func (s *Server) handleUnlinkMemories(store *memory.Store, args unlinkMemoriesArgs) (string, error) {
+	removed, err := store.Unlink(args.SourceID, args.TargetID, args.Type)
+	if err != nil {
+		return "", fmt.Errorf("failed to unlink memories: %w", err)
+	}
+
+	if removed == 0 {
+		return fmt.Sprintf("No links from %s to %s to remove.", args.SourceID, args.TargetID), nil
+	}
+	return fmt.Sprintf("Removed %d link(s) from %s to %s.", removed, args.SourceID, args.TargetID), nil
+}
+
+type getRelatedArgs struct {
+	ID        string   `json:"id"`
+	Depth     int      `json:"depth"`
+	Types     []string `json:"types"`
+	Direction string   `json:"direction"`
+	Limit     int      `json:"limit"`
+}
+
+func (s *Server) handleGetRelated(store *memory.Store, args getRelatedArgs) (string, error) {
+	graph, err := store.Related(args.ID, memory.RelatedOptions{
+		Depth:     args.Depth,
+		Types:     args.Types,
+		Direction: args.Direction,
+		Limit:     args.Limit,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get related memories: %w", err)
+	}
+
+	root := graph.Nodes[0].Memory
+	if len(graph.Nodes) == 1 {
+		return fmt.Sprintf("No memories are linked to %s (%s).", graph.Root, summarizeMemory(root)), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d memories related to %s (%s):\n\n", len(graph.Nodes)-1, graph.Root, summarizeMemory(root)))
+	for i, node := range graph.Nodes[1:] {
+		result.WriteString(fmt.Sprintf("%d. **%s** (ID: %s, depth %d)\n", i+1, summarizeMemory(node.Memory), memory.BaseID(node.Memory.ID), node.Depth))
+	}
+
+	result.WriteString("\nLinks:\n")
+	for _, edge := range graph.Edges {
+		result.WriteString(fmt.Sprintf("- %s -[%s]-> %s\n", edge.Source, edge.Type, edge.Target))
+	}
+
+	if graph.Truncated {
+		result.WriteString(fmt.Sprintf("\n_Stopped after %d memories; raise limit or lower depth to see the rest._\n", len(graph.Nodes)-1))
+	}
+	return result.String(), nil
+}
+
+// formatLinks renders outgoing links as "type -> target" pairs
+func formatLinks(links []memory.Link) string {
+	parts := make([]string, len(links))
+	for i, link := range links {
+		parts[i] = link.Type + " -> " + link.Target
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,197 @@
+// internal/mcp/schema.go
+package mcp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validateSchema checks a decoded JSON value against the subset of JSON Schema
+// used by tool input schemas: type, properties, required, additionalProperties,
+// items, enum, minimum/maximum, minLength and the date-time format.
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if schemaType, ok := schema["type"].(string); ok {
+		if err := checkType(schemaType, value, path); err != nil {
+			return err
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok && !enumContains(enum, value) {
+		return fmt.Errorf("%s must be one of %v", displayPath(path), enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, v, path)
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case float64:
+		if min, ok := toFloat(schema["minimum"]); ok && v < min {
+			return fmt.Errorf("%s must be at least %v", displayPath(path), min)
+		}
+		if max, ok := toFloat(schema["maximum"]); ok && v > max {
+			return fmt.Errorf("%s must be at most %v", displayPath(path), max)
+		}
+	case string:
+		if minLength, ok := toFloat(schema["minLength"]); ok && float64(len(v)) < minLength {
+			return fmt.Errorf("%s must be at least %v characters", displayPath(path), minLength)
+		}
+		if format, _ := schema["format"].(string); format == "date-time" && v != "" {
+			if _, err := parseDateTime(v); err != nil {
+				return fmt.Errorf("%s must be an ISO 8601 date or date-time", displayPath(path))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateObject checks required and declared properties of an object value
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) error {
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s is required", joinPath(path, name))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Validate in a stable order so errors are deterministic
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propSchema, declared := properties[name].(map[string]interface{})
+		if !declared {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s is not a recognized argument", joinPath(path, name))
+				}
+			case map[string]interface{}:
+				if err := validateSchema(additional, obj[name], joinPath(path, name)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := validateSchema(propSchema, obj[name], joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkType verifies the JSON type of a value decoded by encoding/json
+func checkType(schemaType string, value interface{}, path string) error {
+	ok := false
+	switch schemaType {
+	case "object":
+		_, ok = value.(map[string]interface{})
+	case "array":
+		_, ok = value.([]interface{})
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		f, isNumber := value.(float64)
+		ok = isNumber && f == math.Trunc(f)
+	case "null":
+		ok = value == nil
+	default:
+		ok = true
+	}
+
+	if !ok {
+		return fmt.Errorf("%s must be of type %s", displayPath(path), schemaType)
+	}
+	return nil
+}
+
+// parseDateTime accepts RFC 3339 timestamps and plain dates
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func enumContains(enum interface{}, value interface{}) bool {
+	switch values := enum.(type) {
+	case []string:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		for _, v := range values {
+			if v == s {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return strings.TrimPrefix(path, ".")
+}
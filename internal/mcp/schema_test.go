@@ -0,0 +1,60 @@
+// internal/mcp/schema_test.go
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{"name"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 1},
+			"mode":  map[string]interface{}{"type": "string", "enum": []string{"fast", "slow"}},
+			"count": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 10},
+			"when":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"nested": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"flag": map[string]interface{}{"type": "boolean"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		value   map[string]interface{}
+		wantErr string
+	}{
+		{"valid", map[string]interface{}{"name": "a", "mode": "fast", "count": 3.0, "when": "2025-01-02"}, ""},
+		{"valid rfc3339", map[string]interface{}{"name": "a", "when": "2025-01-02T15:04:05Z"}, ""},
+		{"missing required", map[string]interface{}{}, "name is required"},
+		{"empty string", map[string]interface{}{"name": ""}, "at least 1 characters"},
+		{"enum", map[string]interface{}{"name": "a", "mode": "medium"}, "must be one of"},
+		{"integer", map[string]interface{}{"name": "a", "count": 1.5}, "count must be of type integer"},
+		{"minimum", map[string]interface{}{"name": "a", "count": 0.0}, "at least 1"},
+		{"maximum", map[string]interface{}{"name": "a", "count": 11.0}, "at most 10"},
+		{"date-time", map[string]interface{}{"name": "a", "when": "yesterday"}, "date-time"},
+		{"unknown property", map[string]interface{}{"name": "a", "extra": true}, "extra is not a recognized argument"},
+		{"nested path", map[string]interface{}{"name": "a", "nested": map[string]interface{}{"flag": "yes"}}, "nested.flag must be of type boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchema(schema, tt.value, "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"mcp-memory-server/internal/memory"
@@ -40,16 +42,45 @@ type MCPError struct {
 
 // Server implements the MCP protocol for memory operations
 type Server struct {
-	store  *memory.Store
-	logger *logger.Logger
+	store   *memory.Store
+	logger  *logger.Logger
+	tools   *ToolRegistry
+	in      io.Reader
+	out     io.Writer
+	writeMu sync.Mutex // serializes messages written to out
+
+	// Session state negotiated during initialize
+	mu                 sync.RWMutex
+	protocolVersion    string
+	clientInfo         map[string]interface{}
+	clientCapabilities map[string]interface{}
+	initialized        bool
+	logLevel           string // minimum level for notifications/message, empty until logging/setLevel
 }
 
-// NewServer creates a new MCP server
+// NewServer creates a new MCP server speaking over stdin/stdout
 func NewServer(store *memory.Store, logger *logger.Logger) *Server {
-	return &Server{
+	return NewServerWithIO(store, logger, os.Stdin, os.Stdout)
+}
+
+// NewServerWithIO creates a new MCP server reading requests from in and writing responses to out
+func NewServerWithIO(store *memory.Store, logger *logger.Logger, in io.Reader, out io.Writer) *Server {
+	server := &Server{
 		store:  store,
 		logger: logger.WithComponent("mcp_server"),
+		tools:  NewToolRegistry(),
+		in:     in,
+		out:    out,
 	}
+	server.registerBuiltinTools()
+	return server
+}
+
+// ProtocolVersion returns the protocol version negotiated with the client, or empty before initialize
+func (s *Server) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocolVersion
 }
 
 // Run starts the MCP server and handles requests
@@ -57,7 +88,7 @@ func (s *Server) Run(ctx context.Context) error {
 	s.logger.Info("MCP server starting")
 
 	// Don't send server info on startup - wait for initialize
-	scanner := bufio.NewScanner(os.Stdin)
+	scanner := bufio.NewScanner(s.in)
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
@@ -75,8 +106,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 		if err := s.handleRequest(line); err != nil {
 			s.logger.WithError(err).Error("Failed to handle request")
-			// Send error response with proper ID
-			s.sendError(nil, -32603, "Internal error", err.Error())
+			s.sendError(nil, ErrCodeInternal, "Internal error", err.Error())
 		}
 	}
 
@@ -87,18 +117,44 @@ func (s *Server) Run(ctx context.Context) error {
 	return nil
 }
 
-// handleRequest processes an MCP request
+// handleRequest processes an MCP request or notification
 func (s *Server) handleRequest(requestLine string) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(requestLine), &raw); err != nil {
+		if strings.HasPrefix(strings.TrimSpace(requestLine), "[") {
+			return s.sendError(nil, ErrCodeInvalidRequest, "Invalid Request", "Batch requests are not supported")
+		}
+		return s.sendError(nil, ErrCodeParse, "Parse error", "Invalid JSON")
+	}
+
 	var req MCPRequest
 	if err := json.Unmarshal([]byte(requestLine), &req); err != nil {
-		return s.sendError(nil, -32700, "Parse error", "Invalid JSON")
+		return s.sendError(nil, ErrCodeInvalidRequest, "Invalid Request", err.Error())
+	}
+
+	// A message without an id is a notification and must never be answered
+	if _, hasID := raw["id"]; !hasID {
+		s.handleNotification(req)
+		return nil
+	}
+
+	if req.JSONRPC != "2.0" {
+		return s.sendError(req.ID, ErrCodeInvalidRequest, "Invalid Request", "jsonrpc must be \"2.0\"")
+	}
+	if req.Method == "" {
+		return s.sendError(req.ID, ErrCodeInvalidRequest, "Invalid Request", "Missing method")
 	}
 
 	s.logger.Debug("Handling MCP request", "method", req.Method, "id", req.ID)
+	defer observeRequest(req.Method, time.Now())
 
 	switch req.Method {
 	case "initialize":
 		return s.handleInitialize(req)
+	case "ping":
+		return s.sendResponse(req.ID, map[string]interface{}{})
+	case "logging/setLevel":
+		return s.handleSetLevel(req)
 	case "tools/list":
 		return s.handleToolsList(req)
 	case "tools/call":
@@ -108,167 +164,146 @@ func (s *Server) handleRequest(requestLine string) error {
 	case "resources/read":
 		return s.handleResourcesRead(req)
 	default:
-		return s.sendError(req.ID, -32601, "Method not found", fmt.Sprintf("Unknown method: %s", req.Method))
+		return s.sendError(req.ID, ErrCodeMethodNotFound, "Method not found", fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// handleNotification processes client notifications, which never receive a response
+func (s *Server) handleNotification(req MCPRequest) {
+	switch req.Method {
+	case "notifications/initialized":
+		s.mu.Lock()
+		s.initialized = true
+		s.mu.Unlock()
+		s.logger.Info("Client initialization complete", "protocol_version", s.ProtocolVersion())
+	case "notifications/cancelled":
+		// Requests are handled synchronously, so by the time a cancellation
+		// arrives the response has already been sent
+		s.logger.Debug("Ignoring cancellation notification", "params", req.Params)
+	default:
+		s.logger.Debug("Ignoring unknown notification", "method", req.Method)
 	}
 }
 
 // handleInitialize handles the MCP initialize method
 func (s *Server) handleInitialize(req MCPRequest) error {
+	params, _ := req.Params.(map[string]interface{})
+	requested, _ := params["protocolVersion"].(string)
+	if requested == "" {
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid params", "protocolVersion is required")
+	}
+
+	version := negotiateProtocolVersion(requested)
+	clientInfo, _ := params["clientInfo"].(map[string]interface{})
+	clientCapabilities, _ := params["capabilities"].(map[string]interface{})
+
+	s.mu.Lock()
+	s.protocolVersion = version
+	s.clientInfo = clientInfo
+	s.clientCapabilities = clientCapabilities
+	s.initialized = false
+	s.mu.Unlock()
+
+	s.logger.Info("Client initializing",
+		"requested_version", requested,
+		"negotiated_version", version,
+		"client", clientInfo["name"])
+
+	serverInfo := map[string]interface{}{
+		"name":    "memory-server",
+		"version": "1.0.0",
+	}
+	if versionAtLeast(version, "2025-06-18") {
+		serverInfo["title"] = "MCP Memory Server"
+	}
+
 	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{
-				"listChanged": false,
-			},
-			"resources": map[string]interface{}{
-				"subscribe":   false,
-				"listChanged": false,
-			},
+		"protocolVersion": version,
+		"capabilities":    s.serverCapabilities(),
+		"serverInfo":      serverInfo,
+	}
+	if versionAtLeast(version, "2025-03-26") {
+		result["instructions"] = "Use remember to store information, recall to search it and list_memories to browse it."
+	}
+
+	return s.sendResponse(req.ID, result)
+}
+
+// serverCapabilities describes the features this server offers during initialize
+func (s *Server) serverCapabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"logging": map[string]interface{}{},
+		"tools": map[string]interface{}{
+			"listChanged": true,
 		},
-		"serverInfo": map[string]interface{}{
-			"name":    "memory-server",
-			"version": "1.0.0",
+		"resources": map[string]interface{}{
+			"subscribe":   false,
+			"listChanged": false,
 		},
 	}
+}
 
-	return s.sendResponse(req.ID, result)
+// handleSetLevel handles logging/setLevel, controlling which log notifications reach the client
+func (s *Server) handleSetLevel(req MCPRequest) error {
+	params, _ := req.Params.(map[string]interface{})
+	level, _ := params["level"].(string)
+	if _, ok := logLevelSeverity[level]; !ok {
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid params", fmt.Sprintf("Unknown log level: %q", level))
+	}
+
+	s.mu.Lock()
+	s.logLevel = level
+	s.mu.Unlock()
+
+	s.logger.Info("Client log level set", "level", level)
+	return s.sendResponse(req.ID, map[string]interface{}{})
+}
+
+// RegisterTool adds a tool at runtime and tells the client the tool list changed
+func (s *Server) RegisterTool(tool *Tool) error {
+	if err := s.tools.Register(tool); err != nil {
+		return err
+	}
+	s.logger.Info("Tool registered", "tool", tool.Name)
+	s.notifyToolsChanged()
+	return nil
+}
+
+// UnregisterTool removes a tool at runtime, reporting whether it was registered
+func (s *Server) UnregisterTool(name string) bool {
+	if !s.tools.Unregister(name) {
+		return false
+	}
+	s.logger.Info("Tool unregistered", "tool", name)
+	s.notifyToolsChanged()
+	return true
+}
+
+// Tools returns the currently registered tools
+func (s *Server) Tools() []*Tool {
+	return s.tools.List()
+}
+
+// notifyToolsChanged sends notifications/tools/list_changed once the session is initialized
+func (s *Server) notifyToolsChanged() {
+	s.mu.RLock()
+	initialized := s.initialized
+	s.mu.RUnlock()
+
+	if !initialized {
+		return
+	}
+	if err := s.sendNotification("notifications/tools/list_changed", nil); err != nil {
+		s.logger.WithError(err).Warn("Failed to send tools list changed notification")
+	}
 }
 
 // handleToolsList returns available tools
 func (s *Server) handleToolsList(req MCPRequest) error {
-	tools := []map[string]interface{}{
-		{
-			"name":        "remember",
-			"description": "Store information in memory with optional categorization and tags",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"content": map[string]interface{}{
-						"type":        "string",
-						"description": "The content to remember",
-					},
-					"summary": map[string]interface{}{
-						"type":        "string",
-						"description": "Optional summary of the content",
-					},
-					"category": map[string]interface{}{
-						"type":        "string",
-						"description": "Optional category (e.g., 'code', 'concept', 'project')",
-					},
-					"tags": map[string]interface{}{
-						"type":        "array",
-						"items":       map[string]interface{}{"type": "string"},
-						"description": "Optional tags for categorization",
-					},
-				},
-				"required": []string{"content"},
-			},
-		},
-		{
-			"name":        "recall",
-			"description": "Search for stored memories",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"query": map[string]interface{}{
-						"type":        "string",
-						"description": "Search query",
-					},
-					"category": map[string]interface{}{
-						"type":        "string",
-						"description": "Optional category filter",
-					},
-					"tags": map[string]interface{}{
-						"type":        "array",
-						"items":       map[string]interface{}{"type": "string"},
-						"description": "Optional tags filter",
-					},
-					"limit": map[string]interface{}{
-						"type":        "integer",
-						"description": "Maximum number of results (default: 10)",
-						"default":     10,
-					},
-				},
-				"required": []string{"query"},
-			},
-		},
-		{
-			"name":        "forget",
-			"description": "Delete a stored memory by ID",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"id": map[string]interface{}{
-						"type":        "string",
-						"description": "Memory ID to delete",
-					},
-				},
-				"required": []string{"id"},
-			},
-		},
-		{
-			"name":        "list_memories",
-			"description": "List all stored memories with optional filtering",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"category": map[string]interface{}{
-						"type":        "string",
-						"description": "Optional category filter",
-					},
-					"tags": map[string]interface{}{
-						"type":        "array",
-						"items":       map[string]interface{}{"type": "string"},
-						"description": "Optional tags filter",
-					},
-					"limit": map[string]interface{}{
-						"type":        "integer",
-						"description": "Maximum number of results",
-						"default":     20,
-					},
-				},
-			},
-		},
-		{
-			"name":        "memory_stats",
-			"description": "Get statistics about stored memories",
-			"inputSchema": map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
-			},
-		},
-		{
-			"name":        "bulk_delete",
-			"description": "Delete multiple memories based on filters. Requires at least one filter and confirmation.",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"category": map[string]interface{}{
-						"type":        "string",
-						"description": "Delete memories in this category",
-					},
-					"tags": map[string]interface{}{
-						"type":        "array",
-						"items":       map[string]interface{}{"type": "string"},
-						"description": "Delete memories with any of these tags",
-					},
-					"before_date": map[string]interface{}{
-						"type":        "string",
-						"format":      "date-time",
-						"description": "Delete memories created before this date (ISO 8601 format)",
-					},
-					"query": map[string]interface{}{
-						"type":        "string",
-						"description": "Delete memories containing this text in content or summary",
-					},
-					"confirm": map[string]interface{}{
-						"type":        "boolean",
-						"description": "Must be true to execute deletion",
-					},
-				},
-				"required": []string{"confirm"},
-			},
-		},
+	registered := s.tools.List()
+	tools := make([]map[string]interface{}, 0, len(registered))
+	for _, tool := range registered {
+		tools = append(tools, tool.definition())
 	}
 
 	result := map[string]interface{}{
@@ -282,12 +317,12 @@ func (s *Server) handleToolsList(req MCPRequest) error {
 func (s *Server) handleToolsCall(req MCPRequest) error {
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
-		return s.sendError(req.ID, -32602, "Invalid params", "Expected object")
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid params", "Expected object")
 	}
 
 	toolName, ok := params["name"].(string)
 	if !ok {
-		return s.sendError(req.ID, -32602, "Invalid params", "Missing tool name")
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid params", "Missing tool name")
 	}
 
 	arguments, ok := params["arguments"].(map[string]interface{})
@@ -295,30 +330,41 @@ func (s *Server) handleToolsCall(req MCPRequest) error {
 		arguments = make(map[string]interface{})
 	}
 
+	tool, ok := s.tools.Get(toolName)
+	if !ok {
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Unknown tool", toolName)
+	}
+
 	s.logger.Info("Executing tool", "tool", toolName, "arguments", arguments)
 
-	var result interface{}
-	var err error
-
-	switch toolName {
-	case "remember":
-		result, err = s.handleRemember(arguments)
-	case "recall":
-		result, err = s.handleRecall(arguments)
-	case "forget":
-		result, err = s.handleForget(arguments)
-	case "list_memories":
-		result, err = s.handleListMemories(arguments)
-	case "memory_stats":
-		result, err = s.handleMemoryStats(arguments)
-	case "bulk_delete":
-		result, err = s.handleBulkDelete(arguments)
-	default:
-		return s.sendError(req.ID, -32602, "Unknown tool", toolName)
+	// The tool runs against a handle that attributes its operations to this call
+	store := s.store.As(memory.Caller{
+		Client:     s.clientName(),
+		Tool:       toolName,
+		ArgsDigest: memory.ArgsDigest(arguments),
+	})
+	start := time.Now()
+	result, err := tool.Call(store, arguments)
+	observeToolCall(toolName, start, err)
+
+	var argErr *ArgumentError
+	if errors.As(err, &argErr) {
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid arguments", argErr.Err.Error())
 	}
 
+	// Tool failures are reported inside the result so the model can see and
+	// react to them; protocol errors are reserved for malformed requests
 	if err != nil {
-		return s.sendError(req.ID, -32603, "Tool execution failed", err.Error())
+		s.notifyLog("error", map[string]interface{}{"tool": toolName, "error": err.Error()})
+		return s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("Tool execution failed: %s", err.Error()),
+				},
+			},
+			"isError": true,
+		})
 	}
 
 	toolResult := map[string]interface{}{
@@ -335,133 +381,431 @@ func (s *Server) handleToolsCall(req MCPRequest) error {
 
 // Tool implementations
 
-func (s *Server) handleRemember(args map[string]interface{}) (string, error) {
-	content, ok := args["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("content is required")
+// metadataFilterDescription documents the value syntax shared by metadata filters
+const metadataFilterDescription = "Optional metadata filters; every key must match. Values are exact (\"api\"), " +
+	"prefixes (\"ap*\"), presence (\"*\") or ranges (\">5\", \"<=2025-01-01\", \"1..10\")"
+
+// registerBuiltinTools registers the memory tools every server starts with
+func (s *Server) registerBuiltinTools() {
+	builtins := []*Tool{
+		NewTool("remember",
+			"Store information in memory with optional categorization and tags. The response flags likely "+
+				"near-duplicates of existing memories and suggests related memories to link",
+			objectSchema(map[string]interface{}{
+				"content":  stringProp("The content to remember"),
+				"summary":  stringProp("Optional summary of the content"),
+				"category": stringProp("Optional category (e.g., 'code', 'concept', 'project')"),
+				"tags":     stringArrayProp("Optional tags for categorization"),
+				"metadata": metadataProp("Optional key/value metadata, e.g. {\"repo\": \"api\", \"priority\": \"2\"}"),
+				"importance": numberProp("How important the memory is, from 0 to 1. Important memories rank higher "+
+					"and are evicted last", memory.DefaultImportance, 0, 1),
+			}, "content"),
+			s.handleRemember),
+		NewTool("recall",
+			"Search for stored memories. The query accepts free text plus \"exact phrases\", prefix*, "+
+				"field filters (tag:, category:, id:, content:, summary:, keyword:, metadata.<key>:), "+
+				"ranges (created:>2025-01-01, updated:2025-01-01..2025-02-01, access_count:>=3), "+
+				"AND, OR, NOT, -term and parentheses. Words match by stem (deploying finds deployment) and "+
+				"tolerate typos; word~ or word~2 forces typo-tolerant matching",
+			objectSchema(map[string]interface{}{
+				"query":    stringProp("Search query, e.g. tag:go AND NOT tag:deprecated \"connection pool\" created:>2025-01-01"),
+				"category": stringProp("Optional category filter"),
+				"tags":     stringArrayProp("Optional tags filter"),
+				"metadata": metadataProp(metadataFilterDescription),
+				"limit":    integerProp("Maximum number of results per page (default: 10)", 10),
+				"offset":   integerProp("Number of results to skip (ignored when cursor is set)", 0),
+				"sort":     sortProp(true),
+				"order":    orderProp(),
+				"max_tokens": integerProp("Approximate token budget for the response; results are truncated, "+
+					"summarized or deferred to the next page to fit (0 = unlimited)", 0),
+				"verbosity": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{VerbosityIDs, VerbositySummary, VerbosityFull},
+					"description": "How much of each memory to return: ids, summary or full (default: full)",
+				},
+				"cursor":   stringProp("Continuation cursor returned by a previous recall with the same query and sort"),
+				"stemming": booleanProp("Match words by their stem so deploying finds deployment (default: true)"),
+				"fuzziness": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"auto", "0", "1", "2"},
+					"description": "Typos tolerated in words that match nothing: auto scales with word length, 0 disables (default: auto)",
+				},
+			}, "query"),
+			s.handleRecall),
+		NewTool("forget",
+			"Delete a stored memory by ID. Deleted memories go to the trash and can be restored with restore_memory "+
+				"until they are purged. Protected memories are only deleted with override_protection",
+			objectSchema(map[string]interface{}{
+				"id":                  stringProp("Memory ID to delete"),
+				"override_protection": booleanProp("Delete the memory even if it is protected"),
+			}, "id"),
+			s.handleForget),
+		NewTool("list_memories",
+			"List all stored memories with optional filtering",
+			objectSchema(map[string]interface{}{
+				"category": stringProp("Optional category filter"),
+				"tags":     stringArrayProp("Optional tags filter"),
+				"metadata": metadataProp(metadataFilterDescription),
+				"limit":    integerProp("Maximum number of results", 20),
+				"offset":   integerProp("Number of results to skip (ignored when cursor is set)", 0),
+				"cursor":   stringProp("Continuation cursor returned by a previous list_memories with the same filters and sort"),
+				"sort":     sortProp(false),
+				"order":    orderProp(),
+			}),
+			s.handleListMemories),
+		NewTool("memory_stats",
+			"Get statistics about stored memories",
+			objectSchema(map[string]interface{}{}),
+			s.handleMemoryStats),
+		NewTool("bulk_delete",
+			"Delete multiple memories based on filters. Run with dry_run first to preview the matches, then "+
+				"again with confirm and the confirm_token from the preview. Deleted memories go to the trash.",
+			objectSchema(map[string]interface{}{
+				"category": stringProp("Delete memories in this category"),
+				"tags":     stringArrayProp("Delete memories with these tags"),
+				"tag_mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"any", "all"},
+					"description": "Whether memories need any or all of the tags (default: any)",
+				},
+				"before_date":       dateTimeProp("Delete memories created before this date (ISO 8601 format)"),
+				"after_date":        dateTimeProp("Delete memories created after this date (ISO 8601 format)"),
+				"query":             stringProp("Delete memories containing this text in content or summary"),
+				"metadata":          metadataProp(metadataFilterDescription),
+				"max_count":         integerProp("Delete at most this many memories, oldest first (0 = no limit)", 0),
+				"include_protected": booleanProp("Also delete matching protected memories (default: they are kept)"),
+				"dry_run":           booleanProp("Preview the matching memories, versions and bytes without deleting anything"),
+				"confirm":           booleanProp("Must be true to execute deletion"),
+				"confirm_token":     stringProp("Token returned by the dry run with the same filters"),
+			}),
+			s.handleBulkDelete),
+		NewTool("pin_memory",
+			"Pin a memory so it is always included in the core context resource ("+CoreContextURI+"), or unpin it",
+			objectSchema(map[string]interface{}{
+				"id":     stringProp("Memory ID to pin"),
+				"pinned": booleanProp("false to unpin (default: true)"),
+			}, "id"),
+			s.handlePinMemory),
+		NewTool("protect_memory",
+			"Protect a memory from deletion and eviction, or lift the protection. Protected memories are only "+
+				"deleted with an explicit override",
+			objectSchema(map[string]interface{}{
+				"id":        stringProp("Memory ID to protect"),
+				"protected": booleanProp("false to lift the protection (default: true)"),
+			}, "id"),
+			s.handleProtectMemory),
+		NewTool("link_memories",
+			"Link two memories with a typed, directed relation, e.g. a decision that supersedes an older one",
+			objectSchema(map[string]interface{}{
+				"source_id": stringProp("ID of the memory the link starts from"),
+				"target_id": stringProp("ID of the memory the link points to"),
+				"type":      relationTypeProp("Relation from source to target"),
+			}, "source_id", "target_id", "type"),
+			s.handleLinkMemories),
+		NewTool("unlink_memories",
+			"Remove links from one memory to another",
+			objectSchema(map[string]interface{}{
+				"source_id": stringProp("ID of the memory the link starts from"),
+				"target_id": stringProp("ID of the memory the link points to"),
+				"type":      relationTypeProp("Relation to remove (default: every relation between the two)"),
+			}, "source_id", "target_id"),
+			s.handleUnlinkMemories),
+		NewTool("get_related",
+			"Find memories linked to a memory, following links up to a given depth",
+			objectSchema(map[string]interface{}{
+				"id":    stringProp("ID of the memory to start from"),
+				"depth": integerProp(fmt.Sprintf("Number of links to follow (1-%d)", memory.MaxRelatedDepth), 1),
+				"types": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string", "enum": memory.RelationTypes},
+					"description": "Relation types to follow (default: all)",
+				},
+				"direction": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{memory.DirectionOutgoing, memory.DirectionIncoming, memory.DirectionBoth},
+					"description": "Follow links pointing away from each memory, towards it, or both (default: both)",
+				},
+				"limit": integerProp("Maximum number of related memories", memory.DefaultRelatedLimit),
+			}, "id"),
+			s.handleGetRelated),
+		NewTool("propose_consolidations",
+			"Cluster overlapping memories by keyword and TF-IDF similarity and propose merging each cluster "+
+				"into one memory with an extractive summary. Proposals replace earlier pending ones and are only "+
+				"applied after review with apply_consolidation",
+			objectSchema(map[string]interface{}{
+				"threshold":        numberProp("Minimum similarity (0-1) for two memories to be clustered", memory.DefaultConsolidationThreshold, 0, 1),
+				"min_cluster_size": integerProp("Smallest cluster to propose (at least 2)", memory.DefaultMinClusterSize),
+				"max_cluster_size": integerProp("Largest cluster to propose", memory.DefaultMaxClusterSize),
+				"category":         stringProp("Only consolidate memories in this category"),
+			}),
+			s.handleProposeConsolidations),
+		NewTool("list_consolidations",
+			"List consolidation proposals, or show one proposal's merged content and sources for review",
+			objectSchema(map[string]interface{}{
+				"id": stringProp("Proposal ID to show in full"),
+				"status": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{memory.ProposalPending, memory.ProposalApplied, memory.ProposalRejected, "all"},
+					"description": "Proposals to list (default: pending)",
+				},
+			}),
+			s.handleListConsolidations),
+		NewTool("apply_consolidation",
+			"Store a consolidation proposal as a new memory that supersedes its sources. The sources are kept and linked",
+			objectSchema(map[string]interface{}{
+				"id": stringProp("Proposal ID"),
+			}, "id"),
+			s.handleApplyConsolidation),
+		NewTool("reject_consolidation",
+			"Reject a consolidation proposal so the same cluster is not proposed again",
+			objectSchema(map[string]interface{}{
+				"id": stringProp("Proposal ID"),
+			}, "id"),
+			s.handleRejectConsolidation),
+		NewTool("list_trash",
+			"List deleted memories that can still be restored, with when each will be purged",
+			objectSchema(map[string]interface{}{}),
+			s.handleListTrash),
+		NewTool("restore_memory",
+			"Restore a deleted memory from the trash. A base ID restores its most recently deleted version",
+			objectSchema(map[string]interface{}{
+				"id": stringProp("ID of the deleted memory"),
+			}, "id"),
+			s.handleRestoreMemory),
+		NewTool("empty_trash",
+			"Permanently delete every memory in the trash. This cannot be undone",
+			objectSchema(map[string]interface{}{
+				"confirm": booleanProp("Must be true to empty the trash"),
+			}, "confirm"),
+			s.handleEmptyTrash),
+		NewTool("audit_log",
+			"Query the audit log of memory operations, most recent first, and optionally verify its hash chain",
+			objectSchema(map[string]interface{}{
+				"action": stringProp("Only entries for this action: store, access, delete, bulk_delete, evict, restore, purge, link, unlink or flag"),
+				"id":     stringProp("Only entries naming this memory ID or base ID"),
+				"client": stringProp("Only entries from clients whose name contains this text"),
+				"tool":   stringProp("Only entries made by this tool"),
+				"since":  dateTimeProp("Only entries at or after this time"),
+				"until":  dateTimeProp("Only entries before this time"),
+				"limit":  integerProp("Maximum number of entries to return", memory.DefaultAuditLimit),
+				"verify": booleanProp("Also verify the hash chain of the whole log"),
+			}),
+			s.handleAuditLog),
 	}
 
-	summary, _ := args["summary"].(string)
-	category, _ := args["category"].(string)
-
-	var tags []string
-	if tagsInterface, ok := args["tags"].([]interface{}); ok {
-		for _, tag := range tagsInterface {
-			if tagStr, ok := tag.(string); ok {
-				tags = append(tags, tagStr)
-			}
+	if s.store.GitEnabled() {
+		builtins = append(builtins, s.gitSyncTool())
+	}
+	for _, tool := range builtins {
+		if err := s.tools.Register(tool); err != nil {
+			s.logger.WithError(err).Error("Failed to register built-in tool", "tool", tool.Name)
 		}
 	}
+}
+
+type rememberArgs struct {
+	Content    string            `json:"content"`
+	Summary    string            `json:"summary"`
+	Category   string            `json:"category"`
+	Tags       []string          `json:"tags"`
+	Metadata   map[string]string `json:"metadata"`
+	Importance float64           `json:"importance"`
+}
 
-	memory, err := s.store.Store(content, summary, category, tags, nil)
+func (s *Server) handleRemember(store *memory.Store, args rememberArgs) (string, error) {
+	result, err := store.Remember(memory.MemoryInput{
+		Content:    args.Content,
+		Summary:    args.Summary,
+		Category:   args.Category,
+		Tags:       args.Tags,
+		Metadata:   args.Metadata,
+		Importance: args.Importance,
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to store memory: %w", err)
 	}
 
-	return fmt.Sprintf("Memory stored successfully with ID: %s", memory.ID), nil
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("Memory stored successfully with ID: %s", result.Memory.ID))
+	if result.MergedInto != "" {
+		response.WriteString(fmt.Sprintf("\n\nMerged as version %d of near-duplicate memory %s.", result.Memory.Version, result.MergedInto))
+	}
+	if len(result.Duplicates) > 0 && result.MergedInto == "" {
+		response.WriteString("\n\nPossible duplicates (consider forgetting one):\n")
+		writeSimilarMemories(&response, result.Duplicates)
+	}
+	if len(result.Related) > 0 {
+		response.WriteString("\n\nRelated memories (connect them with link_memories):\n")
+		writeSimilarMemories(&response, result.Related)
+	}
+	return response.String(), nil
 }
 
-func (s *Server) handleRecall(args map[string]interface{}) (string, error) {
-	query, ok := args["query"].(string)
-	if !ok {
-		return "", fmt.Errorf("query is required")
+// writeSimilarMemories lists similar memories with their similarity as a percentage
+func writeSimilarMemories(response *strings.Builder, similar []memory.SimilarMemory) {
+	for _, match := range similar {
+		response.WriteString(fmt.Sprintf("- **%s** (ID: %s, %.0f%% similar)\n",
+			summarizeMemory(match.Memory), match.Memory.ID, match.Similarity*100))
 	}
+}
 
+type recallArgs struct {
+	Query     string            `json:"query"`
+	Category  string            `json:"category"`
+	Tags      []string          `json:"tags"`
+	Metadata  map[string]string `json:"metadata"`
+	Limit     *int              `json:"limit"`
+	Offset    int               `json:"offset"`
+	Cursor    string            `json:"cursor"`
+	Sort      string            `json:"sort"`
+	Order     string            `json:"order"`
+	MaxTokens int               `json:"max_tokens"`
+	Verbosity string            `json:"verbosity"`
+	Stemming  *bool             `json:"stemming"`
+	Fuzziness string            `json:"fuzziness"`
+}
+
+func (s *Server) handleRecall(store *memory.Store, args recallArgs) (string, error) {
 	searchQuery := &memory.SearchQuery{
-		Query: query,
-		Limit: 10,
+		Query:    args.Query,
+		Category: args.Category,
+		Tags:     args.Tags,
+		Metadata: args.Metadata,
+		Limit:    10,
+		Offset:   args.Offset,
+		Cursor:   args.Cursor,
+		Sort:     memory.SortField(args.Sort),
+		Order:    args.Order,
 	}
 
-	if category, ok := args["category"].(string); ok {
-		searchQuery.Category = category
+	if args.Limit != nil && *args.Limit > 0 {
+		searchQuery.Limit = *args.Limit
 	}
 
-	if tagsInterface, ok := args["tags"].([]interface{}); ok {
-		for _, tag := range tagsInterface {
-			if tagStr, ok := tag.(string); ok {
-				searchQuery.Tags = append(searchQuery.Tags, tagStr)
-			}
-		}
+	match := memory.DefaultMatchOptions()
+	if args.Stemming != nil {
+		match.Stemming = *args.Stemming
 	}
+	fuzziness, err := memory.ParseFuzziness(args.Fuzziness)
+	if err != nil {
+		return "", err
+	}
+	match.Fuzziness = fuzziness
+	searchQuery.Match = &match
 
-	if limit, ok := args["limit"].(float64); ok {
-		searchQuery.Limit = int(limit)
+	verbosity := args.Verbosity
+	if verbosity == "" {
+		verbosity = VerbosityFull
 	}
 
-	memories, err := s.store.Search(searchQuery)
+	page, err := store.SearchPage(searchQuery)
 	if err != nil {
+		var syntaxErr *memory.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return "", fmt.Errorf("invalid query: %s", syntaxErr.Detail())
+		}
 		return "", fmt.Errorf("search failed: %w", err)
 	}
 
-	if len(memories) == 0 {
+	if page.Total == 0 {
 		return "No memories found matching your query.", nil
 	}
+	if len(page.Memories) == 0 {
+		return "No more memories match your query.", nil
+	}
+
+	fitted := fitToBudget(page.Memories, page.Offset+1, args.MaxTokens, verbosity)
+	shown := len(fitted.Entries)
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Found %d matching memories:\n\n", len(memories)))
+	result.WriteString(fmt.Sprintf("Found %d matching memories, showing %d-%d (~%d tokens):\n\n",
+		page.Total, page.Offset+1, page.Offset+shown, fitted.Tokens))
 
-	for i, memory := range memories {
-		result.WriteString(fmt.Sprintf("## Memory %d (ID: %s)\n", i+1, memory.ID))
-		if memory.Category != "" {
-			result.WriteString(fmt.Sprintf("**Category:** %s\n", memory.Category))
-		}
-		if len(memory.Tags) > 0 {
-			result.WriteString(fmt.Sprintf("**Tags:** %s\n", strings.Join(memory.Tags, ", ")))
-		}
-		if memory.Summary != "" {
-			result.WriteString(fmt.Sprintf("**Summary:** %s\n", memory.Summary))
-		}
-		result.WriteString(fmt.Sprintf("**Created:** %s\n", memory.CreatedAt.Format("2006-01-02 15:04:05")))
-		result.WriteString(fmt.Sprintf("**Content:**\n%s\n\n", memory.Content))
-		result.WriteString("---\n\n")
+	for _, entry := range fitted.Entries {
+		result.WriteString(entry)
+	}
+
+	if len(fitted.Truncated) > 0 {
+		result.WriteString(fmt.Sprintf("\n_Content truncated to fit max_tokens: %s_\n", strings.Join(fitted.Truncated, ", ")))
+	}
+	if len(fitted.Downgraded) > 0 {
+		result.WriteString(fmt.Sprintf("\n_Shown in less detail to fit max_tokens: %s_\n", strings.Join(fitted.Downgraded, ", ")))
+	}
+
+	// Continue after the last result actually shown, which may be before the end of the page
+	next := page.CursorAfter(shown - 1)
+	if next != "" {
+		result.WriteString(fmt.Sprintf("\n_%d more matching memories omitted. Call recall again with cursor \"%s\" to continue._\n",
+			page.Total-page.Offset-shown, next))
 	}
 
 	return result.String(), nil
 }
 
-func (s *Server) handleForget(args map[string]interface{}) (string, error) {
-	id, ok := args["id"].(string)
-	if !ok {
-		return "", fmt.Errorf("id is required")
-	}
+type forgetArgs struct {
+	ID                 string `json:"id"`
+	OverrideProtection bool   `json:"override_protection"`
+}
 
-	if err := s.store.Delete(id); err != nil {
+func (s *Server) handleForget(store *memory.Store, args forgetArgs) (string, error) {
+	deleteMemory := store.Delete
+	if args.OverrideProtection {
+		deleteMemory = store.ForceDelete
+	}
+	if err := deleteMemory(args.ID); err != nil {
+		if errors.Is(err, memory.ErrProtected) {
+			return "", fmt.Errorf("memory %s is protected: set override_protection to delete it anyway", args.ID)
+		}
 		return "", fmt.Errorf("failed to delete memory: %w", err)
 	}
 
-	return fmt.Sprintf("Memory with ID %s has been forgotten.", id), nil
+	if store.TrashEnabled() {
+		return fmt.Sprintf("Memory with ID %s has been forgotten. It is in the trash and can be restored with restore_memory.", args.ID), nil
+	}
+	return fmt.Sprintf("Memory with ID %s has been forgotten.", args.ID), nil
 }
 
-func (s *Server) handleListMemories(args map[string]interface{}) (string, error) {
-	category, _ := args["category"].(string)
-	limit := 20
-	if l, ok := args["limit"].(float64); ok {
-		limit = int(l)
-	}
+type listMemoriesArgs struct {
+	Category string            `json:"category"`
+	Tags     []string          `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+	Limit    *int              `json:"limit"`
+	Offset   int               `json:"offset"`
+	Cursor   string            `json:"cursor"`
+	Sort     string            `json:"sort"`
+	Order    string            `json:"order"`
+}
 
-	var tags []string
-	if tagsInterface, ok := args["tags"].([]interface{}); ok {
-		for _, tag := range tagsInterface {
-			if tagStr, ok := tag.(string); ok {
-				tags = append(tags, tagStr)
-			}
-		}
+func (s *Server) handleListMemories(store *memory.Store, args listMemoriesArgs) (string, error) {
+	limit := 20
+	if args.Limit != nil {
+		limit = *args.Limit
 	}
 
-	memories, err := s.store.List(category, tags, limit)
+	page, err := store.ListPage(&memory.ListOptions{
+		Category: args.Category,
+		Tags:     args.Tags,
+		Metadata: args.Metadata,
+		Limit:    limit,
+		Offset:   args.Offset,
+		Cursor:   args.Cursor,
+		Sort:     memory.SortField(args.Sort),
+		Order:    args.Order,
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to list memories: %w", err)
 	}
 
-	if len(memories) == 0 {
+	if len(page.Memories) == 0 {
 		return "No memories found.", nil
 	}
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Found %d memories:\n\n", len(memories)))
+	result.WriteString(fmt.Sprintf("Found %d memories, showing %d-%d (sorted by %s, %s):\n\n",
+		page.Total, page.Offset+1, page.Offset+len(page.Memories), page.Sort, page.Order))
 
-	for i, memory := range memories {
-		result.WriteString(fmt.Sprintf("%d. **%s** (ID: %s)\n", i+1,
+	for i, memory := range page.Memories {
+		result.WriteString(fmt.Sprintf("%d. **%s** (ID: %s)\n", page.Offset+i+1,
 			memory.Summary, memory.ID))
 		if memory.Category != "" {
 			result.WriteString(fmt.Sprintf("   Category: %s\n", memory.Category))
@@ -469,6 +813,9 @@ func (s *Server) handleListMemories(args map[string]interface{}) (string, error)
 		if len(memory.Tags) > 0 {
 			result.WriteString(fmt.Sprintf("   Tags: %s\n", strings.Join(memory.Tags, ", ")))
 		}
+		if len(memory.Metadata) > 0 {
+			result.WriteString(fmt.Sprintf("   Metadata: %s\n", formatMetadata(memory.Metadata)))
+		}
 		result.WriteString(fmt.Sprintf("   Created: %s, Accessed: %d times\n",
 			memory.CreatedAt.Format("2006-01-02"), memory.AccessCount))
 
@@ -480,17 +827,34 @@ func (s *Server) handleListMemories(args map[string]interface{}) (string, error)
 		result.WriteString(fmt.Sprintf("   Content: %s\n\n", content))
 	}
 
+	if page.NextCursor != "" {
+		result.WriteString(fmt.Sprintf("_More memories available. Call list_memories again with cursor \"%s\" to continue._\n", page.NextCursor))
+	}
+
 	return result.String(), nil
 }
 
-func (s *Server) handleMemoryStats(args map[string]interface{}) (string, error) {
-	stats := s.store.GetStats()
+type memoryStatsArgs struct{}
+
+func (s *Server) handleMemoryStats(store *memory.Store, args memoryStatsArgs) (string, error) {
+	stats := store.GetStats()
 
 	var result strings.Builder
 	result.WriteString("## Memory Statistics\n\n")
 	result.WriteString(fmt.Sprintf("**Total Memories:** %d\n", stats["total_memories"]))
 	result.WriteString(fmt.Sprintf("**Total Access Count:** %d\n", stats["total_access_count"]))
-	result.WriteString(fmt.Sprintf("**Data Directory:** %s\n\n", stats["data_directory"]))
+	result.WriteString(fmt.Sprintf("**Data Directory:** %s\n", stats["data_directory"]))
+	result.WriteString(fmt.Sprintf("**Memories in Trash:** %d\n", stats["trash_count"]))
+	result.WriteString(fmt.Sprintf("**Average Importance:** %.2f\n", stats["average_importance"]))
+	result.WriteString(fmt.Sprintf("**Average Retention Score:** %.2f\n\n", stats["average_retention_score"]))
+
+	if lowest, ok := stats["lowest_retention"].([]memory.RetainedMemory); ok && len(lowest) > 0 {
+		result.WriteString("**Next to evict when storage is full:**\n")
+		for _, retained := range lowest {
+			result.WriteString(fmt.Sprintf("- %s (retention %.2f)\n", retained.ID, retained.Score))
+		}
+		result.WriteString("\n")
+	}
 
 	if categories, ok := stats["categories"].(map[string]int); ok && len(categories) > 0 {
 		result.WriteString("**Categories:**\n")
@@ -502,65 +866,86 @@ func (s *Server) handleMemoryStats(args map[string]interface{}) (string, error)
 	return result.String(), nil
 }
 
-func (s *Server) handleBulkDelete(args map[string]interface{}) (string, error) {
-	// Parse confirmation flag
-	confirm, ok := args["confirm"].(bool)
-	if !ok || !confirm {
-		return "", fmt.Errorf("confirmation required: set confirm to true to execute bulk deletion")
-	}
-
-	// Create BulkDeleteOptions from arguments
-	options := &memory.BulkDeleteOptions{
-		Confirm: confirm,
-	}
+type bulkDeleteArgs struct {
+	Category         string            `json:"category"`
+	Tags             []string          `json:"tags"`
+	TagMode          string            `json:"tag_mode"`
+	BeforeDate       string            `json:"before_date"`
+	AfterDate        string            `json:"after_date"`
+	Query            string            `json:"query"`
+	Metadata         map[string]string `json:"metadata"`
+	MaxCount         int               `json:"max_count"`
+	IncludeProtected bool              `json:"include_protected"`
+	DryRun           bool              `json:"dry_run"`
+	Confirm          bool              `json:"confirm"`
+	ConfirmToken     string            `json:"confirm_token"`
+}
 
-	// Parse optional filters
-	if category, ok := args["category"].(string); ok {
-		options.Category = category
+func (s *Server) handleBulkDelete(store *memory.Store, args bulkDeleteArgs) (string, error) {
+	if !args.Confirm && !args.DryRun {
+		return "", fmt.Errorf("confirmation required: run with dry_run first, then with confirm and the confirm_token it returns")
 	}
 
-	if tagsInterface, ok := args["tags"].([]interface{}); ok {
-		for _, tag := range tagsInterface {
-			if tagStr, ok := tag.(string); ok {
-				options.Tags = append(options.Tags, tagStr)
-			}
-		}
+	options := &memory.BulkDeleteOptions{
+		Category:         args.Category,
+		Tags:             args.Tags,
+		MatchAllTags:     args.TagMode == "all",
+		Query:            args.Query,
+		Metadata:         args.Metadata,
+		MaxCount:         args.MaxCount,
+		IncludeProtected: args.IncludeProtected,
+		DryRun:           args.DryRun,
+		Confirm:          args.Confirm,
+		ConfirmToken:     args.ConfirmToken,
 	}
 
-	if beforeDateStr, ok := args["before_date"].(string); ok && beforeDateStr != "" {
-		// Parse ISO 8601 date
-		beforeDate, err := time.Parse(time.RFC3339, beforeDateStr)
+	if args.BeforeDate != "" {
+		beforeDate, err := parseDateTime(args.BeforeDate)
 		if err != nil {
-			// Try other common formats
-			beforeDate, err = time.Parse("2006-01-02", beforeDateStr)
-			if err != nil {
-				return "", fmt.Errorf("invalid date format for before_date: %s (use ISO 8601 format)", beforeDateStr)
-			}
+			return "", fmt.Errorf("invalid date format for before_date: %s (use ISO 8601 format)", args.BeforeDate)
 		}
 		options.BeforeDate = beforeDate
 	}
-
-	if query, ok := args["query"].(string); ok {
-		options.Query = query
+	if args.AfterDate != "" {
+		afterDate, err := parseDateTime(args.AfterDate)
+		if err != nil {
+			return "", fmt.Errorf("invalid date format for after_date: %s (use ISO 8601 format)", args.AfterDate)
+		}
+		options.AfterDate = afterDate
 	}
 
 	// Execute bulk delete
-	deletedCount, err := s.store.BulkDelete(options)
+	deleted, err := store.BulkDelete(options)
 	if err != nil {
 		return "", fmt.Errorf("bulk delete failed: %w", err)
 	}
+	deletedCount := deleted.Deleted
 
 	// Build result message
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("## Bulk Delete Completed\n\n"))
-	result.WriteString(fmt.Sprintf("**Deleted:** %d memories\n\n", deletedCount))
-	
+	if deleted.DryRun {
+		result.WriteString("## Bulk Delete Dry Run\n\n")
+		result.WriteString(fmt.Sprintf("**Would delete:** %d memories (%d versions, %d bytes)\n\n",
+			len(deleted.Memories), len(deleted.IDs), deleted.Bytes))
+	} else {
+		result.WriteString(fmt.Sprintf("## Bulk Delete Completed\n\n"))
+		result.WriteString(fmt.Sprintf("**Deleted:** %d memories (%d versions, %d bytes)\n\n",
+			len(deleted.Memories), deletedCount, deleted.Bytes))
+	}
+
 	result.WriteString("**Filters Applied:**\n")
 	if options.Category != "" {
 		result.WriteString(fmt.Sprintf("- Category: %s\n", options.Category))
 	}
 	if len(options.Tags) > 0 {
-		result.WriteString(fmt.Sprintf("- Tags: %s\n", strings.Join(options.Tags, ", ")))
+		mode := "any"
+		if options.MatchAllTags {
+			mode = "all"
+		}
+		result.WriteString(fmt.Sprintf("- Tags (%s): %s\n", mode, strings.Join(options.Tags, ", ")))
+	}
+	if !options.AfterDate.IsZero() {
+		result.WriteString(fmt.Sprintf("- After Date: %s\n", options.AfterDate.Format("2006-01-02")))
 	}
 	if !options.BeforeDate.IsZero() {
 		result.WriteString(fmt.Sprintf("- Before Date: %s\n", options.BeforeDate.Format("2006-01-02")))
@@ -568,27 +953,66 @@ func (s *Server) handleBulkDelete(args map[string]interface{}) (string, error) {
 	if options.Query != "" {
 		result.WriteString(fmt.Sprintf("- Query: %s\n", options.Query))
 	}
+	if len(options.Metadata) > 0 {
+		result.WriteString(fmt.Sprintf("- Metadata: %s\n", formatMetadata(options.Metadata)))
+	}
+	if options.MaxCount > 0 {
+		result.WriteString(fmt.Sprintf("- Max Count: %d (oldest first)\n", options.MaxCount))
+	}
 
-	if deletedCount == 0 {
+	switch {
+	case len(deleted.Memories) == 0:
 		result.WriteString("\nNo memories matched the specified filters.")
-	} else {
-		result.WriteString(fmt.Sprintf("\nAll %d matching memories and their versions have been permanently deleted.", deletedCount))
+	case deleted.DryRun:
+		result.WriteString("\nThese memories would be deleted with every version:\n")
+		for _, match := range deleted.Memories {
+			result.WriteString(fmt.Sprintf("- %s (%d versions, %d bytes)\n", match.BaseID, match.Versions, match.Bytes))
+		}
+		result.WriteString(fmt.Sprintf("\nTo delete them, call bulk_delete again with the same filters, confirm set to true "+
+			"and confirm_token \"%s\". The token expires as soon as the matching memories change.", deleted.ConfirmToken))
+	case store.TrashEnabled():
+		result.WriteString(fmt.Sprintf("\nAll %d matching memories and their versions have been moved to the trash. "+
+			"Restore them with restore_memory: %s", len(deleted.Memories), strings.Join(deleted.IDs, ", ")))
+	default:
+		result.WriteString(fmt.Sprintf("\nAll %d matching memories and their versions have been permanently deleted.", len(deleted.Memories)))
+	}
+	if deleted.Truncated > 0 {
+		result.WriteString(fmt.Sprintf("\n\n%d more matching memories were left for a later call by max_count.", deleted.Truncated))
+	}
+	if len(deleted.Protected) > 0 {
+		result.WriteString(fmt.Sprintf("\n\nKept %d protected memories (set include_protected to delete them): %s",
+			len(deleted.Protected), strings.Join(deleted.Protected, ", ")))
 	}
 
 	return result.String(), nil
 }
 
-// handleResourcesList handles resource listing (not implemented for now)
+// handleResourcesList lists the readable resources
 func (s *Server) handleResourcesList(req MCPRequest) error {
 	result := map[string]interface{}{
-		"resources": []interface{}{},
+		"resources": []interface{}{coreContextResource()},
 	}
 	return s.sendResponse(req.ID, result)
 }
 
-// handleResourcesRead handles resource reading (not implemented for now)
+// handleResourcesRead returns the contents of a resource by URI
 func (s *Server) handleResourcesRead(req MCPRequest) error {
-	return s.sendError(req.ID, -32601, "Not implemented", "Resource reading not implemented")
+	params, _ := req.Params.(map[string]interface{})
+	uri, _ := params["uri"].(string)
+	if uri != CoreContextURI {
+		return s.sendError(req.ID, ErrCodeInvalidParams, "Invalid params", fmt.Sprintf("Unknown resource: %q", uri))
+	}
+
+	result := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      uri,
+				"mimeType": "text/markdown",
+				"text":     s.renderCoreContext(),
+			},
+		},
+	}
+	return s.sendResponse(req.ID, result)
 }
 
 // Helper methods for MCP protocol
@@ -603,17 +1027,12 @@ func (s *Server) sendResponse(id interface{}, result interface{}) error {
 	return s.sendJSON(response)
 }
 
-// sendError sends an error response, handling null ID properly
+// sendError sends an error response. A nil id is sent as JSON null, as
+// JSON-RPC requires when the request id could not be determined.
 func (s *Server) sendError(id interface{}, code int, message, data string) error {
-	// Ensure ID is never null - use 0 if not provided
-	responseID := id
-	if responseID == nil {
-		responseID = 0
-	}
-
 	response := MCPResponse{
 		JSONRPC: "2.0",
-		ID:      responseID,
+		ID:      id,
 		Error: &MCPError{
 			Code:    code,
 			Message: message,
@@ -624,21 +1043,56 @@ func (s *Server) sendError(id interface{}, code int, message, data string) error
 	return s.sendJSON(response)
 }
 
-// sendJSON sends JSON to stdout and flushes immediately
+// sendNotification sends a server-initiated JSON-RPC notification
+func (s *Server) sendNotification(method string, params interface{}) error {
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		notification["params"] = params
+	}
+
+	return s.sendJSON(notification)
+}
+
+// notifyLog forwards a log message to the client if it has asked for logs at this level
+func (s *Server) notifyLog(level string, data interface{}) {
+	s.mu.RLock()
+	minLevel := s.logLevel
+	s.mu.RUnlock()
+
+	if minLevel == "" || logLevelSeverity[level] < logLevelSeverity[minLevel] {
+		return
+	}
+
+	if err := s.sendNotification("notifications/message", map[string]interface{}{
+		"level":  level,
+		"logger": "mcp_server",
+		"data":   data,
+	}); err != nil {
+		s.logger.WithError(err).Warn("Failed to send log notification")
+	}
+}
+
+// sendJSON writes one JSON message followed by a newline and flushes immediately
 func (s *Server) sendJSON(v interface{}) error {
 	data, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	// Write to stdout followed by newline and flush
-	_, err = fmt.Printf("%s\n", string(data))
-	if err != nil {
-		return fmt.Errorf("failed to write to stdout: %w", err)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(s.out, "%s\n", data); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
 	}
 
 	// Force flush to ensure data is sent immediately
-	os.Stdout.Sync()
+	if f, ok := s.out.(*os.File); ok {
+		f.Sync()
+	}
 	return nil
 }
 
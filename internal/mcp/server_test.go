@@ -0,0 +1,890 @@
+// internal/mcp/server_test.go
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/pkg/logger"
+)
+
+// testClient drives an mcp.Server over in-memory pipes, the way an MCP host would over stdio
+type testClient struct {
+	t       *testing.T
+	server  *Server
+	store   *memory.Store
+	toSrv   *io.PipeWriter
+	fromSrv *bufio.Scanner
+	done    chan error
+	nextID  int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+
+	tempDir, err := os.MkdirTemp("", "mcp-server-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	cfg := &config.StorageConfig{
+		DataDir:           tempDir,
+		MaxFileSize:       1024 * 1024,
+		MaxStorageSize:    10 * 1024 * 1024,
+		EnableAsync:       false,
+		EnableCompression: false,
+		TrashRetention:    time.Hour,
+		EnableAudit:       true,
+	}
+	log := logger.New("error", "text")
+
+	store, err := memory.NewStore(tempDir, cfg, log)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	c := &testClient{
+		t:       t,
+		server:  NewServerWithIO(store, log, inR, outW),
+		store:   store,
+		toSrv:   inW,
+		fromSrv: bufio.NewScanner(outR),
+		done:    make(chan error, 1),
+	}
+	c.fromSrv.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	go func() {
+		err := c.server.Run(context.Background())
+		outW.Close()
+		c.done <- err
+	}()
+
+	t.Cleanup(func() {
+		inW.Close()
+		select {
+		case <-c.done:
+		case <-time.After(5 * time.Second):
+			t.Error("Server did not stop after input was closed")
+		}
+		store.Close()
+	})
+
+	return c
+}
+
+// sendRaw writes a single line to the server
+func (c *testClient) sendRaw(line string) {
+	c.t.Helper()
+	if _, err := fmt.Fprintln(c.toSrv, line); err != nil {
+		c.t.Fatalf("Failed to write to server: %v", err)
+	}
+}
+
+// notify sends a notification, which carries no id
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	c.sendRaw(string(data))
+}
+
+// call sends a request and returns the next message from the server, which must be its response
+func (c *testClient) call(method string, params interface{}) map[string]interface{} {
+	c.t.Helper()
+	c.nextID++
+	id := c.nextID
+	msg := map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	c.sendRaw(string(data))
+
+	resp := c.read()
+	if got, ok := resp["id"].(float64); !ok || int(got) != id {
+		c.t.Fatalf("Expected response with id %d, got %v", id, resp)
+	}
+	return resp
+}
+
+// read returns the next message the server wrote
+func (c *testClient) read() map[string]interface{} {
+	c.t.Helper()
+
+	lines := make(chan string, 1)
+	go func() {
+		if c.fromSrv.Scan() {
+			lines <- c.fromSrv.Text()
+		}
+		close(lines)
+	}()
+
+	select {
+	case line, ok := <-lines:
+		if !ok {
+			c.t.Fatalf("Server closed output")
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			c.t.Fatalf("Server wrote invalid JSON %q: %v", line, err)
+		}
+		if msg["jsonrpc"] != "2.0" {
+			c.t.Fatalf("Message is missing jsonrpc 2.0: %s", line)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("Timed out waiting for server output")
+		return nil
+	}
+}
+
+// initialize performs the initialize handshake with the requested protocol version
+func (c *testClient) initialize(version string) map[string]interface{} {
+	c.t.Helper()
+	resp := c.call("initialize", map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"roots": map[string]interface{}{"listChanged": true}},
+		"clientInfo":      map[string]interface{}{"name": "test-client", "version": "0.0.1"},
+	})
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		c.t.Fatalf("initialize failed: %v", resp)
+	}
+	c.notify("notifications/initialized", nil)
+
+	// Round-trip a ping so the notification is processed before the test continues
+	c.call("ping", nil)
+	return result
+}
+
+func errorCode(t *testing.T, resp map[string]interface{}) int {
+	t.Helper()
+	e, ok := resp["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected error response, got %v", resp)
+	}
+	return int(e["code"].(float64))
+}
+
+func TestInitializeNegotiatesSupportedVersions(t *testing.T) {
+	for _, version := range SupportedProtocolVersions {
+		t.Run(version, func(t *testing.T) {
+			c := newTestClient(t)
+			result := c.initialize(version)
+
+			if result["protocolVersion"] != version {
+				t.Errorf("Expected protocolVersion %s, got %v", version, result["protocolVersion"])
+			}
+			if c.server.ProtocolVersion() != version {
+				t.Errorf("Server recorded version %s, expected %s", c.server.ProtocolVersion(), version)
+			}
+
+			caps, ok := result["capabilities"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("Missing capabilities: %v", result)
+			}
+			for _, capability := range []string{"tools", "resources", "logging"} {
+				if _, ok := caps[capability]; !ok {
+					t.Errorf("Missing %s capability", capability)
+				}
+			}
+
+			info, _ := result["serverInfo"].(map[string]interface{})
+			if info["name"] != "memory-server" {
+				t.Errorf("Unexpected serverInfo: %v", info)
+			}
+			_, hasTitle := info["title"]
+			if hasTitle != versionAtLeast(version, "2025-06-18") {
+				t.Errorf("serverInfo.title presence should depend on version %s", version)
+			}
+			_, hasInstructions := result["instructions"]
+			if hasInstructions != versionAtLeast(version, "2025-03-26") {
+				t.Errorf("instructions presence should depend on version %s", version)
+			}
+		})
+	}
+}
+
+func TestInitializeUnknownVersionFallsBackToLatest(t *testing.T) {
+	c := newTestClient(t)
+	result := c.initialize("1999-01-01")
+
+	if result["protocolVersion"] != LatestProtocolVersion {
+		t.Errorf("Expected fallback to %s, got %v", LatestProtocolVersion, result["protocolVersion"])
+	}
+}
+
+func TestInitializeRequiresProtocolVersion(t *testing.T) {
+	c := newTestClient(t)
+	resp := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected invalid params, got %d", code)
+	}
+}
+
+func TestPing(t *testing.T) {
+	c := newTestClient(t)
+
+	// Ping is allowed before initialization
+	resp := c.call("ping", nil)
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok || len(result) != 0 {
+		t.Errorf("Expected empty result for ping, got %v", resp)
+	}
+
+	c.initialize(LatestProtocolVersion)
+	if _, ok := c.call("ping", nil)["result"]; !ok {
+		t.Error("Expected result for ping after initialization")
+	}
+}
+
+func TestNotificationsGetNoResponse(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.notify("notifications/cancelled", map[string]interface{}{"requestId": 1})
+	c.notify("notifications/unknown", nil)
+	c.notify("tools/list", nil)
+
+	// If any notification had been answered, this read would return that answer instead
+	resp := c.call("ping", nil)
+	if _, ok := resp["result"]; !ok {
+		t.Errorf("Expected ping result, got %v", resp)
+	}
+}
+
+func TestErrorsUseNullIDWhenUnknown(t *testing.T) {
+	c := newTestClient(t)
+
+	c.sendRaw("{not json")
+	resp := c.read()
+	if id, exists := resp["id"]; !exists || id != nil {
+		t.Errorf("Expected null id for parse error, got %v", resp["id"])
+	}
+	if code := errorCode(t, resp); code != ErrCodeParse {
+		t.Errorf("Expected parse error, got %d", code)
+	}
+
+	c.sendRaw(`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`)
+	resp = c.read()
+	if resp["id"] != nil {
+		t.Errorf("Expected null id for batch rejection, got %v", resp["id"])
+	}
+	if code := errorCode(t, resp); code != ErrCodeInvalidRequest {
+		t.Errorf("Expected invalid request, got %d", code)
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	c := newTestClient(t)
+
+	c.sendRaw(`{"jsonrpc":"1.0","id":"abc","method":"ping"}`)
+	resp := c.read()
+	if resp["id"] != "abc" {
+		t.Errorf("Expected string id to be echoed, got %v", resp["id"])
+	}
+	if code := errorCode(t, resp); code != ErrCodeInvalidRequest {
+		t.Errorf("Expected invalid request, got %d", code)
+	}
+
+	resp = c.call("no/such/method", nil)
+	if code := errorCode(t, resp); code != ErrCodeMethodNotFound {
+		t.Errorf("Expected method not found, got %d", code)
+	}
+}
+
+func TestLoggingSetLevel(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	resp := c.call("logging/setLevel", map[string]interface{}{"level": "verbose"})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected invalid params for unknown level, got %d", code)
+	}
+
+	resp = c.call("logging/setLevel", map[string]interface{}{"level": "error"})
+	if _, ok := resp["result"]; !ok {
+		t.Fatalf("Expected result for setLevel, got %v", resp)
+	}
+
+	// A failing tool call now emits an error log notification before its result
+	c.nextID++
+	c.sendRaw(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"forget","arguments":{"id":"missing"}}}`, c.nextID))
+
+	notification := c.read()
+	if notification["method"] != "notifications/message" {
+		t.Fatalf("Expected log notification, got %v", notification)
+	}
+	if _, hasID := notification["id"]; hasID {
+		t.Error("Notifications must not carry an id")
+	}
+	params, _ := notification["params"].(map[string]interface{})
+	if params["level"] != "error" {
+		t.Errorf("Expected error level, got %v", params["level"])
+	}
+
+	result, _ := c.read()["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Errorf("Expected tool failure to be reported with isError, got %v", result)
+	}
+}
+
+func TestToolCallAfterHandshake(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize("2024-11-05")
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "remember",
+		"arguments": map[string]interface{}{"content": "The handshake works"},
+	})
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected result, got %v", resp)
+	}
+	if result["isError"] == true {
+		t.Errorf("Unexpected tool error: %v", result)
+	}
+
+	resp = c.call("tools/call", map[string]interface{}{"name": "does_not_exist"})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected invalid params for unknown tool, got %d", code)
+	}
+}
+
+func toolNames(t *testing.T, resp map[string]interface{}) map[string]bool {
+	t.Helper()
+	result, _ := resp["result"].(map[string]interface{})
+	tools, ok := result["tools"].([]interface{})
+	if !ok {
+		t.Fatalf("Expected tools list, got %v", resp)
+	}
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		names[tool.(map[string]interface{})["name"].(string)] = true
+	}
+	return names
+}
+
+func TestDynamicToolRegistration(t *testing.T) {
+	c := newTestClient(t)
+	result := c.initialize(LatestProtocolVersion)
+
+	caps := result["capabilities"].(map[string]interface{})
+	if caps["tools"].(map[string]interface{})["listChanged"] != true {
+		t.Error("Expected tools.listChanged capability")
+	}
+
+	type echoArgs struct {
+		Text  string `json:"text"`
+		Times int    `json:"times"`
+	}
+	echo := NewTool("echo", "Echo text back",
+		objectSchema(map[string]interface{}{
+			"text":  stringProp("Text to echo"),
+			"times": integerProp("Repetitions", 1),
+		}, "text"),
+		func(_ *memory.Store, args echoArgs) (string, error) {
+			return fmt.Sprintf("%s x%d", args.Text, args.Times), nil
+		})
+
+	// Registration happens off the request loop, as a config reload would
+	go func() {
+		if err := c.server.RegisterTool(echo); err != nil {
+			t.Errorf("Failed to register tool: %v", err)
+		}
+	}()
+	if msg := c.read(); msg["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("Expected list_changed notification, got %v", msg)
+	}
+
+	if !toolNames(t, c.call("tools/list", nil))["echo"] {
+		t.Error("Registered tool missing from tools/list")
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "echo",
+		"arguments": map[string]interface{}{"text": "hi", "times": 3},
+	})
+	content := resp["result"].(map[string]interface{})["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "hi x3" {
+		t.Errorf("Expected typed arguments to be decoded, got %v", text)
+	}
+
+	if err := c.server.RegisterTool(echo); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+
+	go c.server.UnregisterTool("bulk_delete")
+	if msg := c.read(); msg["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("Expected list_changed notification, got %v", msg)
+	}
+	if toolNames(t, c.call("tools/list", nil))["bulk_delete"] {
+		t.Error("Unregistered tool still listed")
+	}
+	resp = c.call("tools/call", map[string]interface{}{"name": "bulk_delete", "arguments": map[string]interface{}{"confirm": true}})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected unknown tool error, got %d", code)
+	}
+
+	if c.server.UnregisterTool("bulk_delete") {
+		t.Error("Unregistering twice should report false")
+	}
+}
+
+func TestToolArgumentValidation(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+	}{
+		{"missing required", "remember", map[string]interface{}{"summary": "no content"}},
+		{"wrong type", "remember", map[string]interface{}{"content": 42}},
+		{"wrong item type", "remember", map[string]interface{}{"content": "x", "tags": []interface{}{"ok", 1}}},
+		{"non-integer limit", "recall", map[string]interface{}{"query": "x", "limit": 2.5}},
+		{"negative limit", "list_memories", map[string]interface{}{"limit": -1}},
+		{"bad date", "bulk_delete", map[string]interface{}{"confirm": true, "before_date": "last tuesday"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := c.call("tools/call", map[string]interface{}{"name": tt.tool, "arguments": tt.args})
+			if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+				t.Errorf("Expected invalid params, got %d", code)
+			}
+		})
+	}
+}
+
+// toolText calls a tool and returns the text of its first content block
+func (c *testClient) toolText(name string, args map[string]interface{}) string {
+	c.t.Helper()
+	resp := c.call("tools/call", map[string]interface{}{"name": name, "arguments": args})
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		c.t.Fatalf("Expected result from %s, got %v", name, resp)
+	}
+	content := result["content"].([]interface{})
+	return content[0].(map[string]interface{})["text"].(string)
+}
+
+func TestRecallTokenBudgetAndCursor(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	for i := 0; i < 6; i++ {
+		content := fmt.Sprintf("Deployment note %d: %s", i, strings.Repeat("kubernetes rollout details ", 40))
+		if _, err := c.store.Store(content, "", "ops", nil, nil); err != nil {
+			t.Fatalf("Failed to store memory: %v", err)
+		}
+	}
+
+	text := c.toolText("recall", map[string]interface{}{"query": "kubernetes", "max_tokens": 400})
+	if !strings.Contains(text, "Found 6 matching memories") {
+		t.Fatalf("Unexpected recall header: %s", text)
+	}
+	if estimateTokens(text) > 500 {
+		t.Errorf("Response of ~%d tokens ignores the 400 token budget", estimateTokens(text))
+	}
+
+	cursorStart := strings.Index(text, `cursor "`)
+	if cursorStart == -1 {
+		t.Fatalf("Expected a continuation cursor in: %s", text)
+	}
+	cursor := text[cursorStart+len(`cursor "`):]
+	cursor = cursor[:strings.Index(cursor, `"`)]
+
+	next := c.toolText("recall", map[string]interface{}{"query": "kubernetes", "max_tokens": 400, "cursor": cursor})
+	if strings.Contains(next, "showing 1-") {
+		t.Errorf("Continuation should not restart at the first result: %s", next)
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "recall",
+		"arguments": map[string]interface{}{"query": "other", "cursor": cursor},
+	})
+	if result := resp["result"].(map[string]interface{}); result["isError"] != true {
+		t.Errorf("Expected cursor from another query to be rejected, got %v", result)
+	}
+
+	ids := c.toolText("recall", map[string]interface{}{"query": "kubernetes", "verbosity": "ids"})
+	if strings.Contains(ids, "rollout details") {
+		t.Errorf("ids verbosity should not include content: %s", ids)
+	}
+}
+
+func TestRecallQueryLanguage(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.store.Store("Use pgx for the connection pool", "", "decision", []string{"go", "database"}, nil)
+	c.store.Store("The old ORM layer is deprecated", "", "decision", []string{"go", "deprecated"}, nil)
+
+	text := c.toolText("recall", map[string]interface{}{"query": `tag:go AND NOT tag:deprecated "connection pool"`})
+	if !strings.Contains(text, "Found 1 matching memories") || !strings.Contains(text, "pgx") {
+		t.Errorf("Unexpected recall result: %s", text)
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "recall",
+		"arguments": map[string]interface{}{"query": "tag:go AND (category:decision"},
+	})
+	result := resp["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("Expected a syntax error to be reported as a tool error, got %v", result)
+	}
+	message := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(message, "syntax error at position 12") || !strings.Contains(message, "missing closing ')'") {
+		t.Errorf("Syntax error should name the position and problem: %s", message)
+	}
+	if !strings.Contains(message, "\n  tag:go AND (category:decision\n             ^") {
+		t.Errorf("Syntax error should point at the problem: %s", message)
+	}
+}
+
+func TestRecallStemmingAndTypos(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.store.Store("Deployment runbook for the Kubernetes cluster", "", "ops", nil, nil)
+
+	for _, query := range []string{"deploying", "kuberentes", "kubernetis~2"} {
+		text := c.toolText("recall", map[string]interface{}{"query": query})
+		if !strings.Contains(text, "Found 1 matching memories") {
+			t.Errorf("recall %q should find the runbook: %s", query, text)
+		}
+	}
+
+	text := c.toolText("recall", map[string]interface{}{"query": "kuberentes", "fuzziness": "0"})
+	if !strings.Contains(text, "No memories found") {
+		t.Errorf("fuzziness 0 should disable typo tolerance: %s", text)
+	}
+	text = c.toolText("recall", map[string]interface{}{"query": "deploying", "stemming": false})
+	if !strings.Contains(text, "No memories found") {
+		t.Errorf("stemming false should require the literal word: %s", text)
+	}
+}
+
+func TestRememberAndFilterMetadata(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.toolText("remember", map[string]interface{}{
+		"content":  "Rate limits live in the gateway config",
+		"metadata": map[string]interface{}{"repo": "api", "priority": "3"},
+	})
+	c.toolText("remember", map[string]interface{}{
+		"content":  "Terraform state is split per environment",
+		"metadata": map[string]interface{}{"repo": "infra"},
+	})
+
+	list := c.toolText("list_memories", map[string]interface{}{"metadata": map[string]interface{}{"repo": "api"}})
+	if !strings.Contains(list, "Found 1 memories") || !strings.Contains(list, "Metadata: priority=3, repo=api") {
+		t.Errorf("Unexpected list_memories result: %s", list)
+	}
+
+	recall := c.toolText("recall", map[string]interface{}{"query": "metadata.priority:>=2"})
+	if !strings.Contains(recall, "Found 1 matching memories") || !strings.Contains(recall, "**Metadata:** priority=3, repo=api") {
+		t.Errorf("Unexpected recall result: %s", recall)
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "remember",
+		"arguments": map[string]interface{}{"content": "x", "metadata": map[string]interface{}{"priority": 3}},
+	})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected non-string metadata to be rejected, got %d", code)
+	}
+}
+
+func TestLinkMemoriesAndGetRelated(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	service, _ := c.store.Store("Billing service", "", "arch", nil, nil)
+	database, _ := c.store.Store("Billing database", "", "arch", nil, nil)
+	decision, _ := c.store.Store("Move billing to Postgres", "", "decision", nil, nil)
+
+	text := c.toolText("link_memories", map[string]interface{}{"source_id": service.ID, "target_id": database.ID, "type": "depends_on"})
+	if !strings.Contains(text, "Linked "+service.ID+" -[depends_on]-> "+database.ID) {
+		t.Errorf("Unexpected link result: %s", text)
+	}
+	c.toolText("link_memories", map[string]interface{}{"source_id": decision.ID, "target_id": database.ID, "type": "relates_to"})
+
+	text = c.toolText("get_related", map[string]interface{}{"id": database.ID})
+	if !strings.Contains(text, "Found 2 memories related to") || !strings.Contains(text, "Billing service") ||
+		!strings.Contains(text, "Move billing to Postgres") {
+		t.Errorf("Unexpected related result: %s", text)
+	}
+
+	text = c.toolText("get_related", map[string]interface{}{"id": database.ID, "types": []string{"depends_on"}})
+	if !strings.Contains(text, "Found 1 memories") || strings.Contains(text, "Move billing") {
+		t.Errorf("Type filter should keep only depends_on links: %s", text)
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{
+		"name":      "link_memories",
+		"arguments": map[string]interface{}{"source_id": service.ID, "target_id": database.ID, "type": "blocks"},
+	})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Unknown relation type should be invalid params, got %d", code)
+	}
+
+	text = c.toolText("unlink_memories", map[string]interface{}{"source_id": service.ID, "target_id": database.ID})
+	if !strings.Contains(text, "Removed 1 link(s)") {
+		t.Errorf("Unexpected unlink result: %s", text)
+	}
+	text = c.toolText("get_related", map[string]interface{}{"id": service.ID})
+	if !strings.Contains(text, "No memories are linked") {
+		t.Errorf("Service should have no links left: %s", text)
+	}
+}
+
+func TestRememberFlagsDuplicates(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	note := "Run database migrations with goose before deploying the API so new columns exist when the code ships"
+	original, _ := c.store.Store(note, "", "decision", nil, nil)
+
+	text := c.toolText("remember", map[string]interface{}{"content": note + "."})
+	if !strings.Contains(text, "Memory stored successfully") || !strings.Contains(text, "Possible duplicates") ||
+		!strings.Contains(text, original.ID) {
+		t.Errorf("Near-duplicate should be flagged: %s", text)
+	}
+
+	text = c.toolText("remember", map[string]interface{}{"content": "The CDN caches static assets for one hour"})
+	if strings.Contains(text, "Possible duplicates") || strings.Contains(text, "Related memories") {
+		t.Errorf("Unrelated memory should not be flagged: %s", text)
+	}
+}
+
+func TestConsolidationTools(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.store.Store("Staging deploys run from the develop branch every night.", "", "ops", nil, nil)
+	c.store.Store("Every night the develop branch deploys to staging.", "", "ops", nil, nil)
+	c.store.Store("Invoices are emailed on the first of the month.", "", "billing", nil, nil)
+
+	text := c.toolText("propose_consolidations", map[string]interface{}{"threshold": 0.2})
+	if !strings.Contains(text, "Proposed 1 consolidation(s)") {
+		t.Fatalf("Unexpected proposal result: %s", text)
+	}
+	proposals := c.store.ConsolidationProposals(memory.ProposalPending)
+	if len(proposals) != 1 {
+		t.Fatalf("Expected one pending proposal, got %d", len(proposals))
+	}
+	id := proposals[0].ID
+
+	text = c.toolText("list_consolidations", map[string]interface{}{"id": id})
+	if !strings.Contains(text, "**Merged content:**") || !strings.Contains(text, "develop branch") {
+		t.Errorf("Unexpected proposal detail: %s", text)
+	}
+
+	text = c.toolText("apply_consolidation", map[string]interface{}{"id": id})
+	if !strings.Contains(text, "New memory ID:") {
+		t.Errorf("Unexpected apply result: %s", text)
+	}
+	text = c.toolText("list_consolidations", map[string]interface{}{"status": "applied"})
+	if !strings.Contains(text, "Found 1 consolidation proposal(s)") || !strings.Contains(text, id) {
+		t.Errorf("Applied proposal should be listed: %s", text)
+	}
+	text = c.toolText("list_consolidations", map[string]interface{}{})
+	if !strings.Contains(text, "No pending consolidation proposals") {
+		t.Errorf("No proposals should be pending: %s", text)
+	}
+}
+
+func TestPinProtectAndCoreContext(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	core, _ := c.store.Store("Always answer in British English.", "", "style", nil, nil)
+	c.store.Store("The build cache lives on the shared volume.", "", "ops", nil, nil)
+
+	resp := c.call("resources/list", nil)
+	resources, _ := resp["result"].(map[string]interface{})["resources"].([]interface{})
+	if len(resources) != 1 || resources[0].(map[string]interface{})["uri"] != CoreContextURI {
+		t.Fatalf("Expected the core context resource, got %v", resp)
+	}
+
+	c.toolText("pin_memory", map[string]interface{}{"id": core.ID})
+	c.toolText("protect_memory", map[string]interface{}{"id": core.ID})
+
+	resp = c.call("resources/read", map[string]interface{}{"uri": CoreContextURI})
+	contents, _ := resp["result"].(map[string]interface{})["contents"].([]interface{})
+	if len(contents) != 1 {
+		t.Fatalf("Expected one content block, got %v", resp)
+	}
+	text, _ := contents[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "British English") || strings.Contains(text, "build cache") {
+		t.Errorf("Core context should hold only the pinned memory: %s", text)
+	}
+	if !strings.Contains(text, "**Flags:** pinned, protected") {
+		t.Errorf("Core context should show the flags: %s", text)
+	}
+
+	resp = c.call("resources/read", map[string]interface{}{"uri": "memory://unknown"})
+	if code := errorCode(t, resp); code != ErrCodeInvalidParams {
+		t.Errorf("Expected invalid params for an unknown resource, got %d", code)
+	}
+
+	text = c.toolText("forget", map[string]interface{}{"id": core.ID})
+	if !strings.Contains(text, "override_protection") {
+		t.Errorf("Forgetting a protected memory should be refused: %s", text)
+	}
+	text = c.confirmBulkDelete(map[string]interface{}{"query": "e"})
+	if !strings.Contains(text, "Kept 1 protected memories") {
+		t.Errorf("Bulk delete should report the kept memory: %s", text)
+	}
+	text = c.toolText("forget", map[string]interface{}{"id": core.ID, "override_protection": true})
+	if !strings.Contains(text, "has been forgotten") {
+		t.Errorf("override_protection should delete the memory: %s", text)
+	}
+}
+
+func TestTrashTools(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	stale, _ := c.store.Store("Retro notes from the old sprint", "", "sprint", nil, nil)
+	c.store.Store("Service level objective is 99.9 percent", "", "ops", nil, nil)
+
+	text := c.toolText("bulk_delete", map[string]interface{}{"category": "sprint", "dry_run": true})
+	if !strings.Contains(text, "Dry Run") || !strings.Contains(text, "- "+memory.BaseID(stale.ID)+" (1 versions") {
+		t.Errorf("Dry run should list the memory it would delete: %s", text)
+	}
+	if _, err := c.store.Get(stale.ID); err != nil {
+		t.Fatalf("Dry run should not delete anything: %v", err)
+	}
+
+	text = c.toolText("forget", map[string]interface{}{"id": stale.ID})
+	if !strings.Contains(text, "restore_memory") {
+		t.Errorf("forget should mention the trash: %s", text)
+	}
+	text = c.toolText("list_trash", map[string]interface{}{})
+	if !strings.Contains(text, stale.ID) || !strings.Contains(text, "purged after") {
+		t.Errorf("Unexpected trash listing: %s", text)
+	}
+
+	text = c.toolText("restore_memory", map[string]interface{}{"id": stale.ID})
+	if !strings.Contains(text, "has been restored") {
+		t.Errorf("Unexpected restore result: %s", text)
+	}
+	if _, err := c.store.Get(stale.ID); err != nil {
+		t.Errorf("Restored memory should be found: %v", err)
+	}
+
+	c.toolText("forget", map[string]interface{}{"id": stale.ID})
+	text = c.toolText("empty_trash", map[string]interface{}{"confirm": true})
+	if !strings.Contains(text, "Permanently deleted 1 memories") {
+		t.Errorf("Unexpected empty_trash result: %s", text)
+	}
+	text = c.toolText("list_trash", map[string]interface{}{})
+	if !strings.Contains(text, "The trash is empty") {
+		t.Errorf("Trash should be empty: %s", text)
+	}
+}
+
+func TestAuditLogTool(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.toolText("remember", map[string]interface{}{"content": "Backups run nightly at two"})
+	c.toolText("recall", map[string]interface{}{"query": "backups"})
+	c.store.Store("Stored outside any tool call", "", "", nil, nil)
+
+	text := c.toolText("audit_log", map[string]interface{}{"tool": "remember", "verify": true})
+	if !strings.Contains(text, "Verified the hash chain of 3 entries") {
+		t.Errorf("audit_log should verify the chain: %s", text)
+	}
+	if !strings.Contains(text, "Found 1 audit entries") || !strings.Contains(text, "**store**") ||
+		!strings.Contains(text, "by test-client/0.0.1 via remember args ") {
+		t.Errorf("audit_log should attribute the store to the client and tool: %s", text)
+	}
+
+	text = c.toolText("audit_log", map[string]interface{}{"action": "access"})
+	if !strings.Contains(text, "via recall") || !strings.Contains(text, "(search)") {
+		t.Errorf("Searches should be audited as accesses: %s", text)
+	}
+	text = c.toolText("audit_log", map[string]interface{}{"client": "local"})
+	if !strings.Contains(text, "Found 1 audit entries") {
+		t.Errorf("Direct store calls should be attributed to local: %s", text)
+	}
+
+	resp := c.call("tools/call", map[string]interface{}{"name": "audit_log", "arguments": map[string]interface{}{"since": "yesterday"}})
+	if errorCode(t, resp) != ErrCodeInvalidParams {
+		t.Errorf("An invalid since should be rejected: %v", resp)
+	}
+}
+
+// confirmBulkDelete runs bulk_delete as a dry run and then again with the
+// confirmation token it returned
+func (c *testClient) confirmBulkDelete(args map[string]interface{}) string {
+	c.t.Helper()
+	preview := c.toolText("bulk_delete", mergeArgs(args, map[string]interface{}{"dry_run": true}))
+	match := regexp.MustCompile(`confirm_token "([0-9a-f]+)"`).FindStringSubmatch(preview)
+	if match == nil {
+		c.t.Fatalf("Dry run returned no confirmation token: %s", preview)
+	}
+	return c.toolText("bulk_delete", mergeArgs(args, map[string]interface{}{"confirm": true, "confirm_token": match[1]}))
+}
+
+func mergeArgs(args, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(args)+len(extra))
+	for k, v := range args {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func TestBulkDeleteConfirmationToken(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize(LatestProtocolVersion)
+
+	c.store.Store("Incident 41 postmortem", "", "incident", []string{"postmortem", "db"}, nil)
+	c.store.Store("Incident 42 postmortem", "", "incident", []string{"postmortem"}, nil)
+
+	args := map[string]interface{}{"tags": []string{"postmortem", "db"}, "tag_mode": "all"}
+	preview := c.toolText("bulk_delete", mergeArgs(args, map[string]interface{}{"dry_run": true}))
+	if !strings.Contains(preview, "**Would delete:** 1 memories (1 versions") || !strings.Contains(preview, "confirm_token") {
+		t.Errorf("Unexpected dry run: %s", preview)
+	}
+
+	text := c.toolText("bulk_delete", mergeArgs(args, map[string]interface{}{"confirm": true}))
+	if !strings.Contains(text, "confirmation token required") {
+		t.Errorf("Deleting without the token should fail: %s", text)
+	}
+
+	text = c.confirmBulkDelete(args)
+	if !strings.Contains(text, "**Deleted:** 1 memories") {
+		t.Errorf("Unexpected bulk delete result: %s", text)
+	}
+	if results, _ := c.store.Search(&memory.SearchQuery{Query: "postmortem"}); len(results) != 1 {
+		t.Errorf("Only the memory with both tags should be deleted, %d left", len(results))
+	}
+}
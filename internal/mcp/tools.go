@@ -0,0 +1,266 @@
+// internal/mcp/tools.go
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"mcp-memory-server/internal/memory"
+)
+
+// Tool describes an MCP tool and how to execute it
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{}
+
+	call func(store *memory.Store, args map[string]interface{}) (string, error)
+}
+
+// NewTool creates a tool whose arguments are validated against schema and then
+// decoded into T before the handler runs. T is typically a struct with json tags
+// matching the schema properties. The handler is given the store as the
+// calling client, so its operations are audited under that client.
+func NewTool[T any](name, description string, schema map[string]interface{}, handler func(store *memory.Store, args T) (string, error)) *Tool {
+	return &Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+		call: func(store *memory.Store, raw map[string]interface{}) (string, error) {
+			var args T
+			if err := decodeArgs(raw, &args); err != nil {
+				return "", err
+			}
+			return handler(store, args)
+		},
+	}
+}
+
+// Validate checks arguments against the tool's input schema
+func (t *Tool) Validate(args map[string]interface{}) error {
+	if t.InputSchema == nil {
+		return nil
+	}
+	return validateSchema(t.InputSchema, args, "")
+}
+
+// Call validates and decodes the arguments and executes the tool against store
+func (t *Tool) Call(store *memory.Store, args map[string]interface{}) (string, error) {
+	if err := t.Validate(args); err != nil {
+		return "", &ArgumentError{Tool: t.Name, Err: err}
+	}
+	return t.call(store, args)
+}
+
+// definition returns the tool as advertised by tools/list
+func (t *Tool) definition() map[string]interface{} {
+	schema := t.InputSchema
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return map[string]interface{}{
+		"name":        t.Name,
+		"description": t.Description,
+		"inputSchema": schema,
+	}
+}
+
+// ArgumentError reports tool arguments that do not match the tool's input schema
+type ArgumentError struct {
+	Tool string
+	Err  error
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %v", e.Tool, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// decodeArgs converts loosely typed JSON arguments into a typed struct
+func decodeArgs(raw map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode arguments: %w", err)
+	}
+	return nil
+}
+
+// ToolRegistry holds the tools a server exposes. Tools can be added and removed
+// at runtime; registration order is preserved for tools/list.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]*Tool
+	order []string
+}
+
+// NewToolRegistry creates an empty tool registry
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]*Tool),
+	}
+}
+
+// Register adds a tool to the registry
+func (r *ToolRegistry) Register(tool *Tool) error {
+	if tool == nil || tool.Name == "" {
+		return fmt.Errorf("tool name is required")
+	}
+	if tool.call == nil {
+		return fmt.Errorf("tool %s has no handler", tool.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[tool.Name]; exists {
+		return fmt.Errorf("tool already registered: %s", tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	r.order = append(r.order, tool.Name)
+	return nil
+}
+
+// Unregister removes a tool, reporting whether it was registered
+func (r *ToolRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return false
+	}
+	delete(r.tools, name)
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Get returns a registered tool by name
+func (r *ToolRegistry) Get(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns the registered tools in registration order
+func (r *ToolRegistry) List() []*Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]*Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// Schema helpers keep the built-in tool definitions readable
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+func stringArrayProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": description,
+	}
+}
+
+func metadataProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+		"description":          description,
+	}
+}
+
+func integerProp(description string, defaultValue int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "integer",
+		"description": description,
+		"default":     defaultValue,
+		"minimum":     0,
+	}
+}
+
+func numberProp(description string, defaultValue, minimum, maximum float64) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "number",
+		"description": description,
+		"default":     defaultValue,
+		"minimum":     minimum,
+		"maximum":     maximum,
+	}
+}
+
+func sortProp(withRelevance bool) map[string]interface{} {
+	fields := []string{string(memory.SortByCreated), string(memory.SortByUpdated), string(memory.SortByAccessCount)}
+	description := "Sort field (default: created)"
+	if withRelevance {
+		fields = append(fields, string(memory.SortByRelevance))
+		description = "Sort field (default: relevance)"
+	}
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        fields,
+		"description": description,
+	}
+}
+
+func orderProp() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{memory.SortDesc, memory.SortAsc},
+		"description": "Sort order (default: desc)",
+	}
+}
+
+func relationTypeProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        memory.RelationTypes,
+		"description": description,
+	}
+}
+
+func booleanProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": description,
+	}
+}
+
+func dateTimeProp(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"format":      "date-time",
+		"description": description,
+	}
+}
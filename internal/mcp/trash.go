@@ -0,0 +1,69 @@
+// internal/mcp/trash.go
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-memory-server/internal/memory"
+)
+
+type listTrashArgs struct{}
+
+func (s *Server) handleListTrash(store *memory.Store, args listTrashArgs) (string, error) {
+	trashed := store.Trash()
+	if len(trashed) == 0 {
+		return "The trash is empty.", nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d deleted memories:\n\n", len(trashed)))
+	for i, entry := range trashed {
+		m := entry.Memory
+		content := m.Content
+		if len(content) > 100 {
+			content = content[:100] + "..."
+		}
+		result.WriteString(fmt.Sprintf("%d. **ID:** %s\n", i+1, m.ID))
+		if m.Category != "" {
+			result.WriteString(fmt.Sprintf("   Category: %s\n", m.Category))
+		}
+		result.WriteString(fmt.Sprintf("   Deleted: %s, purged after %s\n",
+			entry.DeletedAt.Format("2006-01-02 15:04:05"), entry.PurgeAt.Format("2006-01-02 15:04:05")))
+		result.WriteString(fmt.Sprintf("   Content: %s\n\n", content))
+	}
+	result.WriteString("Restore one with restore_memory.")
+	return result.String(), nil
+}
+
+type restoreMemoryArgs struct {
+	ID string `json:"id"`
+}
+
+func (s *Server) handleRestoreMemory(store *memory.Store, args restoreMemoryArgs) (string, error) {
+	restored, err := store.Restore(args.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to restore memory: %w", err)
+	}
+
+	if !restored.IsCurrentVersion {
+		return fmt.Sprintf("Memory %s has been restored as an earlier version; a newer version is current.", restored.ID), nil
+	}
+	return fmt.Sprintf("Memory %s has been restored.", restored.ID), nil
+}
+
+type emptyTrashArgs struct {
+	Confirm bool `json:"confirm"`
+}
+
+func (s *Server) handleEmptyTrash(store *memory.Store, args emptyTrashArgs) (string, error) {
+	if !args.Confirm {
+		return "", fmt.Errorf("confirmation required: set confirm to true to empty the trash")
+	}
+
+	purged, err := store.EmptyTrash()
+	if err != nil {
+		return "", fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return fmt.Sprintf("Permanently deleted %d memories from the trash.", purged), nil
+}
@@ -0,0 +1,197 @@
+// internal/memory/analysis.go
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mcp-memory-server/pkg/keywords"
+)
+
+// FuzzinessAuto scales typo tolerance with word length (see keywords.AutoFuzziness)
+const FuzzinessAuto = -1
+
+// MaxFuzziness is the largest number of typos a query word may tolerate
+const MaxFuzziness = 2
+
+// MatchOptions controls how free-text query words are compared with memories
+type MatchOptions struct {
+	// Stemming matches words by their stem, so "deploying" finds "deployment".
+	// Without it words must appear literally.
+	Stemming bool `json:"stemming"`
+	// Fuzziness is the number of typos tolerated in a word that matches no
+	// indexed term, or FuzzinessAuto. Words marked with ~ in the query are
+	// always matched fuzzily. 0 disables typo tolerance.
+	Fuzziness int `json:"fuzziness"`
+}
+
+// DefaultMatchOptions stems words and corrects typos in unknown words
+func DefaultMatchOptions() MatchOptions {
+	return MatchOptions{Stemming: true, Fuzziness: FuzzinessAuto}
+}
+
+// ParseFuzziness reads a fuzziness setting: "auto" (or empty) or a number of edits
+func ParseFuzziness(value string) (int, error) {
+	switch strings.ToLower(value) {
+	case "", "auto":
+		return FuzzinessAuto, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > MaxFuzziness {
+		return 0, fmt.Errorf("invalid fuzziness %q (use auto or 0-%d)", value, MaxFuzziness)
+	}
+	return n, nil
+}
+
+// Relevance weights for words matched through the term index
+const (
+	stemMatchWeight  = 1.2
+	fuzzyMatchWeight = 0.8 // divided by 1 + edit distance
+)
+
+// memoryText is the text a memory contributes to the term index
+func memoryText(memory *Memory) string {
+	parts := []string{memory.Content, memory.Summary}
+	parts = append(parts, memory.Tags...)
+	parts = append(parts, memory.Keywords...)
+	return strings.Join(parts, " ")
+}
+
+// contentWords are the lowercased words of the content and summary, split
+// the way query matching splits them
+func contentWords(memory *Memory) []string {
+	return splitWords(strings.ToLower(memory.Content + " " + memory.Summary))
+}
+
+// addTerms indexes the stems, surface words and content words of a memory
+func (s *queryEngine) addTerms(memory *Memory) {
+	words := s.analyzer.Tokens(memoryText(memory))
+	for _, word := range words {
+		if addIndexID(s.wordIndex, word, memory.ID) {
+			s.termDict.Add(word)
+		}
+		addIndexID(s.termIndex, keywords.Stem(word), memory.ID)
+	}
+	for _, word := range contentWords(memory) {
+		if addIndexID(s.textIndex, word, memory.ID) {
+			s.textDict.Add(word)
+		}
+	}
+}
+
+// removeTerms removes a memory from the stem, surface word and content word indices
+func (s *queryEngine) removeTerms(memory *Memory) {
+	words := s.analyzer.Tokens(memoryText(memory))
+	for _, word := range words {
+		if removeIndexID(s.wordIndex, word, memory.ID) {
+			s.termDict.Remove(word)
+		}
+		removeIndexID(s.termIndex, keywords.Stem(word), memory.ID)
+	}
+	for _, word := range contentWords(memory) {
+		if removeIndexID(s.textIndex, word, memory.ID) {
+			s.textDict.Remove(word)
+		}
+	}
+}
+
+// queryIndexes returns the indices queries are narrowed with. The caller
+// must hold s.mu.
+func (s *queryEngine) queryIndexes() queryIndexes {
+	return queryIndexes{
+		category:    s.categoryIndex,
+		tag:         s.tagIndex,
+		keyword:     s.keywordIndex,
+		metadata:    s.metadataIndex,
+		text:        s.textIndex,
+		textDict:    s.textDict,
+		keywordDict: s.keywordDict,
+		// The index holds current memories twice, under their base and
+		// versioned IDs, so this is about half the memories
+		maxCandidates: len(s.index) / 4,
+	}
+}
+
+// resolveTerms looks up the free-text words of a query in the term index,
+// recording on each TextExpr and FuzzyExpr which memories match by stem or
+// within the allowed typos. It returns a relevance bonus per memory ID for
+// words that are not negated. The caller must hold s.mu.
+func (s *queryEngine) resolveTerms(expr Expr, opts MatchOptions) map[string]float64 {
+	scores := make(map[string]float64)
+
+	var walk func(e Expr, negated bool)
+	walk = func(e Expr, negated bool) {
+		switch e := e.(type) {
+		case *AndExpr:
+			for _, child := range e.Children {
+				walk(child, negated)
+			}
+		case *OrExpr:
+			for _, child := range e.Children {
+				walk(child, negated)
+			}
+		case *NotExpr:
+			walk(e.Child, !negated)
+		case *TextExpr:
+			e.resolved = make(map[string]bool)
+			for _, word := range e.Words {
+				s.resolveWord(word, opts.Stemming, opts.Fuzziness, false, e.resolved, scores, negated)
+			}
+		case *FuzzyExpr:
+			e.resolved = make(map[string]bool)
+			s.resolveWord(e.Word, opts.Stemming, e.Edits, true, e.resolved, scores, negated)
+		}
+	}
+	if expr != nil {
+		walk(expr, false)
+	}
+	return scores
+}
+
+// resolveWord marks the memories matching one query word. Typo tolerance
+// only kicks in for words that match nothing as written or by stem unless
+// force is set.
+func (s *queryEngine) resolveWord(word string, stemming bool, edits int, force bool, matched map[string]bool, scores map[string]float64, negated bool) {
+	best := make(map[string]float64)
+	mark := func(ids []string, weight float64) {
+		for _, id := range ids {
+			matched[id] = true
+			if weight > best[id] {
+				best[id] = weight
+			}
+		}
+	}
+
+	for _, token := range s.analyzer.Tokens(word) {
+		stem := keywords.Stem(token)
+		if stemming {
+			mark(s.termIndex[stem], stemMatchWeight)
+		}
+
+		// Typos are looked up among the words as written: stemming a
+		// misspelling can move it further from the stem of the right word
+		maxEdits := edits
+		if maxEdits == FuzzinessAuto {
+			maxEdits = keywords.AutoFuzziness(token)
+		}
+		if maxEdits > MaxFuzziness {
+			maxEdits = MaxFuzziness
+		}
+		if maxEdits <= 0 || (!force && (len(s.wordIndex[token]) > 0 || (stemming && len(s.termIndex[stem]) > 0))) {
+			continue
+		}
+		for _, match := range s.termDict.Lookup(token, maxEdits) {
+			if match.Distance > 0 {
+				mark(s.wordIndex[match.Term], fuzzyMatchWeight/float64(1+match.Distance))
+			}
+		}
+	}
+
+	if negated {
+		return
+	}
+	for id, weight := range best {
+		scores[id] += weight
+	}
+}
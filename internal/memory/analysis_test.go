@@ -0,0 +1,101 @@
+package memory
+
+import "testing"
+
+func newAnalysisTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store := newTestStore(t, nil)
+	for _, content := range []string{
+		"Deployment pipeline for the billing service",
+		"Kubernetes cluster upgrade checklist",
+		"Café opening hours near the office",
+		"Postgres connection pooling notes",
+	} {
+		if _, err := store.Store(content, "", "notes", nil, nil); err != nil {
+			t.Fatalf("Failed to store memory: %v", err)
+		}
+	}
+	return store
+}
+
+func searchContents(t *testing.T, store *Store, query string, match *MatchOptions) []string {
+	t.Helper()
+	page, err := store.SearchPage(&SearchQuery{Query: query, Match: match})
+	if err != nil {
+		t.Fatalf("SearchPage(%q) failed: %v", query, err)
+	}
+	contents := make([]string, len(page.Memories))
+	for i, m := range page.Memories {
+		contents[i] = m.Content
+	}
+	return contents
+}
+
+func TestSearchStemmingAndFuzziness(t *testing.T) {
+	store := newAnalysisTestStore(t)
+
+	tests := []struct {
+		name  string
+		query string
+		match *MatchOptions
+		want  string // expected single result, or "" for none
+	}{
+		{"stem", "deploying", nil, "Deployment pipeline for the billing service"},
+		{"stem plural", "upgrades", nil, "Kubernetes cluster upgrade checklist"},
+		{"typo", "kuberentes", nil, "Kubernetes cluster upgrade checklist"},
+		{"accent folded", "cafe", nil, "Café opening hours near the office"},
+		{"forced fuzzy", "postgress~", nil, "Postgres connection pooling notes"},
+		{"stemming off", "deploying", &MatchOptions{Stemming: false}, ""},
+		{"fuzziness off", "kuberentes", &MatchOptions{Stemming: true}, ""},
+		{"negated typo", "cluster -kuberentes", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchContents(t, store, tt.query, tt.match)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("query %q returned %v, want no results", tt.query, got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("query %q returned %v, want [%s]", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTermIndexFollowsDeletes(t *testing.T) {
+	store := newAnalysisTestStore(t)
+
+	page, err := store.SearchPage(&SearchQuery{Query: "kubernetes"})
+	if err != nil || len(page.Memories) != 1 {
+		t.Fatalf("expected one kubernetes memory, got %v (err %v)", page, err)
+	}
+	if err := store.Delete(page.Memories[0].ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if got := searchContents(t, store, "kuberentes", nil); len(got) != 0 {
+		t.Errorf("deleted memory still found by typo: %v", got)
+	}
+	if store.termDict.Len() != len(store.wordIndex) {
+		t.Errorf("term dictionary has %d words, index has %d", store.termDict.Len(), len(store.wordIndex))
+	}
+}
+
+func TestParseFuzziness(t *testing.T) {
+	for input, want := range map[string]int{"": FuzzinessAuto, "auto": FuzzinessAuto, "0": 0, "2": 2} {
+		got, err := ParseFuzziness(input)
+		if err != nil || got != want {
+			t.Errorf("ParseFuzziness(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"3", "-1", "lots"} {
+		if _, err := ParseFuzziness(input); err == nil {
+			t.Errorf("ParseFuzziness(%q) succeeded, want error", input)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// internal/memory/audit.go
+package memory
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/pkg/crypto"
+)
+
+// Audited actions
+const (
+	AuditStore      = "store"
+	AuditAccess     = "access"
+	AuditDelete     = "delete"
+	AuditBulkDelete = "bulk_delete"
+	AuditEvict      = "evict"
+	AuditRestore    = "restore"
+	AuditPurge      = "purge"
+	AuditLink       = "link"
+	AuditUnlink     = "unlink"
+	AuditFlag       = "flag"
+	AuditReplicate  = "replicate"
+)
+
+// DefaultAuditLimit is how many entries an audit query returns by default
+const DefaultAuditLimit = 50
+
+const auditFile = "audit/audit.log"
+
+// SystemCaller attributes operations the store performs on its own, such as
+// eviction and purging the trash
+var SystemCaller = Caller{Client: "system"}
+
+// ReplicationCaller attributes memory versions imported from other instances
+var ReplicationCaller = Caller{Client: "replication"}
+
+// Caller identifies who performed an audited operation
+type Caller struct {
+	Client     string `json:"client,omitempty"`      // client name and version
+	Tool       string `json:"tool,omitempty"`        // MCP tool or HTTP route
+	ArgsDigest string `json:"args_digest,omitempty"` // SHA-256 of the canonical JSON arguments
+}
+
+// AuditEntry is one record of the audit log. Each entry's hash covers the
+// entry and the previous entry's hash, so editing or removing an entry
+// breaks the chain from that point on.
+type AuditEntry struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	IDs      []string  `json:"ids,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Caller             // who performed it
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+}
+
+// AuditQuery filters audit log entries; empty fields match everything
+type AuditQuery struct {
+	Action string    // exact action
+	ID     string    // memory ID or base ID among the entry's IDs
+	Client string    // substring of the client, ignoring case
+	Tool   string    // exact tool
+	Since  time.Time // entries at or after
+	Until  time.Time // entries before
+	Limit  int       // most recent entries to return (default DefaultAuditLimit)
+}
+
+// AuditLog is an append-only, hash-chained log of memory operations, one
+// JSON entry per line. Lines are encrypted when encryption is enabled.
+type AuditLog struct {
+	path     string
+	crypto   *crypto.Crypto
+	mu       sync.Mutex
+	file     *os.File
+	lastSeq  uint64
+	lastHash string
+}
+
+// ArgsDigest returns the SHA-256 of arguments encoded as JSON. Maps encode
+// with sorted keys, so equal arguments give equal digests.
+func ArgsDigest(arguments interface{}) string {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// OpenAuditLog opens the audit log in a data directory, for reading it
+// without a store
+func OpenAuditLog(dataDir string, cfg *config.StorageConfig) (*AuditLog, error) {
+	var cryptoHandler *crypto.Crypto
+	if cfg.EnableEncryption {
+		var err error
+		if cryptoHandler, err = crypto.New(cfg.EncryptionKeyPath); err != nil {
+			return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+		}
+	}
+	return newAuditLog(dataDir, cryptoHandler)
+}
+
+// newAuditLog opens the audit log for appending, continuing its hash chain
+func newAuditLog(dataDir string, cryptoHandler *crypto.Crypto) (*AuditLog, error) {
+	path := filepath.Join(dataDir, auditFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	log := &AuditLog{path: path, crypto: cryptoHandler}
+	entries, err := log.readAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		log.lastSeq, log.lastHash = last.Seq, last.Hash
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	log.file = file
+	return log, nil
+}
+
+// Append adds an entry to the log, filling in its sequence number, time
+// and hashes
+func (l *AuditLog) Append(entry AuditEntry) (AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return entry, fmt.Errorf("audit log is closed")
+	}
+
+	entry.Seq = l.lastSeq + 1
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.PrevHash = l.lastHash
+	entry.Hash = auditHash(entry)
+
+	line, err := l.encodeLine(entry)
+	if err != nil {
+		return entry, err
+	}
+	if _, err := l.file.WriteString(line + "\n"); err != nil {
+		return entry, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	l.lastSeq, l.lastHash = entry.Seq, entry.Hash
+	return entry, nil
+}
+
+// Query returns the entries matching q, most recent first
+func (l *AuditLog) Query(q AuditQuery) ([]AuditEntry, error) {
+	l.mu.Lock()
+	entries, err := l.readAll()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	client := strings.ToLower(q.Client)
+
+	var matches []AuditEntry
+	for i := len(entries) - 1; i >= 0 && len(matches) < limit; i-- {
+		entry := entries[i]
+		if q.Action != "" && entry.Action != q.Action {
+			continue
+		}
+		if q.Tool != "" && entry.Tool != q.Tool {
+			continue
+		}
+		if client != "" && !strings.Contains(strings.ToLower(entry.Client), client) {
+			continue
+		}
+		if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+			continue
+		}
+		if q.ID != "" && !auditMentions(entry, q.ID) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	return matches, nil
+}
+
+// Verify checks the hash chain and returns the number of entries checked.
+// It fails at the first entry that was altered, removed or reordered.
+func (l *AuditLog) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.readAll()
+	if err != nil {
+		return 0, err
+	}
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Seq != uint64(i+1) {
+			return i, fmt.Errorf("audit entry %d: expected sequence %d", entry.Seq, i+1)
+		}
+		if entry.PrevHash != prevHash || auditHash(entry) != entry.Hash {
+			return i, fmt.Errorf("audit entry %d: hash chain broken", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return len(entries), nil
+}
+
+// Close closes the log; later appends fail
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// readAll reads every entry in order. The caller must hold l.mu.
+func (l *AuditLog) readAll() ([]AuditEntry, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		entry, err := l.decodeLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func (l *AuditLog) encodeLine(entry AuditEntry) (string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if l.crypto == nil {
+		return string(data), nil
+	}
+	line, err := l.crypto.EncryptString(string(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt audit entry: %w", err)
+	}
+	return line, nil
+}
+
+func (l *AuditLog) decodeLine(line string) (AuditEntry, error) {
+	var entry AuditEntry
+	if l.crypto != nil {
+		decrypted, err := l.crypto.DecryptString(line)
+		if err != nil {
+			return entry, fmt.Errorf("failed to decrypt audit entry: %w", err)
+		}
+		line = decrypted
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return entry, fmt.Errorf("failed to unmarshal audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+// auditHash hashes an entry, excluding its own hash, chained to the
+// previous entry's hash
+func auditHash(entry AuditEntry) string {
+	entry.Hash = ""
+	entry.Time = entry.Time.UTC()
+	data, _ := json.Marshal(entry)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// auditMentions reports whether an entry names a memory by ID or base ID
+func auditMentions(entry AuditEntry, id string) bool {
+	for _, entryID := range entry.IDs {
+		if entryID == id || BaseID(entryID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// As returns a handle on the store that attributes the operations made
+// through it to caller
+func (s *Store) As(caller Caller) *Store {
+	return &Store{storeState: s.storeState, caller: caller}
+}
+
+// AuditLog returns the store's audit log, or nil when auditing is disabled
+func (s *Store) AuditLog() *AuditLog {
+	return s.audit
+}
+
+// recordAudit logs an operation by the handle's caller
+func (s *Store) recordAudit(action string, ids []string, detail string) {
+	s.recordAuditAs(s.caller, action, ids, detail)
+}
+
+// recordAuditAs logs an operation by caller and, with git storage, queues
+// it for the next commit. Failures are logged rather than failing the
+// operation, which has already happened.
+func (s *Store) recordAuditAs(caller Caller, action string, ids []string, detail string) {
+	if s.git != nil && action != AuditAccess && action != AuditPurge {
+		s.git.queue(gitChangeDescription(caller, action, ids, detail))
+	}
+	if s.audit == nil {
+		return
+	}
+	if caller.Client == "" {
+		caller.Client = "local"
+	}
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+
+	if _, err := s.audit.Append(AuditEntry{Action: action, IDs: sorted, Detail: detail, Caller: caller}); err != nil {
+		s.logger.WithError(err).Warn("Failed to write audit entry", "action", action)
+	}
+}
+
+// pageIDs returns the IDs of the memories on a page
+func pageIDs(page *Page) []string {
+	ids := make([]string, len(page.Memories))
+	for i, memory := range page.Memories {
+		ids[i] = memory.ID
+	}
+	return ids
+}
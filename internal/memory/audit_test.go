@@ -0,0 +1,208 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/config"
+)
+
+// withAudit enables the audit log and, when encrypted, encryption with a key
+// kept in dir
+func withAudit(dir string, encrypted bool) func(*config.StorageConfig) {
+	return func(cfg *config.StorageConfig) {
+		cfg.EnableAudit = true
+		cfg.EnableEncryption = encrypted
+		cfg.EncryptionKeyPath = filepath.Join(dir, "test.key")
+	}
+}
+
+func TestAuditLogRecordsOperations(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withAudit(dir, false))
+
+	client := store.As(Caller{Client: "test-client/1.0", Tool: "store_memory", ArgsDigest: ArgsDigest(map[string]interface{}{"content": "x"})})
+	memory, err := client.Store("Deploys go out on Tuesdays", "", "ops", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, err := store.Get(memory.ID); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, err := store.Search(&SearchQuery{Query: "deploys"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if err := store.Delete(memory.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// The chain continues across restarts
+	store.Close()
+	store = openTestStore(t, dir, withAudit(dir, false))
+	defer store.Close()
+	if _, err := store.Store("Standups are at ten", "", "", nil, nil); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	log := store.AuditLog()
+	entries, err := log.Query(AuditQuery{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var actions []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		actions = append(actions, entries[i].Action)
+	}
+	if got := strings.Join(actions, ","); got != "store,access,access,delete,store" {
+		t.Errorf("Audited actions = %s", got)
+	}
+
+	stored := entries[len(entries)-1]
+	if stored.Client != "test-client/1.0" || stored.Tool != "store_memory" || len(stored.ArgsDigest) != 64 {
+		t.Errorf("First entry should carry the caller, got %+v", stored)
+	}
+	if entries[0].Client != "local" {
+		t.Errorf("Operations on the store itself should be attributed to local, got %q", entries[0].Client)
+	}
+
+	byID, err := log.Query(AuditQuery{ID: BaseID(memory.ID)})
+	if err != nil || len(byID) != 4 {
+		t.Errorf("Query by base ID = %d entries, %v; want 4", len(byID), err)
+	}
+	byAction, err := log.Query(AuditQuery{Action: AuditDelete, Client: "TEST"})
+	if err != nil || len(byAction) != 0 {
+		t.Errorf("Delete was not made by test-client, got %d entries, %v", len(byAction), err)
+	}
+	limited, err := log.Query(AuditQuery{Limit: 2})
+	if err != nil || len(limited) != 2 || limited[0].Seq != 5 {
+		t.Errorf("Limit should return the most recent entries, got %+v, %v", limited, err)
+	}
+
+	if checked, err := log.Verify(); err != nil || checked != 5 {
+		t.Errorf("Verify = %d, %v; want 5 intact entries", checked, err)
+	}
+}
+
+func TestAuditLogDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withAudit(dir, false))
+	for _, content := range []string{"First memory", "Second memory", "Third memory"} {
+		if _, err := store.Store(content, "", "", nil, nil); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+	store.Close()
+
+	path := filepath.Join(dir, auditFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	tampered := strings.Replace(string(data), `"version 1"`, `"version 9"`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatalf("Failed to write audit log: %v", err)
+	}
+
+	log, err := OpenAuditLog(dir, &config.StorageConfig{})
+	if err != nil {
+		t.Fatalf("OpenAuditLog failed: %v", err)
+	}
+	defer log.Close()
+	if checked, err := log.Verify(); err == nil || checked != 0 {
+		t.Errorf("Verify should fail at the edited first entry, got %d, %v", checked, err)
+	}
+}
+
+func TestAuditLogEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withAudit(dir, true))
+	if _, err := store.Store("The vault combination is 1234", "", "", nil, nil); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	store.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, auditFile))
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if strings.Contains(string(data), `"action"`) {
+		t.Error("Encrypted audit log should not contain plain JSON")
+	}
+
+	log, err := OpenAuditLog(dir, &config.StorageConfig{EnableEncryption: true, EncryptionKeyPath: filepath.Join(dir, "test.key")})
+	if err != nil {
+		t.Fatalf("OpenAuditLog failed: %v", err)
+	}
+	defer log.Close()
+	entries, err := log.Query(AuditQuery{})
+	if err != nil || len(entries) != 1 || entries[0].Action != AuditStore {
+		t.Errorf("Encrypted entries should read back, got %+v, %v", entries, err)
+	}
+	if _, err := log.Verify(); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+}
+
+func TestAuditLogAttributesEvictionToSystem(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withAudit(dir, false))
+	defer store.Close()
+
+	evictable, err := store.Remember(MemoryInput{Content: "The office plants are watered on Mondays", Importance: 0.1})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	store.config.MaxStorageSize = store.totalSize + 64
+	_, err = store.As(Caller{Client: "test-client", Tool: "store_memory"}).Store("A second memory that pushes the store over its limit", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	evictions, err := store.AuditLog().Query(AuditQuery{Action: AuditEvict})
+	if err != nil || len(evictions) != 1 {
+		t.Fatalf("Expected one eviction entry, got %+v, %v", evictions, err)
+	}
+	if evictions[0].Client != SystemCaller.Client || evictions[0].IDs[0] != evictable.Memory.ID {
+		t.Errorf("Eviction should be attributed to the system, got %+v", evictions[0])
+	}
+}
+
+func TestAuditHandlesAttributeConcurrentCallers(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withAudit(dir, false))
+	defer store.Close()
+
+	tool := store.As(Caller{Client: "test-client", Tool: "remember"})
+	replica := store.As(ReplicationCaller)
+	memory, err := tool.Store("Incidents are reviewed on Thursdays", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	// Operations of other callers while the tool's handle is still in use
+	// keep their own attribution
+	imported := *memory
+	imported.ID = memory.ID + "~peer"
+	if err := replica.ImportVersion(&imported); err != nil {
+		t.Fatalf("ImportVersion failed: %v", err)
+	}
+	if _, err := tool.Get(memory.ID); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	entries, err := store.AuditLog().Query(AuditQuery{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	clients := make(map[string]string)
+	for _, entry := range entries {
+		clients[entry.Action] = entry.Client
+	}
+	want := map[string]string{AuditStore: "test-client", AuditReplicate: ReplicationCaller.Client, AuditAccess: "test-client"}
+	for action, client := range want {
+		if clients[action] != client {
+			t.Errorf("%s attributed to %q, want %q", action, clients[action], client)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// confirmBulkDelete previews a bulk deletion and then runs it with the
+// dry run's confirmation token
+func confirmBulkDelete(t *testing.T, store *Store, options *BulkDeleteOptions) *BulkDeleteResult {
+	t.Helper()
+
+	options.DryRun = true
+	preview, err := store.BulkDelete(options)
+	if err != nil {
+		t.Fatalf("BulkDelete dry run failed: %v", err)
+	}
+	options.DryRun = false
+	options.Confirm = true
+	options.ConfirmToken = preview.ConfirmToken
+	result, err := store.BulkDelete(options)
+	if err != nil {
+		t.Fatalf("BulkDelete failed: %v", err)
+	}
+	return result
+}
+
+func TestBulkDeletePreviewAndToken(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	old, _ := store.Store("Standup notes for Monday", "", "notes", []string{"standup", "team"}, nil)
+	store.Store("Standup notes for Monday", "", "notes", []string{"standup", "team"}, nil) // second version
+	store.Store("Standup notes for Tuesday", "", "notes", []string{"standup"}, nil)
+
+	preview, err := store.BulkDelete(&BulkDeleteOptions{Tags: []string{"standup", "team"}, MatchAllTags: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(preview.Memories) != 1 || preview.Memories[0].BaseID != BaseID(old.ID) || preview.Memories[0].Versions != 2 {
+		t.Fatalf("All-tags dry run should match one memory with 2 versions, got %+v", preview.Memories)
+	}
+	if preview.Bytes <= 0 || preview.Bytes != preview.Memories[0].Bytes || preview.ConfirmToken == "" {
+		t.Errorf("Dry run should report bytes and a token, got %+v", preview)
+	}
+
+	// The real call must echo the token of the same deletion
+	options := &BulkDeleteOptions{Tags: []string{"standup", "team"}, MatchAllTags: true, Confirm: true}
+	if _, err := store.BulkDelete(options); err == nil || !strings.Contains(err.Error(), "token required") {
+		t.Errorf("Deleting without a token should fail, got %v", err)
+	}
+	options.ConfirmToken = "0123456789abcdef"
+	if _, err := store.BulkDelete(options); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Deleting with a wrong token should fail, got %v", err)
+	}
+
+	// A token goes stale when the matches change
+	store.Store("Standup notes for Wednesday", "", "notes", []string{"standup", "team"}, nil)
+	options.ConfirmToken = preview.ConfirmToken
+	if _, err := store.BulkDelete(options); err == nil {
+		t.Error("A stale token should be rejected")
+	}
+
+	result := confirmBulkDelete(t, store, &BulkDeleteOptions{Tags: []string{"standup", "team"}, MatchAllTags: true})
+	if result.Deleted != 3 || result.ConfirmToken != "" {
+		t.Errorf("Expected 3 versions deleted, got %+v", result)
+	}
+	if _, err := store.Get(BaseID(old.ID)); err == nil {
+		t.Error("Every version of the matched memory should be deleted")
+	}
+	if results, _ := store.Search(&SearchQuery{Query: "Tuesday"}); len(results) != 1 {
+		t.Error("A memory with only some of the tags should be kept")
+	}
+}
+
+func TestBulkDeleteDateRangeAndMaxCount(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	now := time.Now()
+	var ids []string
+	for i, content := range []string{"Log entry one", "Log entry two", "Log entry three", "Log entry four"} {
+		m, _ := store.Store(content, "", "log", nil, nil)
+		m.CreatedAt = now.Add(time.Duration(i-4) * 24 * time.Hour) // 4, 3, 2 and 1 days ago
+		ids = append(ids, BaseID(m.ID))
+	}
+
+	preview, err := store.BulkDelete(&BulkDeleteOptions{
+		AfterDate:  now.Add(-5 * 24 * time.Hour),
+		BeforeDate: now.Add(-36 * time.Hour),
+		DryRun:     true,
+	})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(preview.Memories) != 3 || preview.Memories[0].BaseID != ids[0] {
+		t.Errorf("Date range should match the 3 oldest memories, oldest first, got %+v", preview.Memories)
+	}
+
+	result := confirmBulkDelete(t, store, &BulkDeleteOptions{Category: "log", MaxCount: 2})
+	if result.Deleted != 2 || result.Truncated != 2 {
+		t.Errorf("MaxCount should delete 2 and leave 2, got %+v", result)
+	}
+	for i, id := range ids {
+		_, err := store.Get(id)
+		if deleted := err != nil; deleted != (i < 2) {
+			t.Errorf("Memory %d deleted = %v, want %v", i, deleted, i < 2)
+		}
+	}
+
+	if _, err := store.BulkDelete(&BulkDeleteOptions{AfterDate: now, BeforeDate: now.Add(-time.Hour), DryRun: true}); err == nil {
+		t.Error("An empty date range should be rejected")
+	}
+	if _, err := store.BulkDelete(&BulkDeleteOptions{Category: "log", MaxCount: -1, DryRun: true}); err == nil {
+		t.Error("A negative max count should be rejected")
+	}
+}
@@ -0,0 +1,682 @@
+// internal/memory/consolidation.go
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"mcp-memory-server/pkg/keywords"
+)
+
+// Consolidation proposal statuses
+const (
+	ProposalPending  = "pending"
+	ProposalApplied  = "applied"
+	ProposalRejected = "rejected"
+)
+
+// Consolidation defaults
+const (
+	DefaultConsolidationThreshold = 0.35
+	DefaultMinClusterSize         = 2
+	DefaultMaxClusterSize         = 20
+)
+
+const (
+	consolidationFile        = "index/consolidation.json"
+	maxVectorTerms           = 25  // highest TF-IDF terms kept per memory
+	maxPostingDocs           = 500 // terms in more memories than this are too common to pair memories on
+	maxSummarySentences      = 2
+	maxProposalKeywords      = 8
+	duplicateSentenceOverlap = 0.8 // word overlap at which a sentence repeats an earlier one
+	tfidfWeight              = 0.7 // the rest of a pair's similarity is keyword overlap
+)
+
+// ConsolidationOptions controls how memories are clustered into proposals
+type ConsolidationOptions struct {
+	Threshold      float64 `json:"threshold,omitempty"`        // minimum similarity (0-1) to cluster two memories
+	MinClusterSize int     `json:"min_cluster_size,omitempty"` // smallest cluster worth proposing, default 2
+	MaxClusterSize int     `json:"max_cluster_size,omitempty"` // largest cluster, default 20
+	Category       string  `json:"category,omitempty"`         // only consolidate memories in this category
+}
+
+// ConsolidationProposal is a suggested merge of overlapping memories. It is
+// only turned into a memory when applied.
+type ConsolidationProposal struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	SourceIDs  []string  `json:"source_ids"` // versioned IDs of the memories to merge
+	Content    string    `json:"content"`    // the sources' sentences with repeats removed
+	Summary    string    `json:"summary"`    // the sentences most central to the cluster
+	Category   string    `json:"category,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Keywords   []string  `json:"keywords,omitempty"` // terms the cluster has in common
+	Similarity float64   `json:"similarity"`         // average similarity of the pairs that formed the cluster
+	CreatedAt  time.Time `json:"created_at"`
+	ResolvedAt time.Time `json:"resolved_at,omitempty"`
+	MergedID   string    `json:"merged_id,omitempty"` // memory created when the proposal was applied
+}
+
+// memoryCluster is a group of memories by index, with its average similarity
+type memoryCluster struct {
+	members    []int
+	similarity float64
+}
+
+// withDefaults fills in unset options and validates the rest
+func (opts ConsolidationOptions) withDefaults() (ConsolidationOptions, error) {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultConsolidationThreshold
+	}
+	if opts.MinClusterSize == 0 {
+		opts.MinClusterSize = DefaultMinClusterSize
+	}
+	if opts.MaxClusterSize == 0 {
+		opts.MaxClusterSize = DefaultMaxClusterSize
+	}
+	if opts.Threshold < 0 || opts.Threshold > 1 {
+		return opts, fmt.Errorf("threshold must be between 0 and 1, got %g", opts.Threshold)
+	}
+	if opts.MinClusterSize < 2 {
+		return opts, fmt.Errorf("min cluster size must be at least 2, got %d", opts.MinClusterSize)
+	}
+	if opts.MaxClusterSize < opts.MinClusterSize {
+		return opts, fmt.Errorf("max cluster size %d is below the min cluster size %d", opts.MaxClusterSize, opts.MinClusterSize)
+	}
+	return opts, nil
+}
+
+// loadProposals reads saved consolidation proposals
+func (s *Store) loadProposals() error {
+	var proposals []*ConsolidationProposal
+	if err := s.readDataFile(consolidationFile, &proposals); err != nil {
+		return err
+	}
+	for _, proposal := range proposals {
+		s.proposals[proposal.ID] = proposal
+	}
+	return nil
+}
+
+// saveProposals writes consolidation proposals. The caller must hold s.consolidationMu.
+func (s *Store) saveProposals() error {
+	proposals := make([]*ConsolidationProposal, 0, len(s.proposals))
+	for _, proposal := range s.proposals {
+		proposals = append(proposals, proposal)
+	}
+	sortProposals(proposals)
+	return s.writeDataFile(consolidationFile, proposals)
+}
+
+// ProposeConsolidations clusters current memories by TF-IDF and keyword
+// similarity and replaces the pending proposals with one per cluster.
+// Clusters that were already applied or rejected are not proposed again, and
+// memories superseded by another memory are left out.
+func (s *Store) ProposeConsolidations(opts ConsolidationOptions) ([]*ConsolidationProposal, error) {
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	memories := s.consolidationCandidates(opts.Category)
+	s.mu.RUnlock()
+
+	vectors := tfidfVectors(memories)
+	clusters := clusterMemories(memories, vectors, opts)
+
+	s.consolidationMu.Lock()
+	defer s.consolidationMu.Unlock()
+
+	for id, proposal := range s.proposals {
+		if proposal.Status == ProposalPending {
+			delete(s.proposals, id)
+		}
+	}
+
+	now := time.Now()
+	var pending []*ConsolidationProposal
+	for _, cluster := range clusters {
+		members := make([]*Memory, len(cluster.members))
+		centroid := make(map[string]float64)
+		for i, index := range cluster.members {
+			members[i] = memories[index]
+			for term, weight := range vectors[index] {
+				centroid[term] += weight
+			}
+		}
+
+		proposal := newProposal(members, centroid, cluster.similarity, now)
+		if _, resolved := s.proposals[proposal.ID]; resolved {
+			continue
+		}
+		s.proposals[proposal.ID] = proposal
+		pending = append(pending, copyProposal(proposal))
+	}
+
+	if err := s.saveProposals(); err != nil {
+		return nil, fmt.Errorf("failed to save consolidation proposals: %w", err)
+	}
+
+	s.logger.Info("Proposed memory consolidations", "memories", len(memories), "proposals", len(pending))
+	sortProposals(pending)
+	return pending, nil
+}
+
+// ConsolidationProposals returns proposals with the given status, or all
+// proposals when status is empty
+func (s *Store) ConsolidationProposals(status string) []*ConsolidationProposal {
+	s.consolidationMu.Lock()
+	defer s.consolidationMu.Unlock()
+
+	proposals := make([]*ConsolidationProposal, 0, len(s.proposals))
+	for _, proposal := range s.proposals {
+		if status == "" || proposal.Status == status {
+			proposals = append(proposals, copyProposal(proposal))
+		}
+	}
+	sortProposals(proposals)
+	return proposals
+}
+
+// GetConsolidationProposal returns a proposal by ID
+func (s *Store) GetConsolidationProposal(id string) (*ConsolidationProposal, error) {
+	s.consolidationMu.Lock()
+	defer s.consolidationMu.Unlock()
+
+	proposal, exists := s.proposals[id]
+	if !exists {
+		return nil, fmt.Errorf("consolidation proposal not found: %s", id)
+	}
+	return copyProposal(proposal), nil
+}
+
+// ApplyConsolidation stores a pending proposal as a new memory that
+// supersedes its sources. The sources are kept and linked from the new
+// memory. Proposals whose sources changed since they were made are refused.
+func (s *Store) ApplyConsolidation(id string) (*Memory, error) {
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	s.consolidationMu.Lock()
+	defer s.consolidationMu.Unlock()
+
+	proposal, exists := s.proposals[id]
+	if !exists {
+		return nil, fmt.Errorf("consolidation proposal not found: %s", id)
+	}
+	if proposal.Status != ProposalPending {
+		return nil, fmt.Errorf("consolidation proposal %s is already %s", id, proposal.Status)
+	}
+
+	s.mu.RLock()
+	for _, sourceID := range proposal.SourceIDs {
+		if source, exists := s.index[sourceID]; !exists || !source.IsCurrentVersion {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf("memory %s changed or was deleted since the proposal was made; propose consolidations again", sourceID)
+		}
+	}
+	s.mu.RUnlock()
+
+	merged, err := s.Store(proposal.Content, proposal.Summary, proposal.Category, proposal.Tags,
+		map[string]string{"consolidation": proposal.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store consolidated memory: %w", err)
+	}
+	for _, sourceID := range proposal.SourceIDs {
+		// The merged text can match a source exactly and become its new version
+		if BaseID(sourceID) == BaseID(merged.ID) {
+			continue
+		}
+		if _, err := s.Link(merged.ID, sourceID, RelationSupersedes); err != nil {
+			return nil, fmt.Errorf("failed to link source memory %s: %w", sourceID, err)
+		}
+	}
+
+	proposal.Status = ProposalApplied
+	proposal.MergedID = merged.ID
+	proposal.ResolvedAt = time.Now()
+	if err := s.saveProposals(); err != nil {
+		return nil, fmt.Errorf("failed to save consolidation proposals: %w", err)
+	}
+
+	s.logger.Info("Applied memory consolidation", "proposal", id, "merged_id", merged.ID, "sources", len(proposal.SourceIDs))
+	return merged, nil
+}
+
+// RejectConsolidation marks a pending proposal as rejected so the same
+// cluster is not proposed again
+func (s *Store) RejectConsolidation(id string) error {
+	if s.readOnly != nil {
+		return s.readOnly
+	}
+	s.consolidationMu.Lock()
+	defer s.consolidationMu.Unlock()
+
+	proposal, exists := s.proposals[id]
+	if !exists {
+		return fmt.Errorf("consolidation proposal not found: %s", id)
+	}
+	if proposal.Status != ProposalPending {
+		return fmt.Errorf("consolidation proposal %s is already %s", id, proposal.Status)
+	}
+
+	proposal.Status = ProposalRejected
+	proposal.ResolvedAt = time.Now()
+	if err := s.saveProposals(); err != nil {
+		return fmt.Errorf("failed to save consolidation proposals: %w", err)
+	}
+	return nil
+}
+
+// consolidationWorker proposes consolidations every interval until the store closes
+func (s *Store) consolidationWorker(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			proposals, err := s.ProposeConsolidations(ConsolidationOptions{})
+			if err != nil {
+				s.logger.WithError(err).Warn("Failed to propose memory consolidations")
+			} else if len(proposals) > 0 {
+				s.logger.Info("Memory consolidations awaiting review", "pending", len(proposals))
+			}
+		}
+	}
+}
+
+// consolidationCandidates returns the current memories that may be
+// consolidated, oldest first. The caller must hold s.mu.
+func (s *Store) consolidationCandidates(category string) []*Memory {
+	var memories []*Memory
+	for id, memory := range s.index {
+		if id != memory.ID || !memory.IsCurrentVersion {
+			continue
+		}
+		if category != "" && !strings.EqualFold(memory.Category, category) {
+			continue
+		}
+		if s.isSuperseded(memory) {
+			continue
+		}
+		memories = append(memories, memory)
+	}
+	sort.Slice(memories, func(i, j int) bool {
+		if !memories[i].CreatedAt.Equal(memories[j].CreatedAt) {
+			return memories[i].CreatedAt.Before(memories[j].CreatedAt)
+		}
+		return memories[i].ID < memories[j].ID
+	})
+	return memories
+}
+
+// isSuperseded reports whether a current memory links to memory with a
+// supersedes link. The caller must hold s.mu.
+func (s *Store) isSuperseded(memory *Memory) bool {
+	base := BaseID(memory.ID)
+	for _, sourceID := range s.linkIndex[base] {
+		source, exists := s.index[sourceID]
+		if !exists || !source.IsCurrentVersion {
+			continue
+		}
+		for _, link := range source.Links {
+			if link.Target == base && link.Type == RelationSupersedes {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tfidfVectors returns a unit-length TF-IDF vector per memory, keeping each
+// memory's highest scoring terms
+func tfidfVectors(memories []*Memory) []map[string]float64 {
+	documents := make([]string, len(memories))
+	for i, memory := range memories {
+		documents[i] = memory.Content + " " + memory.Summary
+	}
+	scores := keywords.CalculateTFIDF(documents, maxVectorTerms)
+
+	vectors := make([]map[string]float64, len(memories))
+	for i, document := range documents {
+		vector := make(map[string]float64)
+		for term, score := range scores[document] {
+			// The TF-IDF tokenizer keeps sentence punctuation on words
+			if term = consolidationTerm(term); term != "" && score > 0 {
+				vector[term] += score
+			}
+		}
+		vectors[i] = unitVector(topTerms(vector, maxVectorTerms))
+	}
+	return vectors
+}
+
+// clusterMemories groups memories whose similarity reaches the threshold.
+// Pairs are joined most similar first and a join that would grow a cluster
+// past the maximum size is skipped, so one broad topic cannot chain every
+// memory into a single cluster.
+func clusterMemories(memories []*Memory, vectors []map[string]float64, opts ConsolidationOptions) []memoryCluster {
+	type edge struct {
+		a, b       int
+		similarity float64
+	}
+
+	postings := make(map[string][]int)
+	for i, vector := range vectors {
+		for term := range vector {
+			postings[term] = append(postings[term], i)
+		}
+	}
+
+	var edges []edge
+	for i, vector := range vectors {
+		dots := make(map[int]float64)
+		for term, weight := range vector {
+			docs := postings[term]
+			if len(docs) > maxPostingDocs {
+				continue
+			}
+			for _, j := range docs {
+				if j > i {
+					dots[j] += weight * vectors[j][term]
+				}
+			}
+		}
+		for j, dot := range dots {
+			similarity := tfidfWeight*dot + (1-tfidfWeight)*keywordOverlap(memories[i].Keywords, memories[j].Keywords)
+			if similarity >= opts.Threshold {
+				edges = append(edges, edge{a: i, b: j, similarity: similarity})
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].similarity != edges[j].similarity {
+			return edges[i].similarity > edges[j].similarity
+		}
+		if edges[i].a != edges[j].a {
+			return edges[i].a < edges[j].a
+		}
+		return edges[i].b < edges[j].b
+	})
+
+	parent := make([]int, len(memories))
+	size := make([]int, len(memories))
+	total := make([]float64, len(memories)) // summed similarity of the joins inside each cluster
+	for i := range parent {
+		parent[i] = i
+		size[i] = 1
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, e := range edges {
+		a, b := find(e.a), find(e.b)
+		if a == b || size[a]+size[b] > opts.MaxClusterSize {
+			continue
+		}
+		parent[b] = a
+		size[a] += size[b]
+		total[a] += total[b] + e.similarity
+	}
+
+	groups := make(map[int][]int)
+	for i := range memories {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	var clusters []memoryCluster
+	for root, members := range groups {
+		if len(members) < opts.MinClusterSize {
+			continue
+		}
+		// A cluster of n members was formed by n-1 joins
+		clusters = append(clusters, memoryCluster{members: members, similarity: total[root] / float64(len(members)-1)})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].members) != len(clusters[j].members) {
+			return len(clusters[i].members) > len(clusters[j].members)
+		}
+		return clusters[i].members[0] < clusters[j].members[0]
+	})
+	return clusters
+}
+
+// newProposal builds a proposal from a cluster of memories, oldest first
+func newProposal(members []*Memory, centroid map[string]float64, similarity float64, now time.Time) *ConsolidationProposal {
+	sourceIDs := make([]string, len(members))
+	var tags []string
+	seenTags := make(map[string]bool)
+	categories := make(map[string]int)
+	category := ""
+	for i, member := range members {
+		sourceIDs[i] = member.ID
+		for _, tag := range member.Tags {
+			if !seenTags[strings.ToLower(tag)] {
+				seenTags[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+		if member.Category != "" {
+			categories[member.Category]++
+			if categories[member.Category] > categories[category] {
+				category = member.Category
+			}
+		}
+	}
+
+	sortedIDs := append([]string(nil), sourceIDs...)
+	sort.Strings(sortedIDs)
+	hash := sha256.Sum256([]byte(strings.Join(sortedIDs, ",")))
+
+	content, summary := extractiveText(members, centroid)
+	return &ConsolidationProposal{
+		ID:         hex.EncodeToString(hash[:])[:12],
+		Status:     ProposalPending,
+		SourceIDs:  sourceIDs,
+		Content:    content,
+		Summary:    summary,
+		Category:   category,
+		Tags:       tags,
+		Keywords:   topTermList(centroid, maxProposalKeywords),
+		Similarity: similarity,
+		CreatedAt:  now,
+	}
+}
+
+// extractiveText merges the sentences of a cluster, dropping sentences that
+// repeat an earlier one, and picks the sentences closest to the cluster
+// centroid as its summary
+func extractiveText(members []*Memory, centroid map[string]float64) (content, summary string) {
+	type sentence struct {
+		text  string
+		words map[string]bool
+		score float64
+		order int
+	}
+
+	var sentences []sentence
+	var paragraphs []string
+	for _, member := range members {
+		var kept []string
+	nextSentence:
+		for _, text := range splitSentences(member.Content) {
+			words := make(map[string]bool)
+			for _, word := range strings.FieldsFunc(strings.ToLower(text), isTermSeparator) {
+				if word = consolidationTerm(word); word != "" {
+					words[word] = true
+				}
+			}
+			for _, earlier := range sentences {
+				if setOverlap(words, earlier.words) >= duplicateSentenceOverlap {
+					continue nextSentence
+				}
+			}
+
+			score := 0.0
+			for word := range words {
+				score += centroid[word]
+			}
+			if len(words) > 0 {
+				score /= math.Sqrt(float64(len(words)))
+			}
+			sentences = append(sentences, sentence{text: text, words: words, score: score, order: len(sentences)})
+			kept = append(kept, text)
+		}
+		if len(kept) > 0 {
+			paragraphs = append(paragraphs, strings.Join(kept, " "))
+		}
+	}
+
+	ranked := append([]sentence(nil), sentences...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > maxSummarySentences {
+		ranked = ranked[:maxSummarySentences]
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].order < ranked[j].order })
+	picked := make([]string, len(ranked))
+	for i, s := range ranked {
+		picked[i] = s.text
+	}
+
+	return strings.Join(paragraphs, "\n\n"), strings.Join(picked, " ")
+}
+
+// splitSentences splits text at sentence punctuation followed by a space
+// and at line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	flush := func(end int) {
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '\n':
+			flush(i + 1)
+		case (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])):
+			flush(i + 1)
+		}
+	}
+	flush(len(runes))
+	return sentences
+}
+
+// isTermSeparator splits words the way the TF-IDF tokenizer does
+func isTermSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+}
+
+// consolidationTerm trims punctuation from a TF-IDF term and drops short ones
+func consolidationTerm(term string) string {
+	term = strings.Trim(term, ".-_")
+	if len(term) < 3 {
+		return ""
+	}
+	return term
+}
+
+// setOverlap is the Jaccard similarity of two word sets
+func setOverlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// topTerms keeps the n highest weighted terms of a vector
+func topTerms(vector map[string]float64, n int) map[string]float64 {
+	if len(vector) <= n {
+		return vector
+	}
+	top := make(map[string]float64, n)
+	for _, term := range topTermList(vector, n) {
+		top[term] = vector[term]
+	}
+	return top
+}
+
+// topTermList returns the n highest weighted terms, highest first
+func topTermList(vector map[string]float64, n int) []string {
+	terms := make([]string, 0, len(vector))
+	for term := range vector {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if vector[terms[i]] != vector[terms[j]] {
+			return vector[terms[i]] > vector[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// unitVector scales a vector to length one
+func unitVector(vector map[string]float64) map[string]float64 {
+	norm := 0.0
+	for _, weight := range vector {
+		norm += weight * weight
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for term, weight := range vector {
+		vector[term] = weight / norm
+	}
+	return vector
+}
+
+// sortProposals orders proposals by status, then largest cluster first
+func sortProposals(proposals []*ConsolidationProposal) {
+	rank := map[string]int{ProposalPending: 0, ProposalApplied: 1, ProposalRejected: 2}
+	sort.Slice(proposals, func(i, j int) bool {
+		a, b := proposals[i], proposals[j]
+		if a.Status != b.Status {
+			return rank[a.Status] < rank[b.Status]
+		}
+		if len(a.SourceIDs) != len(b.SourceIDs) {
+			return len(a.SourceIDs) > len(b.SourceIDs)
+		}
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		return a.ID < b.ID
+	})
+}
+
+func copyProposal(proposal *ConsolidationProposal) *ConsolidationProposal {
+	c := *proposal
+	return &c
+}
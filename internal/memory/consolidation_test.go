@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProposeAndApplyConsolidation(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, nil)
+
+	notes := []string{
+		"Redis cache keys expire after ten minutes. Redis eviction policy is allkeys-lru.",
+		"Redis eviction policy is allkeys-lru. Cache keys for sessions expire after ten minutes.",
+		"Redis cache keys for user profiles also expire after ten minutes.",
+		"Frontend components are written in React with TypeScript.",
+		"Quarterly planning happens in the first week of each quarter.",
+	}
+	var sources []*Memory
+	for _, note := range notes {
+		m, err := store.Store(note, "", "ops", []string{"infra"}, nil)
+		if err != nil {
+			t.Fatalf("Failed to store memory: %v", err)
+		}
+		sources = append(sources, m)
+	}
+
+	proposals, err := store.ProposeConsolidations(ConsolidationOptions{Threshold: 0.2})
+	if err != nil {
+		t.Fatalf("ProposeConsolidations failed: %v", err)
+	}
+	if len(proposals) != 1 {
+		t.Fatalf("Expected one proposal, got %d: %+v", len(proposals), proposals)
+	}
+	proposal := proposals[0]
+	got := strings.Join(proposal.SourceIDs, ",")
+	for _, m := range sources[:3] {
+		if !strings.Contains(got, m.ID) {
+			t.Errorf("Proposal sources %v should include %s", proposal.SourceIDs, m.ID)
+		}
+	}
+	if len(proposal.SourceIDs) != 3 {
+		t.Errorf("Proposal should merge the three redis notes, got %v", proposal.SourceIDs)
+	}
+	if proposal.Summary == "" || proposal.Category != "ops" || len(proposal.Tags) != 1 {
+		t.Errorf("Unexpected proposal: %+v", proposal)
+	}
+	// The repeated eviction sentence appears once
+	if n := strings.Count(strings.ToLower(proposal.Content), "allkeys-lru"); n != 1 {
+		t.Errorf("Merged content should drop repeated sentences, got %d mentions:\n%s", n, proposal.Content)
+	}
+
+	// Proposals survive a restart and can be applied
+	store.Close()
+	store = openTestStore(t, dir, nil)
+	defer store.Close()
+
+	if pending := store.ConsolidationProposals(ProposalPending); len(pending) != 1 || pending[0].ID != proposal.ID {
+		t.Fatalf("Pending proposals after reload = %+v", pending)
+	}
+	merged, err := store.ApplyConsolidation(proposal.ID)
+	if err != nil {
+		t.Fatalf("ApplyConsolidation failed: %v", err)
+	}
+	if merged.Content != proposal.Content || merged.Metadata["consolidation"] != proposal.ID {
+		t.Errorf("Unexpected merged memory: %+v", merged)
+	}
+
+	graph, err := store.Related(merged.ID, RelatedOptions{Direction: DirectionOutgoing, Types: []string{RelationSupersedes}})
+	if err != nil {
+		t.Fatalf("Related failed: %v", err)
+	}
+	if len(graph.Nodes) != 4 {
+		t.Errorf("Merged memory should supersede its 3 sources, got %d nodes", len(graph.Nodes)-1)
+	}
+	for _, m := range sources {
+		if _, err := store.Get(m.ID); err != nil {
+			t.Errorf("Source %s should be kept: %v", m.ID, err)
+		}
+	}
+
+	if _, err := store.ApplyConsolidation(proposal.ID); err == nil {
+		t.Error("Applying a proposal twice should fail")
+	}
+
+	// Superseded sources are not proposed again
+	proposals, err = store.ProposeConsolidations(ConsolidationOptions{Threshold: 0.2})
+	if err != nil {
+		t.Fatalf("ProposeConsolidations failed: %v", err)
+	}
+	for _, p := range proposals {
+		for _, id := range p.SourceIDs {
+			for _, m := range sources[:3] {
+				if id == m.ID {
+					t.Errorf("Superseded memory %s proposed again", id)
+				}
+			}
+		}
+	}
+}
+
+func TestRejectConsolidation(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	store.Store("Deploys to production run from the main branch through the release pipeline.", "", "", nil, nil)
+	store.Store("The release pipeline deploys the main branch to production every afternoon.", "", "", nil, nil)
+
+	proposals, err := store.ProposeConsolidations(ConsolidationOptions{Threshold: 0.2})
+	if err != nil || len(proposals) != 1 {
+		t.Fatalf("ProposeConsolidations = %d proposals, %v", len(proposals), err)
+	}
+	if err := store.RejectConsolidation(proposals[0].ID); err != nil {
+		t.Fatalf("RejectConsolidation failed: %v", err)
+	}
+
+	proposals, err = store.ProposeConsolidations(ConsolidationOptions{Threshold: 0.2})
+	if err != nil || len(proposals) != 0 {
+		t.Errorf("A rejected cluster should not be proposed again, got %d proposals, %v", len(proposals), err)
+	}
+	if rejected := store.ConsolidationProposals(ProposalRejected); len(rejected) != 1 {
+		t.Errorf("Expected one rejected proposal, got %d", len(rejected))
+	}
+
+	if _, err := store.ProposeConsolidations(ConsolidationOptions{MinClusterSize: 1}); err == nil {
+		t.Error("A min cluster size below 2 should be rejected")
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Use v1.2 of the API. It works!\nSecond line? Yes")
+	want := []string{"Use v1.2 of the API.", "It works!", "Second line?", "Yes"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,271 @@
+// internal/memory/events.go
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventCreated   = "created"   // first version of a memory
+	EventVersioned = "versioned" // new version of an existing memory
+	EventDeleted   = "deleted"   // version moved to the trash or removed
+	EventEvicted   = "evicted"   // version removed by storage cleanup
+	EventRestored  = "restored"  // version restored from the trash
+)
+
+// DefaultEventHistory is how many recent events are kept for resuming
+// subscribers when the config leaves it unset
+const DefaultEventHistory = 1000
+
+const (
+	eventsFile       = "index/events.json"
+	subscriberBuffer = 256  // events a slow subscriber may fall behind before it is dropped
+	seqReservation   = 1000 // sequence numbers reserved on disk at a time
+)
+
+// ErrEventsExpired is returned when a subscriber resumes from a sequence
+// number whose following events are no longer kept; it should resynchronize
+// and subscribe from the latest sequence number
+var ErrEventsExpired = errors.New("events after the requested sequence number have expired")
+
+// Event describes a change to a memory. Sequence numbers increase by one per
+// event and survive restarts.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Type     string    `json:"type"`
+	ID       string    `json:"id"`
+	BaseID   string    `json:"base_id"`
+	Version  int       `json:"version"`
+	Category string    `json:"category,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Subscription receives events as they are published. Events is closed when
+// the subscriber falls too far behind or the store closes; resume with
+// Subscribe from the last sequence number received.
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	bus    *EventBus
+}
+
+// Close stops the subscription
+func (sub *Subscription) Close() {
+	sub.bus.unsubscribe(sub)
+}
+
+// EventBus publishes memory events to subscribers and keeps recent events
+// so subscribers can catch up after disconnecting
+type EventBus struct {
+	mu          sync.Mutex
+	history     []Event // most recent events, oldest first
+	maxHistory  int
+	lastSeq     uint64
+	reserved    uint64                // highest sequence number persisted as possibly handed out
+	reserve     func(reserved uint64) // persists reserved; nil keeps sequence numbers in memory only
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+// NewEventBus creates an event bus keeping up to maxHistory recent events
+func NewEventBus(maxHistory int) *EventBus {
+	if maxHistory <= 0 {
+		maxHistory = DefaultEventHistory
+	}
+	return &EventBus{
+		maxHistory:  maxHistory,
+		subscribers: make(map[*Subscription]bool),
+	}
+}
+
+// Publish assigns the event the next sequence number and delivers it. A
+// subscriber whose buffer is full is dropped rather than blocking the store.
+func (b *EventBus) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Persist a block of sequence numbers before handing one out, so a
+	// crash can never make them repeat
+	if b.reserve != nil && b.lastSeq >= b.reserved {
+		b.reserved = b.lastSeq + seqReservation
+		b.reserve(b.reserved)
+	}
+	b.lastSeq++
+	event.Seq = b.lastSeq
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	b.history = append(b.history, event)
+	if len(b.history) > b.maxHistory {
+		b.history = append(b.history[:0:0], b.history[len(b.history)-b.maxHistory:]...)
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return event
+}
+
+// Subscribe returns the kept events after sequence number after, followed
+// by new events on the subscription. It fails with ErrEventsExpired when
+// some events after it are no longer kept.
+func (b *EventBus) Subscribe(after uint64) ([]Event, *Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, errors.New("event bus is closed")
+	}
+	// A sequence number from the future means events were lost in a crash
+	// and numbers reused since
+	if after > b.lastSeq {
+		return nil, nil, ErrEventsExpired
+	}
+	oldest := b.lastSeq + 1
+	if len(b.history) > 0 {
+		oldest = b.history[0].Seq
+	}
+	if after+1 < oldest {
+		return nil, nil, ErrEventsExpired
+	}
+
+	var backlog []Event
+	for _, event := range b.history {
+		if event.Seq > after {
+			backlog = append(backlog, event)
+		}
+	}
+
+	return backlog, b.addSubscriber(), nil
+}
+
+// SubscribeLatest subscribes to events after the latest one, returning its
+// sequence number. Subscribers use it to start over after ErrEventsExpired.
+func (b *EventBus) SubscribeLatest() (uint64, *Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, nil, errors.New("event bus is closed")
+	}
+	return b.lastSeq, b.addSubscriber(), nil
+}
+
+// addSubscriber registers a new subscription. The caller must hold b.mu.
+func (b *EventBus) addSubscriber() *Subscription {
+	events := make(chan Event, subscriberBuffer)
+	sub := &Subscription{Events: events, events: events, bus: b}
+	b.subscribers[sub] = true
+	return sub
+}
+
+// LastSeq returns the sequence number of the latest event
+func (b *EventBus) LastSeq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastSeq
+}
+
+// Close ends every subscription
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (b *EventBus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// savedEvents is the persisted state of the event bus. Reserved is above
+// LastSeq while the store is open, and equal to it after a clean close.
+type savedEvents struct {
+	LastSeq  uint64  `json:"last_seq"`
+	Reserved uint64  `json:"reserved,omitempty"`
+	History  []Event `json:"history"`
+}
+
+// Events returns the store's event bus
+func (s *Store) Events() *EventBus {
+	return s.events
+}
+
+// publishEvent publishes an event for memory
+func (s *Store) publishEvent(eventType string, memory *Memory) {
+	s.events.Publish(Event{
+		Type:     eventType,
+		ID:       memory.ID,
+		BaseID:   BaseID(memory.ID),
+		Version:  memory.Version,
+		Category: memory.Category,
+		Tags:     append([]string(nil), memory.Tags...),
+	})
+}
+
+// loadEvents restores the sequence number and recent events saved at the
+// last close. After a crash the events published since then are lost, so
+// numbering resumes past every reserved number and the history is dropped,
+// which sends resuming subscribers back to resynchronize.
+func (s *Store) loadEvents() error {
+	var saved savedEvents
+	if err := s.readDataFile(eventsFile, &saved); err != nil {
+		return err
+	}
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+
+	s.events.lastSeq = saved.LastSeq
+	s.events.history = saved.History
+	if saved.Reserved > saved.LastSeq {
+		s.logger.Warn("Event history was not saved at the last close, subscribers must resynchronize",
+			"last_saved_seq", saved.LastSeq, "resume_seq", saved.Reserved)
+		s.events.lastSeq = saved.Reserved
+		s.events.history = nil
+	}
+	if len(s.events.history) > s.events.maxHistory {
+		s.events.history = s.events.history[len(s.events.history)-s.events.maxHistory:]
+	}
+	s.events.reserved = s.events.lastSeq
+	s.events.reserve = func(reserved uint64) {
+		saved := savedEvents{LastSeq: s.events.lastSeq, Reserved: reserved, History: s.events.history}
+		if err := s.writeDataFile(eventsFile, saved); err != nil {
+			s.logger.WithError(err).Warn("Failed to reserve event sequence numbers")
+		}
+	}
+	return nil
+}
+
+// closeEvents ends every subscription and saves the event history
+func (s *Store) closeEvents() {
+	s.events.Close()
+	if s.readOnly != nil {
+		return // the history belongs to the writer
+	}
+
+	s.events.mu.Lock()
+	saved := savedEvents{LastSeq: s.events.lastSeq, Reserved: s.events.lastSeq, History: s.events.history}
+	s.events.mu.Unlock()
+	if err := s.writeDataFile(eventsFile, saved); err != nil {
+		s.logger.WithError(err).Warn("Failed to save event history")
+	}
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventBusResumesAndExpires(t *testing.T) {
+	bus := NewEventBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(Event{Type: EventCreated, ID: "m"})
+	}
+
+	backlog, sub, err := bus.Subscribe(3)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer sub.Close()
+	if len(backlog) != 2 || backlog[0].Seq != 4 || backlog[1].Seq != 5 {
+		t.Errorf("Backlog after 3 = %+v, want events 4 and 5", backlog)
+	}
+
+	published := bus.Publish(Event{Type: EventDeleted, ID: "m"})
+	if received := <-sub.Events; received.Seq != published.Seq || received.Type != EventDeleted {
+		t.Errorf("Subscriber received %+v, want %+v", received, published)
+	}
+
+	// Events 2 and earlier are no longer kept, and 9 was never published
+	if _, _, err := bus.Subscribe(1); !errors.Is(err, ErrEventsExpired) {
+		t.Errorf("Subscribe(1) = %v, want ErrEventsExpired", err)
+	}
+	if _, _, err := bus.Subscribe(9); !errors.Is(err, ErrEventsExpired) {
+		t.Errorf("Subscribe(9) = %v, want ErrEventsExpired", err)
+	}
+	if backlog, latest, err := bus.Subscribe(6); err != nil || len(backlog) != 0 {
+		t.Errorf("Subscribe at the latest event = %v, %v", backlog, err)
+	} else {
+		latest.Close()
+	}
+
+	// A subscriber that stops reading is dropped instead of blocking
+	for i := 0; i < subscriberBuffer+1; i++ {
+		bus.Publish(Event{Type: EventCreated, ID: "m"})
+	}
+	drained := 0
+	for range sub.Events {
+		drained++
+	}
+	if drained != subscriberBuffer {
+		t.Errorf("Slow subscriber received %d events before being dropped, want %d", drained, subscriberBuffer)
+	}
+}
+
+func TestStorePublishesMemoryEvents(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withTrash)
+	_, sub, err := store.Events().Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	first, _ := store.Store("Invoices are sent on the first", "", "billing", nil, nil)
+	store.Store("Invoices are sent on the first", "", "billing", nil, nil)
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Restore(first.ID); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	want := []string{EventCreated, EventVersioned, EventDeleted, EventRestored}
+	for i, eventType := range want {
+		event := <-sub.Events
+		if event.Seq != uint64(i+1) || event.Type != eventType || event.BaseID != BaseID(first.ID) || event.Category != "billing" {
+			t.Errorf("Event %d = %+v, want a %s event for %s", i+1, event, eventType, BaseID(first.ID))
+		}
+	}
+
+	// Closing ends subscriptions, and sequence numbers continue after a restart
+	store.Close()
+	if _, ok := <-sub.Events; ok {
+		t.Error("Closing the store should end subscriptions")
+	}
+	store = openTestStore(t, dir, withTrash)
+	defer store.Close()
+
+	backlog, sub, err := store.Events().Subscribe(2)
+	if err != nil || len(backlog) != 2 || backlog[0].Type != EventDeleted {
+		t.Fatalf("Resuming after a restart = %+v, %v", backlog, err)
+	}
+	defer sub.Close()
+	store.Store("Receipts are kept for seven years", "", "", nil, nil)
+	if event := <-sub.Events; event.Seq != 5 || event.Type != EventCreated {
+		t.Errorf("First event after restart = %+v, want sequence 5", event)
+	}
+}
+
+func TestEventSequenceSurvivesCrash(t *testing.T) {
+	dir := t.TempDir()
+	crashed := openTestStore(t, dir, nil)
+	defer crashed.Close()
+	for _, content := range []string{"Alerts page the primary", "Alerts escalate after ten minutes"} {
+		if _, err := crashed.Store(content, "", "", nil, nil); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+	seen := crashed.Events().LastSeq()
+
+	// Opening the directory again without closing it is what a restart
+	// after a crash sees
+	store := openTestStore(t, dir, nil)
+	defer store.Close()
+	if _, _, err := store.Events().Subscribe(seen); !errors.Is(err, ErrEventsExpired) {
+		t.Errorf("Resuming after a crash = %v, want ErrEventsExpired", err)
+	}
+	stored, err := store.Store("Alerts are reviewed weekly", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	// The first event reserved numbers up to seqReservation
+	if latest := store.Events().LastSeq(); latest != seqReservation+1 {
+		t.Errorf("Sequence number of %s after a crash = %d, want %d", stored.ID, latest, seqReservation+1)
+	}
+}
@@ -0,0 +1,89 @@
+// internal/memory/flags.go
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrProtected is returned when deleting a protected memory without an override
+var ErrProtected = errors.New("memory is protected")
+
+// SetPinned pins or unpins a memory. Pinned memories make up the core
+// context and are never outscored by the decay model.
+func (s *Store) SetPinned(id string, pinned bool) (*Memory, error) {
+	return s.setFlag(id, "pinned", func(memory *Memory) bool {
+		changed := memory.Pinned != pinned
+		memory.Pinned = pinned
+		return changed
+	})
+}
+
+// SetProtected protects a memory from deletion and eviction, or lifts the
+// protection. Protected memories are only deleted with an explicit override.
+func (s *Store) SetProtected(id string, protected bool) (*Memory, error) {
+	return s.setFlag(id, "protected", func(memory *Memory) bool {
+		changed := memory.Protected != protected
+		memory.Protected = protected
+		return changed
+	})
+}
+
+// setFlag applies update to the current version of a memory and saves it
+// when the flag changed
+func (s *Store) setFlag(id, flag string, update func(*Memory) bool) (*Memory, error) {
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memory, exists := s.currentMemory(id)
+	if !exists {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	if !update(memory) {
+		return memory, nil
+	}
+
+	if _, err := s.saveMemoryToFile(memory); err != nil {
+		return nil, fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	s.logger.Info("Memory flag changed", "id", memory.ID, "flag", flag, "pinned", memory.Pinned, "protected", memory.Protected)
+	s.recordAudit(AuditFlag, []string{memory.ID}, fmt.Sprintf("pinned=%t protected=%t", memory.Pinned, memory.Protected))
+	return memory, nil
+}
+
+// PinnedMemories returns the current pinned memories, most important first
+func (s *Store) PinnedMemories() []*Memory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pinned []*Memory
+	for id, memory := range s.index {
+		if id == memory.ID && memory.IsCurrentVersion && memory.Pinned {
+			pinned = append(pinned, memory)
+		}
+	}
+	sort.Slice(pinned, func(i, j int) bool {
+		if pinned[i].EffectiveImportance() != pinned[j].EffectiveImportance() {
+			return pinned[i].EffectiveImportance() > pinned[j].EffectiveImportance()
+		}
+		if !pinned[i].CreatedAt.Equal(pinned[j].CreatedAt) {
+			return pinned[i].CreatedAt.Before(pinned[j].CreatedAt)
+		}
+		return pinned[i].ID < pinned[j].ID
+	})
+	return pinned
+}
+
+// isProtected reports whether the memory behind id, or its current version,
+// is protected. The caller must hold s.mu.
+func (s *Store) isProtected(id string) bool {
+	memory, exists := s.currentMemory(id)
+	return exists && memory.Protected
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPinnedAndProtectedFlags(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, nil)
+
+	core, err := store.Store("Production database runs PostgreSQL 16", "", "ops", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	other, err := store.Store("The team standup is at ten", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	if _, err := store.SetPinned(core.ID, true); err != nil {
+		t.Fatalf("SetPinned failed: %v", err)
+	}
+	if _, err := store.SetProtected(core.ID, true); err != nil {
+		t.Fatalf("SetProtected failed: %v", err)
+	}
+	if _, err := store.SetPinned("missing", true); err == nil {
+		t.Error("Pinning a missing memory should fail")
+	}
+
+	// Flags survive a restart and carry over to new versions
+	store.Close()
+	store = openTestStore(t, dir, nil)
+	defer store.Close()
+
+	pinned := store.PinnedMemories()
+	if len(pinned) != 1 || pinned[0].ID != core.ID || !pinned[0].Protected {
+		t.Fatalf("PinnedMemories after reload = %+v", pinned)
+	}
+	again, err := store.Remember(MemoryInput{Content: "Production database runs PostgreSQL 16"})
+	if err != nil || !again.Memory.Pinned || !again.Memory.Protected {
+		t.Errorf("New version should stay pinned and protected, got %+v, %v", again.Memory, err)
+	}
+	if score := store.RetentionScore(again.Memory, again.Memory.LastAccess); score != 1 {
+		t.Errorf("Pinned memories should have retention score 1, got %v", score)
+	}
+
+	// Deletion needs an override
+	if err := store.Delete(core.ID); !errors.Is(err, ErrProtected) {
+		t.Errorf("Delete of a protected memory = %v, want ErrProtected", err)
+	}
+	result := confirmBulkDelete(t, store, &BulkDeleteOptions{Query: "a"})
+	if len(result.Protected) != 1 || result.Protected[0] != BaseID(core.ID) {
+		t.Errorf("BulkDelete should report the protected memory as kept, got %+v", result)
+	}
+	if _, err := store.Get(core.ID); err != nil {
+		t.Errorf("Protected memory should survive bulk delete: %v", err)
+	}
+	if _, err := store.Get(other.ID); err == nil {
+		t.Error("Unprotected memory should be bulk deleted")
+	}
+
+	if err := store.ForceDelete(again.Memory.ID); err != nil {
+		t.Fatalf("ForceDelete failed: %v", err)
+	}
+	if _, err := store.Get(again.Memory.ID); err == nil {
+		t.Error("ForceDelete should delete a protected memory")
+	}
+	if len(store.PinnedMemories()) != 0 {
+		t.Error("Deleted memories should leave the core context")
+	}
+}
+
+func TestProtectedMemoriesSurviveEviction(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	kept, err := store.Remember(MemoryInput{Content: "Rotate the signing keys every quarter", Importance: 0.1, Protected: true})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	evictable, err := store.Remember(MemoryInput{Content: "The office plants are watered on Mondays", Importance: 0.2})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+
+	store.config.MaxStorageSize = store.totalSize + 64
+	if _, err := store.Store("A third memory that pushes the store over its limit", "", "", nil, nil); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, err := store.Get(kept.Memory.ID); err != nil {
+		t.Errorf("The protected memory should not be evicted: %v", err)
+	}
+	if _, err := store.Get(evictable.Memory.ID); err == nil {
+		t.Error("The unprotected memory should have been evicted")
+	}
+
+	// Cleanup may evict protected memories once the config allows it
+	store.config.EvictProtected = true
+	store.config.MaxStorageSize = 1
+	if err := store.cleanupOldMemories(); err != nil {
+		t.Fatalf("cleanupOldMemories failed: %v", err)
+	}
+	if _, err := store.Get(kept.Memory.ID); err == nil {
+		t.Error("EvictProtected should let cleanup evict protected memories")
+	}
+}
@@ -0,0 +1,583 @@
+// internal/memory/git.go
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/pkg/logger"
+)
+
+const (
+	gitRemoteName   = "origin"
+	gitCommitSettle = 50 * time.Millisecond // changes within this of each other share a commit
+	gitIgnore       = "*.tmp\n"             // atomic writes in progress
+)
+
+// gitTimeout is the longest a git command may run, so a remote that stops
+// responding cannot stall syncing forever
+var gitTimeout = 2 * time.Minute
+
+// ErrGitDisabled is returned by git operations on a store without git storage
+var ErrGitDisabled = errors.New("git storage is not enabled")
+
+// GitPullResult reports what pulling from the remote brought into the store
+type GitPullResult struct {
+	Head      string   `json:"head"`                // commit the working tree is at afterwards
+	Merged    bool     `json:"merged"`              // remote commits were merged
+	Updated   []string `json:"updated,omitempty"`   // memory versions added or changed by the merge
+	Removed   []string `json:"removed,omitempty"`   // memory versions the merge removed
+	Conflicts []string `json:"conflicts,omitempty"` // siblings holding the remote side of conflicting edits
+	Pushed    bool     `json:"pushed,omitempty"`
+}
+
+// gitRepo is the git working tree holding a store's memory files
+type gitRepo struct {
+	dir    string
+	config *config.StorageConfig
+	logger *logger.Logger
+	mu     sync.Mutex // serializes git commands
+
+	pendingMu sync.Mutex
+	pending   []string // descriptions of the changes awaiting a commit
+	wake      chan struct{}
+}
+
+// openGitRepo opens the working tree in dir, initializing it on first use
+func openGitRepo(dir string, cfg *config.StorageConfig, log *logger.Logger) (*gitRepo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git storage needs the git command: %w", err)
+	}
+	g := &gitRepo{dir: dir, config: cfg, logger: log, wake: make(chan struct{}, 1)}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if _, err := g.run("init", "-q"); err != nil {
+			return nil, err
+		}
+		if _, err := g.run("symbolic-ref", "HEAD", "refs/heads/"+g.branch()); err != nil {
+			return nil, err
+		}
+	}
+	ignorePath := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignorePath); os.IsNotExist(err) {
+		if err := os.WriteFile(ignorePath, []byte(gitIgnore), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write .gitignore: %w", err)
+		}
+	}
+
+	if cfg.GitRemote != "" {
+		if url, err := g.run("remote", "get-url", gitRemoteName); err != nil {
+			_, err = g.run("remote", "add", gitRemoteName, cfg.GitRemote)
+			if err != nil {
+				return nil, err
+			}
+		} else if strings.TrimSpace(url) != cfg.GitRemote {
+			if _, err := g.run("remote", "set-url", gitRemoteName, cfg.GitRemote); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g *gitRepo) branch() string {
+	if g.config.GitBranch == "" {
+		return "main"
+	}
+	return g.config.GitBranch
+}
+
+// run runs a git command in the working tree and returns its output
+func (g *gitRepo) run(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.WaitDelay = time.Second // don't wait on helpers still holding the output open
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"LC_ALL=C",
+		"GIT_AUTHOR_NAME="+g.config.GitAuthorName,
+		"GIT_AUTHOR_EMAIL="+g.config.GitAuthorEmail,
+		"GIT_COMMITTER_NAME="+g.config.GitAuthorName,
+		"GIT_COMMITTER_EMAIL="+g.config.GitAuthorEmail,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return stdout.String(), fmt.Errorf("git %s: timed out after %s", args[0], gitTimeout)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// head returns the commit HEAD points to, or "" before the first commit
+func (g *gitRepo) head() string {
+	out, err := g.run("rev-parse", "-q", "--verify", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// queue notes a change for the next commit
+func (g *gitRepo) queue(description string) {
+	g.pendingMu.Lock()
+	g.pending = append(g.pending, description)
+	g.pendingMu.Unlock()
+
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush commits the working tree, describing it with the queued changes
+func (g *gitRepo) flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.flushLocked()
+}
+
+// flushLocked is flush for callers holding g.mu
+func (g *gitRepo) flushLocked() error {
+	g.pendingMu.Lock()
+	pending := g.pending
+	g.pending = nil
+	g.pendingMu.Unlock()
+
+	message := "Save memory changes"
+	switch {
+	case len(pending) == 1:
+		message = pending[0]
+	case len(pending) > 1:
+		message = fmt.Sprintf("Record %d memory changes\n\n- %s", len(pending), strings.Join(pending, "\n- "))
+	}
+	_, err := g.commitLocked(message)
+	return err
+}
+
+// commitLocked stages everything and commits it, reporting whether there
+// was anything to commit. The caller must hold g.mu.
+func (g *gitRepo) commitLocked(message string) (bool, error) {
+	if _, err := g.run("add", "-A"); err != nil {
+		return false, err
+	}
+	if _, err := g.run("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := g.run("commit", "-q", "-m", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetch downloads the remote branch without touching the working tree and
+// returns the commit it points to, or "" when nothing has been pushed yet
+func (g *gitRepo) fetch() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, err := g.run("fetch", "-q", gitRemoteName, g.branch()); err != nil {
+		if strings.Contains(err.Error(), "couldn't find remote ref") {
+			return "", nil
+		}
+		return "", err
+	}
+	out, err := g.run("rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// merge merges a fetched commit into the working tree. Versions edited on
+// both sides keep the local edit and gain a sibling holding the remote one;
+// an edit wins over a delete.
+func (g *gitRepo) merge(fetched string) (*GitPullResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.flushLocked(); err != nil {
+		return nil, fmt.Errorf("failed to commit local changes: %w", err)
+	}
+	before := g.head()
+	result := &GitPullResult{Head: before}
+	if fetched == "" {
+		return result, nil // nothing pushed yet
+	}
+	if before != "" {
+		if _, err := g.run("merge-base", "--is-ancestor", fetched, before); err == nil {
+			return result, nil // already merged
+		}
+	}
+
+	message := fmt.Sprintf("Merge memories from %s", g.config.GitRemote)
+	if _, mergeErr := g.run("merge", "-q", "--no-edit", "--allow-unrelated-histories", "-m", message, fetched); mergeErr != nil {
+		out, _ := g.run("diff", "--name-only", "--diff-filter=U", "-z")
+		conflicted := splitNul(out)
+		if len(conflicted) == 0 {
+			g.run("merge", "--abort")
+			return nil, fmt.Errorf("failed to merge: %w", mergeErr)
+		}
+		siblings, err := g.resolveConflicts(conflicted, "git-"+fetched[:8])
+		if err != nil {
+			g.run("merge", "--abort")
+			return nil, err
+		}
+		result.Conflicts = siblings
+		message = fmt.Sprintf("%s\n\nKept %d conflicting edits as siblings:\n- %s", message, len(siblings), strings.Join(siblings, "\n- "))
+		if _, err := g.run("add", "-A"); err != nil {
+			g.run("merge", "--abort")
+			return nil, err
+		}
+		if _, err := g.run("commit", "-q", "-m", message); err != nil {
+			g.run("merge", "--abort")
+			return nil, err
+		}
+	}
+	result.Merged = true
+	result.Head = g.head()
+
+	// Report the memory files the merge changed
+	var out string
+	var err error
+	var changes []string
+	if before == "" {
+		out, err = g.run("ls-files", "-z")
+		for _, path := range splitNul(out) {
+			changes = append(changes, "A", path)
+		}
+	} else {
+		out, err = g.run("diff", "--name-status", "--no-renames", "-z", before, result.Head)
+		changes = splitNul(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(changes); i += 2 {
+		id := memoryFileID(changes[i+1])
+		if id == "" || strings.Contains(changes[i+1], "/") {
+			continue
+		}
+		if changes[i] == "D" {
+			result.Removed = append(result.Removed, id)
+		} else {
+			result.Updated = append(result.Updated, id)
+		}
+	}
+	return result, nil
+}
+
+// resolveConflicts settles the conflicted paths of a merge in progress and
+// returns the IDs of the siblings created. The caller must hold g.mu.
+func (g *gitRepo) resolveConflicts(paths []string, suffix string) ([]string, error) {
+	var siblings []string
+	for _, path := range paths {
+		ours, oursErr := g.run("show", ":2:"+path)
+		theirs, theirsErr := g.run("show", ":3:"+path)
+		keep := ours
+		if oursErr != nil {
+			keep = theirs
+		}
+		if err := os.WriteFile(filepath.Join(g.dir, path), []byte(keep), 0644); err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		if oursErr != nil || theirsErr != nil || !strings.HasSuffix(path, markdownExt) {
+			continue
+		}
+
+		remote, err := decodeMarkdown([]byte(theirs))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remote %s: %w", path, err)
+		}
+		sibling := *remote
+		sibling.ID = SiblingID(remote.ID, suffix)
+		sibling.SiblingOf = remote.ID
+		sibling.IsCurrentVersion = false
+		data, err := encodeMarkdown(&sibling)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(g.dir, sibling.ID+markdownExt), data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write sibling %s: %w", sibling.ID, err)
+		}
+		siblings = append(siblings, sibling.ID)
+	}
+	return siblings, nil
+}
+
+// push sends local commits to the remote branch
+func (g *gitRepo) push() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.flushLocked(); err != nil {
+		return fmt.Errorf("failed to commit local changes: %w", err)
+	}
+	if g.head() == "" {
+		return nil
+	}
+	_, err := g.run("push", "-q", gitRemoteName, "HEAD:refs/heads/"+g.branch())
+	return err
+}
+
+func splitNul(out string) []string {
+	return strings.FieldsFunc(out, func(r rune) bool { return r == 0 })
+}
+
+// gitChangeDescription describes an audited operation as a commit message
+// line, e.g. "Store 1a2b-v2 (version 2) via remember"
+func gitChangeDescription(caller Caller, action string, ids []string, detail string) string {
+	description := strings.ToUpper(action[:1]) + strings.ReplaceAll(action[1:], "_", " ")
+	switch {
+	case len(ids) > 3:
+		description += fmt.Sprintf(" %s and %d more", strings.Join(ids[:3], ", "), len(ids)-3)
+	case len(ids) > 0:
+		description += " " + strings.Join(ids, ", ")
+	}
+	if detail != "" {
+		description += " (" + detail + ")"
+	}
+	if caller.Tool != "" {
+		description += " via " + caller.Tool
+	}
+	return description
+}
+
+// openGit puts the memories directory under git, converting memory files
+// written before git storage was enabled to Markdown
+func (s *Store) openGit() error {
+	if s.config.EnableAsync || s.config.EnableEncryption {
+		return fmt.Errorf("git storage needs synchronous saves and no encryption")
+	}
+	repo, err := openGitRepo(filepath.Join(s.dataDir, "memories"), s.config, s.logger)
+	if err != nil {
+		return fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	s.mu.Lock()
+	converted := 0
+	for id, memory := range s.index {
+		if id != memory.ID {
+			continue
+		}
+		path := s.memoryFilePath("memories", id)
+		if strings.HasSuffix(path, markdownExt) {
+			continue
+		}
+		size, err := s.saveMemoryToFile(memory)
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("failed to convert %s to Markdown: %w", id, err)
+		}
+		os.Remove(path)
+		s.setMemorySize(memory, size)
+		converted++
+	}
+	s.mu.Unlock()
+
+	repo.mu.Lock()
+	message := "Save memory changes"
+	switch {
+	case converted > 0:
+		message = fmt.Sprintf("Convert %d memories to Markdown", converted)
+	case repo.head() == "":
+		message = "Start memory repository"
+	}
+	_, err = repo.commitLocked(message)
+	repo.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to commit existing memories: %w", err)
+	}
+
+	s.git = repo
+	s.wg.Add(1)
+	go s.gitCommitWorker()
+	if s.config.GitSyncInterval > 0 && s.config.GitRemote != "" {
+		s.wg.Add(1)
+		go s.As(SystemCaller).gitSyncWorker(s.config.GitSyncInterval)
+	}
+	return nil
+}
+
+// GitEnabled reports whether memories are kept in a git working tree
+func (s *Store) GitEnabled() bool {
+	return s.git != nil
+}
+
+// GitPull merges the remote branch into the working tree and rebuilds the
+// index from the result. Conflicting edits of a memory version keep the
+// local edit; the remote one becomes a sibling version. Reads go on
+// throughout; changes wait while the merge and the rebuild run, so none is
+// merged half-written or dropped from the rebuilt index.
+func (s *Store) GitPull() (*GitPullResult, error) {
+	if s.git == nil {
+		return nil, ErrGitDisabled
+	}
+	if s.config.GitRemote == "" {
+		return nil, fmt.Errorf("no git remote configured")
+	}
+
+	fetched, err := s.git.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	s.filesMu.Lock()
+	defer s.filesMu.Unlock()
+	result, err := s.git.merge(fetched)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Merged {
+		return result, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.rebuildIndex(result); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Pulled memories from git remote", "head", result.Head, "updated", len(result.Updated),
+		"removed", len(result.Removed), "conflicts", len(result.Conflicts))
+	s.recordAudit(AuditReplicate, append(append([]string(nil), result.Updated...), result.Removed...), "git pull")
+	return result, nil
+}
+
+// GitSync pulls from the remote, then pushes the merged branch back
+func (s *Store) GitSync() (*GitPullResult, error) {
+	result, err := s.GitPull()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.git.push(); err != nil {
+		return result, fmt.Errorf("failed to push: %w", err)
+	}
+	result.Pushed = true
+	result.Head = s.git.head()
+	return result, nil
+}
+
+// rebuildIndex reloads every memory from the working tree after a merge and
+// announces the changed versions. The caller must hold s.mu.
+func (s *Store) rebuildIndex(result *GitPullResult) error {
+	previous := make(map[string]*Memory)
+	for _, id := range result.Removed {
+		if memory, exists := s.index[id]; exists && memory.ID == id {
+			previous[id] = memory
+		}
+	}
+
+	s.clear()
+	s.clearSizes()
+	if err := s.loadIndex(); err != nil {
+		return fmt.Errorf("failed to rebuild index: %w", err)
+	}
+	if demoted := s.normalizeCurrentVersions(); len(demoted) > 0 {
+		s.git.queue(fmt.Sprintf("Keep the newest version current after merging (%s)", strings.Join(demoted, ", ")))
+	}
+
+	for _, id := range result.Updated {
+		if memory, exists := s.index[id]; exists && memory.ID == id {
+			s.journal.append(journalPut, id)
+			if memory.Version > 1 {
+				s.publishEvent(EventVersioned, memory)
+			} else {
+				s.publishEvent(EventCreated, memory)
+			}
+		}
+	}
+	for _, id := range result.Removed {
+		s.journal.append(journalRemove, id)
+		if memory, exists := previous[id]; exists {
+			s.publishEvent(EventDeleted, memory)
+		}
+	}
+	return nil
+}
+
+// normalizeCurrentVersions leaves one current version per memory, the
+// newest, after a merge brought in versions created on both sides. It
+// returns the IDs of the versions no longer current. The caller must hold
+// s.mu.
+func (s *Store) normalizeCurrentVersions() []string {
+	var demoted []string
+	for baseID, versionIDs := range s.versionIndex {
+		var newest *Memory
+		for _, id := range versionIDs {
+			if memory := s.index[id]; memory != nil && memory.IsCurrentVersion && (newest == nil || memory.Version > newest.Version) {
+				newest = memory
+			}
+		}
+		for _, id := range versionIDs {
+			memory := s.index[id]
+			if memory == nil || !memory.IsCurrentVersion || memory == newest {
+				continue
+			}
+			memory.IsCurrentVersion = false
+			if _, err := s.saveMemoryToFile(memory); err != nil {
+				s.logger.WithError(err).Warn("Failed to save superseded memory", "id", id)
+			}
+			demoted = append(demoted, id)
+		}
+		if newest != nil {
+			s.index[baseID] = newest
+		}
+	}
+	return demoted
+}
+
+// gitCommitWorker commits queued changes shortly after they happen
+func (s *Store) gitCommitWorker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.shutdownCh:
+			if err := s.git.flush(); err != nil {
+				s.logger.WithError(err).Warn("Failed to commit memory changes")
+			}
+			return
+		case <-s.git.wake:
+		}
+
+		select {
+		case <-time.After(gitCommitSettle):
+		case <-s.shutdownCh:
+		}
+		if err := s.git.flush(); err != nil {
+			s.logger.WithError(err).Warn("Failed to commit memory changes")
+		}
+	}
+}
+
+// gitSyncWorker pulls from and pushes to the remote every interval
+func (s *Store) gitSyncWorker(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			if _, err := s.GitSync(); err != nil {
+				s.logger.WithError(err).Warn("Failed to sync with git remote")
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+)
+
+// withGit keeps the store in a git repository that syncs with remote
+func withGit(remote string) func(*config.StorageConfig) {
+	return func(cfg *config.StorageConfig) {
+		cfg.GitStorage = true
+		cfg.GitRemote = remote
+		cfg.GitBranch = "main"
+		cfg.GitAuthorName = "Test"
+		cfg.GitAuthorEmail = "test@example.com"
+	}
+}
+
+func gitLog(t *testing.T, store *Store) string {
+	t.Helper()
+	if err := store.git.flush(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	out, err := store.git.run("log", "--format=%B")
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	return out
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	memory := &Memory{
+		ID:               "abc-v2",
+		Content:          "First line\n---\nstill content\n",
+		Summary:          "multi: part",
+		Tags:             []string{"a", "b"},
+		Metadata:         map[string]string{"repo": "api"},
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		AccessCount:      7,
+		Version:          2,
+		IsCurrentVersion: true,
+		Links:            []Link{{Type: "related", Target: "def", CreatedAt: now}},
+		Pinned:           true,
+	}
+	data, err := encodeMarkdown(memory)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "---\nid: \"abc-v2\"\nversion: 2\n") || strings.Contains(string(data), "access_count") {
+		t.Errorf("Unexpected front matter:\n%s", data)
+	}
+
+	decoded, err := decodeMarkdown(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded.Content != memory.Content || decoded.Summary != memory.Summary || decoded.Metadata["repo"] != "api" ||
+		len(decoded.Links) != 1 || !decoded.CreatedAt.Equal(now) || !decoded.Pinned || decoded.AccessCount != 0 {
+		t.Errorf("Round trip = %+v, want %+v without access statistics", decoded, memory)
+	}
+}
+
+func TestGitStorageCommitsAndMerges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "team.git")
+	if out, err := exec.Command("git", "init", "--bare", "-q", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create remote: %v: %s", err, out)
+	}
+
+	alice := newTestStore(t, withGit(remote))
+	stored, _ := alice.Store("Release notes live in docs/releases", "", "docs", nil, nil)
+	if log := gitLog(t, alice); !strings.Contains(log, "Store "+stored.ID+" (version 1)") {
+		t.Errorf("Commit log should describe the store, got:\n%s", log)
+	}
+	if _, err := alice.GitSync(); err != nil {
+		t.Fatalf("Alice's sync failed: %v", err)
+	}
+
+	// A second member pulls the shared memories into their index
+	bob := newTestStore(t, withGit(remote))
+	result, err := bob.GitSync()
+	if err != nil {
+		t.Fatalf("Bob's sync failed: %v", err)
+	}
+	if !containsString(result.Updated, stored.ID) {
+		t.Errorf("Pull updated %v, want %s", result.Updated, stored.ID)
+	}
+	if memory, err := bob.Get(stored.ID); err != nil || memory.Content != stored.Content {
+		t.Fatalf("Bob should have %s after pulling: %v", stored.ID, err)
+	}
+
+	// Both edit the memory; the second to sync keeps the other edit as a sibling
+	alice.Store(stored.Content, "from alice", "docs", nil, nil)
+	bob.Store(stored.Content, "from bob", "docs", nil, nil)
+	if _, err := alice.GitSync(); err != nil {
+		t.Fatalf("Alice's second sync failed: %v", err)
+	}
+	result, err = bob.GitSync()
+	if err != nil {
+		t.Fatalf("Bob's second sync failed: %v", err)
+	}
+	if len(result.Conflicts) != 1 || BaseID(result.Conflicts[0]) != BaseID(stored.ID) {
+		t.Fatalf("Conflicts = %v, want one sibling of %s", result.Conflicts, stored.ID)
+	}
+	if _, err := alice.GitSync(); err != nil {
+		t.Fatalf("Alice's third sync failed: %v", err)
+	}
+
+	for name, store := range map[string]*Store{"alice": alice, "bob": bob} {
+		history, err := store.GetHistory(BaseID(stored.ID))
+		if err != nil || len(history) != 3 {
+			t.Errorf("%s's history = %d versions, %v, want the original and both edits", name, len(history), err)
+		}
+		current, _ := store.Get(BaseID(stored.ID))
+		if current == nil || current.Summary != "from bob" {
+			t.Errorf("%s's current version = %+v, want bob's edit", name, current)
+		}
+		sibling, _ := store.Get(result.Conflicts[0])
+		if sibling == nil || sibling.Summary != "from alice" || sibling.IsCurrentVersion {
+			t.Errorf("%s's sibling = %+v, want alice's edit as a past version", name, sibling)
+		}
+	}
+}
+
+func TestGitPullWhileStoring(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "team.git")
+	if out, err := exec.Command("git", "init", "--bare", "-q", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create remote: %v: %s", err, out)
+	}
+	alice := newTestStore(t, withGit(remote))
+	bob := newTestStore(t, withGit(remote))
+
+	// Bob keeps storing while he pulls Alice's memories
+	var wg sync.WaitGroup
+	var storedMu sync.Mutex
+	var stored []*Memory
+	for writer := 0; writer < 4; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				memory, err := bob.Store(fmt.Sprintf("Bob's note number %d from writer %d", i, writer), "", "notes", nil, nil)
+				if err != nil {
+					t.Errorf("Bob's store failed: %v", err)
+					return
+				}
+				storedMu.Lock()
+				stored = append(stored, memory)
+				storedMu.Unlock()
+			}
+		}(writer)
+	}
+	var pulled []*Memory
+	for i := 0; i < 10; i++ {
+		memory, _ := alice.Store(fmt.Sprintf("Alice's note number %d", i), "", "notes", nil, nil)
+		pulled = append(pulled, memory)
+		if _, err := alice.GitSync(); err != nil {
+			t.Fatalf("Alice's sync failed: %v", err)
+		}
+		if _, err := bob.GitPull(); err != nil {
+			t.Errorf("Bob's pull failed: %v", err)
+		}
+	}
+	wg.Wait()
+
+	for _, memory := range append(stored, pulled...) {
+		if _, err := bob.Get(memory.ID); err != nil {
+			t.Errorf("Bob should have %s (%q): %v", memory.ID, memory.Content, err)
+		}
+	}
+}
+
+func TestGitCommandsTimeOut(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	defer func(timeout time.Duration) { gitTimeout = timeout }(gitTimeout)
+	gitTimeout = time.Nanosecond
+
+	repo := &gitRepo{dir: t.TempDir(), config: &config.StorageConfig{}}
+	if _, err := repo.run("init", "-q"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("git init with an expired timeout = %v, want a timeout", err)
+	}
+}
@@ -0,0 +1,115 @@
+// internal/memory/importance.go
+package memory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Decay model defaults, used when the config leaves them unset
+const (
+	DefaultImportance       = 0.5
+	DefaultDecayHalfLife    = 30 * 24 * time.Hour
+	DefaultRecencyWeight    = 0.4
+	DefaultFrequencyWeight  = 0.3
+	DefaultImportanceWeight = 0.3
+)
+
+const (
+	frequencyMidpoint = 5.0 // accesses at which frequency counts for half
+	retentionBoost    = 0.5 // the most the retention score adds to search relevance
+	lowestRetainedN   = 5   // memories listed as next to evict in stats
+)
+
+// RetainedMemory is a memory with its retention score
+type RetainedMemory struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// ValidateImportance checks an importance value; 0 means unset
+func ValidateImportance(importance float64) (float64, error) {
+	if importance < 0 || importance > 1 || math.IsNaN(importance) {
+		return 0, fmt.Errorf("importance must be between 0 and 1, got %g", importance)
+	}
+	return importance, nil
+}
+
+// EffectiveImportance returns the memory's importance, or the default when unset
+func (m *Memory) EffectiveImportance() float64 {
+	if m.Importance > 0 {
+		return m.Importance
+	}
+	return DefaultImportance
+}
+
+// RetentionScore combines how recently and how often a memory was accessed
+// with its importance into a score between 0 and 1. Recency halves every
+// decay half-life since the last access and frequency approaches 1 as
+// accesses accumulate. Search ranking adds it to relevance and cleanup
+// evicts the lowest scores first. Pinned memories always score 1.
+func (s *queryEngine) RetentionScore(memory *Memory, now time.Time) float64 {
+	if memory.Pinned {
+		return 1
+	}
+	halfLife := s.config.DecayHalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultDecayHalfLife
+	}
+	recencyWeight, frequencyWeight, importanceWeight := s.config.RecencyWeight, s.config.FrequencyWeight, s.config.ImportanceWeight
+	if recencyWeight+frequencyWeight+importanceWeight <= 0 {
+		recencyWeight, frequencyWeight, importanceWeight = DefaultRecencyWeight, DefaultFrequencyWeight, DefaultImportanceWeight
+	}
+
+	age := now.Sub(memory.LastAccess)
+	if age < 0 {
+		age = 0
+	}
+	recency := math.Pow(0.5, float64(age)/float64(halfLife))
+	frequency := float64(memory.AccessCount) / (float64(memory.AccessCount) + frequencyMidpoint)
+
+	score := recencyWeight*recency + frequencyWeight*frequency + importanceWeight*memory.EffectiveImportance()
+	return score / (recencyWeight + frequencyWeight + importanceWeight)
+}
+
+// retentionStats summarizes importance and retention over current memories.
+// The caller must hold s.mu.
+func (s *queryEngine) retentionStats(now time.Time) map[string]interface{} {
+	var scores []RetainedMemory
+	totalScore, totalImportance := 0.0, 0.0
+	for id, memory := range s.index {
+		if id != memory.ID || !memory.IsCurrentVersion {
+			continue
+		}
+		score := s.RetentionScore(memory, now)
+		scores = append(scores, RetainedMemory{ID: memory.ID, Score: score})
+		totalScore += score
+		totalImportance += memory.EffectiveImportance()
+	}
+
+	stats := map[string]interface{}{
+		"average_importance":      0.0,
+		"average_retention_score": 0.0,
+		"lowest_retention":        []RetainedMemory{},
+	}
+	if len(scores) == 0 {
+		return stats
+	}
+
+	count := float64(len(scores))
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].ID < scores[j].ID
+	})
+	if len(scores) > lowestRetainedN {
+		scores = scores[:lowestRetainedN]
+	}
+	stats["average_importance"] = totalImportance / count
+	stats["average_retention_score"] = totalScore / count
+	stats["lowest_retention"] = scores
+	return stats
+}
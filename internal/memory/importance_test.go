@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetentionScore(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	now := time.Now()
+	fresh := &Memory{LastAccess: now}
+	score := store.RetentionScore
+
+	if got := score(&Memory{LastAccess: now, Importance: 0.9}, now); got <= score(fresh, now) {
+		t.Errorf("Important memories should score higher: %v <= %v", got, score(fresh, now))
+	}
+	if got := score(&Memory{LastAccess: now.Add(-90 * 24 * time.Hour)}, now); got >= score(fresh, now) {
+		t.Errorf("Stale memories should score lower: %v >= %v", got, score(fresh, now))
+	}
+	if got := score(&Memory{LastAccess: now, AccessCount: 20}, now); got <= score(fresh, now) {
+		t.Errorf("Frequently accessed memories should score higher: %v <= %v", got, score(fresh, now))
+	}
+
+	// One half-life halves the recency component
+	halfLife := &Memory{LastAccess: now.Add(-DefaultDecayHalfLife)}
+	want := (DefaultRecencyWeight*0.5 + DefaultImportanceWeight*DefaultImportance) /
+		(DefaultRecencyWeight + DefaultFrequencyWeight + DefaultImportanceWeight)
+	if got := score(halfLife, now); got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("Score after one half-life = %v, want %v", got, want)
+	}
+}
+
+func TestImportanceRanksAndSurvivesEviction(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	if _, err := store.Remember(MemoryInput{Content: "Out of range", Importance: 1.5}); err == nil {
+		t.Error("Importance above 1 should be rejected")
+	}
+
+	trivial, err := store.Remember(MemoryInput{Content: "Lunch order preference: the deploy team likes tacos", Importance: 0.1})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	critical, err := store.Remember(MemoryInput{Content: "Deploy freeze: never deploy on Fridays", Importance: 1})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+
+	// Both match the query equally; importance breaks the tie
+	results, err := store.Search(&SearchQuery{Query: "deploy"})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Search = %d results, %v", len(results), err)
+	}
+	if results[0].ID != critical.Memory.ID {
+		t.Errorf("The important memory should rank first, got %s", results[0].ID)
+	}
+
+	// Storing the same content again keeps the importance
+	again, err := store.Remember(MemoryInput{Content: "Deploy freeze: never deploy on Fridays"})
+	if err != nil || again.Memory.Version != 2 || again.Memory.Importance != 1 {
+		t.Errorf("New version should keep importance 1, got %+v, %v", again.Memory, err)
+	}
+
+	stats := store.GetStats()
+	lowest, ok := stats["lowest_retention"].([]RetainedMemory)
+	if !ok || len(lowest) != 2 || lowest[0].ID != trivial.Memory.ID {
+		t.Errorf("lowest_retention = %+v, want %s first", stats["lowest_retention"], trivial.Memory.ID)
+	}
+	if avg, _ := stats["average_importance"].(float64); avg < 0.5 || avg > 0.6 {
+		t.Errorf("average_importance = %v, want 0.55", avg)
+	}
+
+	// Going over the storage limit evicts the least important memory first
+	store.config.MaxStorageSize = store.totalSize + 64
+	if _, err := store.Store("A third memory that pushes the store over its limit", "", "", nil, nil); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, err := store.Get(trivial.Memory.ID); err == nil {
+		t.Error("The least important memory should have been evicted")
+	}
+	if _, err := store.Get(critical.Memory.ID); err != nil {
+		t.Errorf("The important memory should survive eviction: %v", err)
+	}
+}
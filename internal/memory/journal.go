@@ -0,0 +1,306 @@
+// internal/memory/journal.go
+package memory
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mcp-memory-server/pkg/logger"
+)
+
+const (
+	journalFile       = "index/journal.log"
+	maxJournalEntries = 10000 // entries before the writer starts a new generation
+)
+
+// Journal operations
+const (
+	journalOpen   = "open"   // first line of a generation; readers seeing a new one reload everything
+	journalPut    = "put"    // memory file written
+	journalRemove = "remove" // memory file removed or moved to the trash
+)
+
+// journalEntry is one line of the change journal
+type journalEntry struct {
+	Seq        uint64    `json:"seq,omitempty"`
+	Op         string    `json:"op"`
+	ID         string    `json:"id,omitempty"`
+	Generation string    `json:"generation,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// journal records which memory files a Store writes and removes, so other
+// processes reading the data directory can update their index incrementally.
+// Each writer starts a new generation by replacing the file, which also lets
+// a writer notice when a second one has started.
+type journal struct {
+	path       string
+	logger     *logger.Logger
+	mu         sync.Mutex
+	file       *os.File
+	generation string
+	seq        uint64
+	replaced   bool // another writer has started a generation of its own
+}
+
+// openJournal starts a new journal generation in a data directory
+func openJournal(dataDir string, log *logger.Logger) (*journal, error) {
+	j := &journal{path: filepath.Join(dataDir, journalFile), logger: log}
+	if err := j.rotate(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// rotate atomically replaces the journal with a new generation holding only
+// its open line. The caller must hold j.mu or own j exclusively.
+func (j *journal) rotate() error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate journal generation: %w", err)
+	}
+	generation := hex.EncodeToString(id)
+	line, err := json.Marshal(journalEntry{Op: journalOpen, Generation: generation, PID: os.Getpid(), Time: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal header: %w", err)
+	}
+
+	tempFile := j.path + ".tmp"
+	if err := os.WriteFile(tempFile, append(line, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tempFile, j.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, j.generation, j.seq = file, generation, 0
+	return nil
+}
+
+// append records a change to a memory file. Failures are logged; readers
+// then miss the change until their next full reload.
+func (j *journal) append(op, id string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return
+	}
+
+	j.checkReplaced()
+	if j.seq >= maxJournalEntries && !j.replaced {
+		if err := j.rotate(); err != nil {
+			j.logger.WithError(err).Warn("Failed to start a new journal generation")
+		}
+	}
+
+	j.seq++
+	line, err := json.Marshal(journalEntry{Seq: j.seq, Op: op, ID: id, Time: time.Now().UTC()})
+	if err == nil {
+		_, err = j.file.Write(append(line, '\n'))
+	}
+	if err != nil {
+		j.logger.WithError(err).Warn("Failed to append to change journal", "op", op, "id", id)
+	}
+}
+
+// checkReplaced warns once when the journal file is no longer ours, which
+// means another writer opened the data directory. The caller must hold j.mu.
+func (j *journal) checkReplaced() {
+	if j.replaced {
+		return
+	}
+	current, err := os.Stat(j.path)
+	if err != nil {
+		return
+	}
+	mine, err := j.file.Stat()
+	if err != nil || os.SameFile(current, mine) {
+		return
+	}
+
+	j.replaced = true
+	pid := 0
+	if head, err := readJournalHead(j.path); err == nil {
+		pid = head.PID
+	}
+	j.logger.Error("Another process opened the data directory for writing; concurrent writers overwrite each other's changes",
+		"journal", j.path, "other_pid", pid)
+}
+
+// close stops journaling; the file stays for readers to catch up
+func (j *journal) close() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+// readJournalHead reads the open line of a journal
+func readJournalHead(path string) (*journalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	head, _, err := parseJournalHead(bufio.NewReader(file))
+	return head, err
+}
+
+// parseJournalHead reads the open line of a journal and its length in bytes
+func parseJournalHead(reader *bufio.Reader) (*journalEntry, int64, error) {
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read journal header: %w", err)
+	}
+	var head journalEntry
+	if err := json.Unmarshal(line, &head); err != nil || head.Op != journalOpen {
+		return nil, 0, fmt.Errorf("invalid journal header")
+	}
+	return &head, int64(len(line)), nil
+}
+
+// journalTail follows a data directory's change journal from where the
+// previous read stopped
+type journalTail struct {
+	path       string
+	generation string
+	offset     int64
+}
+
+// read returns the entries appended since the previous read. reset is true
+// when the journal is missing or a new generation has started since, in
+// which case the caller must reload everything instead.
+func (t *journalTail) read() (entries []journalEntry, reset bool, err error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.generation, t.offset = "", 0
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	head, headLen, err := parseJournalHead(reader)
+	if err != nil {
+		return nil, false, err
+	}
+	offset := t.offset
+	if head.Generation != t.generation {
+		reset = true
+		offset = headLen
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, false, fmt.Errorf("failed to seek journal: %w", err)
+	}
+	reader.Reset(file)
+
+	// A partially written last line is read again next time
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		offset += int64(len(line))
+		var entry journalEntry
+		if json.Unmarshal(line, &entry) == nil && !reset {
+			entries = append(entries, entry)
+		}
+	}
+
+	t.generation, t.offset = head.Generation, offset
+	if reset {
+		return nil, true, nil
+	}
+	return entries, false, nil
+}
+
+// followJournal keeps a read-only store's index up to date with the writer's
+// change journal until the store is closed
+func (s *Store) followJournal(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.applyJournal()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// applyJournal applies the writer's changes since the last call, reloading
+// every memory when a new writer has started. The caller must hold s.mu.
+func (s *Store) applyJournal() {
+	entries, reset, err := s.journalTail.read()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to read change journal, reloading all memories")
+		reset = true
+	}
+	if reset {
+		s.clear()
+		s.clearSizes()
+		if err := s.loadIndex(); err != nil {
+			s.logger.WithError(err).Warn("Failed to reload memories")
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Op == journalPut || entry.Op == journalRemove {
+			s.reloadMemory(entry.ID)
+		}
+	}
+}
+
+// reloadMemory rereads one memory file into the index, dropping the memory
+// when its file is gone. The caller must hold s.mu.
+func (s *Store) reloadMemory(id string) {
+	for _, name := range []string{id + ".json.gz", id + ".json", id + markdownExt} {
+		path := filepath.Join(s.dataDir, "memories", name)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		memory, err := s.readMemoryFile(path)
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to load memory file", "file", name)
+			return
+		}
+		s.setMemorySize(memory, info.Size())
+		s.add(memory)
+		return
+	}
+	s.dropMemorySize(id)
+	s.remove(id)
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/pkg/logger"
+)
+
+func newLockedTestStore(t *testing.T, dir string) (*Store, error) {
+	t.Helper()
+
+	cfg := testStorageConfig(func(cfg *config.StorageConfig) { cfg.WriterLock = true })
+	return NewStore(dir, cfg, logger.New("error", "text"))
+}
+
+func TestWriterLockOpensSecondProcessReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	first, err := newLockedTestStore(t, dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	kept, _ := first.Store("Backups run nightly at two", "", "ops", nil, nil)
+
+	second, err := newLockedTestStore(t, dir)
+	if err != nil {
+		t.Fatalf("Second process should open read-only, got %v", err)
+	}
+	if !errors.Is(second.ReadOnly(), ErrStoreLocked) || first.ReadOnly() != nil {
+		t.Fatalf("ReadOnly = %v and %v, want only the second store locked", first.ReadOnly(), second.ReadOnly())
+	}
+	if !strings.Contains(second.ReadOnly().Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Errorf("Lock error should name the holder, got %q", second.ReadOnly())
+	}
+	if _, err := second.Store("Restores are tested monthly", "", "ops", nil, nil); !errors.Is(err, ErrStoreLocked) {
+		t.Errorf("Storing through the read-only store = %v, want ErrStoreLocked", err)
+	}
+	if err := second.Delete(kept.ID); !errors.Is(err, ErrStoreLocked) {
+		t.Errorf("Deleting through the read-only store = %v, want ErrStoreLocked", err)
+	}
+	if _, err := second.Get(kept.ID); err != nil {
+		t.Errorf("Reads should work read-only: %v", err)
+	}
+
+	// The read-only store follows the writer's journal
+	added, _ := first.Store("Restores are tested monthly", "", "ops", nil, nil)
+	if err := first.Delete(kept.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	second.mu.Lock()
+	second.applyJournal()
+	second.mu.Unlock()
+	if _, err := second.Get(added.ID); err != nil {
+		t.Errorf("Read-only store should pick up the stored memory: %v", err)
+	}
+	if _, err := second.Get(kept.ID); err == nil {
+		t.Error("Read-only store should drop the deleted memory")
+	}
+
+	// Only the writer's close releases the lock for the next writer
+	second.Close()
+	if info, err := ReadLockInfo(dir); err != nil || info == nil {
+		t.Errorf("Closing the read-only store should keep the writer's lock, got %+v, %v", info, err)
+	}
+	first.Close()
+	if info, err := ReadLockInfo(dir); err != nil || info != nil {
+		t.Errorf("Lock info after close = %+v, %v, want none", info, err)
+	}
+	third, err := newLockedTestStore(t, dir)
+	if err != nil {
+		t.Fatalf("Reopening after close failed: %v", err)
+	}
+	defer third.Close()
+	if third.ReadOnly() != nil {
+		t.Errorf("Reopening after close should be writable, got %v", third.ReadOnly())
+	}
+}
+
+func TestReadOnlyStoreTailsJournal(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withTrash)
+	kept, _ := store.Store("Backups run nightly at two", "", "ops", nil, nil)
+
+	reader, err := NewReadOnlyStore(dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create read-only store: %v", err)
+	}
+	if _, exists := reader.index[kept.ID]; !exists {
+		t.Fatalf("Initial load should include %s", kept.ID)
+	}
+
+	// Changes since the last refresh are applied from the journal alone, so
+	// a file written behind the writer's back is not picked up
+	added, _ := store.Store("Restores are tested monthly", "", "ops", nil, nil)
+	if err := store.Delete(kept.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "memories", "unjournaled.json"), []byte(`{"id":"unjournaled"}`), 0644)
+	if err := reader.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if _, exists := reader.index[added.ID]; !exists {
+		t.Error("Refresh should add the stored memory")
+	}
+	if _, exists := reader.index[kept.ID]; exists {
+		t.Error("Refresh should drop the deleted memory")
+	}
+	if _, exists := reader.index["unjournaled"]; exists {
+		t.Error("Refresh should not rescan the memories directory")
+	}
+
+	if _, err := store.Restore(kept.ID); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	reader.Refresh()
+	if _, exists := reader.index[kept.ID]; !exists {
+		t.Error("Refresh should add the restored memory")
+	}
+
+	// A new writer starts a new generation, which triggers a full reload
+	store.Close()
+	store = openTestStore(t, dir, withTrash)
+	defer store.Close()
+	reader.Refresh()
+	if _, exists := reader.index["unjournaled"]; !exists {
+		t.Error("A new journal generation should reload everything")
+	}
+}
+
+func TestJournalDetectsSecondWriter(t *testing.T) {
+	dir := t.TempDir()
+	// Without the lock both writers open, but the first notices the second
+	first := openTestStore(t, dir, nil)
+	defer first.Close()
+	first.Store("Tickets are triaged daily", "", "", nil, nil)
+	if first.journal.replaced {
+		t.Fatal("A lone writer should not see a second one")
+	}
+
+	second := openTestStore(t, dir, nil)
+	defer second.Close()
+	first.Store("Tickets older than a week are escalated", "", "", nil, nil)
+	if !first.journal.replaced {
+		t.Error("The first writer should notice the second writer's journal")
+	}
+}
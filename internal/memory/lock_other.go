@@ -0,0 +1,16 @@
+// internal/memory/lock_other.go
+//go:build !unix
+
+package memory
+
+import "os"
+
+// lockFile is a no-op where flock is unavailable; the lock file still
+// records the writer, but a second writer is not detected
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
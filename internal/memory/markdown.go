@@ -0,0 +1,117 @@
+// internal/memory/markdown.go
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// markdownExt is the extension of memory files in git storage mode
+const markdownExt = ".md"
+
+// frontMatterDelimiter opens and closes the front matter of a Markdown memory
+const frontMatterDelimiter = "---"
+
+// frontMatterOrder is the order fields appear in front matter; unknown
+// fields follow alphabetically
+var frontMatterOrder = []string{
+	"id", "version", "is_current_version", "previous_version_id", "sibling_of",
+	"summary", "category", "tags", "metadata", "importance", "pinned", "protected",
+	"links", "keywords", "created_at", "updated_at",
+}
+
+// markdownLocalFields are left out of Markdown files: access statistics
+// change on every read and would turn reads into commits and merge conflicts
+var markdownLocalFields = []string{"content", "access_count", "last_access"}
+
+// encodeMarkdown renders a memory as Markdown: YAML front matter holding
+// every field but the content, whose values are JSON (a subset of YAML),
+// followed by the content as the body
+func encodeMarkdown(memory *Memory) ([]byte, error) {
+	data, err := json.Marshal(memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal memory: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to marshal memory: %w", err)
+	}
+	for _, name := range markdownLocalFields {
+		delete(fields, name)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	writeField := func(name string) {
+		if value, exists := fields[name]; exists {
+			fmt.Fprintf(&buf, "%s: %s\n", name, value)
+			delete(fields, name)
+		}
+	}
+	for _, name := range frontMatterOrder {
+		writeField(name)
+	}
+	rest := make([]string, 0, len(fields))
+	for name := range fields {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		writeField(name)
+	}
+	buf.WriteString(frontMatterDelimiter + "\n\n")
+	buf.WriteString(memory.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// decodeMarkdown parses a memory rendered by encodeMarkdown
+func decodeMarkdown(data []byte) (*Memory, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, frontMatterDelimiter+"\n") {
+		return nil, fmt.Errorf("missing front matter")
+	}
+	text = text[len(frontMatterDelimiter)+1:]
+	end := strings.Index(text, "\n"+frontMatterDelimiter+"\n")
+	if end == -1 {
+		return nil, fmt.Errorf("unterminated front matter")
+	}
+	header, body := text[:end+1], text[end+len(frontMatterDelimiter)+2:]
+
+	fields := make(map[string]json.RawMessage)
+	for i, line := range strings.Split(strings.TrimSuffix(header, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("front matter line %d: expected key: value", i+1)
+		}
+		value = strings.TrimSpace(value)
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("front matter line %d: invalid value for %s", i+1, name)
+		}
+		fields[strings.TrimSpace(name)] = json.RawMessage(value)
+	}
+
+	content := strings.TrimPrefix(body, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	encodedContent, _ := json.Marshal(content)
+	fields["content"] = encodedContent
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var memory Memory
+	if err := json.Unmarshal(data, &memory); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal memory: %w", err)
+	}
+	if memory.ID == "" {
+		return nil, fmt.Errorf("front matter has no id")
+	}
+	return &memory, nil
+}
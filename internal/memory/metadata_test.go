@@ -0,0 +1,122 @@
+package memory
+
+import "testing"
+
+func newMetadataTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store := newTestStore(t, nil)
+	fixtures := []struct {
+		content  string
+		metadata map[string]string
+	}{
+		{"API gateway rate limits", map[string]string{"repo": "api", "priority": "2", "due": "2025-03-01"}},
+		{"API retry policy", map[string]string{"repo": "api", "priority": "10", "due": "2025-06-15"}},
+		{"Terraform state layout", map[string]string{"repo": "infra", "priority": "1"}},
+		{"Team offsite notes", nil},
+	}
+	for _, f := range fixtures {
+		if _, err := store.Store(f.content, "", "notes", nil, f.metadata); err != nil {
+			t.Fatalf("Failed to store memory: %v", err)
+		}
+	}
+	return store
+}
+
+func TestMetadataFilters(t *testing.T) {
+	store := newMetadataTestStore(t)
+
+	tests := []struct {
+		filters map[string]string
+		want    int
+	}{
+		{map[string]string{"repo": "api"}, 2},
+		{map[string]string{"repo": "API"}, 2},
+		{map[string]string{"repo": "in*"}, 1},
+		{map[string]string{"repo": "*"}, 3},
+		{map[string]string{"priority": ">2"}, 1}, // numeric, so "10" > "2"
+		{map[string]string{"priority": ">=2"}, 2},
+		{map[string]string{"priority": "1..2"}, 2},
+		{map[string]string{"due": "<2025-04-01"}, 1},
+		{map[string]string{"repo": "api", "priority": "<5"}, 1},
+		{map[string]string{"owner": "*"}, 0},
+	}
+
+	for _, tt := range tests {
+		page, err := store.ListPage(&ListOptions{Metadata: tt.filters})
+		if err != nil {
+			t.Errorf("ListPage(%v) failed: %v", tt.filters, err)
+			continue
+		}
+		if page.Total != tt.want {
+			t.Errorf("ListPage(%v) matched %d memories, want %d", tt.filters, page.Total, tt.want)
+		}
+
+		results, err := store.Search(&SearchQuery{Metadata: tt.filters, Limit: 10})
+		if err != nil {
+			t.Errorf("Search(%v) failed: %v", tt.filters, err)
+			continue
+		}
+		if len(results) != tt.want {
+			t.Errorf("Search(%v) matched %d memories, want %d", tt.filters, len(results), tt.want)
+		}
+	}
+
+	if _, err := store.ListPage(&ListOptions{Metadata: map[string]string{"priority": "5..1"}}); err == nil {
+		t.Error("Expected an empty range to be rejected")
+	}
+}
+
+func TestMetadataQueryLanguage(t *testing.T) {
+	store := newMetadataTestStore(t)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"metadata.repo:api", 2},
+		{"metadata.priority:>=2 AND metadata.repo:api", 2},
+		{"metadata.priority:<2", 1},
+		{"metadata.due:2025-01-01..2025-12-31", 2},
+		{"NOT metadata.repo:*", 1},
+		{"api metadata.priority:10", 1},
+	}
+
+	for _, tt := range tests {
+		results, err := store.Search(&SearchQuery{Query: tt.query, Limit: 10})
+		if err != nil {
+			t.Errorf("Search(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if len(results) != tt.want {
+			t.Errorf("Search(%q) matched %d memories, want %d", tt.query, len(results), tt.want)
+		}
+	}
+}
+
+func TestMetadataIndex(t *testing.T) {
+	store := newMetadataTestStore(t)
+
+	store.mu.RLock()
+	apiIDs := len(store.metadataIndex["repo"]["api"])
+	store.mu.RUnlock()
+	if apiIDs != 2 {
+		t.Fatalf("Expected 2 memories indexed under repo=api, got %d", apiIDs)
+	}
+
+	deleted := confirmBulkDelete(t, store, &BulkDeleteOptions{Metadata: map[string]string{"repo": "api", "priority": ">5"}})
+	if deleted.Deleted != 1 {
+		t.Errorf("Expected 1 memory deleted, got %d", deleted.Deleted)
+	}
+
+	store.mu.RLock()
+	apiIDs = len(store.metadataIndex["repo"]["api"])
+	_, hasDue := store.metadataIndex["due"]
+	store.mu.RUnlock()
+	if apiIDs != 1 {
+		t.Errorf("Expected the deleted memory to leave the metadata index, %d remain", apiIDs)
+	}
+	if !hasDue {
+		t.Error("Expected the remaining memory to keep its due key indexed")
+	}
+}
@@ -0,0 +1,69 @@
+// internal/memory/metrics.go
+package memory
+
+import (
+	"sync/atomic"
+
+	"mcp-memory-server/internal/metrics"
+)
+
+// Store metrics, served on /metrics
+var (
+	operationDuration = metrics.Default.NewHistogram("mcp_memory_operation_duration_seconds",
+		"Latency of store operations.", metrics.DefaultBuckets, "operation")
+	saveDuration = metrics.Default.NewHistogram("mcp_memory_save_duration_seconds",
+		"Latency of encoding and writing a memory file.", metrics.DefaultBuckets)
+	compressionDuration = metrics.Default.NewHistogram("mcp_memory_compression_duration_seconds",
+		"Latency of gzip compression and decompression of memory files.", metrics.DefaultBuckets, "direction")
+	encryptionDuration = metrics.Default.NewHistogram("mcp_memory_encryption_duration_seconds",
+		"Latency of encrypting and decrypting memory files.", metrics.DefaultBuckets, "direction")
+	saveQueueDepth = metrics.Default.NewGauge("mcp_memory_save_queue_depth",
+		"Memories waiting in the async save queue.")
+	saveQueueCapacity = metrics.Default.NewGauge("mcp_memory_save_queue_capacity",
+		"Size of the async save queue.")
+	saveQueueDrops = metrics.Default.NewCounter("mcp_memory_save_queue_drops_total",
+		"Memories saved synchronously because the async save queue was full or closed.")
+	asyncSaveFailures = metrics.Default.NewCounter("mcp_memory_async_save_failures_total",
+		"Memory files that failed to save in the background.")
+	evictions = metrics.Default.NewCounter("mcp_memory_evictions_total",
+		"Memories evicted, by the limit that was exceeded.", "limit")
+	indexEntries = metrics.Default.NewGauge("mcp_memory_index_entries",
+		"Entries in each in-memory index.", "index")
+	storageBytes = metrics.Default.NewGauge("mcp_memory_storage_bytes",
+		"Bytes of memory files, and the storage limit.", "kind")
+	loadDuration = metrics.Default.NewGauge("mcp_memory_load_duration_seconds",
+		"Time taken to load the index from disk when the store opened.")
+)
+
+// Eviction limits
+const (
+	limitStorage   = "storage"
+	limitCategory  = QuotaScopeCategory
+	limitNamespace = QuotaScopeNamespace
+)
+
+// collectorSeq numbers store collectors so each open store registers its own
+var collectorSeq atomic.Uint64
+
+// collectMetrics sets the gauges that are read from the store's state
+func (s *Store) collectMetrics() {
+	saveQueueDepth.Set(float64(len(s.saveQueue)))
+	saveQueueCapacity.Set(float64(cap(s.saveQueue)))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	memories := 0
+	for id, memory := range s.index {
+		if id == memory.ID {
+			memories++
+		}
+	}
+	indexEntries.Set(float64(memories), "memories")
+	indexEntries.Set(float64(len(s.versionIndex)), "base_ids")
+	indexEntries.Set(float64(len(s.keywordIndex)), "keywords")
+	indexEntries.Set(float64(len(s.tagIndex)), "tags")
+	indexEntries.Set(float64(len(s.categoryIndex)), "categories")
+	indexEntries.Set(float64(len(s.trash)), "trash")
+	storageBytes.Set(float64(s.totalSize), "used")
+	storageBytes.Set(float64(s.config.MaxStorageSize), "limit")
+}
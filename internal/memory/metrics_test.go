@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/metrics"
+)
+
+// indexedMemories returns the memories gauge as the next scrape reports it
+func indexedMemories(t *testing.T) string {
+	t.Helper()
+	var b strings.Builder
+	metrics.Default.WriteTo(&b)
+	prefix := `mcp_memory_index_entries{index="memories"} `
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return ""
+}
+
+func TestCloseStopsCollectingMetrics(t *testing.T) {
+	store := openTestStore(t, t.TempDir(), nil)
+	store.Store("Standups start at half past nine", "", "team", nil, nil)
+	if got := indexedMemories(t); got != "1" {
+		t.Fatalf("Indexed memories = %q, want 1", got)
+	}
+
+	// A closed store no longer sets the gauges
+	store.Close()
+	indexEntries.Set(-1, "memories")
+	if got := indexedMemories(t); got != "-1" {
+		t.Errorf("Indexed memories after close = %q, want the store's collector gone", got)
+	}
+}
+
+func TestClosingOneStoreKeepsTheOthersMetrics(t *testing.T) {
+	first := openTestStore(t, t.TempDir(), nil)
+	second := openTestStore(t, t.TempDir(), nil)
+	defer second.Close()
+	second.Store("Deploys freeze on Fridays", "", "team", nil, nil)
+	second.Store("The wiki moved to the new host", "", "team", nil, nil)
+
+	// The open store still reports after the other one closes
+	first.Close()
+	indexEntries.Set(-1, "memories")
+	if got := indexedMemories(t); got != "2" {
+		t.Errorf("Indexed memories = %q, want 2 from the store still open", got)
+	}
+}
@@ -0,0 +1,297 @@
+// internal/memory/pagination.go
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortField selects the ordering of List and Search results
+type SortField string
+
+const (
+	SortByCreated     SortField = "created"
+	SortByUpdated     SortField = "updated"
+	SortByAccessCount SortField = "access_count"
+	SortByRelevance   SortField = "relevance" // Search only
+)
+
+// Sort orders
+const (
+	SortDesc = "desc"
+	SortAsc  = "asc"
+)
+
+// ErrInvalidCursor is wrapped by errors about a cursor that cannot be used
+// with the query it was passed to
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// optionsError marks an error about sort options or filters as the
+// caller's fault for IsQueryError while keeping its message
+type optionsError struct {
+	err error
+}
+
+func (e *optionsError) Error() string { return e.err.Error() }
+func (e *optionsError) Unwrap() error { return e.err }
+
+// IsQueryError reports whether an error from SearchPage or ListPage was
+// caused by the query, its options or its cursor rather than by the store
+func IsQueryError(err error) bool {
+	var syntaxErr *SyntaxError
+	var optsErr *optionsError
+	return errors.As(err, &syntaxErr) || errors.As(err, &optsErr) || errors.Is(err, ErrInvalidCursor)
+}
+
+// checkOffset rejects an offset before the first result
+func checkOffset(offset int) error {
+	if offset < 0 {
+		return &optionsError{fmt.Errorf("offset must not be negative, got %d", offset)}
+	}
+	return nil
+}
+
+const (
+	// DefaultPageSize is used by Search when no limit is given
+	DefaultPageSize = 20
+	// MaxPageSize bounds a single page; larger limits are clamped and reported in Page.Limit
+	MaxPageSize = 500
+)
+
+// ListOptions filters, orders and paginates List results
+type ListOptions struct {
+	Category string            `json:"category,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"` // see MetadataFilter
+	Limit    int               `json:"limit,omitempty"`    // 0 returns every remaining result
+	Offset   int               `json:"offset,omitempty"`   // ignored when Cursor is set
+	Cursor   string            `json:"cursor,omitempty"`   // NextCursor from a previous page
+	Sort     SortField         `json:"sort,omitempty"`     // default created
+	Order    string            `json:"order,omitempty"`    // default desc
+}
+
+// Page is one page of ordered results
+type Page struct {
+	Memories   []*Memory `json:"memories"`
+	Total      int       `json:"total"`  // matches before pagination
+	Offset     int       `json:"offset"` // position of the first result in the full ordering
+	Limit      int       `json:"limit"`  // effective page size, 0 when unlimited
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Sort       SortField `json:"sort"`
+	Order      string    `json:"order"`
+
+	keys        []sortKey
+	fingerprint string
+}
+
+// CursorAfter returns a cursor that resumes immediately after the i-th memory
+// of this page. Callers that show only part of a page use it to continue from
+// the last result they actually returned.
+func (p *Page) CursorAfter(i int) string {
+	if i < 0 || i >= len(p.keys) {
+		return ""
+	}
+	if i == len(p.keys)-1 && p.NextCursor == "" {
+		return "" // nothing follows
+	}
+	return encodePageCursor(pageCursor{
+		Sort:        p.Sort,
+		Order:       p.Order,
+		Key:         p.keys[i],
+		Fingerprint: p.fingerprint,
+	})
+}
+
+// sortKey is the position of a memory in an ordering
+type sortKey struct {
+	Int   int64   `json:"i,omitempty"`
+	Float float64 `json:"f,omitempty"`
+	ID    string  `json:"id"`
+}
+
+// pageCursor is the decoded form of an opaque page cursor
+type pageCursor struct {
+	Sort        SortField `json:"s"`
+	Order       string    `json:"o"`
+	Key         sortKey   `json:"k"`
+	Fingerprint string    `json:"q"`
+}
+
+func encodePageCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ParseSortField validates a sort field name, returning def when empty
+func ParseSortField(value string, def SortField) (SortField, error) {
+	switch SortField(value) {
+	case "":
+		return def, nil
+	case SortByCreated, SortByUpdated, SortByAccessCount, SortByRelevance:
+		return SortField(value), nil
+	}
+	return "", fmt.Errorf("unknown sort field %q (use created, updated, access_count or relevance)", value)
+}
+
+// ParseSortOrder validates a sort order, defaulting to descending
+func ParseSortOrder(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", SortDesc:
+		return SortDesc, nil
+	case SortAsc:
+		return SortAsc, nil
+	}
+	return "", fmt.Errorf("unknown sort order %q (use asc or desc)", value)
+}
+
+// scoredMemory pairs a memory with its relevance score for ordering
+type scoredMemory struct {
+	memory *Memory
+	score  float64
+}
+
+// filterFingerprint identifies a result set so a cursor cannot be replayed against different filters
+func filterFingerprint(query, category string, tags []string, metadata map[string]string) string {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = strings.ToLower(tag)
+	}
+	sort.Strings(normalized)
+
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+"\x01"+value)
+	}
+	sort.Strings(pairs)
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		strings.ToLower(query),
+		strings.ToLower(category),
+		strings.Join(normalized, ","),
+		strings.Join(pairs, "\x02"),
+	}, "\x00")))
+	return hex.EncodeToString(hash[:])[:12]
+}
+
+// keyFor computes the sort key of a memory for the given field
+func keyFor(item scoredMemory, field SortField) sortKey {
+	key := sortKey{ID: item.memory.ID}
+	switch field {
+	case SortByUpdated:
+		key.Int = item.memory.UpdatedAt.UnixNano()
+	case SortByAccessCount:
+		key.Int = int64(item.memory.AccessCount)
+	case SortByRelevance:
+		key.Float = item.score
+	default:
+		key.Int = item.memory.CreatedAt.UnixNano()
+	}
+	return key
+}
+
+// keyBefore reports whether a sorts before b. IDs break ties in ascending
+// order regardless of direction so the ordering is total and stable.
+func keyBefore(a, b sortKey, order string) bool {
+	if a.Int != b.Int {
+		if order == SortAsc {
+			return a.Int < b.Int
+		}
+		return a.Int > b.Int
+	}
+	if a.Float != b.Float {
+		if order == SortAsc {
+			return a.Float < b.Float
+		}
+		return a.Float > b.Float
+	}
+	return a.ID < b.ID
+}
+
+// paginate orders items and cuts out the page described by the options.
+// Items must already be filtered and contain no duplicate IDs.
+func paginate(items []scoredMemory, field SortField, order string, limit, offset int, cursor, fingerprint string) (*Page, error) {
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	keys := make([]sortKey, len(items))
+	for i, item := range items {
+		keys[i] = keyFor(item, field)
+	}
+	indices := make([]int, len(items))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return keyBefore(keys[indices[i]], keys[indices[j]], order)
+	})
+
+	start := offset
+	if cursor != "" {
+		c, err := decodePageCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Fingerprint != fingerprint {
+			return nil, fmt.Errorf("%w: it belongs to a different query", ErrInvalidCursor)
+		}
+		if c.Sort != field || c.Order != order {
+			return nil, fmt.Errorf("%w: it was created with sort %s %s, not %s %s", ErrInvalidCursor, c.Sort, c.Order, field, order)
+		}
+		// Resume after the cursor's position even if that memory has since been deleted
+		start = sort.Search(len(indices), func(i int) bool {
+			return keyBefore(c.Key, keys[indices[i]], order)
+		})
+	}
+	if start > len(indices) {
+		start = len(indices)
+	}
+
+	end := len(indices)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	page := &Page{
+		Memories:    make([]*Memory, 0, end-start),
+		Total:       len(items),
+		Offset:      start,
+		Limit:       limit,
+		Sort:        field,
+		Order:       order,
+		keys:        make([]sortKey, 0, end-start),
+		fingerprint: fingerprint,
+	}
+	for _, idx := range indices[start:end] {
+		page.Memories = append(page.Memories, items[idx].memory)
+		page.keys = append(page.keys, keys[idx])
+	}
+	if end < len(indices) && len(page.keys) > 0 {
+		page.NextCursor = encodePageCursor(pageCursor{
+			Sort:        field,
+			Order:       order,
+			Key:         page.keys[len(page.keys)-1],
+			Fingerprint: fingerprint,
+		})
+	}
+
+	return page, nil
+}
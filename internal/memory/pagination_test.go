@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newPaginationTestStore(t *testing.T, count int) *Store {
+	t.Helper()
+
+	store := newTestStore(t, nil)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		category := "even"
+		if i%2 == 1 {
+			category = "odd"
+		}
+		memory, err := store.Store(fmt.Sprintf("pagination memory number %d", i), "", category, []string{"paging"}, nil)
+		if err != nil {
+			t.Fatalf("Failed to store memory %d: %v", i, err)
+		}
+		memory.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		memory.UpdatedAt = base.Add(time.Duration(count-i) * time.Hour)
+		memory.AccessCount = i % 3 // plenty of ties for the ID tiebreak
+	}
+
+	return store
+}
+
+func TestListPageCursorCoversAllSorts(t *testing.T) {
+	const count = 23
+	store := newPaginationTestStore(t, count)
+
+	for _, field := range []SortField{SortByCreated, SortByUpdated, SortByAccessCount} {
+		for _, order := range []string{SortDesc, SortAsc} {
+			seen := make(map[string]bool)
+			var previous *Memory
+			cursor := ""
+			pages := 0
+
+			for {
+				page, err := store.ListPage(&ListOptions{Limit: 5, Cursor: cursor, Sort: field, Order: order})
+				if err != nil {
+					t.Fatalf("%s %s: ListPage failed: %v", field, order, err)
+				}
+				pages++
+				if page.Total != count {
+					t.Errorf("%s %s: expected total %d, got %d", field, order, count, page.Total)
+				}
+
+				for _, m := range page.Memories {
+					if seen[m.ID] {
+						t.Fatalf("%s %s: memory %s returned twice", field, order, m.ID)
+					}
+					seen[m.ID] = true
+					if previous != nil {
+						a := keyFor(scoredMemory{memory: previous}, field)
+						b := keyFor(scoredMemory{memory: m}, field)
+						if keyBefore(b, a, order) {
+							t.Errorf("%s %s: %s sorted before %s", field, order, previous.ID, m.ID)
+						}
+					}
+					previous = m
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			if len(seen) != count {
+				t.Errorf("%s %s: expected %d memories across pages, got %d", field, order, count, len(seen))
+			}
+			if pages != 5 {
+				t.Errorf("%s %s: expected 5 pages, got %d", field, order, pages)
+			}
+		}
+	}
+}
+
+func TestListPageDefaultsToNewestFirst(t *testing.T) {
+	store := newPaginationTestStore(t, 5)
+
+	memories, err := store.List("", nil, 0)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(memories) != 5 {
+		t.Fatalf("Expected 5 memories, got %d", len(memories))
+	}
+	for i := 1; i < len(memories); i++ {
+		if memories[i].CreatedAt.After(memories[i-1].CreatedAt) {
+			t.Errorf("Expected newest first, but %s follows %s", memories[i].ID, memories[i-1].ID)
+		}
+	}
+}
+
+func TestListPageOffset(t *testing.T) {
+	store := newPaginationTestStore(t, 10)
+
+	all, err := store.ListPage(&ListOptions{Sort: SortByCreated, Order: SortAsc})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+
+	page, err := store.ListPage(&ListOptions{Limit: 3, Offset: 4, Sort: SortByCreated, Order: SortAsc})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if page.Offset != 4 || len(page.Memories) != 3 {
+		t.Fatalf("Expected 3 memories from offset 4, got %d from %d", len(page.Memories), page.Offset)
+	}
+	for i, m := range page.Memories {
+		if m.ID != all.Memories[4+i].ID {
+			t.Errorf("Position %d: expected %s, got %s", 4+i, all.Memories[4+i].ID, m.ID)
+		}
+	}
+
+	past, err := store.ListPage(&ListOptions{Limit: 3, Offset: 50})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if len(past.Memories) != 0 || past.NextCursor != "" {
+		t.Errorf("Expected an empty final page past the end, got %d memories", len(past.Memories))
+	}
+}
+
+func TestNegativeOffsetIsRejected(t *testing.T) {
+	store := newPaginationTestStore(t, 3)
+
+	if _, err := store.ListPage(&ListOptions{Offset: -1}); !IsQueryError(err) {
+		t.Errorf("ListPage with a negative offset = %v, want a query error", err)
+	}
+	if _, err := store.SearchPage(&SearchQuery{Query: "pagination", Offset: -1}); !IsQueryError(err) {
+		t.Errorf("SearchPage with a negative offset = %v, want a query error", err)
+	}
+}
+
+func TestListPageFilteredCursor(t *testing.T) {
+	store := newPaginationTestStore(t, 10)
+
+	page, err := store.ListPage(&ListOptions{Category: "odd", Limit: 2})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if page.Total != 5 {
+		t.Errorf("Expected 5 odd memories, got %d", page.Total)
+	}
+
+	// The cursor only resumes the query that produced it
+	if _, err := store.ListPage(&ListOptions{Category: "even", Limit: 2, Cursor: page.NextCursor}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Reusing a cursor with different filters = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := store.ListPage(&ListOptions{Category: "odd", Limit: 2, Cursor: page.NextCursor, Order: SortAsc}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Reusing a cursor with a different order = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := store.ListPage(&ListOptions{Category: "odd", Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Malformed cursor = %v, want ErrInvalidCursor", err)
+	}
+
+	next, err := store.ListPage(&ListOptions{Category: "odd", Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListPage with cursor failed: %v", err)
+	}
+	if next.Offset != 2 || len(next.Memories) != 2 {
+		t.Errorf("Expected the second page at offset 2, got %d memories at %d", len(next.Memories), next.Offset)
+	}
+}
+
+func TestListPageRejectsRelevanceSort(t *testing.T) {
+	store := newPaginationTestStore(t, 1)
+
+	if _, err := store.ListPage(&ListOptions{Sort: SortByRelevance}); !IsQueryError(err) {
+		t.Error("Expected relevance sort to be rejected without a query")
+	}
+	if _, err := store.ListPage(&ListOptions{Sort: "size"}); !IsQueryError(err) {
+		t.Error("Expected an unknown sort field to be rejected")
+	}
+	if _, err := store.ListPage(&ListOptions{Order: "sideways"}); !IsQueryError(err) {
+		t.Error("Expected an unknown sort order to be rejected")
+	}
+}
+
+func TestSearchPageCursor(t *testing.T) {
+	store := newPaginationTestStore(t, 12)
+
+	query := &SearchQuery{Query: "pagination", Limit: 5}
+	seen := make(map[string]bool)
+	for {
+		page, err := store.SearchPage(query)
+		if err != nil {
+			t.Fatalf("SearchPage failed: %v", err)
+		}
+		if page.Sort != SortByRelevance {
+			t.Errorf("Expected relevance sort by default, got %s", page.Sort)
+		}
+		for _, m := range page.Memories {
+			if seen[m.ID] {
+				t.Fatalf("Memory %s returned twice", m.ID)
+			}
+			seen[m.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(seen) != 12 {
+		t.Errorf("Expected 12 search results across pages, got %d", len(seen))
+	}
+}
+
+func TestPaginateClampsLimit(t *testing.T) {
+	items := make([]scoredMemory, MaxPageSize+10)
+	for i := range items {
+		items[i] = scoredMemory{memory: &Memory{ID: fmt.Sprintf("m%04d", i)}}
+	}
+
+	page, err := paginate(items, SortByCreated, SortDesc, MaxPageSize*2, 0, "", "")
+	if err != nil {
+		t.Fatalf("paginate failed: %v", err)
+	}
+	if page.Limit != MaxPageSize || len(page.Memories) != MaxPageSize {
+		t.Errorf("Expected the page to be clamped to %d, got limit %d with %d memories", MaxPageSize, page.Limit, len(page.Memories))
+	}
+	if page.NextCursor == "" {
+		t.Error("Expected a cursor for the remaining memories")
+	}
+}
@@ -0,0 +1,1366 @@
+// internal/memory/query.go
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"mcp-memory-server/pkg/keywords"
+)
+
+// Query language
+//
+// A query is a sequence of clauses joined by AND (the default), OR and NOT:
+//
+//	tag:go AND NOT tag:deprecated category:decision created:>2025-01-01 "exact phrase" metadata.repo:foo
+//
+// Clauses are
+//   - plain words: consecutive words form one free-text clause that matches when
+//     any of its words appears in the content, summary, keywords or tags, and
+//     results are ranked by relevance as before
+//   - "quoted phrases": the exact phrase in the content or summary
+//   - word*: any word starting with the prefix
+//   - word~ or word~N: the word with up to N typos (default scales with its
+//     length), even when the word itself is indexed
+//   - field:value: tag, category, id, content, summary, keyword and
+//     metadata.<key>. A trailing * makes the value a prefix and metadata.<key>:*
+//     matches any memory that has the key. Metadata also accepts ranges,
+//     compared numerically when both sides are numbers.
+//   - field:range: created, updated and accessed take dates, access_count takes
+//     numbers. Ranges are written >v, >=v, <v, <=v, =v, v or a..b where either
+//     end of a..b may be *.
+//
+// Parentheses group clauses and a leading - is shorthand for NOT. Operators are
+// only recognised in upper case so "and" and "or" remain searchable words.
+
+// Expr is a node of a parsed query
+type Expr interface {
+	// Match reports whether a memory satisfies the expression
+	Match(m *Memory) bool
+	// String renders the expression in a canonical, fully parenthesised form
+	String() string
+}
+
+// AndExpr matches memories that satisfy every child
+type AndExpr struct {
+	Children []Expr
+}
+
+// OrExpr matches memories that satisfy at least one child
+type OrExpr struct {
+	Children []Expr
+}
+
+// NotExpr matches memories that do not satisfy its child
+type NotExpr struct {
+	Child Expr
+}
+
+// TextExpr is free text; it matches when any of its words matches
+type TextExpr struct {
+	Words []string
+
+	// resolved holds memories matching a word by stem or within the allowed
+	// typos, filled in by the store before matching
+	resolved map[string]bool
+}
+
+// FuzzyExpr is a word marked with ~ that matches indexed terms within Edits
+// typos. Edits is FuzzinessAuto when no count is given.
+type FuzzyExpr struct {
+	Word  string
+	Edits int
+
+	resolved map[string]bool
+}
+
+// PhraseExpr matches an exact phrase in the content or summary
+type PhraseExpr struct {
+	Phrase string
+}
+
+// PrefixExpr matches any word in the content, summary, keywords or tags
+// starting with Prefix
+type PrefixExpr struct {
+	Prefix string
+}
+
+// FieldExpr matches a single field. Field is one of tag, category, id,
+// content, summary, keyword or metadata.<key>.
+type FieldExpr struct {
+	Field  string
+	Value  string
+	Prefix bool // Value is a prefix; an empty prefix matches any value
+}
+
+// TimeRangeExpr matches memories whose created, updated or accessed time lies
+// in [From, To). A zero bound is open.
+type TimeRangeExpr struct {
+	Field string
+	From  time.Time
+	To    time.Time
+}
+
+// IntRangeExpr matches memories whose access count lies in [Min, Max]
+type IntRangeExpr struct {
+	Field  string
+	Min    int
+	Max    int
+	HasMin bool
+	HasMax bool
+}
+
+// MetadataRangeExpr matches memories whose metadata value for Key lies
+// between Lo and Hi. Values compare numerically when both are numbers.
+type MetadataRangeExpr struct {
+	Key         string
+	Lo, Hi      string
+	HasLo       bool
+	HasHi       bool
+	LoInclusive bool
+	HiInclusive bool
+}
+
+// SyntaxError reports an invalid query and where parsing failed
+type SyntaxError struct {
+	Query string
+	Pos   int // byte offset into Query
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Detail renders the error followed by the query with a caret under the
+// offending position
+func (e *SyntaxError) Detail() string {
+	caret := strings.Repeat(" ", len([]rune(e.Query[:e.Pos])))
+	return fmt.Sprintf("%s\n  %s\n  %s^", e.Error(), e.Query, caret)
+}
+
+// Fields understood by the query language
+var (
+	queryTermFields  = []string{"tag", "category", "id", "content", "summary", "keyword"}
+	queryTimeFields  = []string{"created", "updated", "accessed"}
+	queryCountFields = []string{"access_count"}
+)
+
+// ParseQuery parses a query string. An empty query returns a nil Expr, which
+// matches everything.
+func ParseQuery(query string) (Expr, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{query: query, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokRParen {
+			return nil, p.errorAt(tok, "unbalanced ')'")
+		}
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+	return expr, nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string // for field words, the value after the colon
+	field string // lowercased field name, empty for plain words
+	raw   string // field name as written
+	// quoted is set when the value of a field word was written in quotes
+	quoted bool
+}
+
+func (t queryToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokPhrase:
+		return fmt.Sprintf("phrase %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '"':
+			phrase, next, err := readQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(phrase) == "" {
+				return nil, &SyntaxError{Query: query, Pos: i, Msg: "empty phrase"}
+			}
+			tokens = append(tokens, queryToken{kind: tokPhrase, text: phrase, pos: i})
+			i = next
+		case c == '-' && i+1 < len(query) && !isQuerySpace(query[i+1]) && query[i+1] != ')':
+			tokens = append(tokens, queryToken{kind: tokNot, text: "-", pos: i})
+			i++
+		default:
+			tok, next, err := readWord(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	tokens = append(tokens, queryToken{kind: tokEOF, pos: len(query)})
+	return tokens, nil
+}
+
+func isQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// readQuoted reads a quoted string starting at the opening quote. A backslash
+// escapes the next character.
+func readQuoted(query string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if i+1 < len(query) {
+				i++
+				b.WriteByte(query[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(query[i])
+		}
+	}
+	return "", 0, &SyntaxError{Query: query, Pos: start, Msg: "unterminated phrase, missing closing '\"'"}
+}
+
+// readWord reads a bare word, an operator or a field:value pair. Only known
+// fields start a pair, so "10:30", "localhost:8080" and "std::vector" are
+// ordinary words.
+func readWord(query string, start int) (queryToken, int, error) {
+	i := start
+	for i < len(query) && !isQuerySpace(query[i]) && query[i] != '(' && query[i] != ')' && query[i] != ':' && query[i] != '"' {
+		i++
+	}
+	word := query[start:i]
+
+	if i < len(query) && query[i] == ':' && isQueryField(word) {
+		field := strings.ToLower(word)
+		valueStart := i + 1
+		if valueStart < len(query) && query[valueStart] == '"' {
+			value, next, err := readQuoted(query, valueStart)
+			if err != nil {
+				return queryToken{}, 0, err
+			}
+			return queryToken{kind: tokWord, text: query[start:next], pos: start, field: field, raw: word, value: value, quoted: true}, next, nil
+		}
+		j := valueStart
+		for j < len(query) && !isQuerySpace(query[j]) && query[j] != '(' && query[j] != ')' {
+			j++
+		}
+		return queryToken{kind: tokWord, text: query[start:j], pos: start, field: field, raw: word, value: query[valueStart:j]}, j, nil
+	}
+
+	// Not a field: colons and quotes inside the word are ordinary characters
+	for i < len(query) && !isQuerySpace(query[i]) && query[i] != '(' && query[i] != ')' {
+		i++
+	}
+	word = query[start:i]
+	// "TODO:" and the like are the word without its colon
+	if trimmed := strings.TrimSuffix(word, ":"); trimmed != "" && !strings.HasSuffix(trimmed, ":") {
+		word = trimmed
+	}
+
+	switch word {
+	case "AND", "&&":
+		return queryToken{kind: tokAnd, text: word, pos: start}, i, nil
+	case "OR", "||":
+		return queryToken{kind: tokOr, text: word, pos: start}, i, nil
+	case "NOT":
+		return queryToken{kind: tokNot, text: word, pos: start}, i, nil
+	}
+	return queryToken{kind: tokWord, text: word, pos: start}, i, nil
+}
+
+// isQueryField reports whether word names a field of the query language:
+// one of the known fields, or metadata. followed by a key
+func isQueryField(word string) bool {
+	field := strings.ToLower(word)
+	if containsString(queryTermFields, field) || containsString(queryTimeFields, field) || containsString(queryCountFields, field) {
+		return true
+	}
+	key, found := strings.CutPrefix(field, "metadata.")
+	if !found {
+		return false
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Parser
+
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) errorAt(tok queryToken, msg string) error {
+	return &SyntaxError{Query: p.query, Pos: tok.pos, Msg: msg}
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *queryParser) parseOr() (Expr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []Expr{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &OrExpr{Children: children}, nil
+}
+
+// parseAnd parses: unary (["AND"] unary)*. Adjacent plain words are merged
+// into a single free-text clause.
+func (p *queryParser) parseAnd() (Expr, error) {
+	var children []Expr
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokEOF, tokRParen, tokOr:
+			if len(children) == 0 {
+				return nil, p.errorAt(tok, fmt.Sprintf("expected a search term, found %s", tok.describe()))
+			}
+			if len(children) == 1 {
+				return children[0], nil
+			}
+			return &AndExpr{Children: children}, nil
+		case tokAnd:
+			if len(children) == 0 {
+				return nil, p.errorAt(tok, "AND needs a term on its left")
+			}
+			p.next()
+			if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen || next.kind == tokOr || next.kind == tokAnd {
+				return nil, p.errorAt(next, fmt.Sprintf("expected a search term after AND, found %s", next.describe()))
+			}
+			// An explicit AND keeps the words on either side apart
+			child, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+			continue
+		}
+
+		// Implicit AND: adjacent plain words join the preceding free-text clause
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if text, ok := child.(*TextExpr); ok && len(children) > 0 {
+			if prev, ok := children[len(children)-1].(*TextExpr); ok {
+				prev.Words = append(prev.Words, text.Words...)
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+}
+
+// parseUnary parses: ("NOT" | "-") unary | primary
+func (p *queryParser) parseUnary() (Expr, error) {
+	if tok := p.peek(); tok.kind == tokNot {
+		p.next()
+		if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen || next.kind == tokOr || next.kind == tokAnd {
+			return nil, p.errorAt(next, fmt.Sprintf("expected a search term after NOT, found %s", next.describe()))
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses: "(" or ")" | phrase | field:value | word
+func (p *queryParser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, p.errorAt(p.peek(), "empty parentheses")
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorAt(tok, "missing closing ')'")
+		}
+		return expr, nil
+	case tokPhrase:
+		return &PhraseExpr{Phrase: strings.ToLower(tok.text)}, nil
+	case tokWord:
+		if tok.field != "" {
+			return p.parseField(tok)
+		}
+		word := strings.ToLower(tok.text)
+		if strings.HasSuffix(word, "*") {
+			prefix := strings.TrimRight(word, "*")
+			if prefix == "" {
+				return nil, p.errorAt(tok, "a prefix needs at least one character before '*'")
+			}
+			return &PrefixExpr{Prefix: prefix}, nil
+		}
+		if i := strings.LastIndex(word, "~"); i >= 0 && isDigits(word[i+1:]) {
+			return p.parseFuzzy(tok, word[:i], word[i+1:])
+		}
+		return &TextExpr{Words: []string{word}}, nil
+	}
+	return nil, p.errorAt(tok, fmt.Sprintf("expected a search term, found %s", tok.describe()))
+}
+
+// isDigits reports whether s is empty or only ASCII digits, so "~user" in a
+// URL is not mistaken for a typo count
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFuzzy parses word~ or word~N
+func (p *queryParser) parseFuzzy(tok queryToken, word, edits string) (Expr, error) {
+	if word == "" {
+		return nil, p.errorAt(tok, "'~' needs a word before it")
+	}
+	if edits == "" {
+		return &FuzzyExpr{Word: word, Edits: FuzzinessAuto}, nil
+	}
+	n, err := strconv.Atoi(edits)
+	if err != nil || n < 0 || n > MaxFuzziness {
+		pos := queryToken{pos: tok.pos + len(word) + 1}
+		return nil, p.errorAt(pos, fmt.Sprintf("invalid typo count %q after '~' (use 0-%d)", edits, MaxFuzziness))
+	}
+	return &FuzzyExpr{Word: word, Edits: n}, nil
+}
+
+// parseField turns a field:value token into a term or range expression
+func (p *queryParser) parseField(tok queryToken) (Expr, error) {
+	field := tok.field
+	value := tok.value
+	valuePos := queryToken{pos: tok.pos + len(field) + 1}
+
+	if value == "" && !tok.quoted {
+		return nil, p.errorAt(valuePos, fmt.Sprintf("missing value for %s:", field))
+	}
+
+	switch {
+	case containsString(queryTimeFields, field):
+		from, to, err := parseTimeRange(value)
+		if err != nil {
+			return nil, p.errorAt(valuePos, fmt.Sprintf("%s: %v", field, err))
+		}
+		return &TimeRangeExpr{Field: field, From: from, To: to}, nil
+
+	case containsString(queryCountFields, field):
+		expr, err := parseIntRange(field, value)
+		if err != nil {
+			return nil, p.errorAt(valuePos, fmt.Sprintf("%s: %v", field, err))
+		}
+		return expr, nil
+
+	case strings.HasPrefix(field, "metadata."):
+		if field == "metadata." {
+			return nil, p.errorAt(tok, "metadata needs a key, e.g. metadata.repo:value")
+		}
+		// Metadata keys keep their case
+		key := tok.raw[len("metadata."):]
+		expr, err := metadataExpr(key, value, tok.quoted)
+		if err != nil {
+			return nil, p.errorAt(valuePos, fmt.Sprintf("%s: %v", field, err))
+		}
+		return expr, nil
+
+	case containsString(queryTermFields, field):
+		return termExpr(field, value, tok.quoted), nil
+	}
+
+	return nil, p.errorAt(tok, fmt.Sprintf("unknown field %q (use %s, %s, %s or metadata.<key>)",
+		field,
+		strings.Join(queryTermFields, ", "),
+		strings.Join(queryTimeFields, ", "),
+		strings.Join(queryCountFields, ", ")))
+}
+
+// termExpr builds an equality or prefix match on a field
+func termExpr(field, value string, quoted bool) *FieldExpr {
+	expr := &FieldExpr{Field: field, Value: strings.ToLower(value)}
+	if !quoted && strings.HasSuffix(expr.Value, "*") {
+		expr.Value = strings.TrimRight(expr.Value, "*")
+		expr.Prefix = true
+	}
+	return expr
+}
+
+// metadataExpr builds a metadata filter. Unquoted values written as ranges
+// (>v, >=v, <v, <=v or a..b) compare numerically when both sides are numbers
+// and as case-insensitive strings otherwise, which orders ISO dates correctly.
+func metadataExpr(key, value string, quoted bool) (Expr, error) {
+	field := "metadata." + key
+	if quoted {
+		return termExpr(field, value, true), nil
+	}
+
+	op, lo, hi := splitRange(value)
+	switch op {
+	case "=":
+		return termExpr(field, lo, false), nil
+	case "..":
+		expr := &MetadataRangeExpr{Key: key, LoInclusive: true, HiInclusive: true}
+		if lo != "*" && lo != "" {
+			expr.Lo, expr.HasLo = strings.ToLower(lo), true
+		}
+		if hi != "*" && hi != "" {
+			expr.Hi, expr.HasHi = strings.ToLower(hi), true
+		}
+		if !expr.HasLo && !expr.HasHi {
+			return nil, fmt.Errorf("range %q has no bounds", value)
+		}
+		if expr.HasLo && expr.HasHi && compareMetadataValues(expr.Lo, expr.Hi) > 0 {
+			return nil, fmt.Errorf("empty range %q", value)
+		}
+		return expr, nil
+	}
+
+	if lo == "" {
+		return nil, fmt.Errorf("missing value after %q", op)
+	}
+	expr := &MetadataRangeExpr{Key: key}
+	switch op {
+	case ">", ">=":
+		expr.Lo, expr.HasLo, expr.LoInclusive = strings.ToLower(lo), true, op == ">="
+	default:
+		expr.Hi, expr.HasHi, expr.HiInclusive = strings.ToLower(lo), true, op == "<="
+	}
+	return expr, nil
+}
+
+// compareMetadataValues orders two lowercased metadata values, numerically
+// when both are numbers
+func compareMetadataValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// MetadataFilter builds an expression requiring every key in filters to
+// match. Values use the query language's value syntax: foo, foo*, * (key
+// present), >5, <=2025-01-01 or a..b.
+func MetadataFilter(filters map[string]string) (Expr, error) {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var exprs []Expr
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("metadata filter needs a key")
+		}
+		value := filters[key]
+		if value == "" {
+			return nil, fmt.Errorf("metadata filter %s needs a value", key)
+		}
+		expr, err := metadataExpr(key, value, false)
+		if err != nil {
+			return nil, fmt.Errorf("metadata filter %s: %w", key, err)
+		}
+		exprs = append(exprs, expr)
+	}
+	return combineFilters(exprs...), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRange splits a range value into its operator and operands. For a..b
+// both operands are returned; otherwise only lo is set.
+func splitRange(value string) (op, lo, hi string) {
+	if idx := strings.Index(value, ".."); idx != -1 {
+		return "..", value[:idx], value[idx+2:]
+	}
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			return candidate, value[len(candidate):], ""
+		}
+	}
+	return "=", value, ""
+}
+
+// parseTimeBound parses a date or timestamp into the span it covers: a whole
+// day for dates, one second for timestamps
+func parseTimeBound(value string) (time.Time, time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, t.Add(time.Second), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local); err == nil {
+		return t, t.Add(time.Second), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC3339)", value)
+}
+
+// parseTimeRange converts a range value into a half-open [from, to) interval
+func parseTimeRange(value string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	op, lo, hi := splitRange(value)
+
+	if op == ".." {
+		if lo != "*" && lo != "" {
+			start, _, err := parseTimeBound(lo)
+			if err != nil {
+				return from, to, err
+			}
+			from = start
+		}
+		if hi != "*" && hi != "" {
+			_, end, err := parseTimeBound(hi)
+			if err != nil {
+				return from, to, err
+			}
+			to = end
+		}
+		if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+			return from, to, fmt.Errorf("empty range %q", value)
+		}
+		return from, to, nil
+	}
+
+	start, end, err := parseTimeBound(lo)
+	if err != nil {
+		return from, to, err
+	}
+	switch op {
+	case ">":
+		from = end
+	case ">=":
+		from = start
+	case "<":
+		to = start
+	case "<=":
+		to = end
+	default:
+		from, to = start, end
+	}
+	return from, to, nil
+}
+
+// parseIntRange converts a range value into an inclusive [min, max] interval
+func parseIntRange(field, value string) (*IntRangeExpr, error) {
+	expr := &IntRangeExpr{Field: field}
+	parse := func(s string) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		return n, nil
+	}
+
+	op, lo, hi := splitRange(value)
+	if op == ".." {
+		if lo != "*" && lo != "" {
+			n, err := parse(lo)
+			if err != nil {
+				return nil, err
+			}
+			expr.Min, expr.HasMin = n, true
+		}
+		if hi != "*" && hi != "" {
+			n, err := parse(hi)
+			if err != nil {
+				return nil, err
+			}
+			expr.Max, expr.HasMax = n, true
+		}
+		if expr.HasMin && expr.HasMax && expr.Min > expr.Max {
+			return nil, fmt.Errorf("empty range %q", value)
+		}
+		return expr, nil
+	}
+
+	n, err := parse(lo)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case ">":
+		expr.Min, expr.HasMin = n+1, true
+	case ">=":
+		expr.Min, expr.HasMin = n, true
+	case "<":
+		expr.Max, expr.HasMax = n-1, true
+	case "<=":
+		expr.Max, expr.HasMax = n, true
+	default:
+		expr.Min, expr.HasMin = n, true
+		expr.Max, expr.HasMax = n, true
+	}
+	return expr, nil
+}
+
+// Matching
+
+func (e *AndExpr) Match(m *Memory) bool {
+	for _, child := range e.Children {
+		if !child.Match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *OrExpr) Match(m *Memory) bool {
+	for _, child := range e.Children {
+		if child.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *NotExpr) Match(m *Memory) bool {
+	return !e.Child.Match(m)
+}
+
+func (e *TextExpr) Match(m *Memory) bool {
+	content := strings.ToLower(m.Content)
+	summary := strings.ToLower(m.Summary)
+	for _, word := range e.Words {
+		if strings.Contains(content, word) || strings.Contains(summary, word) {
+			return true
+		}
+		for _, keyword := range m.Keywords {
+			keyword = strings.ToLower(keyword)
+			if strings.Contains(keyword, word) || strings.Contains(word, keyword) {
+				return true
+			}
+		}
+		for _, tag := range m.Tags {
+			if strings.EqualFold(tag, word) {
+				return true
+			}
+		}
+	}
+	return e.resolved[m.ID]
+}
+
+func (e *FuzzyExpr) Match(m *Memory) bool {
+	return strings.Contains(strings.ToLower(m.Content), e.Word) ||
+		strings.Contains(strings.ToLower(m.Summary), e.Word) ||
+		e.resolved[m.ID]
+}
+
+func (e *PhraseExpr) Match(m *Memory) bool {
+	return strings.Contains(strings.ToLower(m.Content), e.Phrase) ||
+		strings.Contains(strings.ToLower(m.Summary), e.Phrase)
+}
+
+func (e *PrefixExpr) Match(m *Memory) bool {
+	for _, text := range []string{m.Content, m.Summary} {
+		for _, word := range splitWords(strings.ToLower(text)) {
+			if strings.HasPrefix(word, e.Prefix) {
+				return true
+			}
+		}
+	}
+	for _, list := range [][]string{m.Keywords, m.Tags} {
+		for _, word := range list {
+			if strings.HasPrefix(strings.ToLower(word), e.Prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *FieldExpr) Match(m *Memory) bool {
+	switch e.Field {
+	case "tag":
+		for _, tag := range m.Tags {
+			if e.matchValue(tag) {
+				return true
+			}
+		}
+		return false
+	case "keyword":
+		for _, keyword := range m.Keywords {
+			if e.matchValue(keyword) {
+				return true
+			}
+		}
+		return false
+	case "category":
+		return m.Category != "" && e.matchValue(m.Category)
+	case "id":
+		return e.matchValue(m.ID)
+	case "content":
+		return strings.Contains(strings.ToLower(m.Content), e.Value)
+	case "summary":
+		return strings.Contains(strings.ToLower(m.Summary), e.Value)
+	}
+
+	if key := strings.TrimPrefix(e.Field, "metadata."); key != e.Field {
+		value, ok := m.Metadata[key]
+		return ok && e.matchValue(value)
+	}
+	return false
+}
+
+// matchValue compares a field value case-insensitively, as a prefix when requested
+func (e *FieldExpr) matchValue(value string) bool {
+	value = strings.ToLower(value)
+	if e.Prefix {
+		return strings.HasPrefix(value, e.Value)
+	}
+	return value == e.Value
+}
+
+func (e *TimeRangeExpr) Match(m *Memory) bool {
+	var t time.Time
+	switch e.Field {
+	case "updated":
+		t = m.UpdatedAt
+	case "accessed":
+		t = m.LastAccess
+	default:
+		t = m.CreatedAt
+	}
+	if !e.From.IsZero() && t.Before(e.From) {
+		return false
+	}
+	if !e.To.IsZero() && !t.Before(e.To) {
+		return false
+	}
+	return true
+}
+
+func (e *IntRangeExpr) Match(m *Memory) bool {
+	n := m.AccessCount
+	if e.HasMin && n < e.Min {
+		return false
+	}
+	if e.HasMax && n > e.Max {
+		return false
+	}
+	return true
+}
+
+func (e *MetadataRangeExpr) Match(m *Memory) bool {
+	value, ok := m.Metadata[e.Key]
+	return ok && e.matchValue(strings.ToLower(value))
+}
+
+// matchValue checks a lowercased value against the bounds
+func (e *MetadataRangeExpr) matchValue(value string) bool {
+	if e.HasLo {
+		c := compareMetadataValues(value, e.Lo)
+		if c < 0 || (c == 0 && !e.LoInclusive) {
+			return false
+		}
+	}
+	if e.HasHi {
+		c := compareMetadataValues(value, e.Hi)
+		if c > 0 || (c == 0 && !e.HiInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitWords splits text on anything that is not a letter or digit
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Rendering
+
+func (e *AndExpr) String() string { return joinExprs("AND", e.Children) }
+func (e *OrExpr) String() string  { return joinExprs("OR", e.Children) }
+func (e *NotExpr) String() string { return "(NOT " + e.Child.String() + ")" }
+
+func (e *TextExpr) String() string {
+	return "text:" + strconv.Quote(strings.Join(e.Words, " "))
+}
+
+func (e *FuzzyExpr) String() string {
+	if e.Edits == FuzzinessAuto {
+		return e.Word + "~"
+	}
+	return e.Word + "~" + strconv.Itoa(e.Edits)
+}
+
+func (e *PhraseExpr) String() string { return strconv.Quote(e.Phrase) }
+func (e *PrefixExpr) String() string { return e.Prefix + "*" }
+
+func (e *FieldExpr) String() string {
+	value := e.Value
+	if strings.ContainsAny(value, " \t()\"") {
+		value = strconv.Quote(value)
+	}
+	if e.Prefix {
+		value += "*"
+	}
+	return e.Field + ":" + value
+}
+
+func (e *TimeRangeExpr) String() string {
+	from, to := "*", "*"
+	if !e.From.IsZero() {
+		from = e.From.Format(time.RFC3339)
+	}
+	if !e.To.IsZero() {
+		to = e.To.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s:[%s,%s)", e.Field, from, to)
+}
+
+func (e *IntRangeExpr) String() string {
+	min, max := "*", "*"
+	if e.HasMin {
+		min = strconv.Itoa(e.Min)
+	}
+	if e.HasMax {
+		max = strconv.Itoa(e.Max)
+	}
+	return fmt.Sprintf("%s:[%s,%s]", e.Field, min, max)
+}
+
+func (e *MetadataRangeExpr) String() string {
+	lo, hi := "*", "*"
+	left, right := "(", ")"
+	if e.HasLo {
+		lo = e.Lo
+	}
+	if e.HasHi {
+		hi = e.Hi
+	}
+	if e.LoInclusive {
+		left = "["
+	}
+	if e.HiInclusive {
+		right = "]"
+	}
+	return fmt.Sprintf("metadata.%s:%s%s,%s%s", e.Key, left, lo, hi, right)
+}
+
+func joinExprs(op string, children []Expr) string {
+	parts := make([]string, len(children))
+	for i, child := range children {
+		parts[i] = child.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
+
+// rankingText collects the free text and phrases that are not negated; it
+// feeds relevance scoring
+func rankingText(expr Expr) string {
+	var parts []string
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case *AndExpr:
+			for _, child := range e.Children {
+				walk(child)
+			}
+		case *OrExpr:
+			for _, child := range e.Children {
+				walk(child)
+			}
+		case *TextExpr:
+			parts = append(parts, e.Words...)
+		case *PhraseExpr:
+			parts = append(parts, e.Phrase)
+		case *PrefixExpr:
+			parts = append(parts, e.Prefix)
+		case *FuzzyExpr:
+			parts = append(parts, e.Word)
+		}
+	}
+	if expr != nil {
+		walk(expr)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Execution
+
+// queryIndexes are the inverted indices a query can be narrowed with
+type queryIndexes struct {
+	category    map[string][]string
+	tag         map[string][]string
+	keyword     map[string][]string
+	metadata    map[string]map[string][]string
+	text        map[string][]string // lowercased words of content and summary
+	textDict    *keywords.TermDict  // keys of text
+	keywordDict *keywords.TermDict  // keys of keyword
+
+	// maxCandidates stops narrowing by free text once a clause matches more
+	// memories than this; checking every memory is cheaper then. 0 means no
+	// limit.
+	maxCandidates int
+}
+
+// maxKeywordInWordLength bounds the words whose substrings are looked up as
+// keywords; longer words fall back to a full scan
+const maxKeywordInWordLength = 64
+
+// candidates returns the IDs that can possibly match expr, using the indices
+// where the expression allows it. ok is false when the expression cannot be
+// narrowed and every memory has to be checked.
+func (ix queryIndexes) candidates(expr Expr) (ids map[string]bool, ok bool) {
+	switch e := expr.(type) {
+	case *AndExpr:
+		var result map[string]bool
+		for _, child := range e.Children {
+			childIDs, ok := ix.candidates(child)
+			if !ok {
+				continue
+			}
+			if result == nil {
+				result = childIDs
+				continue
+			}
+			for id := range result {
+				if !childIDs[id] {
+					delete(result, id)
+				}
+			}
+		}
+		return result, result != nil
+
+	case *OrExpr:
+		result := make(map[string]bool)
+		for _, child := range e.Children {
+			childIDs, ok := ix.candidates(child)
+			if !ok {
+				return nil, false
+			}
+			for id := range childIDs {
+				result[id] = true
+			}
+		}
+		return result, true
+
+	case *MetadataRangeExpr:
+		result := make(map[string]bool)
+		for value, ids := range ix.metadata[e.Key] {
+			if e.matchValue(value) {
+				for _, id := range ids {
+					result[id] = true
+				}
+			}
+		}
+		return result, true
+
+	case *TextExpr:
+		result := make(map[string]bool)
+		for _, word := range e.Words {
+			if !ix.wordCandidates(word, result) {
+				return nil, false
+			}
+		}
+		for id := range e.resolved {
+			result[id] = true
+		}
+		return result, true
+
+	case *FuzzyExpr:
+		result, ok := ix.textCandidates(e.Word)
+		if !ok {
+			return nil, false
+		}
+		for id := range e.resolved {
+			result[id] = true
+		}
+		return result, true
+
+	case *PhraseExpr:
+		// Any phrase occurrence contains its longest word, which lies inside
+		// some indexed word
+		longest := ""
+		for _, word := range splitWords(e.Phrase) {
+			if len(word) > len(longest) {
+				longest = word
+			}
+		}
+		if longest == "" {
+			return nil, false
+		}
+		return ix.textCandidates(longest)
+
+	case *PrefixExpr:
+		if ix.textDict == nil || ix.keywordDict == nil {
+			return nil, false
+		}
+		var tags []string
+		for tag := range ix.tag {
+			if strings.HasPrefix(tag, e.Prefix) {
+				tags = append(tags, tag)
+			}
+		}
+		result := make(map[string]bool)
+		if !ix.addIDs(result, ix.text, ix.textDict.Prefix(e.Prefix)) ||
+			!ix.addIDs(result, ix.keyword, ix.keywordDict.Prefix(e.Prefix)) ||
+			!ix.addIDs(result, ix.tag, tags) {
+			return nil, false
+		}
+		return result, true
+
+	case *FieldExpr:
+		var index map[string][]string
+		switch e.Field {
+		case "content", "summary":
+			return ix.textCandidates(e.Value)
+		case "tag":
+			index = ix.tag
+		case "category":
+			index = ix.category
+		case "keyword":
+			index = ix.keyword
+			if e.Prefix && ix.keywordDict != nil {
+				result := make(map[string]bool)
+				for _, key := range ix.keywordDict.Prefix(e.Value) {
+					for _, id := range index[key] {
+						result[id] = true
+					}
+				}
+				return result, true
+			}
+		default:
+			key := strings.TrimPrefix(e.Field, "metadata.")
+			if key == e.Field || ix.metadata == nil {
+				return nil, false
+			}
+			index = ix.metadata[key]
+		}
+		result := make(map[string]bool)
+		if !e.Prefix {
+			for _, id := range index[e.Value] {
+				result[id] = true
+			}
+			return result, true
+		}
+		keys := make([]string, 0, len(index))
+		for key := range index {
+			if strings.HasPrefix(key, e.Value) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, id := range index[key] {
+				result[id] = true
+			}
+		}
+		return result, true
+	}
+
+	// Ranges and negations need a full scan
+	return nil, false
+}
+
+// wordCandidates adds the IDs that can match one free-text word as
+// TextExpr.Match does: the word inside content or summary, a keyword
+// containing the word or contained in it, or a tag equal to it. It reports
+// false when the word cannot be narrowed.
+func (ix queryIndexes) wordCandidates(word string, result map[string]bool) bool {
+	if ix.keywordDict == nil || len(word) > maxKeywordInWordLength || !ix.addText(result, word) {
+		return false
+	}
+
+	keys := ix.keywordDict.Substring(word)
+	for i := 0; i < len(word); i++ {
+		for j := i + 1; j <= len(word); j++ {
+			if _, exists := ix.keyword[word[i:j]]; exists {
+				keys = append(keys, word[i:j])
+			}
+		}
+	}
+	return ix.addIDs(result, ix.keyword, keys) && ix.addIDs(result, ix.tag, []string{word})
+}
+
+// textCandidates returns the IDs whose content or summary can contain sub
+func (ix queryIndexes) textCandidates(sub string) (map[string]bool, bool) {
+	result := make(map[string]bool)
+	if !ix.addText(result, sub) {
+		return nil, false
+	}
+	return result, true
+}
+
+// addText adds the IDs whose content or summary can contain sub. Only single
+// words can be narrowed: anything with separators in it may span indexed
+// words.
+func (ix queryIndexes) addText(result map[string]bool, sub string) bool {
+	if ix.textDict == nil || sub == "" {
+		return false
+	}
+	for _, r := range sub {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return ix.addIDs(result, ix.text, ix.textDict.Substring(sub))
+}
+
+// addIDs adds the IDs stored under each key. It reports false, adding
+// nothing, when that would take result past maxCandidates.
+func (ix queryIndexes) addIDs(result map[string]bool, index map[string][]string, keys []string) bool {
+	total := len(result)
+	for _, key := range keys {
+		total += len(index[key])
+	}
+	if ix.maxCandidates > 0 && total > ix.maxCandidates {
+		return false
+	}
+	for _, key := range keys {
+		for _, id := range index[key] {
+			result[id] = true
+		}
+	}
+	return true
+}
+
+// combineFilters ANDs the non-nil expressions together
+func combineFilters(exprs ...Expr) Expr {
+	var children []Expr
+	for _, e := range exprs {
+		if e != nil {
+			children = append(children, e)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+	return &AndExpr{Children: children}
+}
+
+// structuredFilter turns the category, tags and metadata filters of a query
+// into an expression: the category must match, at least one tag must match
+// and every metadata filter must match
+func structuredFilter(category string, tags []string, metadata map[string]string) (Expr, error) {
+	var filters []Expr
+	if category != "" {
+		filters = append(filters, &FieldExpr{Field: "category", Value: strings.ToLower(category)})
+	}
+	if len(tags) > 0 {
+		anyTag := &OrExpr{}
+		for _, tag := range tags {
+			anyTag.Children = append(anyTag.Children, &FieldExpr{Field: "tag", Value: strings.ToLower(tag)})
+		}
+		filters = append(filters, anyTag)
+	}
+	if len(metadata) > 0 {
+		metadataFilter, err := MetadataFilter(metadata)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, metadataFilter)
+	}
+	return combineFilters(filters...), nil
+}
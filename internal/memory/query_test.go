@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"python", `text:"python"`},
+		{"python machine learning", `text:"python machine learning"`},
+		{"python AND django", `(AND text:"python" text:"django")`},
+		{"python OR go", `(OR text:"python" text:"go")`},
+		{"tag:go AND NOT tag:deprecated", `(AND tag:go (NOT tag:deprecated))`},
+		{"tag:go -tag:deprecated", `(AND tag:go (NOT tag:deprecated))`},
+		{"Tag:Go", `tag:go`},
+		{`"Exact Phrase"`, `"exact phrase"`},
+		{`content:"connection pool"`, `content:"connection pool"`},
+		{"data*", `data*`},
+		{"tag:go*", `tag:go*`},
+		{"metadata.Repo:Foo", `metadata.Repo:foo`},
+		{"metadata.repo:*", `metadata.repo:*`},
+		{"access_count:>3", `access_count:[4,*]`},
+		{"access_count:2..5", `access_count:[2,5]`},
+		{"access_count:<=1", `access_count:[*,1]`},
+		{"access_count:7", `access_count:[7,7]`},
+		{"(tag:a OR tag:b) category:x", `(AND (OR tag:a tag:b) category:x)`},
+		{"tag:a OR tag:b category:x", `(OR tag:a (AND tag:b category:x))`},
+		{"NOT NOT tag:a", `(NOT (NOT tag:a))`},
+		{"see http://example.com", `text:"see http://example.com"`},
+		{"TODO: fix", `text:"todo fix"`},
+		// Only known fields take a value; other colons belong to the word
+		{"meeting at 10:30", `text:"meeting at 10:30"`},
+		{"localhost:8080", `text:"localhost:8080"`},
+		{"std::vector", `text:"std::vector"`},
+		{"error:timeout", `text:"error:timeout"`},
+		{"tga:go", `text:"tga:go"`},
+		{"pool and cache", `text:"pool and cache"`},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tt.query, err)
+			continue
+		}
+		got := ""
+		if expr != nil {
+			got = expr.String()
+		}
+		if got != tt.want {
+			t.Errorf("ParseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryDateRanges(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return d
+	}
+
+	tests := []struct {
+		query    string
+		from, to time.Time
+	}{
+		{"created:>2025-01-01", day("2025-01-02"), time.Time{}},
+		{"created:>=2025-01-01", day("2025-01-01"), time.Time{}},
+		{"created:<2025-01-01", time.Time{}, day("2025-01-01")},
+		{"created:<=2025-01-01", time.Time{}, day("2025-01-02")},
+		{"created:2025-01-01", day("2025-01-01"), day("2025-01-02")},
+		{"updated:2025-01-01..2025-01-31", day("2025-01-01"), day("2025-02-01")},
+		{"accessed:2025-03-01..*", day("2025-03-01"), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tt.query, err)
+			continue
+		}
+		r, ok := expr.(*TimeRangeExpr)
+		if !ok {
+			t.Errorf("ParseQuery(%q) = %T, want *TimeRangeExpr", tt.query, expr)
+			continue
+		}
+		if !r.From.Equal(tt.from) || !r.To.Equal(tt.to) {
+			t.Errorf("ParseQuery(%q) = [%v, %v), want [%v, %v)", tt.query, r.From, r.To, tt.from, tt.to)
+		}
+	}
+}
+
+func TestParseQuerySyntaxErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{`tag:go AND (category:x`, 11, "missing closing ')'"},
+		{`tag:go)`, 6, "unbalanced ')'"},
+		{`"unterminated phrase`, 0, "unterminated phrase"},
+		{`tag:go AND`, 10, "expected a search term after AND"},
+		{`NOT`, 3, "expected a search term after NOT"},
+		{`OR tag:go`, 0, "expected a search term"},
+		{`tag: go`, 4, "missing value for tag:"},
+		{`created:>yesterday`, 8, `invalid date "yesterday"`},
+		{`access_count:lots`, 13, `invalid number "lots"`},
+		{`access_count:5..2`, 13, "empty range"},
+		{`()`, 1, "empty parentheses"},
+		{`*`, 0, "a prefix needs at least one character"},
+		{`metadata.:x`, 0, "metadata needs a key"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseQuery(%q): expected a syntax error, got %v", tt.query, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos {
+			t.Errorf("ParseQuery(%q): error at %d, want %d (%v)", tt.query, syntaxErr.Pos, tt.pos, err)
+		}
+		if !strings.Contains(syntaxErr.Msg, tt.msg) {
+			t.Errorf("ParseQuery(%q): error %q does not mention %q", tt.query, syntaxErr.Msg, tt.msg)
+		}
+	}
+}
+
+func TestSyntaxErrorDetail(t *testing.T) {
+	_, err := ParseQuery("tag:go AND")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected a syntax error, got %v", err)
+	}
+
+	want := "syntax error at position 11: expected a search term after AND, found end of query\n  tag:go AND\n            ^"
+	if got := syntaxErr.Detail(); got != want {
+		t.Errorf("Detail() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSearchQueryLanguage(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	pool, _ := store.Store("Use pgx for the connection pool", "Database driver", "decision", []string{"go", "database"}, map[string]string{"repo": "api"})
+	orm, _ := store.Store("The ORM layer is deprecated in favour of plain SQL", "", "decision", []string{"go", "deprecated"}, map[string]string{"repo": "api"})
+	deploy, _ := store.Store("Deployments run through the Kubernetes operator", "", "ops", []string{"kubernetes"}, map[string]string{"repo": "infra"})
+	standup, _ := store.Store("Standup meeting at 10:30 on localhost:8080, see std::vector and error:timeout", "", "notes", nil, nil)
+
+	pool.CreatedAt = time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	orm.CreatedAt = time.Date(2024, 11, 2, 12, 0, 0, 0, time.Local)
+	deploy.CreatedAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	deploy.AccessCount = 5
+
+	tests := []struct {
+		query string
+		want  []*Memory
+	}{
+		{"tag:go AND NOT tag:deprecated", []*Memory{pool}},
+		{"tag:go category:decision created:>2025-01-01", []*Memory{pool}},
+		{`"connection pool"`, []*Memory{pool}},
+		{`"pool connection"`, nil},
+		{"metadata.repo:api", []*Memory{pool, orm}},
+		{"metadata.repo:inf*", []*Memory{deploy}},
+		{"metadata.team:*", nil},
+		{"deploy*", []*Memory{deploy}},
+		{"access_count:>=3", []*Memory{deploy}},
+		{"created:2024-01-01..2024-12-31", []*Memory{orm}},
+		{"tag:kubernetes OR tag:deprecated", []*Memory{orm, deploy}},
+		{"Category:OPS", []*Memory{deploy}},
+		{"pgx kubernetes", []*Memory{pool, deploy}},
+		{"pgx AND kubernetes", nil},
+		{"-category:decision", []*Memory{deploy, standup}},
+		{"meeting AND 10:30", []*Memory{standup}},
+		{"localhost:8080", []*Memory{standup}},
+		{"std::vector", []*Memory{standup}},
+		{"error:timeout", []*Memory{standup}},
+	}
+
+	for _, tt := range tests {
+		results, err := store.Search(&SearchQuery{Query: tt.query, Limit: 10})
+		if err != nil {
+			t.Errorf("Search(%q) failed: %v", tt.query, err)
+			continue
+		}
+		got := make(map[string]bool)
+		for _, m := range results {
+			got[m.ID] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Search(%q) returned %d memories, want %d", tt.query, len(got), len(tt.want))
+			continue
+		}
+		for _, m := range tt.want {
+			if !got[m.ID] {
+				t.Errorf("Search(%q) is missing %q", tt.query, m.Content)
+			}
+		}
+	}
+
+	// Structured SearchQuery fields still narrow the result
+	results, err := store.Search(&SearchQuery{Query: "tag:go", Tags: []string{"deprecated"}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != orm.ID {
+		t.Errorf("Expected only the deprecated memory, got %d results", len(results))
+	}
+
+	if _, err := store.Search(&SearchQuery{Query: "tag:go AND"}); err == nil {
+		t.Error("Expected a syntax error from Search")
+	}
+}
@@ -0,0 +1,212 @@
+// internal/memory/reader.go
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/pkg/keywords"
+	"mcp-memory-server/pkg/logger"
+)
+
+// Reader queries memories without changing them. Store and ReadOnlyStore
+// both implement it with the same query engine.
+type Reader interface {
+	Search(query *SearchQuery) ([]*Memory, error)
+	SearchPage(query *SearchQuery) (*Page, error)
+	List(category string, tags []string, limit int) ([]*Memory, error)
+	ListPage(opts *ListOptions) (*Page, error)
+	GetHistory(baseID string) ([]*Memory, error)
+	GetByKeyword(keyword string, limit int) ([]*Memory, error)
+	GetTopKeywords(limit int) []KeywordCount
+	GetStats() map[string]interface{}
+	GetTimeline() map[string]interface{}
+}
+
+var (
+	_ Reader = (*Store)(nil)
+	_ Reader = (*ReadOnlyStore)(nil)
+)
+
+// KeywordCount is a keyword with the number of current memories using it
+type KeywordCount struct {
+	Keyword string `json:"keyword"`
+	Count   int    `json:"count"`
+}
+
+// queryEngine holds the in-memory index of memories and answers queries
+// against it. Each current memory is indexed under both its base and its
+// versioned ID. mu guards the index; stores embedding the engine use it for
+// their own state too.
+type queryEngine struct {
+	config        *config.StorageConfig
+	logger        *logger.Logger
+	mu            sync.RWMutex
+	index         map[string]*Memory             // In-memory index for fast access
+	categoryIndex map[string][]string            // category -> memory IDs
+	tagIndex      map[string][]string            // tag -> memory IDs
+	keywordIndex  map[string][]string            // keyword -> memory IDs
+	metadataIndex map[string]map[string][]string // metadata key -> lowercased value -> memory IDs
+	versionIndex  map[string][]string            // base ID -> version IDs (ordered by version number)
+	analyzer      *keywords.Analyzer             // turns text into stemmed index terms
+	termIndex     map[string][]string            // stemmed word -> memory IDs
+	wordIndex     map[string][]string            // normalized word -> memory IDs
+	termDict      *keywords.NGramIndex           // words of wordIndex for typo-tolerant lookup
+	textIndex     map[string][]string            // lowercased word of content and summary -> memory IDs
+	textDict      *keywords.TermDict             // words of textIndex for prefix and substring lookup
+	keywordDict   *keywords.TermDict             // keys of keywordIndex for prefix and substring lookup
+	linkIndex     map[string][]string            // link target base ID -> IDs of memories linking to it
+	minHasher     *keywords.MinHasher            // content signatures for duplicate detection
+	signatures    map[string][]uint32            // memory ID -> MinHash signature of its content
+	bandIndex     map[string][]string            // signature band -> memory IDs, for finding near-duplicates
+}
+
+// newQueryEngine creates an empty query engine
+func newQueryEngine(cfg *config.StorageConfig, log *logger.Logger) *queryEngine {
+	e := &queryEngine{
+		config:    cfg,
+		logger:    log,
+		analyzer:  keywords.NewAnalyzer(),
+		minHasher: keywords.NewMinHasher(keywords.DefaultSignatureSize, keywords.DefaultShingleSize),
+	}
+	e.clear()
+	return e
+}
+
+// clear empties the index. The caller must hold s.mu or own s exclusively.
+func (s *queryEngine) clear() {
+	s.index = make(map[string]*Memory)
+	s.categoryIndex = make(map[string][]string)
+	s.tagIndex = make(map[string][]string)
+	s.keywordIndex = make(map[string][]string)
+	s.metadataIndex = make(map[string]map[string][]string)
+	s.versionIndex = make(map[string][]string)
+	s.termIndex = make(map[string][]string)
+	s.wordIndex = make(map[string][]string)
+	s.termDict = keywords.NewNGramIndex()
+	s.textIndex = make(map[string][]string)
+	s.textDict = keywords.NewTermDict()
+	s.keywordDict = keywords.NewTermDict()
+	s.linkIndex = make(map[string][]string)
+	s.signatures = make(map[string][]uint32)
+	s.bandIndex = make(map[string][]string)
+}
+
+// add indexes a memory read from disk, replacing an earlier copy of the same
+// version. The caller must hold s.mu.
+func (s *queryEngine) add(memory *Memory) {
+	baseID := BaseID(memory.ID)
+	if previous, exists := s.index[memory.ID]; exists {
+		s.removeFromIndices(previous)
+		if current := s.index[baseID]; current == previous && !memory.IsCurrentVersion {
+			delete(s.index, baseID)
+		}
+	}
+
+	s.index[memory.ID] = memory
+	s.updateIndices(memory)
+	if memory.IsCurrentVersion {
+		s.index[baseID] = memory
+	}
+
+	// Keep version IDs ordered by version number
+	if memory.Version > 0 && !containsString(s.versionIndex[baseID], memory.ID) {
+		versionIDs := append(s.versionIndex[baseID], memory.ID)
+		sort.SliceStable(versionIDs, func(i, j int) bool {
+			memI, memJ := s.index[versionIDs[i]], s.index[versionIDs[j]]
+			if memI != nil && memJ != nil {
+				return memI.Version < memJ.Version
+			}
+			return false
+		})
+		s.versionIndex[baseID] = versionIDs
+	}
+}
+
+// remove drops a memory version from the index and returns it, or nil when
+// it was not indexed. The caller must hold s.mu.
+func (s *queryEngine) remove(id string) *Memory {
+	memory, exists := s.index[id]
+	if !exists || memory.ID != id {
+		return nil
+	}
+	s.removeFromIndices(memory)
+	delete(s.index, id)
+
+	baseID := BaseID(id)
+	if current, exists := s.index[baseID]; exists && current == memory {
+		delete(s.index, baseID)
+	}
+	versionIDs := s.versionIndex[baseID]
+	for i, versionID := range versionIDs {
+		if versionID == id {
+			versionIDs = append(versionIDs[:i:i], versionIDs[i+1:]...)
+			break
+		}
+	}
+	if len(versionIDs) == 0 {
+		delete(s.versionIndex, baseID)
+	} else {
+		s.versionIndex[baseID] = versionIDs
+	}
+	return memory
+}
+
+// Search searches for memories based on query
+func (s *queryEngine) Search(query *SearchQuery) ([]*Memory, error) {
+	page, err := s.SearchPage(query)
+	if err != nil {
+		return nil, err
+	}
+	return page.Memories, nil
+}
+
+// List lists all memories with optional filtering, newest first
+func (s *queryEngine) List(category string, tags []string, limit int) ([]*Memory, error) {
+	page, err := s.ListPage(&ListOptions{Category: category, Tags: tags, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return page.Memories, nil
+}
+
+// stats returns the statistics both stores report. The caller must hold s.mu.
+func (s *queryEngine) stats() map[string]interface{} {
+	categories := make(map[string]int)
+	totalMemories := 0
+	totalAccess := 0
+	totalKeywords := 0
+	totalLinks := 0
+
+	// Count each memory once, not under both of its IDs
+	for id, memory := range s.index {
+		if id != memory.ID {
+			continue
+		}
+		totalMemories++
+		if memory.Category != "" {
+			categories[memory.Category]++
+		}
+		totalAccess += memory.AccessCount
+		totalKeywords += len(memory.Keywords)
+		if memory.IsCurrentVersion {
+			totalLinks += len(memory.Links)
+		}
+	}
+	retention := s.retentionStats(time.Now())
+
+	return map[string]interface{}{
+		"total_memories":          totalMemories,
+		"categories":              categories,
+		"total_access_count":      totalAccess,
+		"total_keywords":          totalKeywords,
+		"unique_keywords":         len(s.keywordIndex),
+		"top_keywords":            s.topKeywords(10),
+		"total_links":             totalLinks,
+		"average_importance":      retention["average_importance"],
+		"average_retention_score": retention["average_retention_score"],
+		"lowest_retention":        retention["lowest_retention"],
+	}
+}
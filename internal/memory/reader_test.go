@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"mcp-memory-server/pkg/logger"
+)
+
+func TestReadOnlyStoreAnswersLikeStore(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withTrash)
+	defer store.Close()
+	first, _ := store.Store("Kubernetes clusters are upgraded quarterly", "", "ops", []string{"kubernetes"}, nil)
+	store.Store("Kubernetes clusters are upgraded quarterly", "", "ops", []string{"kubernetes"}, nil)
+	store.Store("Postgres backups are verified weekly", "", "databases", []string{"postgres"}, nil)
+
+	reader, err := NewReadOnlyStore(dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create read-only store: %v", err)
+	}
+	defer reader.Close()
+
+	pageIDsOf := func(page *Page, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		return pageIDs(page)
+	}
+
+	query := &SearchQuery{Query: "kubernets upgrade", Limit: 10}
+	want := pageIDsOf(store.queryEngine.SearchPage(query))
+	if got := pageIDsOf(reader.SearchPage(query)); len(want) != 2 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Read-only search = %v, want %v", got, want)
+	}
+	listOpts := &ListOptions{Tags: []string{"postgres"}}
+	if got, want := pageIDsOf(reader.ListPage(listOpts)), pageIDsOf(store.ListPage(listOpts)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Read-only list = %v, want %v", got, want)
+	}
+	if got, want := reader.GetTopKeywords(5), store.GetTopKeywords(5); len(got) == 0 || len(got) != len(want) {
+		t.Errorf("Read-only top keywords = %v, want %v", got, want)
+	}
+	if got, want := reader.GetStats()["total_memories"], store.GetStats()["total_memories"]; got != 3 || got != want {
+		t.Errorf("Read-only total_memories = %v, want 3 like the store's %v", got, want)
+	}
+
+	history, err := reader.GetHistory(first.ID)
+	if err != nil || len(history) != 2 || history[0].Version != 2 {
+		t.Fatalf("Read-only history = %v, %v, want versions 2 and 1", history, err)
+	}
+
+	// Incremental refreshes keep the version chain and search in step
+	if err := store.Delete(history[0].ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	reader.Refresh()
+	if history, err := reader.GetHistory(first.ID); err != nil || len(history) != 1 || history[0].Version != 1 {
+		t.Errorf("History after delete = %v, %v, want only version 1", history, err)
+	}
+	if got, want := pageIDsOf(reader.SearchPage(query)), pageIDsOf(store.queryEngine.SearchPage(query)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Search after delete = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,415 @@
+// internal/memory/relations.go
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Relation types for links between memories
+const (
+	RelationSupersedes = "supersedes"
+	RelationRelatesTo  = "relates_to"
+	RelationDependsOn  = "depends_on"
+	RelationPartOf     = "part_of"
+)
+
+// RelationTypes lists the supported relation types
+var RelationTypes = []string{RelationSupersedes, RelationRelatesTo, RelationDependsOn, RelationPartOf}
+
+// Traversal directions for Related
+const (
+	DirectionOutgoing = "outgoing"
+	DirectionIncoming = "incoming"
+	DirectionBoth     = "both"
+)
+
+// Traversal limits for Related and Graph
+const (
+	MaxRelatedDepth     = 5
+	DefaultRelatedLimit = 50
+	MaxRelatedLimit     = 500
+)
+
+// Link is a typed, directed edge from the memory holding it to Target. The
+// target is stored by base ID so the link follows it to new versions.
+type Link struct {
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RelatedOptions controls a graph traversal from one memory
+type RelatedOptions struct {
+	Depth     int      `json:"depth,omitempty"`     // default 1, at most MaxRelatedDepth
+	Types     []string `json:"types,omitempty"`     // relation types to follow; all when empty
+	Direction string   `json:"direction,omitempty"` // outgoing, incoming or both (default both)
+	Limit     int      `json:"limit,omitempty"`     // memories returned besides the root, default DefaultRelatedLimit
+}
+
+// GraphNode is a memory in a graph with its distance from the root
+type GraphNode struct {
+	Memory *Memory `json:"memory"`
+	Depth  int     `json:"depth"`
+}
+
+// GraphEdge is a link between two graph nodes, by base ID
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// Graph is a set of memories and the links between them
+type Graph struct {
+	Root      string      `json:"root,omitempty"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated,omitempty"` // the limit stopped the traversal
+}
+
+// ParseRelationType validates a relation type. Dashes and spaces are accepted
+// in place of underscores, so "depends-on" is depends_on.
+func ParseRelationType(value string) (string, error) {
+	normalized := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(value)))
+	for _, relation := range RelationTypes {
+		if normalized == relation {
+			return relation, nil
+		}
+	}
+	return "", fmt.Errorf("invalid relation type %q (use %s)", value, strings.Join(RelationTypes, ", "))
+}
+
+// ParseDirection validates a traversal direction; empty means both
+func ParseDirection(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", DirectionBoth:
+		return DirectionBoth, nil
+	case DirectionOutgoing, "out":
+		return DirectionOutgoing, nil
+	case DirectionIncoming, "in":
+		return DirectionIncoming, nil
+	}
+	return "", fmt.Errorf("invalid direction %q (use outgoing, incoming or both)", value)
+}
+
+// BaseID strips the version and sibling suffixes from a memory ID
+func BaseID(id string) string {
+	if idx := strings.Index(id, SiblingSeparator); idx != -1 {
+		id = id[:idx]
+	}
+	if idx := strings.LastIndex(id, "-v"); idx != -1 {
+		if _, err := strconv.Atoi(id[idx+2:]); err == nil {
+			return id[:idx]
+		}
+	}
+	return id
+}
+
+// currentMemory looks up a memory by base or versioned ID, preferring the
+// current version. The caller must hold s.mu.
+func (s *Store) currentMemory(id string) (*Memory, bool) {
+	memory, exists := s.index[id]
+	if exists && memory.IsCurrentVersion {
+		return memory, true
+	}
+	if current, ok := s.index[BaseID(id)]; ok && current.IsCurrentVersion {
+		return current, true
+	}
+	return memory, exists
+}
+
+// Link adds a typed link from source to target. It reports false when the
+// link already exists.
+func (s *Store) Link(sourceID, targetID, relation string) (bool, error) {
+	if s.readOnly != nil {
+		return false, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	relation, err := ParseRelationType(relation)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, exists := s.currentMemory(sourceID)
+	if !exists {
+		return false, fmt.Errorf("memory not found: %s", sourceID)
+	}
+	target, exists := s.currentMemory(targetID)
+	if !exists {
+		return false, fmt.Errorf("memory not found: %s", targetID)
+	}
+	targetBase := BaseID(target.ID)
+	if BaseID(source.ID) == targetBase {
+		return false, fmt.Errorf("cannot link memory %s to itself", sourceID)
+	}
+
+	linkedBefore := false
+	for _, link := range source.Links {
+		if link.Target == targetBase {
+			if link.Type == relation {
+				return false, nil
+			}
+			linkedBefore = true
+		}
+	}
+
+	source.Links = append(source.Links, Link{Type: relation, Target: targetBase, CreatedAt: time.Now()})
+	if !linkedBefore {
+		addIndexID(s.linkIndex, targetBase, source.ID)
+	}
+
+	if _, err := s.saveMemoryToFile(source); err != nil {
+		return false, fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	s.logger.Debug("Linked memories", "source", source.ID, "target", targetBase, "type", relation)
+	s.recordAudit(AuditLink, []string{source.ID, targetBase}, relation)
+	return true, nil
+}
+
+// Unlink removes links from source to target. An empty relation removes
+// links of every type. It returns the number of links removed.
+func (s *Store) Unlink(sourceID, targetID, relation string) (int, error) {
+	if s.readOnly != nil {
+		return 0, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	if relation != "" {
+		var err error
+		if relation, err = ParseRelationType(relation); err != nil {
+			return 0, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, exists := s.currentMemory(sourceID)
+	if !exists {
+		return 0, fmt.Errorf("memory not found: %s", sourceID)
+	}
+	// The target may have been deleted; unlink by the ID given then
+	targetBase := BaseID(targetID)
+	if target, exists := s.currentMemory(targetID); exists {
+		targetBase = BaseID(target.ID)
+	}
+
+	kept := source.Links[:0]
+	removed := 0
+	stillLinked := false
+	for _, link := range source.Links {
+		if link.Target == targetBase && (relation == "" || link.Type == relation) {
+			removed++
+			continue
+		}
+		if link.Target == targetBase {
+			stillLinked = true
+		}
+		kept = append(kept, link)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	source.Links = kept
+	if len(source.Links) == 0 {
+		source.Links = nil
+	}
+	if !stillLinked {
+		removeIndexID(s.linkIndex, targetBase, source.ID)
+	}
+
+	if _, err := s.saveMemoryToFile(source); err != nil {
+		return removed, fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	s.logger.Debug("Unlinked memories", "source", source.ID, "target", targetBase, "removed", removed)
+	s.recordAudit(AuditUnlink, []string{source.ID, targetBase}, fmt.Sprintf("%d links", removed))
+	return removed, nil
+}
+
+// Related returns the memories reachable from id within opts.Depth links,
+// breadth first, together with the links followed
+func (s *Store) Related(id string, opts RelatedOptions) (*Graph, error) {
+	depth := opts.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	if depth > MaxRelatedDepth {
+		return nil, fmt.Errorf("depth %d exceeds the maximum of %d", depth, MaxRelatedDepth)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultRelatedLimit
+	}
+	if limit > MaxRelatedLimit {
+		limit = MaxRelatedLimit
+	}
+	direction, err := ParseDirection(opts.Direction)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]bool)
+	for _, value := range opts.Types {
+		relation, err := ParseRelationType(value)
+		if err != nil {
+			return nil, err
+		}
+		types[relation] = true
+	}
+	follow := func(relation string) bool { return len(types) == 0 || types[relation] }
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	root, exists := s.currentMemory(id)
+	if !exists {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	rootBase := BaseID(root.ID)
+
+	graph := &Graph{Root: rootBase, Nodes: []GraphNode{{Memory: root, Depth: 0}}, Edges: []GraphEdge{}}
+	visited := map[string]bool{rootBase: true}
+	edges := make(map[GraphEdge]bool)
+	addEdge := func(edge GraphEdge) {
+		if !edges[edge] {
+			edges[edge] = true
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	frontier := []*Memory{root}
+	for level := 1; level <= depth && len(frontier) > 0 && !graph.Truncated; level++ {
+		var next []*Memory
+		visit := func(neighbor *Memory, edge GraphEdge) {
+			base := BaseID(neighbor.ID)
+			if !visited[base] {
+				if len(graph.Nodes) > limit {
+					graph.Truncated = true
+					return
+				}
+				visited[base] = true
+				graph.Nodes = append(graph.Nodes, GraphNode{Memory: neighbor, Depth: level})
+				next = append(next, neighbor)
+			}
+			addEdge(edge)
+		}
+
+		for _, memory := range frontier {
+			base := BaseID(memory.ID)
+			if direction != DirectionIncoming {
+				for _, link := range memory.Links {
+					if !follow(link.Type) {
+						continue
+					}
+					if target, ok := s.currentMemory(link.Target); ok {
+						visit(target, GraphEdge{Source: base, Target: link.Target, Type: link.Type})
+					}
+				}
+			}
+			if direction != DirectionOutgoing {
+				for _, sourceID := range s.linkIndex[base] {
+					source, ok := s.index[sourceID]
+					if !ok || !source.IsCurrentVersion {
+						continue
+					}
+					for _, link := range source.Links {
+						if link.Target == base && follow(link.Type) {
+							visit(source, GraphEdge{Source: BaseID(source.ID), Target: base, Type: link.Type})
+						}
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sortEdges(graph.Edges)
+	return graph, nil
+}
+
+// Graph returns the current memories that have links, and their links, for
+// an overview of the whole knowledge graph. Memories with the most links come
+// first when limit cuts the graph short.
+func (s *Store) Graph(limit int) *Graph {
+	if limit <= 0 {
+		limit = DefaultRelatedLimit
+	}
+	if limit > MaxRelatedLimit {
+		limit = MaxRelatedLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	degree := make(map[string]int)
+	nodes := make(map[string]*Memory)
+	var edges []GraphEdge
+	for id, memory := range s.index {
+		if id != memory.ID || !memory.IsCurrentVersion {
+			continue
+		}
+		source := BaseID(memory.ID)
+		for _, link := range memory.Links {
+			target, ok := s.currentMemory(link.Target)
+			if !ok {
+				continue
+			}
+			nodes[source] = memory
+			nodes[link.Target] = target
+			degree[source]++
+			degree[link.Target]++
+			edges = append(edges, GraphEdge{Source: source, Target: link.Target, Type: link.Type})
+		}
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if degree[ids[i]] != degree[ids[j]] {
+			return degree[ids[i]] > degree[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	graph := &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	if len(ids) > limit {
+		ids = ids[:limit]
+		graph.Truncated = true
+	}
+	kept := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		kept[id] = true
+		graph.Nodes = append(graph.Nodes, GraphNode{Memory: nodes[id]})
+	}
+	for _, edge := range edges {
+		if kept[edge.Source] && kept[edge.Target] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	sortEdges(graph.Edges)
+	return graph
+}
+
+func sortEdges(edges []GraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		if edges[i].Target != edges[j].Target {
+			return edges[i].Target < edges[j].Target
+		}
+		return edges[i].Type < edges[j].Type
+	})
+}
@@ -0,0 +1,119 @@
+package memory
+
+import "testing"
+
+func TestLinkAndRelated(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, nil)
+
+	ids := make(map[string]string)
+	for _, name := range []string{"service", "database", "schema", "old-decision", "new-decision"} {
+		m, err := store.Store("Notes about the "+name, "", "arch", nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to store memory: %v", err)
+		}
+		ids[name] = m.ID
+	}
+
+	links := []struct{ source, target, relation string }{
+		{"service", "database", RelationDependsOn},
+		{"schema", "database", RelationPartOf},
+		{"new-decision", "old-decision", "supersedes"},
+		{"new-decision", "service", "relates-to"},
+	}
+	for _, l := range links {
+		created, err := store.Link(ids[l.source], ids[l.target], l.relation)
+		if err != nil || !created {
+			t.Fatalf("Link(%s, %s, %s) = %v, %v", l.source, l.target, l.relation, created, err)
+		}
+	}
+
+	if created, err := store.Link(ids["service"], ids["database"], RelationDependsOn); err != nil || created {
+		t.Errorf("Duplicate link should be a no-op, got %v, %v", created, err)
+	}
+	if _, err := store.Link(ids["service"], ids["service"], RelationRelatesTo); err == nil {
+		t.Error("Linking a memory to itself should fail")
+	}
+	if _, err := store.Link(ids["service"], ids["database"], "blocks"); err == nil {
+		t.Error("Unknown relation types should be rejected")
+	}
+	if _, err := store.Link(ids["service"], "missing", RelationRelatesTo); err == nil {
+		t.Error("Linking to a missing memory should fail")
+	}
+
+	related := func(id string, opts RelatedOptions) map[string]int {
+		t.Helper()
+		graph, err := store.Related(id, opts)
+		if err != nil {
+			t.Fatalf("Related failed: %v", err)
+		}
+		depths := make(map[string]int)
+		for _, node := range graph.Nodes[1:] {
+			depths[node.Memory.ID] = node.Depth
+		}
+		return depths
+	}
+
+	// Depth 1 in both directions: what the database depends on and what depends on it
+	got := related(ids["database"], RelatedOptions{})
+	if len(got) != 2 || got[ids["service"]] != 1 || got[ids["schema"]] != 1 {
+		t.Errorf("Depth 1 neighbours of database = %v", got)
+	}
+
+	// Depth 2 reaches the new decision through the service
+	got = related(ids["database"], RelatedOptions{Depth: 2})
+	if got[ids["new-decision"]] != 2 || len(got) != 3 {
+		t.Errorf("Depth 2 neighbours of database = %v", got)
+	}
+
+	// Outgoing only, filtered by type
+	got = related(ids["new-decision"], RelatedOptions{Direction: DirectionOutgoing, Types: []string{RelationSupersedes}})
+	if len(got) != 1 || got[ids["old-decision"]] != 1 {
+		t.Errorf("Outgoing supersedes links = %v", got)
+	}
+	if got = related(ids["database"], RelatedOptions{Direction: DirectionOutgoing}); len(got) != 0 {
+		t.Errorf("Database has no outgoing links, got %v", got)
+	}
+
+	if _, err := store.Related(ids["database"], RelatedOptions{Depth: MaxRelatedDepth + 1}); err == nil {
+		t.Error("Depth beyond the maximum should fail")
+	}
+
+	graph := store.Graph(0)
+	if len(graph.Nodes) != 5 || len(graph.Edges) != 4 {
+		t.Errorf("Graph has %d nodes and %d edges, want 5 and 4", len(graph.Nodes), len(graph.Edges))
+	}
+
+	// Links survive a restart
+	store.Close()
+	store = openTestStore(t, dir, nil)
+	defer store.Close()
+
+	got = related(ids["database"], RelatedOptions{Direction: DirectionIncoming})
+	if len(got) != 2 {
+		t.Errorf("Incoming links after reload = %v", got)
+	}
+
+	removed, err := store.Unlink(ids["service"], ids["database"], "")
+	if err != nil || removed != 1 {
+		t.Fatalf("Unlink = %d, %v", removed, err)
+	}
+	got = related(ids["database"], RelatedOptions{Direction: DirectionIncoming})
+	if len(got) != 1 || got[ids["schema"]] != 1 {
+		t.Errorf("Incoming links after unlink = %v", got)
+	}
+}
+
+func TestBaseID(t *testing.T) {
+	tests := map[string]string{
+		"0123456789abcdef-v2":  "0123456789abcdef",
+		"0123456789abcdef-v10": "0123456789abcdef",
+		"0123456789abcdef":     "0123456789abcdef",
+		"my-value":             "my-value",
+	}
+	for id, want := range tests {
+		if got := BaseID(id); got != want {
+			t.Errorf("BaseID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
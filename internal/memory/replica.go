@@ -0,0 +1,129 @@
+// internal/memory/replica.go
+package memory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SiblingSeparator joins a version ID and the node whose conflicting edit of
+// that version was kept alongside it, see SiblingID
+const SiblingSeparator = "~"
+
+// SiblingID returns the ID of the sibling holding a conflicting edit of
+// versionID made on node. Siblings belong to the same version chain.
+func SiblingID(versionID, node string) string {
+	return versionID + SiblingSeparator + node
+}
+
+// IsSibling reports whether id names a sibling version
+func IsSibling(id string) bool {
+	return strings.Contains(id, SiblingSeparator)
+}
+
+// ValidVersionID reports whether id is a versioned memory ID safe to use as
+// a file name, optionally with a sibling suffix
+func ValidVersionID(id string) bool {
+	if id == "" || BaseID(id) == id || strings.Contains(id, "..") {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Versions returns copies of every stored memory version, including past
+// versions and siblings
+func (s *Store) Versions() []*Memory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make([]*Memory, 0, len(s.index))
+	for id, memory := range s.index {
+		if id != memory.ID {
+			continue
+		}
+		copied := *memory
+		versions = append(versions, &copied)
+	}
+	return versions
+}
+
+// LookupVersion returns a copy of a stored memory version without counting
+// an access
+func (s *Store) LookupVersion(id string) (*Memory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	memory, exists := s.index[id]
+	if !exists || memory.ID != id {
+		return nil, false
+	}
+	copied := *memory
+	return &copied, true
+}
+
+// ImportVersion stores a memory version received from another instance
+// under its own ID, replacing a local copy of the same version. An imported
+// current version older than the local current one is kept as a past
+// version.
+func (s *Store) ImportVersion(memory *Memory) error {
+	if s.readOnly != nil {
+		return s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	if !ValidVersionID(memory.ID) {
+		return fmt.Errorf("invalid memory version ID %q", memory.ID)
+	}
+	imported := *memory
+	baseID := BaseID(imported.ID)
+
+	s.mu.Lock()
+	_, replaced := s.index[imported.ID]
+	if imported.IsCurrentVersion {
+		if current, exists := s.index[baseID]; exists && current.IsCurrentVersion && current.ID != imported.ID {
+			if current.Version > imported.Version {
+				imported.IsCurrentVersion = false
+			} else {
+				current.IsCurrentVersion = false
+				if _, err := s.saveMemoryToFile(current); err != nil {
+					s.logger.WithError(err).Warn("Failed to save superseded memory", "id", current.ID)
+				}
+			}
+		}
+	}
+
+	size, err := s.saveMemoryToFile(&imported)
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("failed to save imported memory: %w", err)
+	}
+	s.add(&imported)
+	s.setMemorySize(&imported, size)
+
+	if imported.Version > 1 || replaced {
+		s.publishEvent(EventVersioned, &imported)
+	} else {
+		s.publishEvent(EventCreated, &imported)
+	}
+	s.mu.Unlock()
+
+	s.enforceLimits(&imported)
+	s.recordAudit(AuditReplicate, []string{imported.ID}, fmt.Sprintf("version %d", imported.Version))
+	return nil
+}
+
+// RemoveVersion deletes a memory version on behalf of another instance,
+// even if it is protected
+func (s *Store) RemoveVersion(id string) error {
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	return s.deleteMemory(id, true, AuditReplicate, "")
+}
@@ -0,0 +1,198 @@
+// internal/memory/similarity.go
+package memory
+
+import (
+	"sort"
+	"strings"
+
+	"mcp-memory-server/pkg/keywords"
+)
+
+// Duplicate detection parameters
+const (
+	DefaultDuplicateThreshold = 0.8  // used when the config leaves it unset
+	relatedThreshold          = 0.2  // minimum similarity for a related-memory suggestion
+	maxDuplicates             = 3    // near-duplicates reported per stored memory
+	maxRelatedSuggestions     = 5    // related memories suggested per stored memory
+	signatureBands            = 16   // LSH bands of 4 hashes: 0.8 similar pairs collide with p > 0.999
+	maxKeywordCandidates      = 1000 // keywords shared by more memories are too common to suggest relations
+)
+
+// SimilarMemory is an existing memory similar to one being stored
+type SimilarMemory struct {
+	Memory     *Memory `json:"memory"`
+	Similarity float64 `json:"similarity"` // 0-1
+}
+
+// StoreResult describes a stored memory and the existing memories it resembles
+type StoreResult struct {
+	Memory     *Memory         `json:"memory"`
+	Duplicates []SimilarMemory `json:"duplicates,omitempty"`  // likely near-duplicates, most similar first
+	Related    []SimilarMemory `json:"related,omitempty"`     // memories worth linking, most similar first
+	MergedInto string          `json:"merged_into,omitempty"` // base ID the memory was stored under as a new version
+}
+
+// shingleWords are the stemmed words of a memory's content that its MinHash
+// signature is computed over, so rewordings like "deploys"/"deploying" still
+// produce matching shingles
+func (s *queryEngine) shingleWords(content string) []string {
+	words := s.analyzer.Tokens(content)
+	for i, word := range words {
+		words[i] = keywords.Stem(word)
+	}
+	return words
+}
+
+// addSignature indexes the content signature of a memory for duplicate detection
+func (s *queryEngine) addSignature(memory *Memory) {
+	signature := s.minHasher.Signature(s.shingleWords(memory.Content))
+	if signature == nil {
+		return
+	}
+	s.signatures[memory.ID] = signature
+	for _, band := range keywords.Bands(signature, signatureBands) {
+		addIndexID(s.bandIndex, band, memory.ID)
+	}
+}
+
+// removeSignature removes a memory from the duplicate detection index
+func (s *queryEngine) removeSignature(memory *Memory) {
+	signature, exists := s.signatures[memory.ID]
+	if !exists {
+		return
+	}
+	delete(s.signatures, memory.ID)
+	for _, band := range keywords.Bands(signature, signatureBands) {
+		removeIndexID(s.bandIndex, band, memory.ID)
+	}
+}
+
+// duplicateThreshold returns the configured near-duplicate threshold
+func (s *queryEngine) duplicateThreshold() float64 {
+	if s.config.DuplicateThreshold > 0 && s.config.DuplicateThreshold <= 1 {
+		return s.config.DuplicateThreshold
+	}
+	return DefaultDuplicateThreshold
+}
+
+// findSimilar compares a new memory's content signature and keywords with
+// current memories other than excludeBaseID. Candidates come from shared
+// signature bands and shared keywords, so only a handful of memories are
+// compared. Memories at or above the duplicate threshold are duplicates;
+// the rest above relatedThreshold are related. The caller must hold s.mu.
+func (s *queryEngine) findSimilar(signature []uint32, keywordList []string, excludeBaseID string) (duplicates, related []SimilarMemory) {
+	candidates := make(map[string]*Memory)
+	addCandidate := func(id string) {
+		memory, exists := s.index[id]
+		if !exists || !memory.IsCurrentVersion || BaseID(memory.ID) == excludeBaseID {
+			return
+		}
+		candidates[memory.ID] = memory
+	}
+	for _, band := range keywords.Bands(signature, signatureBands) {
+		for _, id := range s.bandIndex[band] {
+			addCandidate(id)
+		}
+	}
+	for _, keyword := range keywordList {
+		ids := s.keywordIndex[strings.ToLower(keyword)]
+		if len(ids) > maxKeywordCandidates {
+			continue
+		}
+		for _, id := range ids {
+			addCandidate(id)
+		}
+	}
+
+	threshold := s.duplicateThreshold()
+	for _, memory := range candidates {
+		contentSimilarity := keywords.Similarity(signature, s.signatures[memory.ID])
+		if contentSimilarity >= threshold {
+			duplicates = append(duplicates, SimilarMemory{Memory: memory, Similarity: contentSimilarity})
+			continue
+		}
+		similarity := contentSimilarity
+		if overlap := keywordOverlap(keywordList, memory.Keywords); overlap > similarity {
+			similarity = overlap
+		}
+		if similarity >= relatedThreshold {
+			related = append(related, SimilarMemory{Memory: memory, Similarity: similarity})
+		}
+	}
+
+	return topSimilar(duplicates, maxDuplicates), topSimilar(related, maxRelatedSuggestions)
+}
+
+// keywordOverlap is the Jaccard similarity of two keyword lists
+func keywordOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, keyword := range a {
+		set[strings.ToLower(keyword)] = true
+	}
+	shared := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, keyword := range b {
+		keyword = strings.ToLower(keyword)
+		if seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		if set[keyword] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+// topSimilar sorts matches by similarity, most similar first, and keeps at most limit
+func topSimilar(matches []SimilarMemory, limit int) []SimilarMemory {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Memory.ID < matches[j].Memory.ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// mergeInto combines the organisation of the memory a near-duplicate is
+// merged into with the new memory's own: tags are unioned, an empty category
+// is inherited and new metadata values override existing ones
+func mergeInto(existing *Memory, category string, tags []string, metadata map[string]string) (string, []string, map[string]string) {
+	if category == "" {
+		category = existing.Category
+	}
+
+	merged := append([]string(nil), existing.Tags...)
+	seen := make(map[string]bool, len(merged))
+	for _, tag := range merged {
+		seen[strings.ToLower(tag)] = true
+	}
+	for _, tag := range tags {
+		if !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			merged = append(merged, tag)
+		}
+	}
+
+	var mergedMetadata map[string]string
+	if len(existing.Metadata) > 0 || len(metadata) > 0 {
+		mergedMetadata = make(map[string]string, len(existing.Metadata)+len(metadata))
+		for key, value := range existing.Metadata {
+			mergedMetadata[key] = value
+		}
+		for key, value := range metadata {
+			mergedMetadata[key] = value
+		}
+	}
+	return category, merged, mergedMetadata
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"testing"
+
+	"mcp-memory-server/internal/config"
+)
+
+const poolingNote = "Use connection pooling with pgbouncer in front of the postgres primary so the API servers never exhaust the database connection limit during traffic spikes"
+
+func TestRememberFlagsDuplicatesAndRelated(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	original, err := store.Store(poolingNote, "", "decision", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to store memory: %v", err)
+	}
+	unrelated, err := store.Store("The frontend build uses vite and deploys static assets to the CDN", "", "frontend", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to store memory: %v", err)
+	}
+
+	// A light rewording is a near-duplicate
+	result, err := store.Remember(MemoryInput{Content: poolingNote + ".", Category: "decision"})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	if len(result.Duplicates) != 1 || result.Duplicates[0].Memory.ID != original.ID {
+		t.Fatalf("Duplicates = %+v, want %s", result.Duplicates, original.ID)
+	}
+	if result.MergedInto != "" || result.Memory.Version != 1 {
+		t.Errorf("Duplicates should not be merged by default, got %+v", result)
+	}
+
+	// A memory on an overlapping topic is related but not a duplicate
+	result, err = store.Remember(MemoryInput{Content: "Postgres connection limits are raised to 500 on the primary database", Category: "ops"})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	if len(result.Duplicates) != 0 {
+		t.Errorf("Unexpected duplicates: %+v", result.Duplicates)
+	}
+	relatedIDs := make(map[string]bool)
+	for _, related := range result.Related {
+		relatedIDs[related.Memory.ID] = true
+	}
+	if !relatedIDs[original.ID] || relatedIDs[unrelated.ID] || relatedIDs[result.Memory.ID] {
+		t.Errorf("Related = %v, want %s but not %s or itself", relatedIDs, original.ID, unrelated.ID)
+	}
+
+	// Storing the exact content again versions it without flagging itself
+	result, err = store.Remember(MemoryInput{Content: poolingNote, Category: "decision"})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	for _, duplicate := range result.Duplicates {
+		if BaseID(duplicate.Memory.ID) == BaseID(original.ID) {
+			t.Errorf("A memory should not be its own duplicate")
+		}
+	}
+}
+
+func TestRememberAutoMergesDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	withMerging := func(cfg *config.StorageConfig) {
+		cfg.DuplicateThreshold = 0.7
+		cfg.AutoMergeDuplicates = true
+	}
+	store := openTestStore(t, dir, withMerging)
+
+	original, err := store.Store(poolingNote, "", "decision", []string{"postgres"}, map[string]string{"repo": "api"})
+	if err != nil {
+		t.Fatalf("Failed to store memory: %v", err)
+	}
+
+	result, err := store.Remember(MemoryInput{Content: poolingNote + " on Black Friday", Tags: []string{"scaling"}})
+	if err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	baseID := BaseID(original.ID)
+	if result.MergedInto != baseID || result.Memory.ID != baseID+"-v2" {
+		t.Fatalf("Near-duplicate should become %s-v2, got %s (merged into %q)", baseID, result.Memory.ID, result.MergedInto)
+	}
+	merged := result.Memory
+	if merged.Category != "decision" || len(merged.Tags) != 2 || merged.Metadata["repo"] != "api" {
+		t.Errorf("Merged memory lost its organisation: %+v", merged)
+	}
+
+	current, err := store.Get(baseID)
+	if err != nil || current.ID != merged.ID {
+		t.Fatalf("Get(%s) = %v, %v; want the merged version", baseID, current, err)
+	}
+
+	// The merge survives a restart
+	store.Close()
+	store = openTestStore(t, dir, withMerging)
+	defer store.Close()
+
+	history, err := store.GetHistory(baseID)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("GetHistory = %d versions, %v; want 2", len(history), err)
+	}
+	if current, err := store.Get(baseID); err != nil || current.Content != merged.Content {
+		t.Errorf("Current version after reload = %+v, %v", current, err)
+	}
+}
+
+func TestKeywordOverlap(t *testing.T) {
+	if got := keywordOverlap([]string{"postgres", "pool"}, []string{"Postgres", "backup"}); got != 1.0/3 {
+		t.Errorf("keywordOverlap = %v, want 1/3", got)
+	}
+	if got := keywordOverlap(nil, []string{"postgres"}); got != 0 {
+		t.Errorf("keywordOverlap with no keywords = %v, want 0", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/metrics"
 	"mcp-memory-server/pkg/crypto"
 	"mcp-memory-server/pkg/keywords"
 	"mcp-memory-server/pkg/logger"
@@ -38,59 +40,112 @@ type Memory struct {
 	Version           int               `json:"version"`
 	PreviousVersionID string            `json:"previous_version_id,omitempty"`
 	IsCurrentVersion  bool              `json:"is_current_version"`
+	Links             []Link            `json:"links,omitempty"`      // outgoing links to other memories
+	Importance        float64           `json:"importance,omitempty"` // 0-1 as set by the caller; 0 means DefaultImportance
+	Pinned            bool              `json:"pinned,omitempty"`     // always part of the core context
+	Protected         bool              `json:"protected,omitempty"`  // deleted or evicted only with an explicit override
+	SiblingOf         string            `json:"sibling_of,omitempty"` // version this one conflicted with during sync, see SiblingID
 }
 
 // SearchQuery represents a search request
 type SearchQuery struct {
-	Query    string   `json:"query"`
-	Tags     []string `json:"tags,omitempty"`
-	Category string   `json:"category,omitempty"`
-	Limit    int      `json:"limit,omitempty"`
+	Query    string            `json:"query"` // query language, see ParseQuery
+	Tags     []string          `json:"tags,omitempty"`
+	Category string            `json:"category,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"` // see MetadataFilter
+	Limit    int               `json:"limit,omitempty"`    // default DefaultPageSize, at most MaxPageSize
+	Offset   int               `json:"offset,omitempty"`   // ignored when Cursor is set
+	Cursor   string            `json:"cursor,omitempty"`   // NextCursor from a previous page
+	Sort     SortField         `json:"sort,omitempty"`     // default relevance
+	Order    string            `json:"order,omitempty"`    // default desc
+	Match    *MatchOptions     `json:"match,omitempty"`    // default DefaultMatchOptions
 }
 
 // BulkDeleteOptions represents options for bulk memory deletion
 type BulkDeleteOptions struct {
-	Category   string    `json:"category,omitempty"`    // Filter by category
-	Tags       []string  `json:"tags,omitempty"`        // Filter by tags (memories must have at least one matching tag)
-	BeforeDate time.Time `json:"before_date,omitempty"` // Delete memories created before this date
-	Query      string    `json:"query,omitempty"`       // Filter by content/summary containing this text
-	Confirm    bool      `json:"confirm"`               // Must be true to execute deletion
+	Category   string            `json:"category,omitempty"`    // Filter by category
+	Tags       []string          `json:"tags,omitempty"`        // Filter by tags (memories must have at least one matching tag)
+	BeforeDate time.Time         `json:"before_date,omitempty"` // Delete memories created before this date
+	Query      string            `json:"query,omitempty"`       // Filter by content/summary containing this text
+	AfterDate  time.Time         `json:"after_date,omitempty"`  // Delete memories created after this date
+	MatchAllTags bool            `json:"match_all_tags"`        // Memories must have every tag rather than any
+	Metadata   map[string]string `json:"metadata,omitempty"`    // Filter by metadata values or ranges (see MetadataFilter)
+	MaxCount   int               `json:"max_count,omitempty"`   // Delete at most this many memories, oldest first (0 = no limit)
+	IncludeProtected bool        `json:"include_protected"`     // Also delete protected memories (override)
+	DryRun     bool              `json:"dry_run"`               // Report what would be trashed without deleting
+	Confirm    bool              `json:"confirm"`               // Must be true to execute deletion
+	ConfirmToken string          `json:"confirm_token"`         // Token from the dry run of the same deletion
 }
 
-// Store manages memory storage and retrieval
+// BulkDeleteResult reports what a bulk deletion did
+type BulkDeleteResult struct {
+	Deleted      int               `json:"deleted"`                 // memory files moved to the trash, counting every version
+	Memories     []BulkDeleteMatch `json:"memories"`                // matched memories, oldest first
+	IDs          []string          `json:"ids"`                     // IDs of every version trashed, or that a dry run would trash
+	Bytes        int64             `json:"bytes"`                   // storage reclaimed by deleting the matches
+	Protected    []string          `json:"protected,omitempty"`     // IDs of matching protected memories that were kept
+	Truncated    int               `json:"truncated,omitempty"`     // matches left out by MaxCount
+	DryRun       bool              `json:"dry_run,omitempty"`       // nothing was deleted
+	ConfirmToken string            `json:"confirm_token,omitempty"` // dry runs only: pass back to delete the same matches
+}
+
+// BulkDeleteMatch is a memory matched by a bulk deletion
+type BulkDeleteMatch struct {
+	BaseID   string `json:"base_id"`
+	Versions int    `json:"versions"` // stored versions, all deleted together
+	Bytes    int64  `json:"bytes"`
+}
+
+// Store manages memory storage and retrieval. Handles returned by As share
+// the store and differ only in who their operations are attributed to.
 type Store struct {
-	dataDir       string
-	config        *config.StorageConfig
-	logger        *logger.Logger
-	mu            sync.RWMutex
-	index          map[string]*Memory  // In-memory index for fast access
-	categoryIndex  map[string][]string // category -> memory IDs
-	tagIndex       map[string][]string // tag -> memory IDs
-	keywordIndex   map[string][]string // keyword -> memory IDs
-	totalSize      int64               // total storage size in bytes
-	memorySizes    map[string]int64    // memory ID -> file size
-	saveQueue      chan *Memory        // async save queue
-	wg             sync.WaitGroup      // wait group for worker goroutines
-	shutdownCh     chan struct{}       // shutdown signal channel
-	versionIndex   map[string][]string // base ID -> version IDs (ordered by version number)
-	crypto         *crypto.Crypto      // encryption handler
+	*storeState
+	caller Caller // who operations through this handle are attributed to
+}
+
+// storeState is what a store and all of its handles share
+type storeState struct {
+	*queryEngine                                      // index of memories and the queries over it
+	dataDir         string
+	totalSize       int64                             // total storage size in bytes
+	memorySizes     map[string]int64                  // memory ID -> file size
+	usageKeys       map[string]usageKey               // memory ID -> category and namespace its size is accounted under
+	categoryUsage   map[string]int64                  // category -> bytes
+	namespaceUsage  map[string]int64                  // namespace -> bytes
+	saveQueue       chan *Memory                      // async save queue
+	wg              sync.WaitGroup                    // wait group for worker goroutines
+	shutdownCh      chan struct{}                     // shutdown signal channel
+	shutdownOnce    sync.Once                         // closes shutdownCh once; sync-mode stores may be closed repeatedly
+	crypto          *crypto.Crypto                    // encryption handler
+	consolidationMu sync.Mutex                        // guards proposals and serializes applying them
+	proposals       map[string]*ConsolidationProposal // consolidation proposal ID -> proposal
+	trash           map[string]*TrashedMemory         // memory ID -> deleted memory awaiting purge
+	audit           *AuditLog                         // hash-chained log of operations; nil when disabled
+	events          *EventBus                         // change feed of memory mutations
+	writerLock      *writerLock                       // advisory lock on the data directory; nil when disabled
+	readOnly        error                             // why changes fail when another process holds the writer lock; nil when writable
+	journal         *journal                          // memory file changes for other processes to tail
+	journalTail     *journalTail                      // the writer's journal, followed while read-only
+	filesMu         sync.RWMutex                      // held shared by changes to memory files, exclusively while a git pull merges and rebuilds the index
+	git             *gitRepo                          // working tree of the memories directory; nil unless git storage is enabled
+	metricsKey      string                            // key of this store's collector in metrics.Default
 }
 
 // NewStore creates a new memory store
 func NewStore(dataDir string, cfg *config.StorageConfig, log *logger.Logger) (*Store, error) {
-	store := &Store{
-		dataDir:       dataDir,
-		config:        cfg,
-		logger:        log.WithComponent("memory_store"),
-		index:         make(map[string]*Memory),
-		categoryIndex: make(map[string][]string),
-		tagIndex:      make(map[string][]string),
-		keywordIndex:  make(map[string][]string),
-		memorySizes:   make(map[string]int64),
-		saveQueue:     make(chan *Memory, cfg.QueueSize), // Configurable queue size
-		shutdownCh:    make(chan struct{}),
-		versionIndex:  make(map[string][]string),
-	}
+	store := &Store{storeState: &storeState{
+		queryEngine: newQueryEngine(cfg, log.WithComponent("memory_store")),
+		dataDir:     dataDir,
+		memorySizes: make(map[string]int64),
+		usageKeys:   make(map[string]usageKey),
+		categoryUsage:  make(map[string]int64),
+		namespaceUsage: make(map[string]int64),
+		saveQueue:   make(chan *Memory, cfg.QueueSize), // Configurable queue size
+		shutdownCh:  make(chan struct{}),
+		proposals:   make(map[string]*ConsolidationProposal),
+		trash:       make(map[string]*TrashedMemory),
+		events:      NewEventBus(cfg.EventHistory),
+	}}
 
 	// Initialize encryption if enabled
 	if cfg.EnableEncryption {
@@ -102,23 +157,106 @@ func NewStore(dataDir string, cfg *config.StorageConfig, log *logger.Logger) (*S
 		log.Info("Encryption enabled", "key_path", cfg.EncryptionKeyPath)
 	}
 
-	// Start async save workers if enabled
-	if cfg.EnableAsync {
-		for i := 0; i < cfg.WorkerThreads; i++ {
-			store.wg.Add(1)
-			go store.saveWorker()
-		}
-	}
-
 	// Ensure directories exist
 	if err := store.ensureDirectories(); err != nil {
 		return nil, fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	// Claim the data directory and start a new change journal for readers.
+	// When another process is already writing, open read-only and follow
+	// its journal instead.
+	if cfg.WriterLock {
+		lock, err := acquireWriterLock(dataDir)
+		switch {
+		case errors.Is(err, ErrStoreLocked):
+			store.readOnly = fmt.Errorf("%w; memories are read-only in this process", err)
+			store.logger.WithError(err).Warn("Another process is writing to the data directory, opening it read-only")
+		case err != nil:
+			return nil, err
+		default:
+			store.writerLock = lock
+		}
+	}
+	if store.readOnly != nil {
+		store.journalTail = &journalTail{path: filepath.Join(dataDir, journalFile)}
+		store.journalTail.read() // start following from the end; the index is loaded below
+	} else {
+		journal, err := openJournal(dataDir, store.logger)
+		if err != nil {
+			store.releaseDataDir()
+			return nil, fmt.Errorf("failed to open change journal: %w", err)
+		}
+		store.journal = journal
+
+		// Open the audit log
+		if cfg.EnableAudit {
+			audit, err := newAuditLog(dataDir, store.crypto)
+			if err != nil {
+				store.releaseDataDir()
+				return nil, fmt.Errorf("failed to open audit log: %w", err)
+			}
+			store.audit = audit
+		}
+
+		// Resume event sequence numbers where the last run stopped
+		if err := store.loadEvents(); err != nil {
+			store.logger.WithError(err).Warn("Failed to load event history, starting a new change feed")
+		}
+	}
+
 	// Load existing memories into index
+	loadStart := time.Now()
 	if err := store.loadIndex(); err != nil {
+		store.releaseDataDir()
 		return nil, fmt.Errorf("failed to load memory index: %w", err)
 	}
+	loadDuration.Set(time.Since(loadStart).Seconds())
+
+	// Load consolidation proposals awaiting review
+	if err := store.loadProposals(); err != nil {
+		store.releaseDataDir()
+		return nil, fmt.Errorf("failed to load consolidation proposals: %w", err)
+	}
+
+	// Load deleted memories awaiting purge
+	if err := store.loadTrash(); err != nil {
+		store.releaseDataDir()
+		return nil, fmt.Errorf("failed to load trash: %w", err)
+	}
+
+	// Commit memory files to git as they change
+	if cfg.GitStorage && store.readOnly == nil {
+		if err := store.openGit(); err != nil {
+			store.releaseDataDir()
+			return nil, err
+		}
+	}
+
+	// Report queue depth and index sizes on /metrics
+	store.metricsKey = fmt.Sprintf("memory_store:%s:%d", dataDir, collectorSeq.Add(1))
+	metrics.Default.OnCollect(store.metricsKey, store.collectMetrics)
+
+	// Start background jobs last so a failed open has nothing to stop.
+	// They all change the data directory, which is the writer's job.
+	if store.readOnly != nil {
+		store.wg.Add(1)
+		go store.followJournal(DefaultWatchInterval)
+	} else {
+		if cfg.EnableAsync {
+			for i := 0; i < cfg.WorkerThreads; i++ {
+				store.wg.Add(1)
+				go store.saveWorker()
+			}
+		}
+		if cfg.TrashRetention > 0 {
+			store.wg.Add(1)
+			go store.trashWorker()
+		}
+		if cfg.ConsolidationInterval > 0 {
+			store.wg.Add(1)
+			go store.As(SystemCaller).consolidationWorker(cfg.ConsolidationInterval)
+		}
+	}
 
 	store.logger.Info("Memory store initialized",
 		"data_dir", dataDir,
@@ -130,13 +268,49 @@ func NewStore(dataDir string, cfg *config.StorageConfig, log *logger.Logger) (*S
 		"queue_size", cfg.QueueSize,
 		"compression_enabled", cfg.EnableCompression,
 		"compression_level", cfg.CompressionLevel,
-		"encryption_enabled", cfg.EnableEncryption)
+		"encryption_enabled", cfg.EnableEncryption,
+		"read_only", store.readOnly != nil)
 
 	return store, nil
 }
 
+// MemoryInput holds the caller-supplied fields of a memory to store
+type MemoryInput struct {
+	Content    string            `json:"content"`
+	Summary    string            `json:"summary,omitempty"`
+	Category   string            `json:"category,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Importance float64           `json:"importance,omitempty"` // 0-1; 0 keeps the previous version's or the default
+	Pinned     bool              `json:"pinned,omitempty"`     // new versions stay pinned regardless
+	Protected  bool              `json:"protected,omitempty"`  // new versions stay protected regardless
+}
+
 // Store saves a memory (fast synchronous path)
 func (s *Store) Store(content, summary, category string, tags []string, metadata map[string]string) (*Memory, error) {
+	result, err := s.Remember(MemoryInput{Content: content, Summary: summary, Category: category, Tags: tags, Metadata: metadata})
+	if err != nil {
+		return nil, err
+	}
+	return result.Memory, nil
+}
+
+// Remember saves a memory like Store and reports existing memories it
+// resembles. With AutoMergeDuplicates set, a near-duplicate is stored as a
+// new version of the memory it duplicates instead of a memory of its own.
+func (s *Store) Remember(input MemoryInput) (*StoreResult, error) {
+	defer operationDuration.ObserveSince(time.Now(), "remember")
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	content, summary, category, tags, metadata := input.Content, input.Summary, input.Category, input.Tags, input.Metadata
+	importance, err := ValidateImportance(input.Importance)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate base ID from content hash
 	baseID := s.generateID(content)
 	now := time.Now()
@@ -154,41 +328,51 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 	for _, kw := range extractedKeywords {
 		keywordList = append(keywordList, kw.Term)
 	}
+	signature := s.minHasher.Signature(s.shingleWords(content))
 
 	s.mu.Lock()
+	result := &StoreResult{}
+	result.Duplicates, result.Related = s.findSimilar(signature, keywordList, baseID)
+	
+	// Merge a near-duplicate into the memory it duplicates, unless the exact
+	// content is already stored and versions on its own
+	if s.config.AutoMergeDuplicates && len(result.Duplicates) > 0 {
+		if existing, exists := s.index[baseID]; !exists || !existing.IsCurrentVersion {
+			target := result.Duplicates[0].Memory
+			baseID = BaseID(target.ID)
+			result.MergedInto = baseID
+			category, tags, metadata = mergeInto(target, category, tags, metadata)
+			s.logger.Info("Merging near-duplicate memory", "into", baseID, "similarity", result.Duplicates[0].Similarity)
+		}
+	}
+	
 	// Check if memory already exists
 	var previousVersionID string
 	var version int = 1
+	var links []Link
+	pinned, protected := input.Pinned, input.Protected
 	
 	// Find the current version if it exists
-	if existing, exists := s.index[baseID]; exists && existing.IsCurrentVersion {
-		// Mark the existing version as not current
-		existing.IsCurrentVersion = false
+	existing, exists := s.index[baseID]
+	if exists && existing.IsCurrentVersion {
 		previousVersionID = existing.ID
 		version = existing.Version + 1
-		
-		// Save the updated existing memory (mark as not current)
-		if s.config.EnableAsync {
-			go func(mem *Memory) {
-				defer func() {
-					if r := recover(); r != nil {
-						s.logger.Warn("Save queue closed during shutdown, saving synchronously", "id", mem.ID)
-						s.saveMemoryAsync(mem)
-					}
-				}()
-				
-				select {
-				case s.saveQueue <- mem:
-				default:
-					s.logger.Warn("Save queue full, memory will be saved synchronously", "id", mem.ID)
-					s.saveMemoryAsync(mem)
-				}
-			}(existing)
-		} else {
-			s.saveMemoryToFile(existing)
+		// Links belong to the memory, not the version
+		links = append(links, existing.Links...)
+		if importance == 0 {
+			importance = existing.Importance
 		}
+		pinned = pinned || existing.Pinned
+		protected = protected || existing.Protected
+	} else {
+		existing = nil
 	}
 	
+	// Versions waiting in the trash keep their IDs so they can be restored
+	for s.versionTaken(baseID, version) {
+		version++
+	}
+
 	// Create versioned ID: baseID-vN
 	versionedID := fmt.Sprintf("%s-v%d", baseID, version)
 	
@@ -207,6 +391,51 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 		Version:           version,
 		PreviousVersionID: previousVersionID,
 		IsCurrentVersion:  true,
+		Links:             links,
+		Importance:        importance,
+		Pinned:            pinned,
+		Protected:         protected,
+	}
+
+	// Under the reject policy a category or namespace takes no memory that
+	// would push it over its quota
+	if s.config.QuotaPolicy == config.QuotaPolicyReject {
+		_, fileData, err := s.encodeMemory(memory)
+		if err == nil {
+			err = s.checkQuota(category, metadata[NamespaceKey], int64(len(fileData)))
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+	
+	if existing != nil {
+		// Mark the existing version as not current
+		existing.IsCurrentVersion = false
+		
+		// Save the updated existing memory (mark as not current)
+		if s.config.EnableAsync {
+			go func(mem *Memory) {
+				defer func() {
+					if r := recover(); r != nil {
+						s.logger.Warn("Save queue closed during shutdown, saving synchronously", "id", mem.ID)
+						saveQueueDrops.Inc()
+						s.saveMemoryAsync(mem)
+					}
+				}()
+				
+				select {
+				case s.saveQueue <- mem:
+				default:
+					s.logger.Warn("Save queue full, memory will be saved synchronously", "id", mem.ID)
+					saveQueueDrops.Inc()
+					s.saveMemoryAsync(mem)
+				}
+			}(existing)
+		} else {
+			s.saveMemoryToFile(existing)
+		}
 	}
 	
 	if version == 1 {
@@ -224,6 +453,11 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 	s.versionIndex[baseID] = append(s.versionIndex[baseID], versionedID)
 	
 	s.updateIndices(memory)
+	if version == 1 {
+		s.publishEvent(EventCreated, memory)
+	} else {
+		s.publishEvent(EventVersioned, memory)
+	}
 	s.mu.Unlock()
 
 	// Save to file based on async configuration
@@ -234,6 +468,7 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 			defer func() {
 				if r := recover(); r != nil {
 					s.logger.Warn("Save queue closed during shutdown, saving synchronously", "id", memory.ID)
+					saveQueueDrops.Inc()
 					s.saveMemoryAsync(memory)
 				}
 			}()
@@ -244,6 +479,7 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 			default:
 				// Queue is full, log warning but don't block
 				s.logger.Warn("Save queue full, memory will be saved synchronously", "id", memory.ID)
+				saveQueueDrops.Inc()
 				// Save synchronously in current goroutine
 				s.saveMemoryAsync(memory)
 			}
@@ -257,25 +493,26 @@ func (s *Store) Store(content, summary, category string, tags []string, metadata
 		
 		// Update storage tracking
 		s.mu.Lock()
-		oldSize := s.memorySizes[memory.ID]
-		s.totalSize = s.totalSize - oldSize + fileSize
-		s.memorySizes[memory.ID] = fileSize
-		needsCleanup := s.totalSize > s.config.MaxStorageSize
+		s.setMemorySize(memory, fileSize)
 		s.mu.Unlock()
 		
-		// Clean up if over limit
-		if needsCleanup {
-			if err := s.cleanupOldMemories(); err != nil {
-				s.logger.WithError(err).Warn("Failed to cleanup old memories")
-			}
-		}
+		// Clean up if over a limit
+		s.enforceLimits(memory)
 	}
 
-	return memory, nil
+	detail := fmt.Sprintf("version %d", version)
+	if result.MergedInto != "" {
+		detail += ", merged near-duplicate"
+	}
+	s.recordAudit(AuditStore, []string{memory.ID}, detail)
+
+	result.Memory = memory
+	return result, nil
 }
 
 // Get retrieves a memory by ID (returns current version if base ID is provided)
 func (s *Store) Get(id string) (*Memory, error) {
+	defer operationDuration.ObserveSince(time.Now(), "get")
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -288,186 +525,230 @@ func (s *Store) Get(id string) (*Memory, error) {
 	memory.AccessCount++
 	memory.LastAccess = time.Now()
 
-	// Save updated stats
-	if _, err := s.saveMemoryToFile(memory); err != nil {
-		s.logger.WithError(err).Warn("Failed to update memory access stats")
+	// Save updated stats; a read-only store keeps them in memory only
+	if s.readOnly == nil {
+		if _, err := s.saveMemoryToFile(memory); err != nil {
+			s.logger.WithError(err).Warn("Failed to update memory access stats")
+		}
 	}
 
+	s.recordAudit(AuditAccess, []string{memory.ID}, "get")
 	s.logger.Debug("Retrieved memory", "id", id, "version", memory.Version, "access_count", memory.AccessCount)
 	return memory, nil
 }
 
-// Search searches for memories based on query
+// Search searches for memories based on query, recording the access
 func (s *Store) Search(query *SearchQuery) ([]*Memory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	page, err := s.SearchPage(query)
+	if err != nil {
+		return nil, err
+	}
+	return page.Memories, nil
+}
 
-	type scoredMemory struct {
-		memory *Memory
-		score  float64
+// SearchPage searches for memories and returns one ordered page of
+// results, recording the access in the audit log
+func (s *Store) SearchPage(query *SearchQuery) (*Page, error) {
+	page, err := s.queryEngine.SearchPage(query)
+	if err != nil {
+		return nil, err
 	}
+	s.recordAudit(AuditAccess, pageIDs(page), "search")
+	return page, nil
+}
 
-	var results []scoredMemory
-	queryLower := strings.ToLower(query.Query)
+// SearchPage searches for memories and returns one ordered page of results
+func (s *queryEngine) SearchPage(query *SearchQuery) (*Page, error) {
+	defer operationDuration.ObserveSince(time.Now(), "search")
+	sortField, err := ParseSortField(string(query.Sort), SortByRelevance)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+	order, err := ParseSortOrder(query.Order)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+	if err := checkOffset(query.Offset); err != nil {
+		return nil, err
+	}
+
+	expr, err := ParseQuery(query.Query)
+	if err != nil {
+		return nil, err
+	}
+	structured, err := structuredFilter(query.Category, query.Tags, query.Metadata)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+	filter := combineFilters(expr, structured)
+	rankText := strings.ToLower(rankingText(expr))
+	matchOpts := DefaultMatchOptions()
+	if query.Match != nil {
+		matchOpts = *query.Match
+	}
 
-	// Use indices for faster search if category or tags are specified
+	s.mu.RLock()
+
+	// Resolve free-text words to memories matching them by stem or with typos
+	termScores := s.resolveTerms(filter, matchOpts)
+
+	// Narrow the search with the category, tag and keyword indices where the
+	// query allows it, then check every candidate against the full expression
 	var candidateIDs map[string]bool
-	if query.Category != "" {
-		candidateIDs = make(map[string]bool)
-		for _, id := range s.categoryIndex[query.Category] {
-			candidateIDs[id] = true
-		}
+	if filter != nil {
+		ix := s.queryIndexes()
+		candidateIDs, _ = ix.candidates(filter)
 	}
 
-	if len(query.Tags) > 0 {
-		tagCandidates := make(map[string]bool)
-		for _, tag := range query.Tags {
-			for _, id := range s.tagIndex[strings.ToLower(tag)] {
-				tagCandidates[id] = true
-			}
+	// The index holds each current memory under both its base and versioned
+	// ID, so skip the second copy. Matches are copied so they can be scored
+	// and sorted without the lock while Get updates access counts in place.
+	var matched []*Memory
+	seen := make(map[string]bool)
+	consider := func(memory *Memory) {
+		if seen[memory.ID] {
+			return
 		}
-		if candidateIDs != nil {
-			// Intersection of category and tag candidates
-			for id := range candidateIDs {
-				if !tagCandidates[id] {
-					delete(candidateIDs, id)
-				}
-			}
-		} else {
-			candidateIDs = tagCandidates
+		seen[memory.ID] = true
+		if filter != nil && !filter.Match(memory) {
+			return
 		}
+		matched = append(matched, memory)
 	}
-	
-	// Check if query terms match any keywords for faster lookup
-	queryWords := strings.Fields(queryLower)
-	keywordCandidates := make(map[string]bool)
-	for _, word := range queryWords {
-		// Check exact keyword matches
-		for _, id := range s.keywordIndex[word] {
-			keywordCandidates[id] = true
-		}
-		// Check partial keyword matches
-		for keyword, ids := range s.keywordIndex {
-			if strings.Contains(keyword, word) || strings.Contains(word, keyword) {
-				for _, id := range ids {
-					keywordCandidates[id] = true
-				}
+
+	if candidateIDs != nil {
+		for id := range candidateIDs {
+			if memory, exists := s.index[id]; exists {
+				consider(memory)
 			}
 		}
-	}
-	
-	// If we have keyword matches, include them in candidates
-	if len(keywordCandidates) > 0 {
-		if candidateIDs != nil {
-			// Union with existing candidates
-			for id := range keywordCandidates {
-				candidateIDs[id] = true
-			}
-		} else {
-			candidateIDs = keywordCandidates
+	} else {
+		for _, memory := range s.index {
+			consider(memory)
 		}
 	}
-
-	// Search through candidates or all memories
-	for id, memory := range s.index {
-		if candidateIDs != nil && !candidateIDs[id] {
-			continue
-		}
-		score := s.calculateRelevanceScore(memory, query, queryLower)
-		if score > 0 {
-			results = append(results, scoredMemory{memory: memory, score: score})
-		}
+	snapshots := make([]Memory, len(matched))
+	for i, memory := range matched {
+		snapshots[i] = *memory
+		matched[i] = &snapshots[i]
 	}
+	totalMemories := len(s.index)
+	s.mu.RUnlock()
 
-	// Sort by relevance score
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].score > results[j].score
-	})
+	// Rank with an hourly clock so relevance, and with it the cursor of a
+	// relevance-sorted page, stays stable while a client pages through
+	rankedAt := time.Now().Truncate(time.Hour)
+
+	results := make([]scoredMemory, 0, len(matched))
+	for _, memory := range matched {
+		score := s.calculateRelevanceScore(memory, query, rankText, rankedAt) + termScores[memory.ID]
+		results = append(results, scoredMemory{memory: memory, score: score})
+	}
 
 	limit := query.Limit
-	if limit == 0 || limit > 50 {
-		limit = 20 // Default limit
+	if limit <= 0 {
+		limit = DefaultPageSize
 	}
 
-	var memories []*Memory
-	for i, result := range results {
-		if i >= limit {
-			break
-		}
-		memories = append(memories, result.memory)
+	page, err := paginate(results, sortField, order, limit, query.Offset, query.Cursor,
+		filterFingerprint(query.Query, query.Category, query.Tags, query.Metadata))
+	if err != nil {
+		return nil, err
 	}
 
 	s.logger.Info("Search completed",
 		"query", query.Query,
-		"results", len(memories),
-		"total_memories", len(s.index))
-
-	return memories, nil
+		"results", len(page.Memories),
+		"matches", page.Total,
+		"total_memories", totalMemories)
+	return page, nil
 }
 
-// List lists all memories with optional filtering
+// List lists all memories with optional filtering, newest first, recording the access
 func (s *Store) List(category string, tags []string, limit int) ([]*Memory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	page, err := s.ListPage(&ListOptions{Category: category, Tags: tags, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return page.Memories, nil
+}
 
-	var results []*Memory
+// ListPage lists memories with optional filtering and returns one ordered
+// page, recording the access in the audit log
+func (s *Store) ListPage(opts *ListOptions) (*Page, error) {
+	page, err := s.queryEngine.ListPage(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.recordAudit(AuditAccess, pageIDs(page), "list")
+	return page, nil
+}
 
-	// Use indices for faster filtering
-	var candidateIDs map[string]bool
-	if category != "" {
-		candidateIDs = make(map[string]bool)
-		for _, id := range s.categoryIndex[category] {
-			candidateIDs[id] = true
-		}
+// ListPage lists memories with optional filtering and returns one ordered page
+func (s *queryEngine) ListPage(opts *ListOptions) (*Page, error) {
+	defer operationDuration.ObserveSince(time.Now(), "list")
+	sortField, err := ParseSortField(string(opts.Sort), SortByCreated)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+	if sortField == SortByRelevance {
+		return nil, &optionsError{fmt.Errorf("relevance sort requires a search query")}
+	}
+	order, err := ParseSortOrder(opts.Order)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+	if err := checkOffset(opts.Offset); err != nil {
+		return nil, err
 	}
 
-	if len(tags) > 0 {
-		tagCandidates := make(map[string]bool)
-		for _, tag := range tags {
-			for _, id := range s.tagIndex[strings.ToLower(tag)] {
-				tagCandidates[id] = true
-			}
+	filter, err := structuredFilter(opts.Category, opts.Tags, opts.Metadata)
+	if err != nil {
+		return nil, &optionsError{err}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []scoredMemory
+	seen := make(map[string]bool)
+	collect := func(memory *Memory) {
+		if seen[memory.ID] {
+			return
 		}
-		if candidateIDs != nil {
-			// Intersection
-			for id := range candidateIDs {
-				if !tagCandidates[id] {
-					delete(candidateIDs, id)
-				}
-			}
-		} else {
-			candidateIDs = tagCandidates
+		seen[memory.ID] = true
+		if filter == nil || filter.Match(memory) {
+			results = append(results, scoredMemory{memory: memory})
 		}
 	}
 
+	// Use indices for faster filtering
+	var candidateIDs map[string]bool
+	if filter != nil {
+		ix := s.queryIndexes()
+		candidateIDs, _ = ix.candidates(filter)
+	}
+
 	// Collect results
 	if candidateIDs != nil {
 		for id := range candidateIDs {
 			if memory, exists := s.index[id]; exists {
-				results = append(results, memory)
+				collect(memory)
 			}
 		}
 	} else {
 		// No filters, return all
 		for _, memory := range s.index {
-			results = append(results, memory)
+			collect(memory)
 		}
 	}
 
-	// Sort by creation time (newest first)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].CreatedAt.After(results[j].CreatedAt)
-	})
-
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
-	}
-
-	return results, nil
+	return paginate(results, sortField, order, opts.Limit, opts.Offset, opts.Cursor,
+		filterFingerprint("", opts.Category, opts.Tags, opts.Metadata))
 }
 
 // GetByKeyword retrieves memories that contain a specific keyword
-func (s *Store) GetByKeyword(keyword string, limit int) ([]*Memory, error) {
+func (s *queryEngine) GetByKeyword(keyword string, limit int) ([]*Memory, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
@@ -498,19 +779,15 @@ func (s *Store) GetByKeyword(keyword string, limit int) ([]*Memory, error) {
 }
 
 // GetTopKeywords returns the most frequently used keywords
-func (s *Store) GetTopKeywords(limit int) []struct {
-	Keyword string
-	Count   int
-} {
+func (s *queryEngine) GetTopKeywords(limit int) []KeywordCount {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	
-	type keywordCount struct {
-		keyword string
-		count   int
-	}
-	
-	var counts []keywordCount
+	return s.topKeywords(limit)
+}
+
+// topKeywords counts keyword use over current versions. The caller must hold s.mu.
+func (s *queryEngine) topKeywords(limit int) []KeywordCount {
+	var counts []KeywordCount
 	for keyword, ids := range s.keywordIndex {
 		// Count only current versions
 		currentCount := 0
@@ -520,34 +797,23 @@ func (s *Store) GetTopKeywords(limit int) []struct {
 			}
 		}
 		if currentCount > 0 {
-			counts = append(counts, keywordCount{keyword: keyword, count: currentCount})
+			counts = append(counts, KeywordCount{Keyword: keyword, Count: currentCount})
 		}
 	}
 	
 	// Sort by count
 	sort.Slice(counts, func(i, j int) bool {
-		return counts[i].count > counts[j].count
+		return counts[i].Count > counts[j].Count
 	})
 	
 	if limit > 0 && len(counts) > limit {
 		counts = counts[:limit]
 	}
-	
-	result := make([]struct {
-		Keyword string
-		Count   int
-	}, len(counts))
-	
-	for i, kc := range counts {
-		result[i].Keyword = kc.keyword
-		result[i].Count = kc.count
-	}
-	
-	return result
+	return counts
 }
 
 // GetHistory retrieves all versions of a memory
-func (s *Store) GetHistory(baseID string) ([]*Memory, error) {
+func (s *queryEngine) GetHistory(baseID string) ([]*Memory, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -579,192 +845,128 @@ func (s *Store) GetHistory(baseID string) ([]*Memory, error) {
 
 // Delete removes a memory
 func (s *Store) Delete(id string) error {
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	return s.deleteMemory(id, false, AuditDelete, "")
+}
+
+// ForceDelete deletes a memory even if it is protected
+func (s *Store) ForceDelete(id string) error {
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	return s.deleteMemory(id, true, AuditDelete, "")
+}
+
+// deleteMemory deletes a memory and records action in the audit log;
+// evictions are attributed to the store itself, with reason as the detail
+func (s *Store) deleteMemory(id string, override bool, action, reason string) error {
+	defer operationDuration.ObserveSince(time.Now(), "delete")
+	if s.readOnly != nil {
+		return s.readOnly
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.index[id]; !exists {
+	memory, exists := s.index[id]
+	if !exists {
 		return fmt.Errorf("memory not found: %s", id)
 	}
-
-	// Remove file
-	filename := fmt.Sprintf("%s.json", id)
-	if s.config.EnableCompression {
-		filename = fmt.Sprintf("%s.json.gz", id)
-	}
-	filepath := filepath.Join(s.dataDir, "memories", filename)
-	if err := os.Remove(filepath); err != nil {
-		return fmt.Errorf("failed to remove memory file: %w", err)
+	if !override && s.isProtected(id) {
+		return fmt.Errorf("cannot delete %s: %w", id, ErrProtected)
 	}
 
-	// Get memory before removing
-	memory := s.index[id]
-
-	// Update storage size
-	s.totalSize -= s.memorySizes[id]
-	delete(s.memorySizes, id)
+	// A base ID deletes the version it points to
+	if err := s.removeMemory(memory); err != nil {
+		return err
+	}
 
-	// Remove from indices
-	s.removeFromIndices(memory)
-	delete(s.index, id)
+	detail := ""
+	if override && memory.Protected {
+		detail = "protection overridden"
+	}
+	if action == AuditEvict {
+		s.recordAuditAs(SystemCaller, action, []string{memory.ID}, reason)
+		s.publishEvent(EventEvicted, memory)
+	} else {
+		s.recordAudit(action, []string{memory.ID}, detail)
+		s.publishEvent(EventDeleted, memory)
+	}
 
-	s.logger.Info("Memory deleted", "id", id)
+	s.logger.Info("Memory deleted", "id", memory.ID, "trashed", s.TrashEnabled())
 	return nil
 }
 
-// BulkDelete deletes multiple memories based on the provided options
-func (s *Store) BulkDelete(options *BulkDeleteOptions) (int, error) {
+// BulkDelete deletes multiple memories based on the provided options. A dry
+// run reports the matches with a confirmation token, and the deletion itself
+// must echo that token so it removes exactly what was previewed.
+func (s *Store) BulkDelete(options *BulkDeleteOptions) (*BulkDeleteResult, error) {
+	defer operationDuration.ObserveSince(time.Now(), "bulk_delete")
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
 	// Validate options - require at least one filter
-	if !options.Confirm {
-		return 0, fmt.Errorf("confirmation required: set confirm to true")
+	if !options.Confirm && !options.DryRun {
+		return nil, fmt.Errorf("confirmation required: set confirm to true")
 	}
 
-	if options.Category == "" && len(options.Tags) == 0 && options.BeforeDate.IsZero() && options.Query == "" {
-		return 0, fmt.Errorf("at least one filter (category, tags, beforeDate, or query) must be specified")
+	if options.Category == "" && len(options.Tags) == 0 && options.BeforeDate.IsZero() && options.AfterDate.IsZero() && options.Query == "" && len(options.Metadata) == 0 {
+		return nil, fmt.Errorf("at least one filter (category, tags, beforeDate, afterDate, query, or metadata) must be specified")
 	}
 
-	s.mu.Lock()
-	// First, collect all memories that match the criteria
-	var toDelete []string
-	queryLower := strings.ToLower(options.Query)
-	
-	// Track base IDs that have been matched to ensure we delete all versions
-	baseIDsToDelete := make(map[string]bool)
+	if !options.BeforeDate.IsZero() && !options.AfterDate.IsZero() && !options.AfterDate.Before(options.BeforeDate) {
+		return nil, fmt.Errorf("afterDate must be earlier than beforeDate")
+	}
 
-	for id, memory := range s.index {
-		// Skip non-current versions initially, we'll handle them via baseIDsToDelete
-		if !memory.IsCurrentVersion && !strings.Contains(id, "-v") {
-			continue
-		}
-		
-		matches := true
+	if options.MaxCount < 0 {
+		return nil, fmt.Errorf("maxCount cannot be negative, got %d", options.MaxCount)
+	}
 
-		// Filter by category
-		if options.Category != "" && memory.Category != options.Category {
-			matches = false
-		}
+	metadataFilter, err := MetadataFilter(options.Metadata)
+	if err != nil {
+		return nil, err
+	}
 
-		// Filter by tags (must have at least one matching tag)
-		if matches && len(options.Tags) > 0 {
-			hasMatchingTag := false
-			for _, filterTag := range options.Tags {
-				for _, memoryTag := range memory.Tags {
-					if strings.EqualFold(memoryTag, filterTag) {
-						hasMatchingTag = true
-						break
-					}
-				}
-				if hasMatchingTag {
-					break
-				}
-			}
-			if !hasMatchingTag {
-				matches = false
-			}
-		}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		// Filter by date
-		if matches && !options.BeforeDate.IsZero() && !memory.CreatedAt.Before(options.BeforeDate) {
-			matches = false
-		}
-
-		// Filter by query content
-		if matches && options.Query != "" {
-			contentMatches := strings.Contains(strings.ToLower(memory.Content), queryLower)
-			summaryMatches := memory.Summary != "" && strings.Contains(strings.ToLower(memory.Summary), queryLower)
-			if !contentMatches && !summaryMatches {
-				matches = false
-			}
-		}
-
-		if matches {
-			// Extract base ID
-			baseID := id
-			if idx := strings.LastIndex(id, "-v"); idx != -1 {
-				baseID = id[:idx]
-			}
-			baseIDsToDelete[baseID] = true
-		}
-	}
-	
-	// Now collect all versions of matched memories
-	for baseID := range baseIDsToDelete {
-		// Add all versions from the version index
-		if versionIDs, exists := s.versionIndex[baseID]; exists {
-			toDelete = append(toDelete, versionIDs...)
-		}
-		// Also add the base ID reference if it exists
-		if _, exists := s.index[baseID]; exists {
-			// Don't add duplicates
-			alreadyAdded := false
-			for _, id := range toDelete {
-				if id == baseID {
-					alreadyAdded = true
-					break
-				}
-			}
-			if !alreadyAdded {
-				toDelete = append(toDelete, baseID)
-			}
+	result, baseIDs := s.matchBulkDelete(options, metadataFilter)
+	result.ConfirmToken = bulkDeleteToken(options, result.IDs)
+	if options.DryRun {
+		result.DryRun = true
+		return result, nil
+	}
+	if options.ConfirmToken != result.ConfirmToken {
+		if options.ConfirmToken == "" {
+			return nil, fmt.Errorf("confirmation token required: run a dry run first and pass its token")
 		}
+		return nil, fmt.Errorf("confirmation token does not match: the matching memories changed since the dry run, run it again")
 	}
-	s.mu.Unlock()
+	result.ConfirmToken = ""
 
-	// Delete the memories
+	// Move every version of the matched memories to the trash
 	deletedCount := 0
 	var errors []string
 	
-	for _, id := range toDelete {
-		// Skip if this is a base ID that points to a versioned memory (not an actual file)
-		s.mu.RLock()
-		memory, exists := s.index[id]
-		s.mu.RUnlock()
-		
-		if !exists {
-			continue
-		}
-		
-		// Skip base ID entries that are just references
-		if !strings.Contains(id, "-v") && memory.Version > 0 {
-			// This is just a reference to the current version, not an actual memory file
-			s.mu.Lock()
-			delete(s.index, id)
-			s.mu.Unlock()
-			continue
-		}
-
-		// Try to delete the actual memory file
-		filename := fmt.Sprintf("%s.json", id)
-		if s.config.EnableCompression {
-			filename = fmt.Sprintf("%s.json.gz", id)
-		}
-		filepath := filepath.Join(s.dataDir, "memories", filename)
-		
-		// Check if file exists before trying to remove it
-		if _, err := os.Stat(filepath); err == nil {
-			if err := os.Remove(filepath); err != nil {
+	for _, baseID := range baseIDs {
+		for _, id := range s.memoryVersions(baseID) {
+			memory, exists := s.index[id]
+			if !exists {
+				continue
+			}
+			if err := s.removeMemory(memory); err != nil {
 				errors = append(errors, fmt.Sprintf("failed to delete %s: %v", id, err))
 				continue
 			}
-		}
-
-		// Update indices
-		s.mu.Lock()
-		if memory, exists := s.index[id]; exists {
-			s.totalSize -= s.memorySizes[id]
-			delete(s.memorySizes, id)
-			s.removeFromIndices(memory)
-			delete(s.index, id)
+			s.publishEvent(EventDeleted, memory)
 			deletedCount++
 		}
-		s.mu.Unlock()
-	}
-
-	// Clean up version index for deleted base IDs
-	s.mu.Lock()
-	for baseID := range baseIDsToDelete {
-		delete(s.versionIndex, baseID)
+		// Drop a stale base ID reference left without versions
+		if memory, exists := s.index[baseID]; exists && len(s.versionIndex[baseID]) == 0 && memory.ID != baseID {
+			delete(s.index, baseID)
+		}
 	}
-	s.mu.Unlock()
 
 	if len(errors) > 0 {
 		s.logger.Warn("Some memories could not be deleted", 
@@ -775,22 +977,200 @@ func (s *Store) BulkDelete(options *BulkDeleteOptions) (int, error) {
 
 	s.logger.Info("Bulk delete completed", 
 		"deleted_count", deletedCount,
+		"bytes_reclaimed", result.Bytes,
+		"protected_skipped", len(result.Protected),
 		"filters", map[string]interface{}{
 			"category": options.Category,
 			"tags": options.Tags,
+			"match_all_tags": options.MatchAllTags,
 			"before_date": options.BeforeDate,
+			"after_date": options.AfterDate,
 			"query": options.Query,
+			"metadata": options.Metadata,
+			"max_count": options.MaxCount,
 		})
 
-	return deletedCount, nil
+	var deletedIDs []string
+	for _, id := range result.IDs {
+		if _, exists := s.index[id]; !exists {
+			deletedIDs = append(deletedIDs, id)
+		}
+	}
+	s.recordAudit(AuditBulkDelete, deletedIDs, fmt.Sprintf("%d versions, %d bytes", deletedCount, result.Bytes))
+
+	result.Deleted = deletedCount
+	return result, nil
+}
+
+// matchBulkDelete finds the memories a bulk deletion removes, oldest first
+// and capped at MaxCount, and returns their base IDs. A memory matches when
+// any of its versions does. The caller must hold s.mu.
+func (s *Store) matchBulkDelete(options *BulkDeleteOptions, metadataFilter Expr) (*BulkDeleteResult, []string) {
+	result := &BulkDeleteResult{IDs: []string{}}
+	queryLower := strings.ToLower(options.Query)
+	matched := make(map[string]bool)
+	protected := make(map[string]bool)
+
+	for id, memory := range s.index {
+		// Base IDs reference a version that is visited on its own
+		if memory.ID != id {
+			continue
+		}
+
+		// Filter by category
+		if options.Category != "" && memory.Category != options.Category {
+			continue
+		}
+
+		// Filter by tags (any or all of them)
+		if len(options.Tags) > 0 && !matchTags(memory.Tags, options.Tags, options.MatchAllTags) {
+			continue
+		}
+
+		// Filter by date
+		if !options.BeforeDate.IsZero() && !memory.CreatedAt.Before(options.BeforeDate) {
+			continue
+		}
+		if !options.AfterDate.IsZero() && !memory.CreatedAt.After(options.AfterDate) {
+			continue
+		}
+
+		// Filter by query content
+		if options.Query != "" {
+			contentMatches := strings.Contains(strings.ToLower(memory.Content), queryLower)
+			summaryMatches := memory.Summary != "" && strings.Contains(strings.ToLower(memory.Summary), queryLower)
+			if !contentMatches && !summaryMatches {
+				continue
+			}
+		}
+
+		// Filter by metadata
+		if metadataFilter != nil && !metadataFilter.Match(memory) {
+			continue
+		}
+
+		// Keep protected memories, and all their versions, unless overridden
+		baseID := BaseID(id)
+		if !options.IncludeProtected && s.isProtected(baseID) {
+			protected[baseID] = true
+			continue
+		}
+		matched[baseID] = true
+	}
+
+	for baseID := range protected {
+		result.Protected = append(result.Protected, baseID)
+	}
+	sort.Strings(result.Protected)
+
+	// Oldest memories first, so MaxCount keeps the oldest matches
+	created := make(map[string]time.Time, len(matched))
+	baseIDs := make([]string, 0, len(matched))
+	for baseID := range matched {
+		for _, id := range s.memoryVersions(baseID) {
+			if memory, exists := s.index[id]; exists && (created[baseID].IsZero() || memory.CreatedAt.Before(created[baseID])) {
+				created[baseID] = memory.CreatedAt
+			}
+		}
+		baseIDs = append(baseIDs, baseID)
+	}
+	sort.Slice(baseIDs, func(i, j int) bool {
+		if !created[baseIDs[i]].Equal(created[baseIDs[j]]) {
+			return created[baseIDs[i]].Before(created[baseIDs[j]])
+		}
+		return baseIDs[i] < baseIDs[j]
+	})
+	if options.MaxCount > 0 && len(baseIDs) > options.MaxCount {
+		result.Truncated = len(baseIDs) - options.MaxCount
+		baseIDs = baseIDs[:options.MaxCount]
+	}
+
+	for _, baseID := range baseIDs {
+		match := BulkDeleteMatch{BaseID: baseID}
+		for _, id := range s.memoryVersions(baseID) {
+			if _, exists := s.index[id]; exists {
+				match.Versions++
+				match.Bytes += s.memorySizes[id]
+				result.IDs = append(result.IDs, id)
+			}
+		}
+		result.Bytes += match.Bytes
+		result.Memories = append(result.Memories, match)
+	}
+	sort.Strings(result.IDs)
+	return result, baseIDs
+}
+
+// memoryVersions returns the IDs of every stored version of a memory,
+// including unversioned memories stored under their base ID. The caller
+// must hold s.mu.
+func (s *Store) memoryVersions(baseID string) []string {
+	if versions := s.versionIndex[baseID]; len(versions) > 0 {
+		return append([]string(nil), versions...)
+	}
+	if memory, exists := s.index[baseID]; exists && memory.ID == baseID {
+		return []string{baseID}
+	}
+	return nil
+}
+
+// matchTags reports whether memoryTags contain any, or with all set every,
+// filter tag, ignoring case
+func matchTags(memoryTags, filterTags []string, all bool) bool {
+	for _, filterTag := range filterTags {
+		found := false
+		for _, memoryTag := range memoryTags {
+			if strings.EqualFold(memoryTag, filterTag) {
+				found = true
+				break
+			}
+		}
+		if found && !all {
+			return true
+		}
+		if !found && all {
+			return false
+		}
+	}
+	return all
+}
+
+// bulkDeleteToken derives the confirmation token of a bulk deletion from its
+// filters and the memory versions it matches
+func bulkDeleteToken(options *BulkDeleteOptions, ids []string) string {
+	data, _ := json.Marshal(struct {
+		Category         string            `json:"category"`
+		Tags             []string          `json:"tags"`
+		MatchAllTags     bool              `json:"match_all_tags"`
+		BeforeDate       time.Time         `json:"before_date"`
+		AfterDate        time.Time         `json:"after_date"`
+		Query            string            `json:"query"`
+		Metadata         map[string]string `json:"metadata"`
+		IncludeProtected bool              `json:"include_protected"`
+		MaxCount         int               `json:"max_count"`
+		IDs              []string          `json:"ids"`
+	}{options.Category, options.Tags, options.MatchAllTags, options.BeforeDate.UTC(), options.AfterDate.UTC(),
+		options.Query, options.Metadata, options.IncludeProtected, options.MaxCount, ids})
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:16]
 }
 
 // Close gracefully shuts down the store
 func (s *Store) Close() error {
 	s.logger.Info("Closing memory store")
+
+	// Stop reporting on /metrics so the registry lets go of this store;
+	// other stores open in the process keep their collectors
+	metrics.Default.OnCollect(s.metricsKey, nil)
 	
 	// Only proceed with shutdown if async is enabled
 	if !s.config.EnableAsync {
+		// Stop background jobs
+		s.shutdownOnce.Do(func() { close(s.shutdownCh) })
+		s.wg.Wait()
+		s.closeEvents()
+		s.closeAudit()
+		s.releaseDataDir()
 		s.logger.Info("Memory store closed (sync mode)")
 		return nil
 	}
@@ -824,6 +1204,9 @@ func (s *Store) Close() error {
 		s.logger.Warn("Timeout waiting for save workers to complete")
 		return fmt.Errorf("timeout waiting for workers to complete")
 	}
+	s.closeEvents()
+	s.closeAudit()
+	s.releaseDataDir()
 	
 	s.logger.Info("Memory store closed successfully")
 	return nil
@@ -834,37 +1217,39 @@ func (s *Store) GetStats() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	categories := make(map[string]int)
-	totalAccess := 0
-	totalKeywords := 0
-	uniqueKeywords := len(s.keywordIndex)
+	stats := s.stats()
+	stats["data_directory"] = s.dataDir
+	stats["read_only"] = s.readOnly != nil
+	stats["total_size"] = s.totalSize
+	stats["max_storage_size"] = s.config.MaxStorageSize
+	stats["storage_used_pct"] = float64(s.totalSize) / float64(s.config.MaxStorageSize) * 100
+	stats["trash_count"] = len(s.trash)
+	usage := s.usage(s.memorySizes)
+	usage["quotas"] = s.quotaUsage()
+	usage["quota_policy"] = s.config.QuotaPolicy
+	stats["usage"] = usage
+	return stats
+}
 
-	for _, memory := range s.index {
-		if memory.Category != "" {
-			categories[memory.Category]++
-		}
-		totalAccess += memory.AccessCount
-		totalKeywords += len(memory.Keywords)
-	}
-	
-	// Get top 10 keywords
-	topKeywords := s.GetTopKeywords(10)
+// Helper methods
 
-	return map[string]interface{}{
-		"total_memories":     len(s.index),
-		"categories":         categories,
-		"total_access_count": totalAccess,
-		"data_directory":     s.dataDir,
-		"total_size":         s.totalSize,
-		"max_storage_size":   s.config.MaxStorageSize,
-		"storage_used_pct":   float64(s.totalSize) / float64(s.config.MaxStorageSize) * 100,
-		"total_keywords":     totalKeywords,
-		"unique_keywords":    uniqueKeywords,
-		"top_keywords":       topKeywords,
+// closeAudit closes the audit log once background work has stopped
+func (s *Store) closeAudit() {
+	if s.audit == nil {
+		return
+	}
+	if err := s.audit.Close(); err != nil {
+		s.logger.WithError(err).Warn("Failed to close audit log")
 	}
 }
 
-// Helper methods
+// releaseDataDir stops journaling and lets another writer open the data directory
+func (s *Store) releaseDataDir() {
+	s.journal.close()
+	if err := s.writerLock.release(); err != nil {
+		s.logger.WithError(err).Warn("Failed to release writer lock")
+	}
+}
 
 func (s *Store) generateID(content string) string {
 	hash := sha256.Sum256([]byte(content))
@@ -876,6 +1261,7 @@ func (s *Store) ensureDirectories() error {
 		filepath.Join(s.dataDir, "memories"),
 		filepath.Join(s.dataDir, "index"),
 		filepath.Join(s.dataDir, "logs"),
+		filepath.Join(s.dataDir, trashDir),
 	}
 
 	for _, dir := range dirs {
@@ -888,34 +1274,72 @@ func (s *Store) ensureDirectories() error {
 }
 
 func (s *Store) saveMemoryToFile(memory *Memory) (int64, error) {
+	defer saveDuration.ObserveSince(time.Now())
+	filename, fileData, err := s.encodeMemory(memory)
+	if err != nil {
+		return 0, err
+	}
+	filepath := filepath.Join(s.dataDir, "memories", filename)
+
+	// Check file size limit
+	if int64(len(fileData)) > s.config.MaxFileSize {
+		return 0, fmt.Errorf("memory file size %d exceeds limit %d", len(fileData), s.config.MaxFileSize)
+	}
+
+	// Atomic write
+	tempFile := filepath + ".tmp"
+	if err := os.WriteFile(tempFile, fileData, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, filepath); err != nil {
+		os.Remove(tempFile)
+		return 0, fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	s.journal.append(journalPut, memory.ID)
+
+	return int64(len(fileData)), nil
+}
+
+// encodeMemory returns the file name and contents a memory is saved as
+func (s *Store) encodeMemory(memory *Memory) (string, []byte, error) {
 	var filename string
-	if s.config.EnableCompression {
+	switch {
+	case s.config.GitStorage:
+		filename = memory.ID + markdownExt
+	case s.config.EnableCompression:
 		filename = fmt.Sprintf("%s.json.gz", memory.ID)
-	} else {
+	default:
 		filename = fmt.Sprintf("%s.json", memory.ID)
 	}
-	filepath := filepath.Join(s.dataDir, "memories", filename)
 
 	data, err := json.Marshal(memory)
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal memory: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal memory: %w", err)
 	}
 
 	var fileData []byte
-	if s.config.EnableCompression {
+	if s.config.GitStorage {
+		// Human-readable for review; git compresses history itself
+		if fileData, err = encodeMarkdown(memory); err != nil {
+			return "", nil, err
+		}
+	} else if s.config.EnableCompression {
 		// Compress data
+		compressStart := time.Now()
 		var compressed bytes.Buffer
 		gzipWriter, err := gzip.NewWriterLevel(&compressed, s.config.CompressionLevel)
 		if err != nil {
-			return 0, fmt.Errorf("failed to create gzip writer: %w", err)
+			return "", nil, fmt.Errorf("failed to create gzip writer: %w", err)
 		}
 		if _, err := gzipWriter.Write(data); err != nil {
-			return 0, fmt.Errorf("failed to compress data: %w", err)
+			return "", nil, fmt.Errorf("failed to compress data: %w", err)
 		}
 		if err := gzipWriter.Close(); err != nil {
-			return 0, fmt.Errorf("failed to close gzip writer: %w", err)
+			return "", nil, fmt.Errorf("failed to close gzip writer: %w", err)
 		}
 		fileData = compressed.Bytes()
+		compressionDuration.ObserveSince(compressStart, "compress")
 	} else {
 		// Use uncompressed data
 		fileData = data
@@ -923,30 +1347,62 @@ func (s *Store) saveMemoryToFile(memory *Memory) (int64, error) {
 	
 	// Encrypt if enabled
 	if s.config.EnableEncryption && s.crypto != nil {
+		encryptStart := time.Now()
 		encrypted, err := s.crypto.Encrypt(fileData)
 		if err != nil {
-			return 0, fmt.Errorf("failed to encrypt data: %w", err)
+			return "", nil, fmt.Errorf("failed to encrypt data: %w", err)
 		}
 		fileData = encrypted
+		encryptionDuration.ObserveSince(encryptStart, "encrypt")
 	}
 
-	// Check file size limit
-	if int64(len(fileData)) > s.config.MaxFileSize {
-		return 0, fmt.Errorf("memory file size %d exceeds limit %d", len(fileData), s.config.MaxFileSize)
-	}
+	return filename, fileData, nil
+}
 
-	// Atomic write
-	tempFile := filepath + ".tmp"
-	if err := os.WriteFile(tempFile, fileData, 0644); err != nil {
-		return 0, fmt.Errorf("failed to write temp file: %w", err)
+// writeDataFile atomically writes v as JSON to a file under the data
+// directory, encrypting it when encryption is enabled
+func (s *Store) writeDataFile(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	if s.config.EnableEncryption && s.crypto != nil {
+		if data, err = s.crypto.Encrypt(data); err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
 	}
 
-	if err := os.Rename(tempFile, filepath); err != nil {
+	path := filepath.Join(s.dataDir, name)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
 		os.Remove(tempFile)
-		return 0, fmt.Errorf("failed to rename temp file: %w", err)
+		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
+	return nil
+}
 
-	return int64(len(fileData)), nil
+// readDataFile reads a file written by writeDataFile into v. A missing file
+// leaves v untouched.
+func (s *Store) readDataFile(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.dataDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if s.config.EnableEncryption && s.crypto != nil {
+		if data, err = s.crypto.Decrypt(data); err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", name, err)
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return nil
 }
 
 func (s *Store) loadIndex() error {
@@ -961,7 +1417,7 @@ func (s *Store) loadIndex() error {
 	}
 
 	for _, entry := range entries {
-		if !strings.HasSuffix(entry.Name(), ".json.gz") && !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() || memoryFileID(entry.Name()) == "" {
 			continue
 		}
 
@@ -972,91 +1428,71 @@ func (s *Store) loadIndex() error {
 			continue
 		}
 
-		fileData, err := os.ReadFile(filepath)
+		memory, err := s.readMemoryFile(filepath)
 		if err != nil {
-			s.logger.WithError(err).Warn("Failed to read memory file", "file", entry.Name())
+			s.logger.WithError(err).Warn("Failed to load memory", "file", entry.Name())
 			continue
 		}
 
-		// Decrypt if enabled
-		var data []byte
-		if s.config.EnableEncryption && s.crypto != nil {
-			data, err = s.crypto.Decrypt(fileData)
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to decrypt memory", "file", entry.Name())
-				continue
-			}
-		} else {
-			data = fileData
-		}
+		s.setMemorySize(memory, info.Size())
+		s.add(memory)
+	}
 
-		// Decompress if gzipped
-		var jsonData []byte
-		if strings.HasSuffix(entry.Name(), ".gz") {
-			gzipReader, err := gzip.NewReader(bytes.NewReader(data))
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to create gzip reader", "file", entry.Name())
-				continue
-			}
-			jsonData, err = io.ReadAll(gzipReader)
-			gzipReader.Close()
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to decompress memory", "file", entry.Name())
-				continue
-			}
-		} else {
-			jsonData = data
-		}
+	return nil
+}
 
-		var memory Memory
-		if err := json.Unmarshal(jsonData, &memory); err != nil {
-			s.logger.WithError(err).Warn("Failed to unmarshal memory", "file", entry.Name())
-			continue
+// readMemoryFile reads a memory file, decrypting and decompressing it as needed
+func (s *Store) readMemoryFile(path string) (*Memory, error) {
+	return decodeMemoryFile(path, s.crypto)
+}
+
+// decodeMemoryFile reads a memory file, decrypting it when c is set and
+// decompressing it when gzipped
+func decodeMemoryFile(path string, c *crypto.Crypto) (*Memory, error) {
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory file: %w", err)
+	}
+
+	// Decrypt if enabled
+	data := fileData
+	if c != nil {
+		decryptStart := time.Now()
+		if data, err = c.Decrypt(fileData); err != nil {
+			return nil, fmt.Errorf("failed to decrypt memory: %w", err)
 		}
+		encryptionDuration.ObserveSince(decryptStart, "decrypt")
+	}
 
-		s.index[memory.ID] = &memory
-		s.memorySizes[memory.ID] = info.Size()
-		s.totalSize += info.Size()
-		s.updateIndices(&memory)
-		
-		// Build version index
-		if memory.IsCurrentVersion {
-			// Extract base ID from versioned ID (remove -vN suffix)
-			baseID := memory.ID
-			if idx := strings.LastIndex(memory.ID, "-v"); idx != -1 {
-				baseID = memory.ID[:idx]
-			}
-			// Also index by base ID for quick lookup
-			s.index[baseID] = &memory
+	// Git storage keeps memories as Markdown
+	if strings.HasSuffix(path, markdownExt) {
+		return decodeMarkdown(data)
+	}
+
+	// Decompress if gzipped
+	jsonData := data
+	if strings.HasSuffix(path, ".gz") {
+		decompressStart := time.Now()
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
 		}
-		
-		// Add to version index
-		if memory.Version > 0 {
-			baseID := memory.ID
-			if idx := strings.LastIndex(memory.ID, "-v"); idx != -1 {
-				baseID = memory.ID[:idx]
-			}
-			s.versionIndex[baseID] = append(s.versionIndex[baseID], memory.ID)
+		jsonData, err = io.ReadAll(gzipReader)
+		gzipReader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress memory: %w", err)
 		}
+		compressionDuration.ObserveSince(decompressStart, "decompress")
 	}
 
-	// Sort version indices by version number
-	for baseID, versionIDs := range s.versionIndex {
-		sort.Slice(versionIDs, func(i, j int) bool {
-			memI := s.index[versionIDs[i]]
-			memJ := s.index[versionIDs[j]]
-			if memI != nil && memJ != nil {
-				return memI.Version < memJ.Version
-			}
-			return false
-		})
-		s.versionIndex[baseID] = versionIDs
+	var memory Memory
+	if err := json.Unmarshal(jsonData, &memory); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal memory: %w", err)
 	}
-
-	return nil
+	return &memory, nil
 }
 
-func (s *Store) calculateRelevanceScore(memory *Memory, query *SearchQuery, queryLower string) float64 {
+func (s *queryEngine) calculateRelevanceScore(memory *Memory, query *SearchQuery, queryLower string, now time.Time) float64 {
 	score := 0.0
 
 	// Content matching
@@ -1091,15 +1527,13 @@ func (s *Store) calculateRelevanceScore(memory *Memory, query *SearchQuery, quer
 		score += 0.3
 	}
 
-	// Recent access boost
-	if time.Since(memory.LastAccess) < 24*time.Hour {
-		score += 0.1
-	}
+	// Recency, frequency and importance
+	score += retentionBoost * s.RetentionScore(memory, now)
 
 	return score
 }
 
-func (s *Store) hasAnyTag(memoryTags, queryTags []string) bool {
+func (s *queryEngine) hasAnyTag(memoryTags, queryTags []string) bool {
 	for _, queryTag := range queryTags {
 		for _, memoryTag := range memoryTags {
 			if strings.EqualFold(memoryTag, queryTag) {
@@ -1110,144 +1544,134 @@ func (s *Store) hasAnyTag(memoryTags, queryTags []string) bool {
 	return false
 }
 
-// updateIndices adds memory to category, tag and keyword indices
-func (s *Store) updateIndices(memory *Memory) {
+// updateIndices adds memory to category, tag, keyword, metadata, link and term indices
+func (s *queryEngine) updateIndices(memory *Memory) {
 	// Update category index
 	if memory.Category != "" {
-		category := strings.ToLower(memory.Category)
-		found := false
-		for _, id := range s.categoryIndex[category] {
-			if id == memory.ID {
-				found = true
-				break
-			}
-		}
-		if !found {
-			s.categoryIndex[category] = append(s.categoryIndex[category], memory.ID)
-		}
+		addIndexID(s.categoryIndex, strings.ToLower(memory.Category), memory.ID)
 	}
 
 	// Update tag index
 	for _, tag := range memory.Tags {
-		tagKey := strings.ToLower(tag)
-		found := false
-		for _, id := range s.tagIndex[tagKey] {
-			if id == memory.ID {
-				found = true
-				break
-			}
-		}
-		if !found {
-			s.tagIndex[tagKey] = append(s.tagIndex[tagKey], memory.ID)
-		}
+		addIndexID(s.tagIndex, strings.ToLower(tag), memory.ID)
 	}
-	
+
 	// Update keyword index
 	for _, keyword := range memory.Keywords {
 		keywordKey := strings.ToLower(keyword)
-		found := false
-		for _, id := range s.keywordIndex[keywordKey] {
-			if id == memory.ID {
-				found = true
-				break
-			}
+		if addIndexID(s.keywordIndex, keywordKey, memory.ID) {
+			s.keywordDict.Add(keywordKey)
 		}
-		if !found {
-			s.keywordIndex[keywordKey] = append(s.keywordIndex[keywordKey], memory.ID)
+	}
+
+	// Update metadata index
+	for key, value := range memory.Metadata {
+		values := s.metadataIndex[key]
+		if values == nil {
+			values = make(map[string][]string)
+			s.metadataIndex[key] = values
 		}
+		addIndexID(values, strings.ToLower(value), memory.ID)
+	}
+
+	// Update link index
+	for _, link := range memory.Links {
+		addIndexID(s.linkIndex, link.Target, memory.ID)
 	}
+
+	// Update term index
+	s.addTerms(memory)
+
+	// Update duplicate detection index
+	s.addSignature(memory)
 }
 
-// removeFromIndices removes memory from category, tag and keyword indices
-func (s *Store) removeFromIndices(memory *Memory) {
+// removeFromIndices removes memory from category, tag, keyword, metadata, link, term and signature indices
+func (s *queryEngine) removeFromIndices(memory *Memory) {
 	// Remove from category index
 	if memory.Category != "" {
-		category := strings.ToLower(memory.Category)
-		ids := s.categoryIndex[category]
-		for i, id := range ids {
-			if id == memory.ID {
-				s.categoryIndex[category] = append(ids[:i], ids[i+1:]...)
-				break
-			}
-		}
-		if len(s.categoryIndex[category]) == 0 {
-			delete(s.categoryIndex, category)
-		}
+		removeIndexID(s.categoryIndex, strings.ToLower(memory.Category), memory.ID)
 	}
 
 	// Remove from tag index
 	for _, tag := range memory.Tags {
-		tagKey := strings.ToLower(tag)
-		ids := s.tagIndex[tagKey]
-		for i, id := range ids {
-			if id == memory.ID {
-				s.tagIndex[tagKey] = append(ids[:i], ids[i+1:]...)
-				break
-			}
-		}
-		if len(s.tagIndex[tagKey]) == 0 {
-			delete(s.tagIndex, tagKey)
-		}
+		removeIndexID(s.tagIndex, strings.ToLower(tag), memory.ID)
 	}
-	
+
 	// Remove from keyword index
 	for _, keyword := range memory.Keywords {
 		keywordKey := strings.ToLower(keyword)
-		ids := s.keywordIndex[keywordKey]
-		for i, id := range ids {
-			if id == memory.ID {
-				s.keywordIndex[keywordKey] = append(ids[:i], ids[i+1:]...)
-				break
-			}
-		}
-		if len(s.keywordIndex[keywordKey]) == 0 {
-			delete(s.keywordIndex, keywordKey)
+		if removeIndexID(s.keywordIndex, keywordKey, memory.ID) {
+			s.keywordDict.Remove(keywordKey)
 		}
 	}
-}
 
-// cleanupOldMemories removes oldest memories to stay under storage limit
-func (s *Store) cleanupOldMemories() error {
-	// Sort memories by last access time (oldest first)
-	type memoryWithTime struct {
-		id         string
-		lastAccess time.Time
-		size       int64
+	// Remove from metadata index
+	for key, value := range memory.Metadata {
+		values := s.metadataIndex[key]
+		if values == nil {
+			continue
+		}
+		removeIndexID(values, strings.ToLower(value), memory.ID)
+		if len(values) == 0 {
+			delete(s.metadataIndex, key)
+		}
 	}
 
-	var memories []memoryWithTime
-	for id, memory := range s.index {
-		memories = append(memories, memoryWithTime{
-			id:         id,
-			lastAccess: memory.LastAccess,
-			size:       s.memorySizes[memory.ID],
-		})
+	// Remove from link index
+	for _, link := range memory.Links {
+		removeIndexID(s.linkIndex, link.Target, memory.ID)
 	}
 
-	sort.Slice(memories, func(i, j int) bool {
-		return memories[i].lastAccess.Before(memories[j].lastAccess)
-	})
+	// Remove from term index
+	s.removeTerms(memory)
 
-	// Remove memories until we're under the limit
-	targetSize := int64(float64(s.config.MaxStorageSize) * 0.9) // Clean to 90% of limit
-	for _, mem := range memories {
-		if s.totalSize <= targetSize {
-			break
-		}
+	// Remove from duplicate detection index
+	s.removeSignature(memory)
+}
 
-		if err := s.Delete(mem.id); err != nil {
-			s.logger.WithError(err).Warn("Failed to delete memory during cleanup", "id", mem.id)
-			continue
-		}
+// addIndexID adds id under key, reporting whether key is new. Memories are
+// indexed one at a time, so a value repeated within the same memory can only
+// find its ID at the end of the list and no scan is needed.
+func addIndexID(index map[string][]string, key, id string) bool {
+	ids := index[key]
+	if n := len(ids); n > 0 && ids[n-1] == id {
+		return false
+	}
+	index[key] = append(ids, id)
+	return len(ids) == 0
+}
 
-		s.logger.Info("Cleaned up old memory", "id", mem.id, "size", mem.size, "last_access", mem.lastAccess)
+// removeIndexID removes id from key, reporting whether key is now gone
+func removeIndexID(index map[string][]string, key, id string) bool {
+	ids, exists := index[key]
+	if !exists {
+		return false
 	}
+	for i, existing := range ids {
+		if existing == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(index, key)
+		return true
+	}
+	index[key] = ids
+	return false
+}
 
+// cleanupOldMemories removes the memories least worth keeping to stay under
+// the storage limit
+func (s *Store) cleanupOldMemories() error {
+	targetSize := int64(float64(s.config.MaxStorageSize) * 0.9) // Clean to 90% of limit
+	s.evict(limitStorage, "storage limit", func(*Memory) bool { return true }, func() bool { return s.totalSize <= targetSize })
 	return nil
 }
 
 // GetTimeline returns memory creation timeline data for charts
-func (s *Store) GetTimeline() map[string]interface{} {
+func (s *queryEngine) GetTimeline() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1265,8 +1689,11 @@ func (s *Store) GetTimeline() map[string]interface{} {
 		labels = append(labels, day.Format("Jan 2"))
 	}
 
-	// Count memories per day
-	for _, memory := range s.index {
+	// Count memories per day, each once rather than under both of its IDs
+	for id, memory := range s.index {
+		if id != memory.ID {
+			continue
+		}
 		dayStr := memory.CreatedAt.Format("2006-01-02")
 		if _, exists := days[dayStr]; exists {
 			days[dayStr]++
@@ -1288,11 +1715,15 @@ func (s *Store) GetTimeline() map[string]interface{} {
 
 // ReadOnlyStore provides read-only access to memory data for reporting
 type ReadOnlyStore struct {
-	dataDir string
-	logger  *logger.Logger
-	mu      sync.RWMutex
-	index   map[string]*Memory
-	crypto  *crypto.Crypto // encryption handler for decryption
+	*queryEngine                // index of memories and the queries over it
+	dataDir      string
+	crypto       *crypto.Crypto // encryption handler for decryption
+	journal      *journalTail   // position in the writer's change journal
+
+	watchStop chan struct{} // closed to stop the watcher; nil when not watching
+	watchDone chan struct{} // closed when the watcher has stopped
+	updatesMu sync.Mutex
+	updates   map[chan IndexUpdate]struct{} // subscribers to index updates
 }
 
 // NewReadOnlyStore creates a new read-only memory store for reporting
@@ -1302,14 +1733,19 @@ func NewReadOnlyStore(dataDir string, log *logger.Logger) (*ReadOnlyStore, error
 
 // NewReadOnlyStoreWithConfig creates a new read-only memory store with optional config for encryption
 func NewReadOnlyStoreWithConfig(dataDir string, cfg *config.StorageConfig, log *logger.Logger) (*ReadOnlyStore, error) {
+	// Ranking only needs the decay settings, which fall back to defaults
+	if cfg == nil {
+		cfg = &config.StorageConfig{}
+	}
 	store := &ReadOnlyStore{
-		dataDir: dataDir,
-		logger:  log.WithComponent("readonly_memory_store"),
-		index:   make(map[string]*Memory),
+		queryEngine: newQueryEngine(cfg, log.WithComponent("readonly_memory_store")),
+		dataDir:     dataDir,
+		journal:     &journalTail{path: filepath.Join(dataDir, journalFile)},
+		updates:     make(map[chan IndexUpdate]struct{}),
 	}
 
 	// Initialize encryption if config provided and enabled
-	if cfg != nil && cfg.EnableEncryption {
+	if cfg.EnableEncryption {
 		cryptoHandler, err := crypto.New(cfg.EncryptionKeyPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize encryption: %w", err)
@@ -1319,7 +1755,7 @@ func NewReadOnlyStoreWithConfig(dataDir string, cfg *config.StorageConfig, log *
 	}
 
 	// Load existing memories into index
-	if err := store.loadIndex(); err != nil {
+	if _, err := store.refresh(); err != nil {
 		return nil, fmt.Errorf("failed to load memory index: %w", err)
 	}
 
@@ -1330,85 +1766,231 @@ func NewReadOnlyStoreWithConfig(dataDir string, cfg *config.StorageConfig, log *
 	return store, nil
 }
 
-// Refresh reloads the memory index from disk
+// Refresh brings the memory index up to date with the data directory. It
+// applies the writer's change journal since the last refresh, and reloads
+// everything when the journal is missing or a new writer has started.
 func (s *ReadOnlyStore) Refresh() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	update, err := s.refresh()
+	s.mu.Unlock()
+	s.publishUpdate(update)
+	return err
+}
 
-	// Clear existing index
-	s.index = make(map[string]*Memory)
+// refresh updates the index from the journal. The caller must hold s.mu.
+func (s *ReadOnlyStore) refresh() (IndexUpdate, error) {
+	var update IndexUpdate
+	entries, reset, err := s.journal.read()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to read change journal, reloading all memories")
+		reset = true
+	}
+	if reset {
+		s.clear()
+		update.Reloaded = true
+		return update, s.loadIndex()
+	}
 
-	// Reload from disk
-	return s.loadIndex()
+	for _, entry := range entries {
+		switch entry.Op {
+		case journalPut:
+			update.record(entry.ID, s.loadMemory(entry.ID))
+		case journalRemove:
+			s.remove(entry.ID)
+			update.record(entry.ID, false)
+		}
+	}
+	return update, nil
 }
 
-// GetStats returns store statistics (read-only version)
-func (s *ReadOnlyStore) GetStats() map[string]interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	categories := make(map[string]int)
-	totalAccess := 0
-	var totalSize int64
+// record notes a memory as updated when present or removed otherwise
+func (u *IndexUpdate) record(id string, present bool) {
+	if present && !containsString(u.Updated, id) {
+		u.Updated = append(u.Updated, id)
+	} else if !present && !containsString(u.Removed, id) {
+		u.Removed = append(u.Removed, id)
+	}
+}
 
-	for _, memory := range s.index {
-		if memory.Category != "" {
-			categories[memory.Category]++
+// loadMemory rereads one memory file into the index, dropping the memory when
+// its file is gone. It reports whether the memory is in the index afterwards.
+func (s *ReadOnlyStore) loadMemory(id string) bool {
+	for _, name := range []string{id + ".json.gz", id + ".json", id + markdownExt} {
+		memory, err := decodeMemoryFile(filepath.Join(s.dataDir, "memories", name), s.crypto)
+		if err == nil {
+			s.add(memory)
+			return true
 		}
-		totalAccess += memory.AccessCount
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.WithError(err).Warn("Failed to load memory file", "file", name)
+			_, exists := s.index[id]
+			return exists
+		}
+	}
+	s.remove(id)
+	return false
+}
+
+// Watch keeps the index up to date as memory files change, using filesystem
+// notifications where available and otherwise scanning the memories
+// directory every pollInterval. It runs until Close.
+func (s *ReadOnlyStore) Watch(pollInterval time.Duration) error {
+	s.mu.Lock()
+	if s.watchStop != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("already watching")
 	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.watchStop, s.watchDone = stop, done
+	s.mu.Unlock()
 
-	// Calculate approximate total size by examining files
-	memoriesDir := filepath.Join(s.dataDir, "memories")
-	if entries, err := os.ReadDir(memoriesDir); err == nil {
-		for _, entry := range entries {
-			if info, err := entry.Info(); err == nil {
-				totalSize += info.Size()
+	dir := filepath.Join(s.dataDir, "memories")
+	changes := make(chan dirChange, watchChangeBuffer)
+	if err := startNotify(dir, stop, changes); err != nil {
+		s.logger.Info("Polling for memory changes", "interval", pollInterval, "reason", err.Error())
+		go pollDir(dir, pollInterval, stop, changes)
+	} else {
+		s.logger.Info("Watching for memory changes", "dir", dir)
+	}
+	go func() {
+		defer close(done)
+		s.watchLoop(changes, stop)
+	}()
+
+	// Pick up anything written between loading the index and watching
+	return s.Refresh()
+}
+
+// watchLoop applies batches of changed files until stop is closed
+func (s *ReadOnlyStore) watchLoop(changes <-chan dirChange, stop <-chan struct{}) {
+	for {
+		var change dirChange
+		select {
+		case <-stop:
+			return
+		case change = <-changes:
+		}
+
+		// Let a burst of writes, like a new version and its predecessor,
+		// settle into one update
+		settle := time.NewTimer(watchSettle)
+	collect:
+		for {
+			select {
+			case more := <-changes:
+				change.merge(more)
+			case <-settle.C:
+				break collect
+			case <-stop:
+				settle.Stop()
+				return
 			}
 		}
+		s.applyChange(change)
 	}
+}
 
-	return map[string]interface{}{
-		"total_memories":     len(s.index),
-		"categories":         categories,
-		"total_access_count": totalAccess,
-		"data_directory":     s.dataDir,
-		"total_size":         totalSize,
-		"storage_used_pct":   0, // We don't know the limit in read-only mode
+// applyChange rereads the memory files of a batch of changes
+func (s *ReadOnlyStore) applyChange(change dirChange) {
+	var update IndexUpdate
+	s.mu.Lock()
+	if change.Resync {
+		s.clear()
+		if err := s.loadIndex(); err != nil {
+			s.logger.WithError(err).Warn("Failed to reload memories")
+		}
+		update.Reloaded = true
+	} else {
+		for _, name := range change.Names {
+			if id := memoryFileID(name); id != "" {
+				update.record(id, s.loadMemory(id))
+			}
+		}
 	}
+	s.mu.Unlock()
+	s.publishUpdate(update)
 }
 
-// List lists all memories with optional filtering (read-only version)
-func (s *ReadOnlyStore) List(category string, tags []string, limit int) ([]*Memory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var results []*Memory
+// Updates subscribes to the changes refreshes and the watcher apply to the
+// index. The returned function unsubscribes. A subscriber that falls behind
+// misses updates rather than holding them up.
+func (s *ReadOnlyStore) Updates() (<-chan IndexUpdate, func()) {
+	ch := make(chan IndexUpdate, updatesBuffer)
+	s.updatesMu.Lock()
+	s.updates[ch] = struct{}{}
+	s.updatesMu.Unlock()
 
-	for _, memory := range s.index {
-		// Filter by category
-		if category != "" && memory.Category != category {
-			continue
+	return ch, func() {
+		s.updatesMu.Lock()
+		defer s.updatesMu.Unlock()
+		if _, exists := s.updates[ch]; exists {
+			delete(s.updates, ch)
+			close(ch)
 		}
+	}
+}
 
-		// Filter by tags
-		if len(tags) > 0 && !s.hasAnyTag(memory.Tags, tags) {
-			continue
+func (s *ReadOnlyStore) publishUpdate(update IndexUpdate) {
+	if update.empty() {
+		return
+	}
+	update.Time = time.Now()
+	s.updatesMu.Lock()
+	defer s.updatesMu.Unlock()
+	for ch := range s.updates {
+		select {
+		case ch <- update:
+		default:
 		}
+	}
+}
 
-		results = append(results, memory)
+// Close stops watching and ends update subscriptions
+func (s *ReadOnlyStore) Close() error {
+	s.mu.Lock()
+	stop, done := s.watchStop, s.watchDone
+	s.watchStop, s.watchDone = nil, nil
+	s.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
 	}
 
-	// Sort by creation time (newest first)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].CreatedAt.After(results[j].CreatedAt)
-	})
+	s.updatesMu.Lock()
+	defer s.updatesMu.Unlock()
+	for ch := range s.updates {
+		delete(s.updates, ch)
+		close(ch)
+	}
+	return nil
+}
 
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
+// GetStats returns store statistics (read-only version)
+func (s *ReadOnlyStore) GetStats() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Calculate approximate total size by examining files
+	var totalSize int64
+	sizes := make(map[string]int64)
+	memoriesDir := filepath.Join(s.dataDir, "memories")
+	if entries, err := os.ReadDir(memoriesDir); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				totalSize += info.Size()
+				if id := memoryFileID(entry.Name()); id != "" {
+					sizes[id] = info.Size()
+				}
+			}
+		}
 	}
 
-	return results, nil
+	stats := s.stats()
+	stats["data_directory"] = s.dataDir
+	stats["total_size"] = totalSize
+	stats["storage_used_pct"] = 0 // We don't know the limit in read-only mode
+	stats["usage"] = s.usage(sizes)
+	return stats
 }
 
 // saveWorker processes the async save queue
@@ -1451,69 +2033,21 @@ func (s *Store) saveMemoryAsync(memory *Memory) {
 	fileSize, err := s.saveMemoryToFile(memory)
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to save memory file asynchronously", "id", memory.ID)
+		asyncSaveFailures.Inc()
 		return
 	}
 
 	// Update storage tracking
 	s.mu.Lock()
-	oldSize := s.memorySizes[memory.ID]
-	s.totalSize = s.totalSize - oldSize + fileSize
-	s.memorySizes[memory.ID] = fileSize
-
-	// Check if cleanup is needed
-	needsCleanup := s.totalSize > s.config.MaxStorageSize
+	s.setMemorySize(memory, fileSize)
 	s.mu.Unlock()
 
-	// Clean up if over limit (slow operation)
-	if needsCleanup {
-		if err := s.cleanupOldMemories(); err != nil {
-			s.logger.WithError(err).Warn("Failed to cleanup old memories")
-		}
-	}
+	// Clean up if over a limit (slow operation)
+	s.enforceLimits(memory)
 
 	s.logger.Debug("Memory saved asynchronously", "id", memory.ID, "size", fileSize)
 }
 
-// GetTimeline returns memory creation timeline data for charts (read-only version)
-func (s *ReadOnlyStore) GetTimeline() map[string]interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	// Group memories by day for the last 30 days
-	now := time.Now()
-	days := make(map[string]int)
-	labels := make([]string, 0, 30)
-	data := make([]int, 0, 30)
-
-	// Initialize last 30 days
-	for i := 29; i >= 0; i-- {
-		day := now.AddDate(0, 0, -i)
-		dayStr := day.Format("2006-01-02")
-		days[dayStr] = 0
-		labels = append(labels, day.Format("Jan 2"))
-	}
-
-	// Count memories per day
-	for _, memory := range s.index {
-		dayStr := memory.CreatedAt.Format("2006-01-02")
-		if _, exists := days[dayStr]; exists {
-			days[dayStr]++
-		}
-	}
-
-	// Convert to array in chronological order
-	for i := 29; i >= 0; i-- {
-		day := now.AddDate(0, 0, -i)
-		dayStr := day.Format("2006-01-02")
-		data = append(data, days[dayStr])
-	}
-
-	return map[string]interface{}{
-		"labels": labels,
-		"data":   data,
-	}
-}
-
 // loadIndex loads memories from disk (read-only version)
 func (s *ReadOnlyStore) loadIndex() error {
 	memoriesDir := filepath.Join(s.dataDir, "memories")
@@ -1527,68 +2061,18 @@ func (s *ReadOnlyStore) loadIndex() error {
 	}
 
 	for _, entry := range entries {
-		if !strings.HasSuffix(entry.Name(), ".json.gz") && !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() || memoryFileID(entry.Name()) == "" {
 			continue
 		}
 
-		filepath := filepath.Join(memoriesDir, entry.Name())
-
-		fileData, err := os.ReadFile(filepath)
+		memory, err := decodeMemoryFile(filepath.Join(memoriesDir, entry.Name()), s.crypto)
 		if err != nil {
-			s.logger.WithError(err).Warn("Failed to read memory file", "file", entry.Name())
-			continue
-		}
-
-		// Decrypt if enabled
-		var data []byte
-		if s.crypto != nil {
-			data, err = s.crypto.Decrypt(fileData)
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to decrypt memory", "file", entry.Name())
-				continue
-			}
-		} else {
-			data = fileData
-		}
-
-		// Decompress if gzipped
-		var jsonData []byte
-		if strings.HasSuffix(entry.Name(), ".gz") {
-			gzipReader, err := gzip.NewReader(bytes.NewReader(data))
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to create gzip reader", "file", entry.Name())
-				continue
-			}
-			jsonData, err = io.ReadAll(gzipReader)
-			gzipReader.Close()
-			if err != nil {
-				s.logger.WithError(err).Warn("Failed to decompress memory", "file", entry.Name())
-				continue
-			}
-		} else {
-			jsonData = data
-		}
-
-		var memory Memory
-		if err := json.Unmarshal(jsonData, &memory); err != nil {
-			s.logger.WithError(err).Warn("Failed to unmarshal memory", "file", entry.Name())
+			s.logger.WithError(err).Warn("Failed to load memory file", "file", entry.Name())
 			continue
 		}
 
-		s.index[memory.ID] = &memory
+		s.add(memory)
 	}
 
 	return nil
 }
-
-// hasAnyTag checks if memory has any of the query tags (read-only version)
-func (s *ReadOnlyStore) hasAnyTag(memoryTags, queryTags []string) bool {
-	for _, queryTag := range queryTags {
-		for _, memoryTag := range memoryTags {
-			if strings.EqualFold(memoryTag, queryTag) {
-				return true
-			}
-		}
-	}
-	return false
-}
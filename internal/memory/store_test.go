@@ -2,8 +2,10 @@ package memory
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -173,4 +175,279 @@ func TestStoreSyncModeClose(t *testing.T) {
 	if duration > 100*time.Millisecond {
 		t.Errorf("Close took too long in sync mode: %v", duration)
 	}
-}
\ No newline at end of file
+}
+// testStorageConfig returns the config test stores start from, adjusted by
+// configure when it is not nil
+func testStorageConfig(configure func(*config.StorageConfig)) *config.StorageConfig {
+	cfg := &config.StorageConfig{
+		MaxStorageSize: 10 * 1024 * 1024,
+		MaxFileSize:    1 * 1024 * 1024,
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	return cfg
+}
+
+// openTestStore opens a store in dir for tests that close and reopen it;
+// the caller closes the store
+func openTestStore(tb testing.TB, dir string, configure func(*config.StorageConfig)) *Store {
+	tb.Helper()
+
+	store, err := NewStore(dir, testStorageConfig(configure), logger.New("error", "text"))
+	if err != nil {
+		tb.Fatalf("Failed to create store: %v", err)
+	}
+	return store
+}
+
+// newTestStore creates a store in a fresh temporary directory that is closed
+// when the test ends
+func newTestStore(tb testing.TB, configure func(*config.StorageConfig)) *Store {
+	tb.Helper()
+
+	store := openTestStore(tb, tb.TempDir(), configure)
+	tb.Cleanup(func() { store.Close() })
+	return store
+}
+
+// addIndexedMemory adds a memory to the in-memory index only, so large
+// stores can be built without touching disk
+func addIndexedMemory(s *Store, m *Memory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.IsCurrentVersion = true
+	s.index[m.ID] = m
+	s.updateIndices(m)
+}
+
+func TestSearchIndexNarrowingMatchesFullScan(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	fixtures := []struct {
+		content  string
+		keywords []string
+		tags     []string
+	}{
+		{"Use pgx for the connection pool", []string{"pgx", "connection pool"}, []string{"go", "database"}},
+		{"Connection pooling in Postgres", []string{"postgres", "pooling"}, []string{"database"}},
+		{"Kubernetes upgrade runbook", []string{"kubernetes"}, []string{"ops"}},
+		{"kubectl cheat sheet", []string{"kubectl"}, []string{"ops", "k8s"}},
+		{"Notes on C++ templates", []string{"c++"}, nil},
+		{"Cache invalidation strategy", []string{"cache"}, []string{"go"}},
+	}
+	for i, f := range fixtures {
+		addIndexedMemory(store, &Memory{
+			ID:         fmt.Sprintf("mem-%d", i),
+			Content:    f.content,
+			Keywords:   f.keywords,
+			Tags:       f.tags,
+			CreatedAt:  time.Now(),
+			LastAccess: time.Now(),
+		})
+	}
+
+	queries := []string{
+		"pool", "pooling", "go", "kube*", "k8s", `"connection pool"`, "content:pool",
+		"keyword:poo*", "pool OR cache", "pool -postgres", "c++", "templates c++",
+		"kuberentes", "sheet", "database tag:go",
+	}
+	for _, q := range queries {
+		page, err := store.SearchPage(&SearchQuery{Query: q, Limit: MaxPageSize})
+		if err != nil {
+			t.Fatalf("SearchPage(%q) failed: %v", q, err)
+		}
+		got := make(map[string]bool)
+		for _, m := range page.Memories {
+			got[m.ID] = true
+		}
+
+		expr, _ := ParseQuery(q)
+		store.mu.RLock()
+		store.resolveTerms(expr, DefaultMatchOptions())
+		want := make(map[string]bool)
+		for _, m := range store.index {
+			if expr.Match(m) {
+				want[m.ID] = true
+			}
+		}
+		// Without the candidate limit, which a store this small always
+		// hits, the indices must still yield every match
+		ix := store.queryIndexes()
+		ix.maxCandidates = 0
+		candidates, narrowed := ix.candidates(expr)
+		store.mu.RUnlock()
+
+		if narrowed {
+			for id := range want {
+				if !candidates[id] {
+					t.Errorf("query %q: candidates %v miss %s", q, candidates, id)
+				}
+			}
+		}
+
+		if len(got) != len(want) {
+			t.Errorf("query %q: indexed search found %v, full scan %v", q, got, want)
+			continue
+		}
+		for id := range want {
+			if !got[id] {
+				t.Errorf("query %q: indexed search missed %s", q, id)
+			}
+		}
+	}
+}
+
+func TestSearchWhileReading(t *testing.T) {
+	store := newTestStore(t, nil)
+	memory, err := store.Store("Backups run nightly at two", "", "ops", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	// Reads count accesses in place while searches score and sort the
+	// same memory; run with -race to catch searches reading it unlocked
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			store.Get(memory.ID)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		page, err := store.SearchPage(&SearchQuery{Query: "backups", Sort: SortByAccessCount})
+		if err != nil {
+			t.Fatalf("SearchPage failed: %v", err)
+		}
+		if len(page.Memories) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(page.Memories))
+		}
+	}
+	<-done
+}
+
+const benchmarkMemories = 100000
+
+var (
+	benchmarkStore *Store
+	benchmarkWords []string
+)
+
+// TestMain closes and removes the shared benchmark store once every test
+// and benchmark has run
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchmarkStore != nil {
+		benchmarkStore.Close()
+		os.RemoveAll(benchmarkStore.dataDir)
+	}
+	os.Exit(code)
+}
+
+// loadBenchmarkStore builds a store of 100k synthetic memories over a 20k
+// word vocabulary with a skewed word distribution. It is built once and
+// shared by the benchmarks, and removed by TestMain.
+func loadBenchmarkStore(b *testing.B) *Store {
+	b.Helper()
+	if benchmarkStore != nil {
+		return benchmarkStore
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	syllables := []string{"ka", "lo", "mi", "ne", "ru", "ta", "po", "si", "de", "va", "go", "zu"}
+	seen := make(map[string]bool)
+	for len(benchmarkWords) < 20000 {
+		var word strings.Builder
+		for n := 2 + rng.Intn(3); n > 0; n-- {
+			word.WriteString(syllables[rng.Intn(len(syllables))])
+		}
+		if !seen[word.String()] {
+			seen[word.String()] = true
+			benchmarkWords = append(benchmarkWords, word.String())
+		}
+	}
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(len(benchmarkWords)-1))
+
+	dir, err := os.MkdirTemp("", "memory-bench-*")
+	if err != nil {
+		b.Fatalf("Failed to create temp dir: %v", err)
+	}
+	cfg := &config.StorageConfig{DataDir: dir, MaxFileSize: 10 * 1024 * 1024, MaxStorageSize: 100 * 1024 * 1024}
+	store, err := NewStore(dir, cfg, logger.New("error", "text"))
+	if err != nil {
+		b.Fatalf("Failed to create store: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < benchmarkMemories; i++ {
+		words := make([]string, 12)
+		for j := range words {
+			words[j] = benchmarkWords[zipf.Uint64()]
+		}
+		addIndexedMemory(store, &Memory{
+			ID:         fmt.Sprintf("bench-%d", i),
+			Content:    strings.Join(words, " "),
+			Keywords:   words[:4],
+			Category:   "bench",
+			CreatedAt:  now.Add(-time.Duration(i) * time.Minute),
+			LastAccess: now,
+		})
+	}
+
+	benchmarkStore = store
+	return store
+}
+
+func BenchmarkSearch100k(b *testing.B) {
+	store := loadBenchmarkStore(b)
+	rare := benchmarkWords[len(benchmarkWords)-1]
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"rare word", rare},
+		{"common word", benchmarkWords[0]},
+		{"prefix", rare[:len(rare)-2] + "*"},
+		{"phrase", `"` + benchmarkWords[1] + " " + benchmarkWords[2] + `"`},
+		{"keyword field", "keyword:" + rare},
+		// A word with a separator cannot use the term dictionaries and
+		// checks every memory, as every free-text search used to
+		{"full scan", rare + "-x"},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			search := &SearchQuery{Query: q.query, Match: &MatchOptions{Stemming: true}}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.SearchPage(search); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkKeywordSubstringLookup100k(b *testing.B) {
+	store := loadBenchmarkStore(b)
+	word := benchmarkWords[len(benchmarkWords)/2]
+
+	b.Run("dictionary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			store.keywordDict.Substring(word)
+		}
+	})
+
+	// The partial keyword match search used before the dictionary existed
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var matches []string
+			for keyword := range store.keywordIndex {
+				if strings.Contains(keyword, word) || strings.Contains(word, keyword) {
+					matches = append(matches, keyword)
+				}
+			}
+		}
+	})
+}
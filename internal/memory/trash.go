@@ -0,0 +1,328 @@
+// internal/memory/trash.go
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	trashDir         = "trash"
+	trashFile        = "index/trash.json"
+	maxPurgeInterval = time.Hour // how often the trash is checked for expired memories, at most
+)
+
+// TrashedMemory is a deleted memory awaiting purge
+type TrashedMemory struct {
+	Memory    *Memory   `json:"memory"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+	Size      int64     `json:"size"`
+}
+
+// TrashEnabled reports whether deletes go to the trash rather than
+// removing memory files outright
+func (s *Store) TrashEnabled() bool {
+	return s.config.TrashRetention > 0
+}
+
+// memoryFilePath returns the path of a memory's file in dir, whichever
+// format it was written in
+func (s *Store) memoryFilePath(dir, id string) string {
+	compressed := filepath.Join(s.dataDir, dir, id+".json.gz")
+	plain := filepath.Join(s.dataDir, dir, id+".json")
+	markdown := filepath.Join(s.dataDir, dir, id+markdownExt)
+	for _, path := range []string{compressed, plain, markdown} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	if s.config.GitStorage {
+		return markdown
+	}
+	if s.config.EnableCompression {
+		return compressed
+	}
+	return plain
+}
+
+// removeMemory moves a memory file to the trash, or removes it when the
+// trash is disabled, and drops the memory from every index. The caller must
+// hold s.mu.
+func (s *Store) removeMemory(memory *Memory) error {
+	path := s.memoryFilePath("memories", memory.ID)
+	if s.TrashEnabled() {
+		trashPath := filepath.Join(s.dataDir, trashDir, filepath.Base(path))
+		if err := os.Rename(path, trashPath); err != nil {
+			return fmt.Errorf("failed to move memory file to trash: %w", err)
+		}
+		s.trash[memory.ID] = &TrashedMemory{
+			Memory:    memory,
+			DeletedAt: time.Now(),
+			Size:      s.memorySizes[memory.ID],
+		}
+		if err := s.saveTrash(); err != nil {
+			s.logger.WithError(err).Warn("Failed to save trash index")
+		}
+	} else if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove memory file: %w", err)
+	}
+
+	s.journal.append(journalRemove, memory.ID)
+
+	s.dropMemorySize(memory.ID)
+	s.removeFromIndices(memory)
+	delete(s.index, memory.ID)
+
+	// Drop the base ID reference and the version entry
+	baseID := BaseID(memory.ID)
+	if current, exists := s.index[baseID]; exists && current == memory {
+		delete(s.index, baseID)
+	}
+	versions := s.versionIndex[baseID]
+	for i, id := range versions {
+		if id == memory.ID {
+			versions = append(versions[:i:i], versions[i+1:]...)
+			break
+		}
+	}
+	if len(versions) == 0 {
+		delete(s.versionIndex, baseID)
+	} else {
+		s.versionIndex[baseID] = versions
+	}
+	return nil
+}
+
+// versionTaken reports whether a version ID is in use by a stored or
+// trashed memory. The caller must hold s.mu.
+func (s *Store) versionTaken(baseID string, version int) bool {
+	id := fmt.Sprintf("%s-v%d", baseID, version)
+	if _, exists := s.index[id]; exists {
+		return true
+	}
+	_, trashed := s.trash[id]
+	return trashed
+}
+
+// Trash lists the deleted memories awaiting purge, most recently deleted first
+func (s *Store) Trash() []*TrashedMemory {
+	s.purgeExpired(time.Now())
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trashed := make([]*TrashedMemory, 0, len(s.trash))
+	for _, entry := range s.trash {
+		copied := *entry
+		copied.PurgeAt = entry.DeletedAt.Add(s.config.TrashRetention)
+		trashed = append(trashed, &copied)
+	}
+	sort.Slice(trashed, func(i, j int) bool {
+		if !trashed[i].DeletedAt.Equal(trashed[j].DeletedAt) {
+			return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+		}
+		return trashed[i].Memory.ID < trashed[j].Memory.ID
+	})
+	return trashed
+}
+
+// Restore moves a deleted memory back out of the trash. A base ID restores
+// the most recently deleted version.
+func (s *Store) Restore(id string) (*Memory, error) {
+	if s.readOnly != nil {
+		return nil, s.readOnly
+	}
+	s.filesMu.RLock()
+	defer s.filesMu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.trash[id]
+	if !exists {
+		for _, candidate := range s.trash {
+			if BaseID(candidate.Memory.ID) == id && (entry == nil || candidate.DeletedAt.After(entry.DeletedAt)) {
+				entry = candidate
+			}
+		}
+		if entry == nil {
+			return nil, fmt.Errorf("memory not in trash: %s", id)
+		}
+	}
+	memory := entry.Memory
+	if _, exists := s.index[memory.ID]; exists {
+		return nil, fmt.Errorf("memory %s already exists", memory.ID)
+	}
+
+	trashPath := s.memoryFilePath(trashDir, memory.ID)
+	path := filepath.Join(s.dataDir, "memories", filepath.Base(trashPath))
+	if err := os.Rename(trashPath, path); err != nil {
+		return nil, fmt.Errorf("failed to restore memory file: %w", err)
+	}
+	s.journal.append(journalPut, memory.ID)
+	delete(s.trash, memory.ID)
+
+	// A newer version stored since the delete stays current
+	baseID := BaseID(memory.ID)
+	if memory.IsCurrentVersion {
+		if current, exists := s.index[baseID]; exists && current.IsCurrentVersion {
+			memory.IsCurrentVersion = false
+			if _, err := s.saveMemoryToFile(memory); err != nil {
+				s.logger.WithError(err).Warn("Failed to save restored memory", "id", memory.ID)
+			}
+		} else {
+			s.index[baseID] = memory
+		}
+	}
+
+	s.index[memory.ID] = memory
+	s.setMemorySize(memory, entry.Size)
+	s.updateIndices(memory)
+	versions := append(s.versionIndex[baseID], memory.ID)
+	sort.Slice(versions, func(i, j int) bool {
+		return s.index[versions[i]].Version < s.index[versions[j]].Version
+	})
+	s.versionIndex[baseID] = versions
+
+	if err := s.saveTrash(); err != nil {
+		s.logger.WithError(err).Warn("Failed to save trash index")
+	}
+	s.logger.Info("Memory restored", "id", memory.ID)
+	s.recordAudit(AuditRestore, []string{memory.ID}, "")
+	s.publishEvent(EventRestored, memory)
+	return memory, nil
+}
+
+// EmptyTrash permanently deletes every memory in the trash
+func (s *Store) EmptyTrash() (int, error) {
+	if s.readOnly != nil {
+		return 0, s.readOnly
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged, err := s.purge(func(*TrashedMemory) bool { return true })
+	if len(purged) > 0 {
+		s.recordAudit(AuditPurge, purged, "empty trash")
+	}
+	return len(purged), err
+}
+
+// purgeExpired permanently deletes memories trashed longer than the
+// retention period
+func (s *Store) purgeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now.Add(-s.config.TrashRetention)
+	purged, err := s.purge(func(entry *TrashedMemory) bool { return entry.DeletedAt.Before(cutoff) })
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to purge trash")
+	}
+	if len(purged) > 0 {
+		s.logger.Info("Purged expired memories from trash", "count", len(purged))
+		s.recordAuditAs(SystemCaller, AuditPurge, purged, "retention expired")
+	}
+}
+
+// purge removes the trashed memories selected by match and returns their
+// IDs. The caller must hold s.mu.
+func (s *Store) purge(match func(*TrashedMemory) bool) ([]string, error) {
+	var purged []string
+	var firstErr error
+	for id, entry := range s.trash {
+		if !match(entry) {
+			continue
+		}
+		if err := os.Remove(s.memoryFilePath(trashDir, id)); err != nil && !os.IsNotExist(err) {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to purge %s: %w", id, err)
+			}
+			continue
+		}
+		delete(s.trash, id)
+		purged = append(purged, id)
+	}
+	if len(purged) > 0 {
+		if err := s.saveTrash(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return purged, firstErr
+}
+
+// saveTrash persists when each trashed memory was deleted. The caller must
+// hold s.mu.
+func (s *Store) saveTrash() error {
+	deletedAt := make(map[string]time.Time, len(s.trash))
+	for id, entry := range s.trash {
+		deletedAt[id] = entry.DeletedAt
+	}
+	return s.writeDataFile(trashFile, deletedAt)
+}
+
+// loadTrash reads the memory files in the trash. Files missing from the
+// trash index count as deleted when they were last modified.
+func (s *Store) loadTrash() error {
+	deletedAt := make(map[string]time.Time)
+	if err := s.readDataFile(trashFile, &deletedAt); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dataDir, trashDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || memoryFileID(entry.Name()) == "" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		memory, err := s.readMemoryFile(filepath.Join(s.dataDir, trashDir, entry.Name()))
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to load trashed memory", "file", entry.Name())
+			continue
+		}
+		deleted, ok := deletedAt[memory.ID]
+		if !ok {
+			deleted = info.ModTime()
+		}
+		s.trash[memory.ID] = &TrashedMemory{Memory: memory, DeletedAt: deleted, Size: info.Size()}
+	}
+	return nil
+}
+
+// trashWorker purges expired memories from the trash until shutdown
+func (s *Store) trashWorker() {
+	defer s.wg.Done()
+
+	interval := s.config.TrashRetention / 10
+	if interval > maxPurgeInterval {
+		interval = maxPurgeInterval
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	s.purgeExpired(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.purgeExpired(time.Now())
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+)
+
+// withTrash compresses memories and keeps deleted ones in the trash for an hour
+func withTrash(cfg *config.StorageConfig) {
+	cfg.EnableCompression = true
+	cfg.CompressionLevel = 6
+	cfg.TrashRetention = time.Hour
+}
+
+func TestDeleteMovesToTrashAndRestores(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withTrash)
+
+	m, err := store.Store("The staging cluster runs on spot instances", "", "ops", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := store.Delete(m.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(m.ID); err == nil {
+		t.Error("Deleted memory should not be found")
+	}
+	if _, err := store.Get(BaseID(m.ID)); err == nil {
+		t.Error("Deleted memory should not be found by its base ID")
+	}
+	if results, _ := store.Search(&SearchQuery{Query: "staging"}); len(results) != 0 {
+		t.Errorf("Deleted memory should not be searchable, got %d results", len(results))
+	}
+
+	// The trash survives a restart
+	store.Close()
+	store = openTestStore(t, dir, withTrash)
+	defer store.Close()
+
+	trashed := store.Trash()
+	if len(trashed) != 1 || trashed[0].Memory.ID != m.ID {
+		t.Fatalf("Trash after reload = %+v", trashed)
+	}
+	if want := trashed[0].DeletedAt.Add(time.Hour); !trashed[0].PurgeAt.Equal(want) {
+		t.Errorf("PurgeAt = %v, want %v", trashed[0].PurgeAt, want)
+	}
+
+	restored, err := store.Restore(BaseID(m.ID))
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.ID != m.ID || !restored.IsCurrentVersion {
+		t.Errorf("Unexpected restored memory: %+v", restored)
+	}
+	if results, _ := store.Search(&SearchQuery{Query: "staging"}); len(results) != 1 {
+		t.Errorf("Restored memory should be searchable, got %d results", len(results))
+	}
+	if len(store.Trash()) != 0 {
+		t.Error("Restored memory should leave the trash")
+	}
+	if _, err := store.Restore(m.ID); err == nil {
+		t.Error("Restoring a memory that is not in the trash should fail")
+	}
+}
+
+func TestTrashedVersionsAreNotOverwritten(t *testing.T) {
+	store := newTestStore(t, withTrash)
+
+	content := "Feature flags are evaluated at request time"
+	first, _ := store.Store(content, "", "", nil, nil)
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// Storing the same content again takes the next free version
+	second, err := store.Store(content, "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("New memory reused the trashed ID %s", first.ID)
+	}
+
+	// The restored version stays behind the newer current one
+	restored, err := store.Restore(first.ID)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.IsCurrentVersion {
+		t.Error("Restored memory should not replace the newer current version")
+	}
+	current, err := store.Get(BaseID(first.ID))
+	if err != nil || current.ID != second.ID {
+		t.Errorf("Current version = %v, %v; want %s", current, err, second.ID)
+	}
+	history, err := store.GetHistory(BaseID(first.ID))
+	if err != nil || len(history) != 2 {
+		t.Errorf("History should hold both versions, got %d, %v", len(history), err)
+	}
+}
+
+func TestBulkDeleteDryRunAndPurge(t *testing.T) {
+	store := newTestStore(t, withTrash)
+
+	a, _ := store.Store("Old sprint notes one", "", "sprint", nil, nil)
+	b, _ := store.Store("Old sprint notes two", "", "sprint", nil, nil)
+	keep, _ := store.Store("Architecture decision record", "", "adr", nil, nil)
+
+	preview, err := store.BulkDelete(&BulkDeleteOptions{Category: "sprint", DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if !preview.DryRun || preview.Deleted != 0 || len(preview.IDs) != 2 {
+		t.Fatalf("Unexpected dry run result: %+v", preview)
+	}
+	for _, id := range []string{a.ID, b.ID} {
+		if _, err := store.Get(id); err != nil {
+			t.Errorf("Dry run should not delete %s: %v", id, err)
+		}
+	}
+
+	result, err := store.BulkDelete(&BulkDeleteOptions{Category: "sprint", Confirm: true, ConfirmToken: preview.ConfirmToken})
+	if err != nil {
+		t.Fatalf("BulkDelete failed: %v", err)
+	}
+	if result.Deleted != 2 || len(result.IDs) != 2 || result.IDs[0] != preview.IDs[0] || result.IDs[1] != preview.IDs[1] {
+		t.Errorf("Bulk delete should trash exactly the previewed IDs %v, got %+v", preview.IDs, result)
+	}
+	if len(store.Trash()) != 2 {
+		t.Errorf("Expected 2 memories in the trash, got %d", len(store.Trash()))
+	}
+
+	// Expired memories are purged for good
+	store.purgeExpired(time.Now().Add(2 * time.Hour))
+	if len(store.Trash()) != 0 {
+		t.Error("Expired memories should be purged")
+	}
+	if _, err := store.Restore(a.ID); err == nil {
+		t.Error("Purged memories cannot be restored")
+	}
+
+	if err := store.Delete(keep.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	purged, err := store.EmptyTrash()
+	if err != nil || purged != 1 {
+		t.Errorf("EmptyTrash = %d, %v; want 1", purged, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(store.dataDir, trashDir))
+	if len(entries) != 0 {
+		t.Errorf("Trash directory should be empty, has %d files", len(entries))
+	}
+}
@@ -0,0 +1,291 @@
+// internal/memory/usage.go
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mcp-memory-server/internal/config"
+)
+
+// NamespaceKey is the metadata key naming the namespace a memory belongs to
+const NamespaceKey = "namespace"
+
+// quotaWildcard is the quota name covering every category or namespace
+// without a quota of its own
+const quotaWildcard = "*"
+
+// Quota scopes
+const (
+	QuotaScopeCategory  = "category"
+	QuotaScopeNamespace = "namespace"
+)
+
+// ErrQuotaExceeded is returned when storing into a category or namespace
+// that has reached its quota under the reject policy
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Namespace returns the namespace of a memory, or "" when it has none
+func (m *Memory) Namespace() string {
+	return m.Metadata[NamespaceKey]
+}
+
+// QuotaUsage is the storage used by a category or namespace against its quota
+type QuotaUsage struct {
+	Scope   string  `json:"scope"` // QuotaScopeCategory or QuotaScopeNamespace
+	Name    string  `json:"name"`
+	Limit   int64   `json:"limit"`
+	Used    int64   `json:"used"`
+	UsedPct float64 `json:"used_pct"`
+}
+
+// usageKey is what a memory's bytes are accounted under
+type usageKey struct {
+	category  string
+	namespace string
+}
+
+// quotaFor returns the quota of a category or namespace, or 0 when it has none
+func quotaFor(quotas map[string]int64, name string) int64 {
+	if quota, exists := quotas[name]; exists {
+		return quota
+	}
+	return quotas[quotaWildcard]
+}
+
+// setMemorySize records the file size of a memory version. The caller must
+// hold s.mu.
+func (s *Store) setMemorySize(memory *Memory, size int64) {
+	s.dropMemorySize(memory.ID)
+	key := usageKey{category: memory.Category, namespace: memory.Namespace()}
+	s.memorySizes[memory.ID] = size
+	s.usageKeys[memory.ID] = key
+	s.totalSize += size
+	s.categoryUsage[key.category] += size
+	if key.namespace != "" {
+		s.namespaceUsage[key.namespace] += size
+	}
+}
+
+// dropMemorySize forgets the file size of a memory version. The caller must
+// hold s.mu.
+func (s *Store) dropMemorySize(id string) {
+	size, exists := s.memorySizes[id]
+	if !exists {
+		return
+	}
+	key := s.usageKeys[id]
+	s.totalSize -= size
+	if s.categoryUsage[key.category] -= size; s.categoryUsage[key.category] <= 0 {
+		delete(s.categoryUsage, key.category)
+	}
+	if key.namespace != "" {
+		if s.namespaceUsage[key.namespace] -= size; s.namespaceUsage[key.namespace] <= 0 {
+			delete(s.namespaceUsage, key.namespace)
+		}
+	}
+	delete(s.memorySizes, id)
+	delete(s.usageKeys, id)
+}
+
+// clearSizes forgets every file size. The caller must hold s.mu.
+func (s *Store) clearSizes() {
+	s.memorySizes = make(map[string]int64)
+	s.usageKeys = make(map[string]usageKey)
+	s.categoryUsage = make(map[string]int64)
+	s.namespaceUsage = make(map[string]int64)
+	s.totalSize = 0
+}
+
+// checkQuota returns ErrQuotaExceeded when the reject policy is in effect and
+// a memory file of size bytes would take the category or namespace over its
+// quota. The caller must hold s.mu.
+func (s *Store) checkQuota(category, namespace string, size int64) error {
+	if s.config.QuotaPolicy != config.QuotaPolicyReject {
+		return nil
+	}
+	if quota := quotaFor(s.config.CategoryQuotas, category); quota > 0 && s.categoryUsage[category]+size > quota {
+		return fmt.Errorf("%w: category %q uses %d of %d bytes, %d more do not fit", ErrQuotaExceeded, category, s.categoryUsage[category], quota, size)
+	}
+	if namespace == "" {
+		return nil
+	}
+	if quota := quotaFor(s.config.NamespaceQuotas, namespace); quota > 0 && s.namespaceUsage[namespace]+size > quota {
+		return fmt.Errorf("%w: namespace %q uses %d of %d bytes, %d more do not fit", ErrQuotaExceeded, namespace, s.namespaceUsage[namespace], quota, size)
+	}
+	return nil
+}
+
+// enforceLimits evicts memories once a save has pushed storage over its
+// limit, or the memory's category or namespace over its quota under the
+// evict policy
+func (s *Store) enforceLimits(memory *Memory) {
+	category, namespace := memory.Category, memory.Namespace()
+	categoryQuota := quotaFor(s.config.CategoryQuotas, category)
+	namespaceQuota := quotaFor(s.config.NamespaceQuotas, namespace)
+	evictQuota := s.config.QuotaPolicy != config.QuotaPolicyReject
+
+	s.mu.RLock()
+	overStorage := s.totalSize > s.config.MaxStorageSize
+	overCategory := evictQuota && categoryQuota > 0 && s.categoryUsage[category] > categoryQuota
+	overNamespace := evictQuota && namespace != "" && namespaceQuota > 0 && s.namespaceUsage[namespace] > namespaceQuota
+	s.mu.RUnlock()
+
+	if overStorage {
+		if err := s.cleanupOldMemories(); err != nil {
+			s.logger.WithError(err).Warn("Failed to cleanup old memories")
+		}
+	}
+	if overCategory {
+		s.evict(limitCategory, fmt.Sprintf("category quota %q", category),
+			func(m *Memory) bool { return m.Category == category },
+			func() bool { return s.categoryUsage[category] <= categoryQuota*9/10 })
+	}
+	if overNamespace {
+		s.evict(limitNamespace, fmt.Sprintf("namespace quota %q", namespace),
+			func(m *Memory) bool { return m.Namespace() == namespace },
+			func() bool { return s.namespaceUsage[namespace] <= namespaceQuota*9/10 })
+	}
+}
+
+// evict deletes the matching memories least worth keeping until done
+// reports true, counting them against limit. done is called with s.mu held
+// for reading.
+func (s *Store) evict(limit, reason string, match func(*Memory) bool, done func() bool) {
+	type candidate struct {
+		id         string
+		lastAccess time.Time
+		score      float64
+		size       int64
+	}
+
+	now := time.Now()
+	var candidates []candidate
+	s.mu.RLock()
+	for id, memory := range s.index {
+		// Protected memories are only evicted when the config allows it
+		if id != memory.ID || !match(memory) || (memory.Protected && !s.config.EvictProtected) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			id:         id,
+			lastAccess: memory.LastAccess,
+			score:      s.RetentionScore(memory, now),
+			size:       s.memorySizes[id],
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].lastAccess.Before(candidates[j].lastAccess)
+	})
+
+	for _, c := range candidates {
+		s.mu.RLock()
+		finished := done()
+		s.mu.RUnlock()
+		if finished {
+			break
+		}
+
+		if err := s.deleteMemory(c.id, true, AuditEvict, reason); err != nil {
+			s.logger.WithError(err).Warn("Failed to delete memory during cleanup", "id", c.id)
+			continue
+		}
+		evictions.Inc(limit)
+
+		s.logger.Info("Cleaned up old memory", "id", c.id, "size", c.size, "reason", reason, "last_access", c.lastAccess, "retention_score", c.score)
+	}
+}
+
+// QuotaUsage reports every configured quota with the storage it covers; a
+// wildcard quota is reported for each category or namespace it applies to
+func (s *Store) QuotaUsage() []QuotaUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quotaUsage()
+}
+
+// quotaUsage implements QuotaUsage. The caller must hold s.mu.
+func (s *Store) quotaUsage() []QuotaUsage {
+	var usage []QuotaUsage
+	collect := func(scope string, quotas, used map[string]int64) {
+		names := make(map[string]bool)
+		for name := range quotas {
+			if name != quotaWildcard {
+				names[name] = true
+			}
+		}
+		if _, exists := quotas[quotaWildcard]; exists {
+			for name := range used {
+				if name != "" || scope == QuotaScopeCategory {
+					names[name] = true
+				}
+			}
+		}
+		for name := range names {
+			limit := quotaFor(quotas, name)
+			usage = append(usage, QuotaUsage{
+				Scope:   scope,
+				Name:    name,
+				Limit:   limit,
+				Used:    used[name],
+				UsedPct: float64(used[name]) / float64(limit) * 100,
+			})
+		}
+	}
+	collect(QuotaScopeCategory, s.config.CategoryQuotas, s.categoryUsage)
+	collect(QuotaScopeNamespace, s.config.NamespaceQuotas, s.namespaceUsage)
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Scope != usage[j].Scope {
+			return usage[i].Scope < usage[j].Scope
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
+// usage breaks the stored bytes down by category, namespace and tag, and
+// separates current versions from the overhead of past ones. A memory counts
+// toward each of its tags. The caller must hold s.mu.
+func (s *queryEngine) usage(sizes map[string]int64) map[string]interface{} {
+	byCategory := make(map[string]int64)
+	byNamespace := make(map[string]int64)
+	byTag := make(map[string]int64)
+	var current, overhead int64
+
+	for id, memory := range s.index {
+		if id != memory.ID {
+			continue
+		}
+		size := sizes[id]
+		if memory.Category != "" {
+			byCategory[memory.Category] += size
+		}
+		if namespace := memory.Namespace(); namespace != "" {
+			byNamespace[namespace] += size
+		}
+		for _, tag := range memory.Tags {
+			byTag[tag] += size
+		}
+		if memory.IsCurrentVersion {
+			current += size
+		} else {
+			overhead += size
+		}
+	}
+
+	return map[string]interface{}{
+		"by_category":            byCategory,
+		"by_namespace":           byNamespace,
+		"by_tag":                 byTag,
+		"current_bytes":          current,
+		"version_overhead_bytes": overhead,
+	}
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/metrics"
+)
+
+// withQuotas sets the quota policy and the category and namespace quotas
+func withQuotas(policy string, categoryQuotas, namespaceQuotas map[string]int64) func(*config.StorageConfig) {
+	return func(cfg *config.StorageConfig) {
+		cfg.CategoryQuotas = categoryQuotas
+		cfg.NamespaceQuotas = namespaceQuotas
+		cfg.QuotaPolicy = policy
+	}
+}
+
+// evictionCount scrapes the evictions counted against a limit
+func evictionCount(t *testing.T, limit string) string {
+	t.Helper()
+	var b strings.Builder
+	metrics.Default.WriteTo(&b)
+	prefix := fmt.Sprintf("mcp_memory_evictions_total{limit=%q} ", limit)
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return "0"
+}
+
+func TestCategoryQuotaEvictsWithinCategory(t *testing.T) {
+	store := newTestStore(t, withQuotas(config.QuotaPolicyEvict, map[string]int64{"logs": 2000}, nil))
+
+	note, err := store.Store("Releases go out on Tuesdays", "", "notes", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	evictedBefore := evictionCount(t, limitCategory)
+	for i := 0; i < 20; i++ {
+		if _, err := store.Store(fmt.Sprintf("Build %d finished in %d seconds", i, 40+i), "", "logs", []string{"ci"}, nil); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	quotas := store.QuotaUsage()
+	if len(quotas) != 1 || quotas[0].Name != "logs" || quotas[0].Used > quotas[0].Limit {
+		t.Fatalf("Quota usage = %+v, want logs within its quota", quotas)
+	}
+	if _, err := store.Get(note.ID); err != nil {
+		t.Errorf("Evicting logs should leave other categories alone: %v", err)
+	}
+	if evictionCount(t, limitCategory) == evictedBefore {
+		t.Error("Quota evictions should be counted in the metrics")
+	}
+
+	usage := store.GetStats()["usage"].(map[string]interface{})
+	byCategory := usage["by_category"].(map[string]int64)
+	if byCategory["logs"] != quotas[0].Used || byCategory["notes"] == 0 {
+		t.Errorf("Usage by category = %v, want logs at %d", byCategory, quotas[0].Used)
+	}
+	if usage["by_tag"].(map[string]int64)["ci"] != byCategory["logs"] {
+		t.Errorf("Usage by tag = %v, want ci to cover the logs", usage["by_tag"])
+	}
+}
+
+func TestQuotaRejectPolicy(t *testing.T) {
+	store := newTestStore(t, withQuotas(config.QuotaPolicyReject, map[string]int64{"*": 1 << 20}, map[string]int64{"team-a": 1 << 20}))
+
+	first, err := store.Store("Lunch is at noon", "", "notes", nil, nil)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	size := store.memorySizes[first.ID]
+
+	// A memory that would take the category past its quota is rejected even
+	// though the category is still below it
+	store.config.CategoryQuotas["*"] = size + 10
+	if _, err := store.Store("Coffee is on the second floor", "", "notes", nil, nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Store past the category quota = %v, want ErrQuotaExceeded", err)
+	}
+	if _, exists := store.index[store.generateID("Coffee is on the second floor")]; exists {
+		t.Error("A rejected memory should not be indexed")
+	}
+	store.config.CategoryQuotas["*"] = 3 * size
+	if _, err := store.Store("Coffee is on the 2nd floor", "", "notes", nil, nil); err != nil {
+		t.Errorf("A memory that fits the category quota should be stored: %v", err)
+	}
+
+	// A rejected new version leaves the current one in place
+	store.config.CategoryQuotas["*"] = store.categoryUsage["notes"]
+	if _, err := store.Store(first.Content, "Lunch", "notes", nil, nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Storing a version past the quota = %v, want ErrQuotaExceeded", err)
+	}
+	if current, err := store.Get(BaseID(first.ID)); err != nil || current.ID != first.ID || !current.IsCurrentVersion {
+		t.Errorf("Current version after a rejected version = %+v, %v, want %s", current, err, first.ID)
+	}
+
+	store.config.CategoryQuotas["*"] = 1 << 20
+	team, err := store.Store("Team A owns billing", "", "teams", nil, map[string]string{NamespaceKey: "team-a"})
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	store.config.NamespaceQuotas["team-a"] = store.memorySizes[team.ID] + 10
+	if _, err := store.Store("Team A is on call this week", "", "oncall", nil, map[string]string{NamespaceKey: "team-a"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Store past the namespace quota = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestUsageSeparatesVersionOverhead(t *testing.T) {
+	store := newTestStore(t, nil)
+
+	first, err := store.Store("The VPN endpoint is vpn.example.com", "", "ops", nil, map[string]string{NamespaceKey: "infra"})
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, err := store.Store(first.Content, "VPN endpoint", "ops", nil, map[string]string{NamespaceKey: "infra"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	stats := store.GetStats()
+	usage := stats["usage"].(map[string]interface{})
+	current, overhead := usage["current_bytes"].(int64), usage["version_overhead_bytes"].(int64)
+	if current == 0 || overhead == 0 {
+		t.Errorf("Current %d and overhead %d bytes, want both counted", current, overhead)
+	}
+	if total := stats["total_size"].(int64); current+overhead != total {
+		t.Errorf("Current and overhead add up to %d, want the total %d", current+overhead, total)
+	}
+	if byNamespace := usage["by_namespace"].(map[string]int64); byNamespace["infra"] != current+overhead {
+		t.Errorf("Usage by namespace = %v, want infra to hold both versions", byNamespace)
+	}
+
+	// Deleting releases the namespace's bytes
+	if err := store.Delete(BaseID(first.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if used := store.namespaceUsage["infra"]; used != 0 {
+		t.Errorf("Namespace usage after deleting = %d, want 0", used)
+	}
+}
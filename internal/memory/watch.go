@@ -0,0 +1,130 @@
+// internal/memory/watch.go
+package memory
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is how often the memories directory is scanned where
+// filesystem notifications are unavailable
+const DefaultWatchInterval = 2 * time.Second
+
+const (
+	watchSettle       = 50 * time.Millisecond // a burst of file changes within this becomes one update
+	updatesBuffer     = 16                    // updates a slow subscriber may fall behind before missing some
+	watchChangeBuffer = 16
+)
+
+// errNotifyUnsupported is returned where filesystem notifications are not
+// implemented; the watcher polls instead
+var errNotifyUnsupported = errors.New("filesystem notifications are not supported on this platform")
+
+// IndexUpdate describes the changes one refresh applied to a ReadOnlyStore
+type IndexUpdate struct {
+	Updated  []string  `json:"updated,omitempty"`  // IDs of memories added or changed
+	Removed  []string  `json:"removed,omitempty"`  // IDs of memories removed
+	Reloaded bool      `json:"reloaded,omitempty"` // the whole index was reloaded
+	Time     time.Time `json:"time"`
+}
+
+func (u *IndexUpdate) empty() bool {
+	return len(u.Updated) == 0 && len(u.Removed) == 0 && !u.Reloaded
+}
+
+// dirChange is a batch of changed file names in a watched directory. Resync
+// is set when changes may have been missed and everything must be reread.
+type dirChange struct {
+	Names  []string
+	Resync bool
+}
+
+// merge adds another batch, keeping each name once
+func (c *dirChange) merge(other dirChange) {
+	c.Resync = c.Resync || other.Resync
+	for _, name := range other.Names {
+		if !containsString(c.Names, name) {
+			c.Names = append(c.Names, name)
+		}
+	}
+}
+
+// memoryFileID returns the memory ID a file in the memories directory holds,
+// or "" for temporary and unrelated files
+func memoryFileID(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".json.gz"):
+		return strings.TrimSuffix(name, ".json.gz")
+	case strings.HasSuffix(name, ".json"):
+		return strings.TrimSuffix(name, ".json")
+	case strings.HasSuffix(name, markdownExt):
+		return strings.TrimSuffix(name, markdownExt)
+	}
+	return ""
+}
+
+// fileState is what polling compares to notice a changed file
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+func scanDir(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files[entry.Name()] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+	}
+	return files
+}
+
+// pollDir scans dir every interval and sends the names of files added,
+// changed or removed since the previous scan until stop is closed
+func pollDir(dir string, interval time.Duration, stop <-chan struct{}, changes chan<- dirChange) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	known := scanDir(dir)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := scanDir(dir)
+		var change dirChange
+		for name, state := range current {
+			if old, exists := known[name]; !exists || old != state {
+				change.Names = append(change.Names, name)
+			}
+		}
+		for name := range known {
+			if _, exists := current[name]; !exists {
+				change.Names = append(change.Names, name)
+			}
+		}
+		known = current
+		if len(change.Names) == 0 {
+			continue
+		}
+
+		select {
+		case changes <- change:
+		case <-stop:
+			return
+		}
+	}
+}
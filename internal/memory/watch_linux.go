@@ -0,0 +1,79 @@
+// internal/memory/watch_linux.go
+//go:build linux
+
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const notifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM |
+	syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// startNotify watches dir with inotify and sends the names of changed files
+// to changes until stop is closed
+func startNotify(dir string, stop <-chan struct{}, changes chan<- dirChange) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, dir, notifyMask); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	// A non-blocking descriptor goes through the runtime poller, so closing
+	// the file ends a pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-stop
+		file.Close()
+	}()
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			change := parseNotifyEvents(buf[:n])
+			if len(change.Names) == 0 && !change.Resync {
+				continue
+			}
+			select {
+			case changes <- change:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// parseNotifyEvents turns a read of inotify events into a batch of names
+func parseNotifyEvents(buf []byte) dirChange {
+	var change dirChange
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		if nameEnd > len(buf) {
+			break
+		}
+
+		// A dropped event or a moved directory loses track of changes
+		if event.Mask&(syscall.IN_Q_OVERFLOW|syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+			change.Resync = true
+		}
+		if event.Len > 0 {
+			name := string(bytes.TrimRight(buf[nameStart:nameEnd], "\x00"))
+			change.merge(dirChange{Names: []string{name}})
+		}
+		offset = nameEnd
+	}
+	return change
+}
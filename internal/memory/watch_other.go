@@ -0,0 +1,9 @@
+// internal/memory/watch_other.go
+//go:build !linux
+
+package memory
+
+// startNotify is unavailable here; the watcher polls instead
+func startNotify(dir string, stop <-chan struct{}, changes chan<- dirChange) error {
+	return errNotifyUnsupported
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp-memory-server/pkg/logger"
+)
+
+// nextUpdate waits for an index update matching want
+func nextUpdate(t *testing.T, updates <-chan IndexUpdate, want func(IndexUpdate) bool) IndexUpdate {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case update := <-updates:
+			if want(update) {
+				return update
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for an index update")
+		}
+	}
+}
+
+func TestReadOnlyStoreWatchAppliesChanges(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, withTrash)
+	defer store.Close()
+	reader, err := NewReadOnlyStore(dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create read-only store: %v", err)
+	}
+	updates, unsubscribe := reader.Updates()
+	defer unsubscribe()
+	if err := reader.Watch(10 * time.Millisecond); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer reader.Close()
+	if err := reader.Watch(10 * time.Millisecond); err == nil {
+		t.Error("Watching twice should fail")
+	}
+
+	stored, _ := store.Store("Status page lives at status.example.com", "", "ops", nil, nil)
+	nextUpdate(t, updates, func(u IndexUpdate) bool { return containsString(u.Updated, stored.ID) })
+	reader.mu.RLock()
+	_, exists := reader.index[stored.ID]
+	reader.mu.RUnlock()
+	if !exists {
+		t.Errorf("Watched store should contain %s", stored.ID)
+	}
+
+	if err := store.Delete(stored.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	nextUpdate(t, updates, func(u IndexUpdate) bool { return containsString(u.Removed, stored.ID) })
+	reader.mu.RLock()
+	_, exists = reader.index[stored.ID]
+	reader.mu.RUnlock()
+	if exists {
+		t.Errorf("Watched store should drop %s", stored.ID)
+	}
+
+	// Closing ends subscriptions
+	reader.Close()
+	for range updates {
+	}
+}
+
+func TestPollDirReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a1b2.json")
+	os.WriteFile(path, []byte("{}"), 0644)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	changes := make(chan dirChange)
+	go pollDir(dir, 10*time.Millisecond, stop, changes)
+
+	wait := func(what string) dirChange {
+		t.Helper()
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		return dirChange{}
+	}
+
+	// Let the first scan see the existing file, then change it
+	time.Sleep(30 * time.Millisecond)
+	os.WriteFile(path, []byte(`{"id":"a1b2"}`), 0644)
+	if change := wait("a change"); len(change.Names) != 1 || change.Names[0] != "a1b2.json" {
+		t.Errorf("Change = %+v, want a1b2.json", change)
+	}
+	os.Remove(path)
+	if change := wait("a removal"); len(change.Names) != 1 || memoryFileID(change.Names[0]) != "a1b2" {
+		t.Errorf("Removal = %+v, want a1b2.json", change)
+	}
+}
@@ -0,0 +1,269 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 100µs to 10s
+var DefaultBuckets = []float64{0.0001, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry the server exposes on /metrics
+var Default = NewRegistry()
+
+// Metric types as named in the exposition format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	families   map[string]*family
+	collectors map[string]func() // key -> hook run before each scrape
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families:   make(map[string]*family),
+		collectors: make(map[string]func()),
+	}
+}
+
+// family is a metric name with its series, one per combination of label values
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64 // histograms only
+
+	mu     sync.Mutex
+	series map[string]*series // joined label values -> series
+}
+
+// series is the state of one combination of label values
+type series struct {
+	values []string
+	value  float64  // counters and gauges
+	counts []uint64 // histograms: observations per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Counter is a value that only goes up
+type Counter struct{ f *family }
+
+// Gauge is a value that goes up and down
+type Gauge struct{ f *family }
+
+// Histogram counts observations into buckets
+type Histogram struct{ f *family }
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, typeCounter, labels, nil)}
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, typeGauge, labels, nil)}
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds and
+// label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{r.register(name, help, typeHistogram, labels, buckets)}
+}
+
+// OnCollect runs fn before every scrape, typically to set gauges from state
+// that is cheaper to read than to track. Registering a key again replaces
+// its hook, and a nil fn removes it.
+func (r *Registry) OnCollect(key string, fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if fn == nil {
+		delete(r.collectors, key)
+		return
+	}
+	r.collectors[key] = fn
+}
+
+// register adds a family; registering a name twice is a programming error
+func (r *Registry) register(name, help, kind string, labels []string, buckets []float64) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	if len(labels) == 0 {
+		f.get(nil)
+	}
+	r.families[name] = f
+	return f
+}
+
+// get returns the series for the label values, creating it. The caller must
+// hold f.mu unless the family is not yet shared.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, exists := f.series[key]
+	if !exists {
+		s = &series{values: append([]string(nil), values...)}
+		if f.kind == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds v, which must not be negative, to the counter
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.get(labels).value += v
+	c.f.mu.Unlock()
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.f.mu.Lock()
+	g.f.get(labels).value = v
+	g.f.mu.Unlock()
+}
+
+// Add adds v to the gauge
+func (g *Gauge) Add(v float64, labels ...string) {
+	g.f.mu.Lock()
+	g.f.get(labels).value += v
+	g.f.mu.Unlock()
+}
+
+// Observe records one observation
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(labels)
+	if i := sort.SearchFloat64s(h.f.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince records the seconds elapsed since start
+func (h *Histogram) ObserveSince(start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format,
+// sorted by name, after running the collect hooks
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]func(), 0, len(r.collectors))
+	for _, fn := range r.collectors {
+		collectors = append(collectors, fn)
+	}
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	for _, fn := range collectors {
+		fn()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// write renders the family with its series sorted by label values
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, strings.ReplaceAll(f.help, "\n", " "))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != typeHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelSet(s.values, "", ""), formatValue(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelSet(s.values, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelSet(s.values, "", ""), s.count)
+	}
+}
+
+// labelSet renders label pairs, with an extra pair when name is set
+func (f *family) labelSet(values []string, name, value string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, label := range f.labels {
+		pairs = append(pairs, label+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	if name != "" {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(value)+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values for the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatValue renders a sample value as Prometheus expects it
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	return b.String()
+}
+
+func TestTextFormat(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests served.", "route")
+	depth := r.NewGauge("test_queue_depth", "Queued items.")
+	latency := r.NewHistogram("test_latency_seconds", "Latency.", []float64{0.5, 0.1}, "route")
+
+	requests.Inc(`/a"b`)
+	requests.Add(2, "/c")
+	r.OnCollect("depth", func() { depth.Set(7) })
+	latency.Observe(0.05, "/c")
+	latency.Observe(0.1, "/c")
+	latency.Observe(3, "/c")
+
+	want := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/c",le="0.1"} 2
+test_latency_seconds_bucket{route="/c",le="0.5"} 2
+test_latency_seconds_bucket{route="/c",le="+Inf"} 3
+test_latency_seconds_sum{route="/c"} 3.15
+test_latency_seconds_count{route="/c"} 3
+# HELP test_queue_depth Queued items.
+# TYPE test_queue_depth gauge
+test_queue_depth 7
+# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"b"} 1
+test_requests_total{route="/c"} 2
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("Exposition =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInstrumentRecordsStatus(t *testing.T) {
+	handler := Instrument("/things", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/things/42", nil))
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	text := scrape(t, Default)
+	for _, line := range []string{
+		`mcp_http_requests_total{route="/things",method="GET",code="404"} 1`,
+		`mcp_http_request_duration_seconds_count{route="/things",method="GET"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Missing %s in:\n%s", line, text)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// internal/replication/clock.go
+package replication
+
+// Ordering is how two vector clocks relate
+type Ordering int
+
+const (
+	Equal      Ordering = iota // both saw the same changes
+	Before                     // the first clock happened before the second
+	After                      // the first clock happened after the second
+	Concurrent                 // each saw changes the other did not
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	}
+	return "concurrent"
+}
+
+// VectorClock counts the changes each node made to a memory version
+type VectorClock map[string]uint64
+
+// Compare reports how c relates to other
+func (c VectorClock) Compare(other VectorClock) Ordering {
+	less, greater := false, false
+	for node, count := range c {
+		if count > other[node] {
+			greater = true
+		}
+	}
+	for node, count := range other {
+		if count > c[node] {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+// Merge returns a clock that has seen the changes of both c and other
+func (c VectorClock) Merge(other VectorClock) VectorClock {
+	merged := c.Copy()
+	for node, count := range other {
+		if count > merged[node] {
+			merged[node] = count
+		}
+	}
+	return merged
+}
+
+// Tick returns a copy of c with one more change by node
+func (c VectorClock) Tick(node string) VectorClock {
+	ticked := c.Copy()
+	ticked[node]++
+	return ticked
+}
+
+// Copy returns an independent copy of c
+func (c VectorClock) Copy() VectorClock {
+	copied := make(VectorClock, len(c))
+	for node, count := range c {
+		copied[node] = count
+	}
+	return copied
+}
@@ -0,0 +1,702 @@
+// internal/replication/replication.go
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/internal/metrics"
+	"mcp-memory-server/pkg/logger"
+)
+
+// Sync protocol routes
+const (
+	ChangesPath = "/sync/changes" // GET pulls changes after ?since=, POST pushes a ChangeSet
+	StatusPath  = "/sync/status"
+)
+
+const (
+	stateFile = "replication/state.json"
+
+	// DefaultBatchSize is how many changes one request carries by default
+	DefaultBatchSize = 500
+	// MaxBatchSize is the most changes one request carries
+	MaxBatchSize = 5000
+
+	maxRequestSize = 256 * 1024 * 1024
+)
+
+// Change is the latest state of one memory version in a node's change log
+type Change struct {
+	Seq     uint64         `json:"seq"` // position in the sending node's log
+	ID      string         `json:"id"`
+	Clock   VectorClock    `json:"clock"`
+	Origin  string         `json:"origin"` // node that made the change
+	Deleted bool           `json:"deleted,omitempty"`
+	Memory  *memory.Memory `json:"memory,omitempty"` // nil when deleted
+}
+
+// ChangeSet is a batch of changes from a node's log
+type ChangeSet struct {
+	Node    string   `json:"node"`
+	Changes []Change `json:"changes"`
+	Last    uint64   `json:"last"` // sequence number to resume after
+	More    bool     `json:"more,omitempty"`
+}
+
+// ApplyResult counts what applying a change set did
+type ApplyResult struct {
+	Applied   int      `json:"applied"`             // versions imported or removed
+	Ignored   int      `json:"ignored"`             // changes already seen
+	Failed    int      `json:"failed"`              // changes that could not be applied
+	Conflicts []string `json:"conflicts,omitempty"` // IDs of siblings kept for concurrent edits
+}
+
+func (a *ApplyResult) add(other ApplyResult) {
+	a.Applied += other.Applied
+	a.Ignored += other.Ignored
+	a.Failed += other.Failed
+	a.Conflicts = append(a.Conflicts, other.Conflicts...)
+}
+
+// SyncResult reports one exchange with a peer
+type SyncResult struct {
+	Peer   string      `json:"peer"`
+	Node   string      `json:"node"`
+	Pulled ApplyResult `json:"pulled"` // the peer's changes applied here
+	Pushed ApplyResult `json:"pushed"` // our changes applied by the peer
+}
+
+// PeerStatus tracks the exchange with one peer
+type PeerStatus struct {
+	Node      string    `json:"node"`
+	Pulled    uint64    `json:"pulled"` // last sequence number pulled from the peer's log
+	Pushed    uint64    `json:"pushed"` // last sequence number of our log pushed to the peer
+	LastSync  time.Time `json:"last_sync,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Status summarizes a node's replication state
+type Status struct {
+	Node       string                `json:"node"`
+	Seq        uint64                `json:"seq"`
+	Versions   int                   `json:"versions"`
+	Tombstones int                   `json:"tombstones"`
+	Peers      map[string]PeerStatus `json:"peers"`
+}
+
+// entry is the replication state of one memory version
+type entry struct {
+	Clock   VectorClock `json:"clock"`
+	Origin  string      `json:"origin"`
+	Digest  string      `json:"digest,omitempty"`
+	Deleted bool        `json:"deleted,omitempty"`
+	Seq     uint64      `json:"seq"`
+}
+
+// state is what the replicator persists between runs
+type state struct {
+	Node    string                 `json:"node"`
+	Seq     uint64                 `json:"seq"` // sequence number of the last change logged
+	Entries map[string]*entry      `json:"entries"`
+	Peers   map[string]*PeerStatus `json:"peers"`
+}
+
+// Replicator exchanges memory changes with other instances. Every memory
+// version carries a vector clock; the log holds the latest change of each
+// version, so a peer that was away catches up with one pass over it.
+// Concurrent edits of a version are resolved the same way on every node: the
+// edit from the higher node ID keeps the version ID and the other is kept as
+// a sibling version.
+type Replicator struct {
+	config *config.SyncConfig
+	store  *memory.Store
+	path   string
+	client *http.Client
+	logger *logger.Logger
+
+	mu    sync.Mutex
+	state state
+}
+
+// NewReplicator creates a replicator for store, restoring its state from
+// dataDir
+func NewReplicator(cfg *config.SyncConfig, store *memory.Store, dataDir string, log *logger.Logger) (*Replicator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Replicator{
+		config: cfg,
+		store:  store.As(memory.ReplicationCaller),
+		path:   filepath.Join(dataDir, stateFile),
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: log.WithComponent("replication"),
+	}
+
+	data, err := os.ReadFile(r.path)
+	switch {
+	case os.IsNotExist(err):
+		r.state.Node = cfg.NodeID
+		if r.state.Node == "" {
+			r.state.Node = newNodeID()
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read replication state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &r.state); err != nil {
+			return nil, fmt.Errorf("failed to parse replication state: %w", err)
+		}
+		if cfg.NodeID != "" && cfg.NodeID != r.state.Node {
+			return nil, fmt.Errorf("data directory is replicated as node %s, not %s", r.state.Node, cfg.NodeID)
+		}
+	}
+	if r.state.Entries == nil {
+		r.state.Entries = make(map[string]*entry)
+	}
+	if r.state.Peers == nil {
+		r.state.Peers = make(map[string]*PeerStatus)
+	}
+
+	r.mu.Lock()
+	r.observe()
+	r.save()
+	r.mu.Unlock()
+	return r, nil
+}
+
+// Node returns the ID of this instance in vector clocks
+func (r *Replicator) Node() string {
+	return r.state.Node
+}
+
+// Run syncs with every configured peer each interval until ctx is cancelled
+func (r *Replicator) Run(ctx context.Context) error {
+	r.logger.Info("Replication started", "node", r.Node(), "peers", len(r.config.Peers))
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		for _, peer := range r.config.Peers {
+			result, err := r.Sync(ctx, peer)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				r.logger.WithError(err).Warn("Sync failed", "peer", peer)
+				continue
+			}
+			if len(result.Pulled.Conflicts) > 0 || len(result.Pushed.Conflicts) > 0 {
+				r.logger.Info("Sync kept conflicting edits as siblings", "peer", peer, "pulled", result.Pulled.Conflicts, "pushed", result.Pushed.Conflicts)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync pulls a peer's changes, then pushes ours to it
+func (r *Replicator) Sync(ctx context.Context, peer string) (*SyncResult, error) {
+	peer = strings.TrimRight(peer, "/")
+	result := &SyncResult{Peer: peer}
+	err := r.sync(ctx, peer, result)
+
+	r.mu.Lock()
+	status := r.peer(peer)
+	status.LastSync = time.Now()
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	r.save()
+	r.mu.Unlock()
+	return result, err
+}
+
+func (r *Replicator) sync(ctx context.Context, peer string, result *SyncResult) error {
+	for {
+		r.mu.Lock()
+		status := *r.peer(peer)
+		r.mu.Unlock()
+
+		var set ChangeSet
+		url := fmt.Sprintf("%s%s?since=%d&limit=%d", peer, ChangesPath, status.Pulled, DefaultBatchSize)
+		if err := r.do(ctx, http.MethodGet, url, nil, &set); err != nil {
+			return fmt.Errorf("failed to pull from %s: %w", peer, err)
+		}
+		if set.Node == r.Node() {
+			return fmt.Errorf("peer %s is this node", peer)
+		}
+		result.Node = set.Node
+
+		// A peer with a new identity starts a new log
+		if status.Node != set.Node {
+			r.mu.Lock()
+			r.state.Peers[peer] = &PeerStatus{Node: set.Node}
+			r.mu.Unlock()
+			if status.Node != "" {
+				r.logger.Warn("Peer changed identity, resyncing", "peer", peer, "was", status.Node, "now", set.Node)
+				continue
+			}
+		}
+
+		result.Pulled.add(r.Apply(&set))
+		r.mu.Lock()
+		r.peer(peer).Pulled = set.Last
+		r.mu.Unlock()
+		if !set.More {
+			break
+		}
+	}
+
+	for {
+		r.mu.Lock()
+		pushed := r.peer(peer).Pushed
+		r.mu.Unlock()
+
+		set := r.Changes(pushed, DefaultBatchSize)
+		if len(set.Changes) > 0 {
+			var applied ApplyResult
+			if err := r.do(ctx, http.MethodPost, peer+ChangesPath, set, &applied); err != nil {
+				return fmt.Errorf("failed to push to %s: %w", peer, err)
+			}
+			result.Pushed.add(applied)
+		}
+		r.mu.Lock()
+		r.peer(peer).Pushed = set.Last
+		r.mu.Unlock()
+		if !set.More {
+			return nil
+		}
+	}
+}
+
+// Changes returns the changes logged after since, oldest first
+func (r *Replicator) Changes(since uint64, limit int) *ChangeSet {
+	if limit <= 0 {
+		limit = DefaultBatchSize
+	}
+	if limit > MaxBatchSize {
+		limit = MaxBatchSize
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.observe() {
+		r.save()
+	}
+
+	ids := make([]string, 0)
+	for id, e := range r.state.Entries {
+		if e.Seq > since {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return r.state.Entries[ids[i]].Seq < r.state.Entries[ids[j]].Seq
+	})
+
+	set := &ChangeSet{Node: r.state.Node, Changes: []Change{}, Last: r.state.Seq}
+	if len(ids) > limit {
+		ids = ids[:limit]
+		set.More = true
+		set.Last = r.state.Entries[ids[limit-1]].Seq
+	}
+	for _, id := range ids {
+		e := r.state.Entries[id]
+		change := Change{Seq: e.Seq, ID: id, Clock: e.Clock.Copy(), Origin: e.Origin, Deleted: e.Deleted}
+		if !e.Deleted {
+			stored, exists := r.store.LookupVersion(id)
+			if !exists {
+				// Deleted since it was observed; the next pass logs the delete
+				continue
+			}
+			change.Memory = stored
+		}
+		set.Changes = append(set.Changes, change)
+	}
+	return set
+}
+
+// Apply merges a peer's changes into the store. Changes that fail are
+// logged and counted but do not stop the rest.
+func (r *Replicator) Apply(set *ChangeSet) ApplyResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observe()
+
+	var result ApplyResult
+	for i := range set.Changes {
+		if err := r.apply(&set.Changes[i], &result); err != nil {
+			result.Failed++
+			r.logger.WithError(err).Warn("Failed to apply change", "id", set.Changes[i].ID, "from", set.Node)
+		}
+	}
+	r.save()
+	return result
+}
+
+// apply merges one change. The caller must hold r.mu.
+func (r *Replicator) apply(change *Change, result *ApplyResult) error {
+	if !memory.ValidVersionID(change.ID) {
+		return fmt.Errorf("invalid memory version ID %q", change.ID)
+	}
+	remoteDigest := ""
+	if !change.Deleted {
+		if change.Memory == nil || change.Memory.ID != change.ID {
+			return fmt.Errorf("change of %s has no memory", change.ID)
+		}
+		remoteDigest = digest(change.Memory)
+	}
+
+	// A version never seen here is as good as deleted with an empty clock
+	local := r.state.Entries[change.ID]
+	if local == nil {
+		local = &entry{Clock: VectorClock{}, Deleted: true}
+	}
+
+	switch change.Clock.Compare(local.Clock) {
+	case Equal, Before:
+		result.Ignored++
+		return nil
+	case After:
+		if err := r.adopt(change); err != nil {
+			return err
+		}
+		r.record(change.ID, &entry{Clock: change.Clock.Copy(), Origin: change.Origin, Digest: remoteDigest, Deleted: change.Deleted})
+		result.Applied++
+		return nil
+	}
+
+	// Concurrent changes: every node picks the same outcome and ends up with
+	// the merged clock
+	merged := local.Clock.Merge(change.Clock)
+	switch {
+	case local.Deleted == change.Deleted && local.Digest == remoteDigest:
+		origin := local.Origin
+		if change.Origin > origin {
+			origin = change.Origin
+		}
+		r.record(change.ID, &entry{Clock: merged, Origin: origin, Digest: local.Digest, Deleted: local.Deleted})
+		result.Ignored++
+	case change.Deleted:
+		// An edit survives a concurrent delete
+		r.record(change.ID, &entry{Clock: merged, Origin: local.Origin, Digest: local.Digest})
+		result.Ignored++
+	case local.Deleted:
+		if err := r.adopt(change); err != nil {
+			return err
+		}
+		r.record(change.ID, &entry{Clock: merged, Origin: change.Origin, Digest: remoteDigest})
+		result.Applied++
+	default:
+		remoteWins := change.Origin > local.Origin || change.Origin == local.Origin && remoteDigest > local.Digest
+		if remoteWins {
+			if stored, exists := r.store.LookupVersion(change.ID); exists {
+				sibling := siblingOf(stored, siblingSuffix(local, change.Origin))
+				if err := r.store.ImportVersion(sibling); err != nil {
+					return fmt.Errorf("failed to keep conflicting version: %w", err)
+				}
+				result.Conflicts = append(result.Conflicts, sibling.ID)
+			}
+			if err := r.adopt(change); err != nil {
+				return err
+			}
+			r.record(change.ID, &entry{Clock: merged, Origin: change.Origin, Digest: remoteDigest})
+			result.Applied++
+		} else {
+			remote := &entry{Origin: change.Origin, Digest: remoteDigest}
+			sibling := siblingOf(change.Memory, siblingSuffix(remote, local.Origin))
+			if err := r.store.ImportVersion(sibling); err != nil {
+				return fmt.Errorf("failed to keep conflicting version: %w", err)
+			}
+			result.Conflicts = append(result.Conflicts, sibling.ID)
+			r.record(change.ID, &entry{Clock: merged, Origin: local.Origin, Digest: local.Digest})
+			result.Ignored++
+		}
+		r.logger.Info("Concurrent edits kept as siblings", "id", change.ID, "conflict", result.Conflicts[len(result.Conflicts)-1])
+	}
+	return nil
+}
+
+// adopt makes the store match a change that supersedes the local version
+func (r *Replicator) adopt(change *Change) error {
+	if !change.Deleted {
+		return r.store.ImportVersion(change.Memory)
+	}
+	if _, exists := r.store.LookupVersion(change.ID); !exists {
+		return nil
+	}
+	return r.store.RemoveVersion(change.ID)
+}
+
+// observe logs local changes made since the last pass: versions whose
+// replicated fields changed tick this node's clock, and versions that
+// disappeared become tombstones. Siblings replicate like other versions, so
+// the node whose edit won learns the losing one. The caller must hold r.mu.
+func (r *Replicator) observe() bool {
+	node := r.state.Node
+	changed := false
+	seen := make(map[string]bool)
+	for _, stored := range r.store.Versions() {
+		seen[stored.ID] = true
+		d := digest(stored)
+		e := r.state.Entries[stored.ID]
+		if e != nil && !e.Deleted && e.Digest == d {
+			continue
+		}
+		clock := VectorClock{}
+		if e != nil {
+			clock = e.Clock
+		}
+		r.record(stored.ID, &entry{Clock: clock.Tick(node), Origin: node, Digest: d})
+		changed = true
+	}
+	for id, e := range r.state.Entries {
+		if !e.Deleted && !seen[id] {
+			r.record(id, &entry{Clock: e.Clock.Tick(node), Origin: node, Deleted: true})
+			changed = true
+		}
+	}
+	return changed
+}
+
+// record logs the new state of a version. The caller must hold r.mu.
+func (r *Replicator) record(id string, e *entry) {
+	r.state.Seq++
+	e.Seq = r.state.Seq
+	r.state.Entries[id] = e
+}
+
+// peer returns the status of a peer, creating it. The caller must hold r.mu.
+func (r *Replicator) peer(url string) *PeerStatus {
+	status, exists := r.state.Peers[url]
+	if !exists {
+		status = &PeerStatus{}
+		r.state.Peers[url] = status
+	}
+	return status
+}
+
+// Status returns a summary of the replication state
+func (r *Replicator) Status() *Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &Status{Node: r.state.Node, Seq: r.state.Seq, Peers: make(map[string]PeerStatus)}
+	for _, e := range r.state.Entries {
+		if e.Deleted {
+			status.Tombstones++
+		} else {
+			status.Versions++
+		}
+	}
+	for url, peer := range r.state.Peers {
+		status.Peers[url] = *peer
+	}
+	return status
+}
+
+// save persists the state. The caller must hold r.mu.
+func (r *Replicator) save() {
+	data, err := json.Marshal(r.state)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to marshal replication state")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		r.logger.WithError(err).Warn("Failed to create replication directory")
+		return
+	}
+	tempFile := r.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		r.logger.WithError(err).Warn("Failed to write replication state")
+		return
+	}
+	if err := os.Rename(tempFile, r.path); err != nil {
+		os.Remove(tempFile)
+		r.logger.WithError(err).Warn("Failed to save replication state")
+	}
+}
+
+// Handler serves the sync protocol
+func (r *Replicator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ChangesPath, metrics.InstrumentFunc(ChangesPath, r.authorized(r.handleChanges)))
+	mux.HandleFunc(StatusPath, metrics.InstrumentFunc(StatusPath, r.authorized(r.handleStatus)))
+	return mux
+}
+
+// authorized rejects requests without the configured token
+func (r *Replicator) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.config.Token != "" {
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(r.config.Token)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, req)
+	}
+}
+
+func (r *Replicator) handleChanges(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		query := req.URL.Query()
+		since, err := strconv.ParseUint(query.Get("since"), 10, 64)
+		if err != nil && query.Get("since") != "" {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil && query.Get("limit") != "" {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, r.Changes(since, limit))
+	case http.MethodPost:
+		var set ChangeSet
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRequestSize)).Decode(&set); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if set.Node == r.Node() {
+			http.Error(w, "Changes come from this node", http.StatusConflict)
+			return
+		}
+		writeJSON(w, r.Apply(&set))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Replicator) handleStatus(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, r.Status())
+}
+
+// do sends a sync request to a peer and decodes the JSON response into out
+func (r *Replicator) do(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// replicated is the part of a memory version that replication compares;
+// timestamps, access counts and whether a version is current are local
+type replicated struct {
+	Content           string            `json:"content"`
+	Summary           string            `json:"summary,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Category          string            `json:"category,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Version           int               `json:"version"`
+	PreviousVersionID string            `json:"previous_version_id,omitempty"`
+	Links             []memory.Link     `json:"links,omitempty"`
+	Importance        float64           `json:"importance,omitempty"`
+	Pinned            bool              `json:"pinned,omitempty"`
+	Protected         bool              `json:"protected,omitempty"`
+}
+
+// digest fingerprints the replicated fields of a memory version
+func digest(m *memory.Memory) string {
+	data, _ := json.Marshal(replicated{
+		Content:           m.Content,
+		Summary:           m.Summary,
+		Tags:              m.Tags,
+		Category:          m.Category,
+		Metadata:          m.Metadata,
+		Version:           m.Version,
+		PreviousVersionID: m.PreviousVersionID,
+		Links:             m.Links,
+		Importance:        m.Importance,
+		Pinned:            m.Pinned,
+		Protected:         m.Protected,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// siblingSuffix names the sibling of a losing edit after its node, or after
+// its content when both edits came from the same node
+func siblingSuffix(loser *entry, winnerOrigin string) string {
+	if loser.Origin != winnerOrigin {
+		return loser.Origin
+	}
+	return loser.Digest[:12]
+}
+
+// siblingOf copies a losing edit into a sibling of its version
+func siblingOf(m *memory.Memory, suffix string) *memory.Memory {
+	sibling := *m
+	sibling.ID = memory.SiblingID(m.ID, suffix)
+	sibling.SiblingOf = m.ID
+	sibling.IsCurrentVersion = false
+	return &sibling
+}
+
+func newNodeID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "node-" + hex.EncodeToString(b)
+}
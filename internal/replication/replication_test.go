@@ -0,0 +1,211 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/pkg/logger"
+)
+
+// instance is one memory server serving the sync protocol on localhost
+type instance struct {
+	dir        string
+	store      *memory.Store
+	replicator *Replicator
+	server     *httptest.Server
+}
+
+func newInstance(t *testing.T, node, token string) *instance {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := memory.NewStore(dir, &config.StorageConfig{
+		MaxStorageSize: 10 * 1024 * 1024,
+		MaxFileSize:    1024 * 1024,
+	}, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := &config.SyncConfig{NodeID: node, Listen: "127.0.0.1:0", Interval: time.Minute, Timeout: 5 * time.Second, Token: token}
+	replicator, err := NewReplicator(cfg, store, dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create replicator: %v", err)
+	}
+	server := httptest.NewServer(replicator.Handler())
+	t.Cleanup(server.Close)
+	return &instance{dir: dir, store: store, replicator: replicator, server: server}
+}
+
+func (in *instance) sync(t *testing.T, peer *instance) *SyncResult {
+	t.Helper()
+	result, err := in.replicator.Sync(context.Background(), peer.server.URL)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	return result
+}
+
+// contents maps each stored version ID to its summary
+func (in *instance) contents() map[string]string {
+	contents := make(map[string]string)
+	for _, m := range in.store.Versions() {
+		contents[m.ID] = m.Summary
+	}
+	return contents
+}
+
+func TestVectorClockCompare(t *testing.T) {
+	a := VectorClock{"a": 2, "b": 1}
+	tests := []struct {
+		other VectorClock
+		want  Ordering
+	}{
+		{VectorClock{"a": 2, "b": 1}, Equal},
+		{VectorClock{"a": 1}, After},
+		{VectorClock{"a": 2, "b": 1, "c": 1}, Before},
+		{VectorClock{"a": 1, "b": 2}, Concurrent},
+	}
+	for _, tt := range tests {
+		if got := a.Compare(tt.other); got != tt.want {
+			t.Errorf("%v.Compare(%v) = %s, want %s", a, tt.other, got, tt.want)
+		}
+	}
+	if merged := a.Merge(VectorClock{"a": 1, "c": 3}); !reflect.DeepEqual(merged, VectorClock{"a": 2, "b": 1, "c": 3}) {
+		t.Errorf("Merge = %v", merged)
+	}
+}
+
+func TestTwoInstancesConverge(t *testing.T) {
+	a := newInstance(t, "node-a", "")
+	b := newInstance(t, "node-b", "")
+
+	// A memory stored on one instance reaches the other
+	first, _ := a.store.Store("Deploys are frozen on Fridays", "freeze", "ops", []string{"deploy"}, nil)
+	b.sync(t, a)
+	if stored, exists := b.store.LookupVersion(first.ID); !exists || stored.Content != first.Content {
+		t.Fatalf("B should have pulled %s, got %+v", first.ID, stored)
+	}
+
+	// Syncing again changes nothing
+	if result := a.sync(t, b); result.Pulled.Applied != 0 || result.Pushed.Applied != 0 {
+		t.Errorf("Repeated sync = %+v, want nothing applied", result)
+	}
+
+	// Concurrent edits of the same memory become sibling versions
+	a.store.Store(first.Content, "freeze starts Thursday", "ops", []string{"deploy"}, nil)
+	b.store.Store(first.Content, "freeze starts Friday noon", "ops", []string{"deploy"}, nil)
+	result := a.sync(t, b)
+	conflicts := append(result.Pulled.Conflicts, result.Pushed.Conflicts...)
+	sibling := memory.SiblingID(memory.BaseID(first.ID)+"-v2", "node-a")
+	if len(conflicts) != 1 || conflicts[0] != sibling {
+		t.Errorf("Conflicts = %v, want %s", conflicts, sibling)
+	}
+	if !reflect.DeepEqual(a.contents(), b.contents()) {
+		t.Fatalf("Instances diverged:\nA %v\nB %v", a.contents(), b.contents())
+	}
+	current, _ := b.store.Get(memory.BaseID(first.ID))
+	if current.Summary != "freeze starts Friday noon" {
+		t.Errorf("Current version = %q, want the edit from the higher node ID", current.Summary)
+	}
+	history, _ := a.store.GetHistory(memory.BaseID(first.ID))
+	if len(history) != 3 {
+		t.Errorf("History = %d versions, want both edits and the original", len(history))
+	}
+	kept, _ := a.store.LookupVersion(sibling)
+	if kept == nil || kept.Summary != "freeze starts Thursday" || kept.SiblingOf != memory.BaseID(first.ID)+"-v2" || kept.IsCurrentVersion {
+		t.Errorf("Sibling = %+v, want A's edit as a past version", kept)
+	}
+
+	// Deletes propagate
+	if err := b.store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	b.sync(t, a)
+	if _, exists := a.store.LookupVersion(first.ID); exists {
+		t.Error("The delete should reach A")
+	}
+	if !reflect.DeepEqual(a.contents(), b.contents()) {
+		t.Errorf("Instances diverged after delete:\nA %v\nB %v", a.contents(), b.contents())
+	}
+
+	// State survives a restart
+	status := a.replicator.Status()
+	restarted, err := NewReplicator(a.replicator.config, a.store, a.dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if got := restarted.Status(); got.Seq != status.Seq || got.Node != "node-a" {
+		t.Errorf("Restarted status = %+v, want %+v", got, status)
+	}
+}
+
+func TestSyncRequiresToken(t *testing.T) {
+	a := newInstance(t, "node-a", "secret")
+	b := newInstance(t, "node-b", "other")
+
+	if _, err := b.replicator.Sync(context.Background(), a.server.URL); err == nil {
+		t.Error("Sync with the wrong token should fail")
+	}
+	resp, err := http.Get(a.server.URL + StatusPath)
+	if err != nil {
+		t.Fatalf("Status request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Status without token = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestListeningBeyondLoopbackRequiresToken(t *testing.T) {
+	for _, tc := range []struct {
+		listen, token string
+		ok            bool
+	}{
+		{"127.0.0.1:9100", "", true},
+		{"localhost:9100", "", true},
+		{"[::1]:9100", "", true},
+		{":9100", "", false},
+		{"0.0.0.0:9100", "", false},
+		{"192.168.1.20:9100", "", false},
+		{":9100", "secret", true},
+	} {
+		cfg := &config.SyncConfig{NodeID: "node-a", Listen: tc.listen, Token: tc.token, Interval: time.Minute, Timeout: time.Second}
+		if err := cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("Validate(listen %q, token %q) = %v, want ok %v", tc.listen, tc.token, err, tc.ok)
+		}
+	}
+}
+
+func TestChangesArePaged(t *testing.T) {
+	a := newInstance(t, "node-a", "")
+	for _, content := range []string{"one", "two", "three"} {
+		a.store.Store("Runbook step "+content, "", "", nil, nil)
+	}
+
+	var ids []string
+	set := a.replicator.Changes(0, 2)
+	if !set.More || len(set.Changes) != 2 {
+		t.Fatalf("First page = %d changes, more %v", len(set.Changes), set.More)
+	}
+	for _, change := range set.Changes {
+		ids = append(ids, change.ID)
+	}
+	set = a.replicator.Changes(set.Last, 2)
+	if set.More || len(set.Changes) != 1 {
+		t.Fatalf("Second page = %d changes, more %v", len(set.Changes), set.More)
+	}
+	ids = append(ids, set.Changes[0].ID)
+	sort.Strings(ids)
+	if len(ids) != 3 || ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Errorf("Pages = %v, want three distinct versions", ids)
+	}
+}
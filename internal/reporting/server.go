@@ -6,36 +6,44 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"mcp-memory-server/internal/api"
 	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/internal/metrics"
+	"mcp-memory-server/internal/webhook"
 	"mcp-memory-server/pkg/logger"
 )
 
 // Store interface for memory operations
 type Store interface {
-	GetStats() map[string]interface{}
-	List(category string, tags []string, limit int) ([]*memory.Memory, error)
-	GetTimeline() map[string]interface{}
+	memory.Reader
 	Refresh() error
+	Updates() (<-chan memory.IndexUpdate, func())
 }
 
+// updatesKeepAlive is the interval of comments on an idle update stream
+const updatesKeepAlive = 15 * time.Second
+
 // Server provides a web interface for memory reporting
 type Server struct {
-	host   string
-	port   int
-	store  Store
-	logger *logger.Logger
-	server *http.Server
+	host    string
+	port    int
+	store   Store
+	dataDir string // where the memory server persists its webhook state
+	logger  *logger.Logger
+	server  *http.Server
 }
 
 // NewServer creates a new reporting server
-func NewServer(host string, port int, store Store, logger *logger.Logger) *Server {
+func NewServer(host string, port int, store Store, dataDir string, logger *logger.Logger) *Server {
 	return &Server{
-		host:   host,
-		port:   port,
-		store:  store,
-		logger: logger.WithComponent("reporting_server"),
+		host:    host,
+		port:    port,
+		store:   store,
+		dataDir: dataDir,
+		logger:  logger.WithComponent("reporting_server"),
 	}
 }
 
@@ -44,11 +52,17 @@ func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// Static routes
-	mux.HandleFunc("/", s.handleDashboard)
-	mux.HandleFunc("/api/stats", s.handleStats)
-	mux.HandleFunc("/api/memories", s.handleMemories)
-	mux.HandleFunc("/api/timeline", s.handleTimeline)
-	mux.HandleFunc("/api/refresh", s.handleRefresh)
+	mux.HandleFunc("/", metrics.InstrumentFunc("/", s.handleDashboard))
+	mux.HandleFunc("/api/stats", metrics.InstrumentFunc("/api/stats", s.handleStats))
+	mux.HandleFunc("/api/memories", metrics.InstrumentFunc("/api/memories", s.handleMemories))
+	mux.HandleFunc("/api/timeline", metrics.InstrumentFunc("/api/timeline", s.handleTimeline))
+	mux.HandleFunc("/api/search", metrics.InstrumentFunc("/api/search", s.handleSearch))
+	mux.HandleFunc("/api/history", metrics.InstrumentFunc("/api/history", s.handleHistory))
+	mux.HandleFunc("/api/keywords", metrics.InstrumentFunc("/api/keywords", s.handleKeywords))
+	mux.HandleFunc("/api/webhooks", metrics.InstrumentFunc("/api/webhooks", s.handleWebhooks))
+	mux.HandleFunc("/api/refresh", metrics.InstrumentFunc("/api/refresh", s.handleRefresh))
+	mux.HandleFunc("/api/updates", metrics.InstrumentFunc("/api/updates", s.handleUpdates))
+	mux.Handle(metrics.Path, metrics.Handler())
 
 	address := fmt.Sprintf("%s:%d", s.host, s.port)
 	s.server = &http.Server{
@@ -177,6 +191,23 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             background-color: #f9fafb;
             font-weight: 600;
         }
+        .metadata {
+            display: inline-block;
+            background-color: #eef2ff;
+            color: #3730a3;
+            padding: 2px 6px;
+            border-radius: 4px;
+            margin: 1px 2px;
+            font-size: 0.85em;
+        }
+        .search-input {
+            margin: 10px 20px 0 20px;
+            padding: 8px 12px;
+            width: calc(100% - 64px);
+            border: 1px solid #d1d5db;
+            border-radius: 6px;
+            font-size: 14px;
+        }
         .error {
             color: #dc2626;
             background-color: #fef2f2;
@@ -254,19 +285,52 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
                 <canvas id="categories-chart" width="400" height="200"></canvas>
             </div>
 
+            <div class="chart-container">
+                <h3>Storage Usage</h3>
+                <p class="stat-label" id="usage-summary"></p>
+                <div id="usage-quotas"></div>
+                <div id="usage-breakdown"></div>
+            </div>
+
             <div class="chart-container">
                 <h3>Memory Creation Timeline (Last 30 Days)</h3>
                 <canvas id="timeline-chart" width="400" height="200"></canvas>
             </div>
 
+            <div class="chart-container">
+                <h3>Top Keywords</h3>
+                <div id="top-keywords"></div>
+            </div>
+
+            <div class="memories-table" id="webhooks-panel" style="display: none; margin-bottom: 20px;">
+                <h3 style="margin: 0; padding: 20px 20px 0 20px;">Webhook Dead Letters</h3>
+                <p class="stat-label" style="padding: 0 20px;" id="webhooks-summary"></p>
+                <table>
+                    <thead>
+                        <tr>
+                            <th>Delivery</th>
+                            <th>Target</th>
+                            <th>Event</th>
+                            <th>Attempts</th>
+                            <th>Last Error</th>
+                            <th>Failed</th>
+                        </tr>
+                    </thead>
+                    <tbody id="webhooks-tbody">
+                    </tbody>
+                </table>
+            </div>
+
             <div class="memories-table">
                 <h3 style="margin: 0; padding: 20px 20px 0 20px;">Recent Memories</h3>
+                <input type="search" id="search-input" class="search-input" placeholder="Search memories (press Enter)">
                 <table>
                     <thead>
                         <tr>
                             <th>Summary</th>
                             <th>Category</th>
                             <th>Tags</th>
+                            <th>Metadata</th>
                             <th>Created</th>
                             <th>Access Count</th>
                         </tr>
@@ -280,6 +344,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 
     <script>
         let categoriesChart, timelineChart;
+        let searchQuery = '';
 
         async function fetchStats() {
             const response = await fetch('/api/stats');
@@ -288,7 +353,10 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
         }
 
         async function fetchMemories() {
-            const response = await fetch('/api/memories?limit=10');
+            const url = searchQuery
+                ? '/api/search?limit=10&query=' + encodeURIComponent(searchQuery)
+                : '/api/memories?limit=10';
+            const response = await fetch(url);
             if (!response.ok) throw new Error('Failed to fetch memories');
             return await response.json();
         }
@@ -299,6 +367,12 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             return await response.json();
         }
 
+        async function fetchWebhooks() {
+            const response = await fetch('/api/webhooks');
+            if (!response.ok) throw new Error('Failed to fetch webhooks');
+            return await response.json();
+        }
+
         async function refreshData() {
             const btn = document.getElementById('refresh-btn');
             btn.disabled = true;
@@ -347,6 +421,39 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             document.getElementById('data-dir').textContent = stats.data_directory.split('/').pop();
         }
 
+        function updateUsage(usage) {
+            document.getElementById('usage-summary').textContent =
+                'Current versions ' + formatBytes(usage.current_bytes || 0) +
+                ', version overhead ' + formatBytes(usage.version_overhead_bytes || 0);
+
+            const quotas = document.getElementById('usage-quotas');
+            quotas.innerHTML = '';
+            (usage.quotas || []).forEach(quota => {
+                const row = document.createElement('p');
+                row.className = 'stat-label';
+                row.textContent = quota.scope + ' ' + (quota.name || '(none)') + ': ' + formatBytes(quota.used) +
+                    ' of ' + formatBytes(quota.limit) + ' (' + quota.used_pct.toFixed(1) + '%)';
+                quotas.appendChild(row);
+            });
+
+            const breakdown = document.getElementById('usage-breakdown');
+            breakdown.innerHTML = '';
+            [['By category', usage.by_category], ['By namespace', usage.by_namespace], ['By tag', usage.by_tag]].forEach(([title, sizes]) => {
+                const names = Object.keys(sizes || {}).sort((a, b) => sizes[b] - sizes[a]).slice(0, 10);
+                if (names.length === 0) return;
+                const heading = document.createElement('p');
+                heading.className = 'stat-label';
+                heading.textContent = title;
+                breakdown.appendChild(heading);
+                names.forEach(name => {
+                    const span = document.createElement('span');
+                    span.className = 'metadata';
+                    span.textContent = name + ' ' + formatBytes(sizes[name]);
+                    breakdown.appendChild(span);
+                });
+            });
+        }
+
         function updateCategoriesChart(categories) {
             const ctx = document.getElementById('categories-chart').getContext('2d');
             
@@ -424,13 +531,64 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             });
         }
 
+        function updateKeywords(keywords) {
+            const container = document.getElementById('top-keywords');
+            container.innerHTML = '';
+            if (keywords.length === 0) {
+                container.textContent = 'No keywords yet';
+                return;
+            }
+            keywords.forEach(keyword => {
+                const span = document.createElement('span');
+                span.className = 'metadata';
+                span.textContent = keyword.keyword + ' (' + keyword.count + ')';
+                container.appendChild(span);
+            });
+        }
+
+        function escapeHTML(text) {
+            const div = document.createElement('div');
+            div.textContent = text;
+            return div.innerHTML;
+        }
+
+        function formatMetadata(metadata) {
+            if (!metadata) return '-';
+            const keys = Object.keys(metadata).sort();
+            if (keys.length === 0) return '-';
+            return keys.map(key => ` + "`" + `<span class="metadata">${escapeHTML(key)}=${escapeHTML(metadata[key])}</span>` + "`" + `).join(' ');
+        }
+
+        function updateWebhooks(webhooks) {
+            const panel = document.getElementById('webhooks-panel');
+            panel.style.display = webhooks.enabled ? 'block' : 'none';
+            if (!webhooks.enabled) return;
+
+            const dead = webhooks.dead_letters || [];
+            document.getElementById('webhooks-summary').textContent =
+                (webhooks.queue || []).length + ' deliveries pending, ' + dead.length + ' failed';
+            const tbody = document.getElementById('webhooks-tbody');
+            tbody.innerHTML = '';
+            dead.forEach(delivery => {
+                const row = tbody.insertRow();
+                row.innerHTML = ` + "`" + `
+                    <td>${escapeHTML(delivery.id)}</td>
+                    <td>${escapeHTML(delivery.target)}</td>
+                    <td>${escapeHTML(delivery.event.type)} ${escapeHTML(delivery.event.id)}</td>
+                    <td>${delivery.attempts}</td>
+                    <td>${escapeHTML(delivery.last_error || '-')}</td>
+                    <td>${new Date(delivery.failed_at).toLocaleString()}</td>
+                ` + "`" + `;
+            });
+        }
+
         function updateMemoriesTable(memories) {
             const tbody = document.getElementById('memories-tbody');
             tbody.innerHTML = '';
             
             if (memories.length === 0) {
                 const row = tbody.insertRow();
-                row.innerHTML = '<td colspan="5" style="text-align: center; color: #6b7280;">No memories found</td>';
+                row.innerHTML = '<td colspan="6" style="text-align: center; color: #6b7280;">No memories found</td>';
                 return;
             }
             
@@ -440,31 +598,38 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
                     <td>${memory.summary || (memory.content ? memory.content.substring(0, 50) + '...' : 'No content')}</td>
                     <td>${memory.category || '-'}</td>
                     <td>${memory.tags && memory.tags.length > 0 ? memory.tags.join(', ') : '-'}</td>
+                    <td>${formatMetadata(memory.metadata)}</td>
                     <td>${new Date(memory.created_at).toLocaleDateString()}</td>
                     <td>${memory.access_count || 0}</td>
                 ` + "`" + `;
             });
         }
 
-        async function loadDashboard() {
+        async function loadDashboard(live) {
             try {
-                document.getElementById('loading').style.display = 'block';
-                document.getElementById('error').style.display = 'none';
-                document.getElementById('dashboard').style.display = 'none';
+                if (!live) {
+                    document.getElementById('loading').style.display = 'block';
+                    document.getElementById('error').style.display = 'none';
+                    document.getElementById('dashboard').style.display = 'none';
 
-                // Auto-refresh data from server
-                await fetch('/api/refresh', { method: 'POST' });
+                    // Auto-refresh data from server
+                    await fetch('/api/refresh', { method: 'POST' });
+                }
 
-                const [stats, memories, timeline] = await Promise.all([
+                const [stats, memories, timeline, webhooks] = await Promise.all([
                     fetchStats(),
                     fetchMemories(),
-                    fetchTimeline()
+                    fetchTimeline(),
+                    fetchWebhooks()
                 ]);
 
                 updateStats(stats);
                 updateCategoriesChart(stats.categories || {});
+                updateUsage(stats.usage || {});
                 updateTimelineChart(timeline);
+                updateKeywords(stats.top_keywords || []);
                 updateMemoriesTable(memories);
+                updateWebhooks(webhooks);
 
                 document.getElementById('loading').style.display = 'none';
                 document.getElementById('dashboard').style.display = 'block';
@@ -475,11 +640,31 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        document.getElementById('search-input').addEventListener('keydown', async event => {
+            if (event.key !== 'Enter') return;
+            searchQuery = event.target.value.trim();
+            try {
+                updateMemoriesTable(await fetchMemories());
+            } catch (error) {
+                showError('Search failed: ' + error.message);
+            }
+        });
+
         // Load dashboard on page load
         loadDashboard();
 
-        // Auto-refresh every 10 seconds for real-time updates
-        setInterval(loadDashboard, 10000);
+        // Redraw as the server picks up memory changes, or poll every 10
+        // seconds where Server-Sent Events are unavailable
+        if (window.EventSource) {
+            let pendingUpdate;
+            const updates = new EventSource('/api/updates');
+            updates.addEventListener('update', () => {
+                clearTimeout(pendingUpdate);
+                pendingUpdate = setTimeout(() => loadDashboard(true), 250);
+            });
+        } else {
+            setInterval(loadDashboard, 10000);
+        }
     </script>
 </body>
 </html>`
@@ -495,23 +680,98 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleMemories returns recent memories as JSON
+// handleMemories returns a page of memories as JSON. Pagination details are
+// returned in the X-Total-Count and X-Next-Cursor headers.
 func (s *Server) handleMemories(w http.ResponseWriter, r *http.Request) {
-	limit := 20
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if _, err := fmt.Sscanf(l, "%d", &limit); err != nil {
-			limit = 20
-		}
+	opts, err := api.ListOptionsFromQuery(r.URL.Query(), 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.store.ListPage(opts)
+	if err != nil {
+		http.Error(w, err.Error(), api.QueryErrorStatus(err))
+		return
+	}
+
+	api.WritePageHeaders(w.Header(), page)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Memories)
+}
+
+// handleSearch searches memories with the query language of the recall
+// tool. It takes the same filter and pagination parameters as /api/memories.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	opts, err := api.ListOptionsFromQuery(r.URL.Query(), 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.store.SearchPage(&memory.SearchQuery{
+		Query:    r.URL.Query().Get("query"),
+		Category: opts.Category,
+		Tags:     opts.Tags,
+		Metadata: opts.Metadata,
+		Limit:    opts.Limit,
+		Offset:   opts.Offset,
+		Cursor:   opts.Cursor,
+		Sort:     opts.Sort,
+		Order:    opts.Order,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), api.QueryErrorStatus(err))
+		return
+	}
+
+	api.WritePageHeaders(w.Header(), page)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Memories)
+}
+
+// handleHistory returns every version of a memory, newest first
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
 	}
 
-	memories, err := s.store.List("", nil, limit)
+	versions, err := s.store.GetHistory(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(memories)
+	json.NewEncoder(w).Encode(versions)
+}
+
+// handleKeywords returns the most used keywords, or with keyword set the
+// memories using it
+func (s *Server) handleKeywords(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", l), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if keyword := r.URL.Query().Get("keyword"); keyword != "" {
+		memories, err := s.store.GetByKeyword(keyword, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(memories)
+		return
+	}
+	json.NewEncoder(w).Encode(s.store.GetTopKeywords(limit))
 }
 
 // handleTimeline returns memory creation timeline data
@@ -522,6 +782,25 @@ func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(timeline)
 }
 
+// handleWebhooks returns the pending webhook deliveries and the dead letters
+// the memory server last persisted
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := webhook.ReadSnapshot(s.dataDir)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to read webhook state")
+		http.Error(w, "Failed to read webhook state", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"enabled": snapshot != nil}
+	if snapshot != nil {
+		response["queue"] = snapshot.Queue
+		response["dead_letters"] = snapshot.DeadLetters
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // handleRefresh refreshes the memory data from disk
 func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -538,4 +817,46 @@ func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	s.logger.Info("Memory data refreshed")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
-}
\ No newline at end of file
+}
+
+// handleUpdates streams the changes applied to the memory index as
+// Server-Sent Events, so the dashboard redraws without polling
+func (s *Server) handleUpdates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	updates, unsubscribe := s.store.Updates()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(updatesKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(update)
+			fmt.Fprintf(w, "event: update\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
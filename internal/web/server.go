@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"mcp-memory-server/internal/api"
 	"mcp-memory-server/internal/config"
 	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/internal/metrics"
 	"mcp-memory-server/pkg/logger"
 )
 
@@ -25,7 +29,7 @@ type Server struct {
 func NewServer(cfg *config.WebConfig, store *memory.Store, logger *logger.Logger) *Server {
 	return &Server{
 		config: cfg,
-		store:  store,
+		store:  store.As(memory.Caller{Client: "web"}), // dashboard reads are audited under the web client
 		logger: logger.WithComponent("web_server"),
 	}
 }
@@ -40,10 +44,12 @@ func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// Static routes
-	mux.HandleFunc("/", s.handleDashboard)
-	mux.HandleFunc("/api/stats", s.handleStats)
-	mux.HandleFunc("/api/memories", s.handleMemories)
-	mux.HandleFunc("/api/timeline", s.handleTimeline)
+	mux.HandleFunc("/", metrics.InstrumentFunc("/", s.handleDashboard))
+	mux.HandleFunc("/api/stats", metrics.InstrumentFunc("/api/stats", s.handleStats))
+	mux.HandleFunc("/api/memories", metrics.InstrumentFunc("/api/memories", s.handleMemories))
+	mux.HandleFunc("/api/timeline", metrics.InstrumentFunc("/api/timeline", s.handleTimeline))
+	mux.HandleFunc("/api/graph", metrics.InstrumentFunc("/api/graph", s.handleGraph))
+	mux.Handle(metrics.Path, metrics.Handler())
 
 	address := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 	s.server = &http.Server{
@@ -173,6 +179,15 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             background-color: #10b981;
             transition: width 0.3s ease;
         }
+        .metadata {
+            display: inline-block;
+            background-color: #eef2ff;
+            color: #3730a3;
+            padding: 2px 6px;
+            border-radius: 4px;
+            margin: 1px 2px;
+            font-size: 0.85em;
+        }
         .error {
             color: #dc2626;
             background-color: #fef2f2;
@@ -180,6 +195,22 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             border-radius: 4px;
             margin: 10px 0;
         }
+        #graph {
+            width: 100%;
+            height: 480px;
+            border: 1px solid #e5e7eb;
+            border-radius: 4px;
+        }
+        #graph text {
+            font-size: 11px;
+            fill: #374151;
+            pointer-events: none;
+        }
+        .graph-legend span {
+            display: inline-block;
+            margin-right: 12px;
+            font-size: 0.85em;
+        }
         .loading {
             text-align: center;
             padding: 40px;
@@ -225,11 +256,25 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
                 <canvas id="categories-chart" width="400" height="200"></canvas>
             </div>
 
+            <div class="chart-container">
+                <h3>Storage Usage</h3>
+                <p class="stat-label" id="usage-summary"></p>
+                <div id="usage-quotas"></div>
+                <div id="usage-breakdown"></div>
+            </div>
+
             <div class="chart-container">
                 <h3>Memory Creation Timeline</h3>
                 <canvas id="timeline-chart" width="400" height="200"></canvas>
             </div>
 
+            <div class="chart-container">
+                <h3>Knowledge Graph</h3>
+                <div class="graph-legend" id="graph-legend"></div>
+                <svg id="graph"></svg>
+                <p id="graph-empty" class="stat-label" style="display: none;">No linked memories yet. Use the link_memories tool to relate memories.</p>
+            </div>
+
             <div class="memories-table">
                 <h3 style="margin: 0; padding: 20px 20px 0 20px;">Recent Memories</h3>
                 <table>
@@ -238,6 +283,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
                             <th>Summary</th>
                             <th>Category</th>
                             <th>Tags</th>
+                            <th>Metadata</th>
                             <th>Created</th>
                             <th>Access Count</th>
                         </tr>
@@ -282,6 +328,22 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        async function fetchGraph() {
+            try {
+                const response = await fetch('/api/graph?limit=100');
+                const data = await response.json();
+                return data;
+            } catch (error) {
+                throw new Error('Failed to fetch graph: ' + error.message);
+            }
+        }
+
+        function escapeHTML(text) {
+            const div = document.createElement('div');
+            div.textContent = text;
+            return div.innerHTML;
+        }
+
         function formatBytes(bytes) {
             if (bytes === 0) return '0 B';
             const k = 1024;
@@ -306,6 +368,39 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        function updateUsage(usage) {
+            document.getElementById('usage-summary').textContent =
+                'Current versions ' + formatBytes(usage.current_bytes || 0) +
+                ', version overhead ' + formatBytes(usage.version_overhead_bytes || 0);
+
+            const quotas = document.getElementById('usage-quotas');
+            quotas.innerHTML = '';
+            (usage.quotas || []).forEach(quota => {
+                const row = document.createElement('p');
+                row.className = 'stat-label';
+                row.textContent = quota.scope + ' ' + (quota.name || '(none)') + ': ' + formatBytes(quota.used) +
+                    ' of ' + formatBytes(quota.limit) + ' (' + quota.used_pct.toFixed(1) + '%)';
+                quotas.appendChild(row);
+            });
+
+            const breakdown = document.getElementById('usage-breakdown');
+            breakdown.innerHTML = '';
+            [['By category', usage.by_category], ['By namespace', usage.by_namespace], ['By tag', usage.by_tag]].forEach(([title, sizes]) => {
+                const names = Object.keys(sizes || {}).sort((a, b) => sizes[b] - sizes[a]).slice(0, 10);
+                if (names.length === 0) return;
+                const heading = document.createElement('p');
+                heading.className = 'stat-label';
+                heading.textContent = title;
+                breakdown.appendChild(heading);
+                names.forEach(name => {
+                    const span = document.createElement('span');
+                    span.className = 'metadata';
+                    span.textContent = name + ' ' + formatBytes(sizes[name]);
+                    breakdown.appendChild(span);
+                });
+            });
+        }
+
         function updateCategoriesChart(categories) {
             const ctx = document.getElementById('categories-chart').getContext('2d');
             
@@ -374,6 +469,13 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
             });
         }
 
+        function formatMetadata(metadata) {
+            if (!metadata) return '-';
+            const keys = Object.keys(metadata).sort();
+            if (keys.length === 0) return '-';
+            return keys.map(key => ` + "`" + `<span class="metadata">${escapeHTML(key)}=${escapeHTML(metadata[key])}</span>` + "`" + `).join(' ');
+        }
+
         function updateMemoriesTable(memories) {
             const tbody = document.getElementById('memories-tbody');
             tbody.innerHTML = '';
@@ -384,32 +486,154 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
                     <td>${memory.summary || memory.content.substring(0, 50) + '...'}</td>
                     <td>${memory.category || '-'}</td>
                     <td>${memory.tags ? memory.tags.join(', ') : '-'}</td>
+                    <td>${formatMetadata(memory.metadata)}</td>
                     <td>${new Date(memory.created_at).toLocaleDateString()}</td>
                     <td>${memory.access_count}</td>
                 ` + "`" + `;
             });
         }
 
+        const relationColors = {
+            supersedes: '#ef4444',
+            relates_to: '#6b7280',
+            depends_on: '#3b82f6',
+            part_of: '#10b981'
+        };
+
+        // Lays the graph out with a small force simulation and draws it as SVG
+        function updateGraph(graph) {
+            const svg = document.getElementById('graph');
+            const ns = 'http://www.w3.org/2000/svg';
+            svg.innerHTML = '';
+
+            const empty = graph.nodes.length === 0;
+            svg.style.display = empty ? 'none' : 'block';
+            document.getElementById('graph-empty').style.display = empty ? 'block' : 'none';
+            document.getElementById('graph-legend').innerHTML = Object.entries(relationColors)
+                .map(([type, color]) => ` + "`" + `<span style="color: ${color}">&#9632; ${type}</span>` + "`" + `).join('');
+            if (empty) return;
+
+            const width = svg.clientWidth || 800;
+            const height = svg.clientHeight || 480;
+            const baseID = id => id.replace(/-v\d+$/, '');
+            const nodes = graph.nodes.map((node, i) => {
+                const angle = 2 * Math.PI * i / graph.nodes.length;
+                return {
+                    id: baseID(node.memory.id),
+                    label: node.memory.summary || node.memory.content.substring(0, 30),
+                    x: width / 2 + Math.cos(angle) * width / 3,
+                    y: height / 2 + Math.sin(angle) * height / 3,
+                    vx: 0,
+                    vy: 0
+                };
+            });
+            const byID = Object.fromEntries(nodes.map(node => [node.id, node]));
+            const edges = graph.edges.filter(edge => byID[edge.source] && byID[edge.target]);
+
+            for (let step = 0; step < 300; step++) {
+                for (const a of nodes) {
+                    for (const b of nodes) {
+                        if (a === b) continue;
+                        const dx = a.x - b.x, dy = a.y - b.y;
+                        const dist2 = Math.max(dx * dx + dy * dy, 1);
+                        a.vx += dx / dist2 * 200;
+                        a.vy += dy / dist2 * 200;
+                    }
+                    a.vx += (width / 2 - a.x) * 0.005;
+                    a.vy += (height / 2 - a.y) * 0.005;
+                }
+                for (const edge of edges) {
+                    const a = byID[edge.source], b = byID[edge.target];
+                    const dx = b.x - a.x, dy = b.y - a.y;
+                    a.vx += dx * 0.01; a.vy += dy * 0.01;
+                    b.vx -= dx * 0.01; b.vy -= dy * 0.01;
+                }
+                for (const node of nodes) {
+                    node.x = Math.min(width - 20, Math.max(20, node.x + node.vx));
+                    node.y = Math.min(height - 20, Math.max(20, node.y + node.vy));
+                    node.vx *= 0.5;
+                    node.vy *= 0.5;
+                }
+            }
+
+            const defs = document.createElementNS(ns, 'defs');
+            for (const [type, color] of Object.entries(relationColors)) {
+                const marker = document.createElementNS(ns, 'marker');
+                marker.setAttribute('id', 'arrow-' + type);
+                marker.setAttribute('viewBox', '0 0 10 10');
+                marker.setAttribute('refX', '18');
+                marker.setAttribute('refY', '5');
+                marker.setAttribute('markerWidth', '6');
+                marker.setAttribute('markerHeight', '6');
+                marker.setAttribute('orient', 'auto');
+                const path = document.createElementNS(ns, 'path');
+                path.setAttribute('d', 'M0,0 L10,5 L0,10 z');
+                path.setAttribute('fill', color);
+                marker.appendChild(path);
+                defs.appendChild(marker);
+            }
+            svg.appendChild(defs);
+
+            for (const edge of edges) {
+                const a = byID[edge.source], b = byID[edge.target];
+                const line = document.createElementNS(ns, 'line');
+                line.setAttribute('x1', a.x);
+                line.setAttribute('y1', a.y);
+                line.setAttribute('x2', b.x);
+                line.setAttribute('y2', b.y);
+                line.setAttribute('stroke', relationColors[edge.type] || '#9ca3af');
+                line.setAttribute('stroke-width', '1.5');
+                line.setAttribute('marker-end', 'url(#arrow-' + edge.type + ')');
+                const title = document.createElementNS(ns, 'title');
+                title.textContent = edge.type;
+                line.appendChild(title);
+                svg.appendChild(line);
+            }
+
+            for (const node of nodes) {
+                const circle = document.createElementNS(ns, 'circle');
+                circle.setAttribute('cx', node.x);
+                circle.setAttribute('cy', node.y);
+                circle.setAttribute('r', '8');
+                circle.setAttribute('fill', '#2563eb');
+                const title = document.createElementNS(ns, 'title');
+                title.textContent = node.id + ': ' + node.label;
+                circle.appendChild(title);
+                svg.appendChild(circle);
+
+                const text = document.createElementNS(ns, 'text');
+                text.setAttribute('x', node.x + 11);
+                text.setAttribute('y', node.y + 4);
+                text.textContent = node.label.length > 30 ? node.label.substring(0, 30) + '...' : node.label;
+                svg.appendChild(text);
+            }
+        }
+
         async function loadDashboard() {
             try {
                 document.getElementById('loading').style.display = 'block';
                 document.getElementById('error').style.display = 'none';
                 document.getElementById('dashboard').style.display = 'none';
 
-                const [stats, memories, timeline] = await Promise.all([
+                const [stats, memories, timeline, graph] = await Promise.all([
                     fetchStats(),
                     fetchMemories(),
-                    fetchTimeline()
+                    fetchTimeline(),
+                    fetchGraph()
                 ]);
 
                 updateStats(stats);
                 updateCategoriesChart(stats.categories || {});
+                updateUsage(stats.usage || {});
                 updateTimelineChart(timeline);
                 updateMemoriesTable(memories);
 
                 document.getElementById('loading').style.display = 'none';
                 document.getElementById('dashboard').style.display = 'block';
 
+                // The graph is laid out after the dashboard is visible so the SVG has a size
+                updateGraph(graph);
+
             } catch (error) {
                 document.getElementById('loading').style.display = 'none';
                 document.getElementById('error').style.display = 'block';
@@ -437,23 +661,24 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleMemories returns recent memories as JSON
+// handleMemories returns a page of memories as JSON. Pagination details are
+// returned in the X-Total-Count and X-Next-Cursor headers.
 func (s *Server) handleMemories(w http.ResponseWriter, r *http.Request) {
-	limit := 20
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if _, err := fmt.Sscanf(l, "%d", &limit); err != nil {
-			limit = 20
-		}
+	opts, err := api.ListOptionsFromQuery(r.URL.Query(), 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	memories, err := s.store.List("", nil, limit)
+	page, err := s.store.ListPage(opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), api.QueryErrorStatus(err))
 		return
 	}
 
+	api.WritePageHeaders(w.Header(), page)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(memories)
+	json.NewEncoder(w).Encode(page.Memories)
 }
 
 // handleTimeline returns memory creation timeline data
@@ -463,3 +688,53 @@ func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(timeline)
 }
+
+// handleGraph returns linked memories as nodes and edges. With ?id= it returns
+// the memories within ?depth= links of that memory; otherwise the most linked
+// part of the whole graph, up to ?limit= memories.
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var graph *memory.Graph
+	if id := query.Get("id"); id != "" {
+		depth := 0
+		if value := query.Get("depth"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid depth %q", value), http.StatusBadRequest)
+				return
+			}
+			depth = n
+		}
+		var types []string
+		if value := query.Get("types"); value != "" {
+			types = strings.Split(value, ",")
+		}
+
+		var err error
+		graph, err = s.store.Related(id, memory.RelatedOptions{
+			Depth:     depth,
+			Types:     types,
+			Direction: query.Get("direction"),
+			Limit:     limit,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		graph = s.store.Graph(limit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
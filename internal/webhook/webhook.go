@@ -0,0 +1,528 @@
+// internal/webhook/webhook.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/pkg/logger"
+)
+
+// Delivery headers
+const (
+	HeaderSignature = "X-Memory-Signature" // "sha256=" + hex HMAC of timestamp + "." + body
+	HeaderTimestamp = "X-Memory-Timestamp" // Unix seconds the delivery was signed at
+	HeaderDelivery  = "X-Memory-Delivery"  // delivery ID, stable across retries
+	HeaderEvent     = "X-Memory-Event"     // event type
+)
+
+const (
+	stateFile      = "webhooks/state.json"
+	maxDeadLetters = 1000
+)
+
+// Delivery is one event to send to one target
+type Delivery struct {
+	ID          string       `json:"id"`
+	Target      string       `json:"target"`
+	Event       memory.Event `json:"event"`
+	Attempts    int          `json:"attempts"`
+	NextAttempt time.Time    `json:"next_attempt"`
+	LastError   string       `json:"last_error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FailedAt    time.Time    `json:"failed_at,omitempty"` // when it was moved to the dead-letter list
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	DeliveryID string       `json:"delivery_id"`
+	Event      memory.Event `json:"event"`
+}
+
+// state is what the dispatcher persists between runs
+type state struct {
+	Cursor      uint64      `json:"cursor"` // sequence number of the last event queued
+	Queue       []*Delivery `json:"queue"`
+	DeadLetters []*Delivery `json:"dead_letters"`
+}
+
+// Dispatcher delivers memory events to webhook targets. Pending deliveries
+// and its position in the event feed are persisted, so deliveries survive
+// restarts and events published while it was stopped are still sent.
+type Dispatcher struct {
+	config  *config.WebhookConfig
+	targets []config.WebhookTarget
+	events  *memory.EventBus
+	path    string
+	client  *http.Client
+	logger  *logger.Logger
+
+	mu    sync.Mutex
+	state state
+	wake  chan struct{}
+}
+
+// NewDispatcher creates a dispatcher for the configured targets, restoring
+// its queue from dataDir
+func NewDispatcher(cfg *config.WebhookConfig, events *memory.EventBus, dataDir string, log *logger.Logger) (*Dispatcher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	for _, target := range cfg.Targets {
+		for _, eventType := range target.Events {
+			switch eventType {
+			case memory.EventCreated, memory.EventVersioned, memory.EventDeleted, memory.EventEvicted, memory.EventRestored:
+			default:
+				return nil, fmt.Errorf("webhook %s: unknown event type %q", target.Name, eventType)
+			}
+		}
+	}
+
+	d := &Dispatcher{
+		config:  cfg,
+		targets: cfg.Targets,
+		events:  events,
+		path:    filepath.Join(dataDir, stateFile),
+		client:  &http.Client{Timeout: cfg.Timeout},
+		logger:  log.WithComponent("webhooks"),
+		wake:    make(chan struct{}, 1),
+	}
+
+	data, err := os.ReadFile(d.path)
+	switch {
+	case os.IsNotExist(err):
+		// Start with the next event rather than replaying the history
+		d.state.Cursor = events.LastSeq()
+		d.save()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read webhook state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &d.state); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook state: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// Run queues matching events and delivers them until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.logger.Info("Webhook dispatcher started", "targets", len(d.targets), "pending", len(d.Queue()))
+
+	var sub *memory.Subscription
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		if sub == nil {
+			var err error
+			if sub, err = d.subscribe(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind or the store closed; resume from
+				// the cursor
+				sub = nil
+				if ctx.Err() != nil {
+					return nil
+				}
+				continue
+			}
+			d.enqueue([]memory.Event{event})
+		case <-timer.C:
+			d.deliverDue(ctx)
+		case <-d.wake:
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped")
+			return nil
+		}
+
+		// Sleep until the earliest pending delivery is due
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d.untilNextAttempt())
+	}
+}
+
+// subscribe resumes the event feed after the cursor, queueing the events
+// kept since. When those have expired it logs the gap and starts from the
+// latest event.
+func (d *Dispatcher) subscribe() (*memory.Subscription, error) {
+	d.mu.Lock()
+	cursor := d.state.Cursor
+	d.mu.Unlock()
+
+	backlog, sub, err := d.events.Subscribe(cursor)
+	if errors.Is(err, memory.ErrEventsExpired) {
+		var lastSeq uint64
+		if lastSeq, sub, err = d.events.SubscribeLatest(); err != nil {
+			return nil, err
+		}
+		d.logger.Warn("Webhook events were missed while they expired", "after", cursor, "resumed_at", lastSeq)
+		d.mu.Lock()
+		d.state.Cursor = lastSeq
+		d.save()
+		d.mu.Unlock()
+		return sub, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.enqueue(backlog)
+	return sub, nil
+}
+
+// enqueue queues a delivery of each event to every target it matches
+func (d *Dispatcher) enqueue(events []memory.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, event := range events {
+		if event.Seq <= d.state.Cursor {
+			continue
+		}
+		d.state.Cursor = event.Seq
+		for _, target := range d.targets {
+			if !matches(target, event) {
+				continue
+			}
+			d.state.Queue = append(d.state.Queue, &Delivery{
+				ID:          fmt.Sprintf("%d-%s", event.Seq, target.Name),
+				Target:      target.Name,
+				Event:       event,
+				NextAttempt: now,
+				CreatedAt:   now,
+			})
+		}
+	}
+	d.save()
+}
+
+// deliverDue attempts every delivery that is due
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	d.mu.Lock()
+	now := time.Now()
+	var due []*Delivery
+	for _, delivery := range d.state.Queue {
+		if !delivery.NextAttempt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		err := d.send(ctx, delivery)
+		if ctx.Err() != nil {
+			// Interrupted by shutdown; the delivery stays due
+			return
+		}
+
+		d.mu.Lock()
+		d.recordAttempt(delivery, err)
+		d.save()
+		d.mu.Unlock()
+	}
+}
+
+// recordAttempt removes a delivered delivery from the queue or schedules its
+// retry, moving it to the dead-letter list after the last attempt. The
+// caller must hold d.mu.
+func (d *Dispatcher) recordAttempt(delivery *Delivery, err error) {
+	delivery.Attempts++
+	if err == nil {
+		d.removeQueued(delivery.ID)
+		d.logger.Debug("Webhook delivered", "id", delivery.ID, "attempts", delivery.Attempts)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		d.removeQueued(delivery.ID)
+		delivery.FailedAt = time.Now().UTC()
+		d.state.DeadLetters = append(d.state.DeadLetters, delivery)
+		if len(d.state.DeadLetters) > maxDeadLetters {
+			d.state.DeadLetters = d.state.DeadLetters[len(d.state.DeadLetters)-maxDeadLetters:]
+		}
+		d.logger.Warn("Webhook delivery failed permanently", "id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		return
+	}
+
+	delivery.NextAttempt = time.Now().Add(d.backoff(delivery.Attempts))
+	d.logger.Debug("Webhook delivery failed, will retry", "id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+}
+
+// backoff returns the delay before the retry following attempts failures
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.InitialBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+	return delay
+}
+
+// send posts a delivery to its target; any status other than 2xx fails it
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) error {
+	var target *config.WebhookTarget
+	for i := range d.targets {
+		if d.targets[i].Name == delivery.Target {
+			target = &d.targets[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("webhook target %s is no longer configured", delivery.Target)
+	}
+
+	body, err := json.Marshal(Payload{DeliveryID: delivery.ID, Event: delivery.Event})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mcp-memory-server-webhooks")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event.Type)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if target.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(target.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("target responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of a delivery body: the hex
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body, for
+// receivers checking deliveries
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Queue returns the pending deliveries, next due first
+func (d *Dispatcher) Queue() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.queue()
+}
+
+// DeadLetters returns the deliveries that failed every attempt, most recent
+// first
+func (d *Dispatcher) DeadLetters() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.deadLetters()
+}
+
+// Snapshot is the queue and dead letters a dispatcher last persisted
+type Snapshot struct {
+	Queue       []Delivery `json:"queue"`        // next due first
+	DeadLetters []Delivery `json:"dead_letters"` // most recent first
+}
+
+// ReadSnapshot reads the state a dispatcher persisted in dataDir, so other
+// processes such as the reporting dashboard can show it. It returns nil when
+// no dispatcher has run there.
+func ReadSnapshot(dataDir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, stateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook state: %w", err)
+	}
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook state: %w", err)
+	}
+	return &Snapshot{Queue: saved.queue(), DeadLetters: saved.deadLetters()}, nil
+}
+
+// queue returns a copy of the pending deliveries, next due first
+func (st *state) queue() []Delivery {
+	queue := copyDeliveries(st.Queue)
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].NextAttempt.Before(queue[j].NextAttempt) })
+	return queue
+}
+
+// deadLetters returns a copy of the dead letters, most recent first
+func (st *state) deadLetters() []Delivery {
+	dead := copyDeliveries(st.DeadLetters)
+	for i, j := 0, len(dead)-1; i < j; i, j = i+1, j-1 {
+		dead[i], dead[j] = dead[j], dead[i]
+	}
+	return dead
+}
+
+// Retry moves a dead letter back to the queue for a fresh set of attempts
+func (d *Dispatcher) Retry(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, delivery := range d.state.DeadLetters {
+		if delivery.ID != id {
+			continue
+		}
+		d.state.DeadLetters = append(d.state.DeadLetters[:i:i], d.state.DeadLetters[i+1:]...)
+		delivery.Attempts = 0
+		delivery.FailedAt = time.Time{}
+		delivery.NextAttempt = time.Now().UTC()
+		d.state.Queue = append(d.state.Queue, delivery)
+		d.save()
+
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	return fmt.Errorf("dead letter not found: %s", id)
+}
+
+// untilNextAttempt returns how long until the earliest pending delivery is
+// due, or an hour when nothing is pending
+func (d *Dispatcher) untilNextAttempt() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wait := time.Hour
+	for _, delivery := range d.state.Queue {
+		if until := time.Until(delivery.NextAttempt); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// removeQueued drops a delivery from the queue. The caller must hold d.mu.
+func (d *Dispatcher) removeQueued(id string) {
+	for i, queued := range d.state.Queue {
+		if queued.ID == id {
+			d.state.Queue = append(d.state.Queue[:i:i], d.state.Queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// save persists the queue, dead letters and cursor. The caller must hold
+// d.mu.
+func (d *Dispatcher) save() {
+	data, err := json.MarshalIndent(d.state, "", "  ")
+	if err != nil {
+		d.logger.WithError(err).Warn("Failed to marshal webhook state")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		d.logger.WithError(err).Warn("Failed to create webhook directory")
+		return
+	}
+	tempFile := d.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		d.logger.WithError(err).Warn("Failed to write webhook state")
+		return
+	}
+	if err := os.Rename(tempFile, d.path); err != nil {
+		os.Remove(tempFile)
+		d.logger.WithError(err).Warn("Failed to save webhook state")
+	}
+}
+
+// matches reports whether an event passes a target's filters
+func matches(target config.WebhookTarget, event memory.Event) bool {
+	if len(target.Events) > 0 && !containsFold(target.Events, event.Type) {
+		return false
+	}
+	if len(target.Categories) > 0 && !containsFold(target.Categories, event.Category) {
+		return false
+	}
+	if len(target.Tags) > 0 {
+		for _, tag := range event.Tags {
+			if containsFold(target.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyDeliveries(deliveries []*Delivery) []Delivery {
+	copied := make([]Delivery, len(deliveries))
+	for i, delivery := range deliveries {
+		copied[i] = *delivery
+	}
+	return copied
+}
@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-memory-server/internal/config"
+	"mcp-memory-server/internal/memory"
+	"mcp-memory-server/pkg/logger"
+)
+
+// receiver records signed deliveries and fails the first failures requests
+type receiver struct {
+	t        *testing.T
+	secret   string
+	mu       sync.Mutex
+	failures int
+	payloads []Payload
+	requests int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+	if rc.requests <= rc.failures {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if !Verify(rc.secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+		rc.t.Errorf("Invalid signature %q", r.Header.Get(HeaderSignature))
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rc.t.Errorf("Invalid payload %s: %v", body, err)
+	}
+	if r.Header.Get(HeaderDelivery) != payload.DeliveryID || r.Header.Get(HeaderEvent) != payload.Event.Type {
+		rc.t.Errorf("Headers do not match payload %+v: %v", payload, r.Header)
+	}
+	rc.payloads = append(rc.payloads, payload)
+}
+
+func (rc *receiver) received() []Payload {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Payload(nil), rc.payloads...)
+}
+
+func newWebhookTestStore(t *testing.T, dir string) *memory.Store {
+	t.Helper()
+
+	cfg := &config.StorageConfig{
+		MaxStorageSize: 10 * 1024 * 1024,
+		MaxFileSize:    1024 * 1024,
+	}
+	store, err := memory.NewStore(dir, cfg, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return store
+}
+
+func testWebhookConfig(url string, maxAttempts int) *config.WebhookConfig {
+	return &config.WebhookConfig{
+		Targets: []config.WebhookTarget{{
+			Name:       "ops",
+			URL:        url,
+			Secret:     "s3cret",
+			Categories: []string{"ops"},
+			Tags:       []string{"deploy", "incident"},
+		}},
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     40 * time.Millisecond,
+		Timeout:        time.Second,
+	}
+}
+
+// startDispatcher runs a dispatcher until the returned function is called
+func startDispatcher(t *testing.T, cfg *config.WebhookConfig, store *memory.Store, dir string) (*Dispatcher, func()) {
+	t.Helper()
+
+	dispatcher, err := NewDispatcher(cfg, store.Events(), dir, logger.New("error", "text"))
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := dispatcher.Run(ctx); err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	}()
+	return dispatcher, func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliversMatchingEventsWithRetries(t *testing.T) {
+	dir, err := os.MkdirTemp("", "webhook-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rc := &receiver{t: t, secret: "s3cret", failures: 2}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	store := newWebhookTestStore(t, dir)
+	defer store.Close()
+	dispatcher, stop := startDispatcher(t, testWebhookConfig(ts.URL, 5), store, dir)
+	defer stop()
+
+	store.Store("Deploys need two approvals", "", "billing", []string{"deploy"}, nil)
+	store.Store("Dashboards live in Grafana", "", "ops", []string{"monitoring"}, nil)
+	deploy, _ := store.Store("Roll back failed deploys first", "", "ops", []string{"Deploy"}, nil)
+
+	waitFor(t, "delivery", func() bool { return len(rc.received()) == 1 })
+	payload := rc.received()[0]
+	if payload.Event.ID != deploy.ID || payload.Event.Type != memory.EventCreated || payload.DeliveryID != "3-ops" {
+		t.Errorf("Unexpected delivery %+v", payload)
+	}
+	waitFor(t, "an empty queue", func() bool { return len(dispatcher.Queue()) == 0 })
+	rc.mu.Lock()
+	requests := rc.requests
+	rc.mu.Unlock()
+	if requests != 3 || len(dispatcher.DeadLetters()) != 0 {
+		t.Errorf("Delivery should succeed on the third attempt, got %d requests", requests)
+	}
+}
+
+func TestDeadLettersAndRetry(t *testing.T) {
+	dir, err := os.MkdirTemp("", "webhook-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rc := &receiver{t: t, secret: "s3cret", failures: 2}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	store := newWebhookTestStore(t, dir)
+	defer store.Close()
+	dispatcher, stop := startDispatcher(t, testWebhookConfig(ts.URL, 2), store, dir)
+
+	memory, _ := store.Store("Incident reviews happen within a week", "", "ops", []string{"incident"}, nil)
+	waitFor(t, "a dead letter", func() bool { return len(dispatcher.DeadLetters()) == 1 })
+	dead := dispatcher.DeadLetters()[0]
+	if dead.Attempts != 2 || dead.Event.ID != memory.ID || dead.LastError == "" || dead.FailedAt.IsZero() {
+		t.Errorf("Unexpected dead letter %+v", dead)
+	}
+
+	// Other processes read the dead letters from the persisted state
+	snapshot, err := ReadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if snapshot == nil || len(snapshot.DeadLetters) != 1 || snapshot.DeadLetters[0].ID != dead.ID || len(snapshot.Queue) != 0 {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+	if snapshot, err := ReadSnapshot(t.TempDir()); err != nil || snapshot != nil {
+		t.Errorf("Expected no snapshot without a dispatcher, got %+v, %v", snapshot, err)
+	}
+
+	// Dead letters survive a restart and can be retried
+	stop()
+	dispatcher, stop = startDispatcher(t, testWebhookConfig(ts.URL, 2), store, dir)
+	defer stop()
+	if len(dispatcher.DeadLetters()) != 1 {
+		t.Fatalf("Dead letters should be persisted")
+	}
+	if err := dispatcher.Retry("missing"); err == nil {
+		t.Error("Retrying an unknown dead letter should fail")
+	}
+	if err := dispatcher.Retry(dead.ID); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	waitFor(t, "the retried delivery", func() bool { return len(rc.received()) == 1 })
+	if len(dispatcher.DeadLetters()) != 0 {
+		t.Error("A retried dead letter should leave the list")
+	}
+}
+
+func TestCatchesUpOnEventsWhileStopped(t *testing.T) {
+	dir, err := os.MkdirTemp("", "webhook-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rc := &receiver{t: t, secret: "s3cret"}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+
+	store := newWebhookTestStore(t, dir)
+	defer store.Close()
+
+	// Events before the first start are not replayed
+	store.Store("Old deploy notes", "", "ops", []string{"deploy"}, nil)
+	_, stop := startDispatcher(t, testWebhookConfig(ts.URL, 3), store, dir)
+	stop()
+
+	missed, _ := store.Store("Deploy windows are Tuesday and Thursday", "", "ops", []string{"deploy"}, nil)
+	_, stop = startDispatcher(t, testWebhookConfig(ts.URL, 3), store, dir)
+	defer stop()
+
+	waitFor(t, "the missed event", func() bool { return len(rc.received()) == 1 })
+	if got := rc.received()[0].Event.ID; got != missed.ID {
+		t.Errorf("Delivered %s, want the event published while stopped %s", got, missed.ID)
+	}
+}
+
+func TestNewDispatcherValidatesTargets(t *testing.T) {
+	bus := memory.NewEventBus(0)
+	cfg := testWebhookConfig("http://localhost", 3)
+	cfg.Targets[0].Events = []string{"updated"}
+	if _, err := NewDispatcher(cfg, bus, t.TempDir(), logger.New("error", "text")); err == nil {
+		t.Error("Unknown event types should be rejected")
+	}
+	cfg = testWebhookConfig("ftp://localhost", 3)
+	if _, err := NewDispatcher(cfg, bus, t.TempDir(), logger.New("error", "text")); err == nil {
+		t.Error("Non-HTTP URLs should be rejected")
+	}
+}
@@ -0,0 +1,64 @@
+// pkg/keywords/analyzer.go
+package keywords
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Analyzer turns text into index terms: it splits text into words,
+// normalizes them, drops stop words and optionally stems them. The same
+// analyzer must be used for indexing and querying so terms line up.
+type Analyzer struct {
+	stopWords map[string]bool
+	stem      bool
+}
+
+// NewAnalyzer creates an English analyzer with stemming enabled
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{
+		stopWords: makeStopWords(),
+		stem:      true,
+	}
+}
+
+// NewAnalyzerWithStemming creates an English analyzer with stemming switched on or off
+func NewAnalyzerWithStemming(stem bool) *Analyzer {
+	a := NewAnalyzer()
+	a.stem = stem
+	return a
+}
+
+// Tokens splits text into normalized words, dropping stop words and
+// single characters. Duplicates are kept in order of appearance.
+func (a *Analyzer) Tokens(text string) []string {
+	words := strings.FieldsFunc(Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if len(word) < 2 || a.stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// Terms returns the distinct terms of text in order of first appearance
+func (a *Analyzer) Terms(text string) []string {
+	tokens := a.Tokens(text)
+	seen := make(map[string]bool, len(tokens))
+	terms := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if a.stem {
+			token = Stem(token)
+		}
+		if !seen[token] {
+			seen[token] = true
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
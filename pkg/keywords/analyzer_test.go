@@ -0,0 +1,149 @@
+// pkg/keywords/analyzer_test.go
+package keywords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"ties":           "ti",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"troubled":       "troubl",
+		"sized":          "size",
+		"hopping":        "hop",
+		"tanned":         "tan",
+		"falling":        "fall",
+		"hissing":        "hiss",
+		"failing":        "fail",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"effective":      "effect",
+		"adjustment":     "adjust",
+		"controllable":   "control",
+		"deploying":      "deploy",
+		"deployed":       "deploy",
+		"deployment":     "deploy",
+		"deployments":    "deploy",
+		"kubernetes":     "kubernet",
+		"go":             "go",
+		"k8s":            "k8s",
+	}
+
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Café":       "cafe",
+		"CAFÉ":       "cafe",
+		"café":      "cafe",
+		"Straße":     "strasse",
+		"Ærøskøbing": "aeroskobing",
+		"ﬁle":        "file",
+		"ＡＢＣ１２３":     "abc123",
+		"Go 1.21":    "go 1.21",
+	}
+
+	for input, want := range tests {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestAnalyzerTerms(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	got := analyzer.Terms("Deploying the Kubernetes deployments, and the café's deploy script")
+	want := []string{"deploy", "kubernet", "cafe", "script"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() = %v, want %v", got, want)
+	}
+
+	plain := NewAnalyzerWithStemming(false)
+	got = plain.Terms("Deploying deployments")
+	want = []string{"deploying", "deployments"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() without stemming = %v, want %v", got, want)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"kitten", "sitting", 3},
+		{"kubernetes", "kuberentes", 1}, // adjacent transposition
+		{"flaw", "lawn", 2},
+		{"abc", "", 3},
+		{"déploy", "deploy", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if !WithinDistance(tt.a, tt.b, tt.want) {
+			t.Errorf("WithinDistance(%q, %q, %d) = false", tt.a, tt.b, tt.want)
+		}
+		if tt.want > 0 && WithinDistance(tt.a, tt.b, tt.want-1) {
+			t.Errorf("WithinDistance(%q, %q, %d) = true", tt.a, tt.b, tt.want-1)
+		}
+	}
+}
+
+func TestNGramIndexLookup(t *testing.T) {
+	idx := NewNGramIndex()
+	for _, term := range []string{"kubernet", "deploy", "docker", "postgr", "redi", "go"} {
+		idx.Add(term)
+	}
+
+	matches := idx.Lookup("kuberent", 1)
+	if len(matches) != 1 || matches[0].Term != "kubernet" || matches[0].Distance != 1 {
+		t.Errorf("Lookup(kuberent) = %v", matches)
+	}
+
+	if matches := idx.Lookup("deploy", 2); len(matches) == 0 || matches[0].Term != "deploy" || matches[0].Distance != 0 {
+		t.Errorf("Expected an exact match first, got %v", matches)
+	}
+
+	if matches := idx.Lookup("dokcer", 0); len(matches) != 0 {
+		t.Errorf("Expected no matches without fuzziness, got %v", matches)
+	}
+
+	idx.Remove("kubernet")
+	if matches := idx.Lookup("kuberent", 1); len(matches) != 0 {
+		t.Errorf("Expected removed term to be gone, got %v", matches)
+	}
+	if idx.Len() != 5 {
+		t.Errorf("Expected 5 terms, got %d", idx.Len())
+	}
+}
+
+func TestAutoFuzziness(t *testing.T) {
+	tests := map[string]int{"go": 0, "api": 0, "redis": 1, "docker": 1, "kuberentes": 2}
+	for term, want := range tests {
+		if got := AutoFuzziness(term); got != want {
+			t.Errorf("AutoFuzziness(%q) = %d, want %d", term, got, want)
+		}
+	}
+}
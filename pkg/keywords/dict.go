@@ -0,0 +1,208 @@
+// pkg/keywords/dict.go
+package keywords
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TermDict is a dictionary of terms supporting exact, prefix and substring
+// lookups without scanning every term. Terms are kept in a sorted slice for
+// prefix lookups and indexed by their trigrams for substring lookups.
+//
+// Additions and removals are cheap: they update the trigram postings and are
+// merged into the sorted slice on the next prefix lookup, so bulk loading
+// does not pay for keeping the slice sorted after every insert.
+type TermDict struct {
+	mu      sync.RWMutex
+	terms   map[string]struct{}
+	sorted  []string                       // sorted terms as of the last merge; may hold removed terms
+	pending []string                       // terms added since the last merge
+	dirty   bool                           // sorted needs merging with pending or pruning removed terms
+	grams   map[string]map[string]struct{} // trigram -> terms containing it
+}
+
+// NewTermDict creates an empty term dictionary
+func NewTermDict() *TermDict {
+	return &TermDict{
+		terms: make(map[string]struct{}),
+		grams: make(map[string]map[string]struct{}),
+	}
+}
+
+// Add inserts a term
+func (d *TermDict) Add(term string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.terms[term]; exists {
+		return
+	}
+	d.terms[term] = struct{}{}
+	d.pending = append(d.pending, term)
+	d.dirty = true
+	for _, gram := range trigrams(term) {
+		set := d.grams[gram]
+		if set == nil {
+			set = make(map[string]struct{})
+			d.grams[gram] = set
+		}
+		set[term] = struct{}{}
+	}
+}
+
+// Remove deletes a term
+func (d *TermDict) Remove(term string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.terms[term]; !exists {
+		return
+	}
+	delete(d.terms, term)
+	d.dirty = true
+	for _, gram := range trigrams(term) {
+		if set := d.grams[gram]; set != nil {
+			delete(set, term)
+			if len(set) == 0 {
+				delete(d.grams, gram)
+			}
+		}
+	}
+}
+
+// Has reports whether term is in the dictionary
+func (d *TermDict) Has(term string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, exists := d.terms[term]
+	return exists
+}
+
+// Len returns the number of terms
+func (d *TermDict) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.terms)
+}
+
+// Prefix returns the terms starting with prefix in sorted order
+func (d *TermDict) Prefix(prefix string) []string {
+	d.mu.RLock()
+	if d.dirty {
+		d.mu.RUnlock()
+		d.merge()
+		d.mu.RLock()
+	}
+	defer d.mu.RUnlock()
+
+	var matches []string
+	for i := sort.SearchStrings(d.sorted, prefix); i < len(d.sorted); i++ {
+		term := d.sorted[i]
+		if !strings.HasPrefix(term, prefix) {
+			break
+		}
+		if _, exists := d.terms[term]; exists {
+			matches = append(matches, term)
+		}
+	}
+	return matches
+}
+
+// Substring returns the terms containing sub in sorted order. Substrings of
+// three or more characters are answered from the trigram postings; shorter
+// ones have no trigram to go by and check every term.
+func (d *TermDict) Substring(sub string) []string {
+	grams := trigrams(sub)
+	if len(grams) == 0 {
+		d.mu.RLock()
+		var matches []string
+		for term := range d.terms {
+			if strings.Contains(term, sub) {
+				matches = append(matches, term)
+			}
+		}
+		d.mu.RUnlock()
+		sort.Strings(matches)
+		return matches
+	}
+
+	d.mu.RLock()
+	// Start from the rarest trigram and check the rest of the substring directly
+	var smallest map[string]struct{}
+	for _, gram := range grams {
+		set := d.grams[gram]
+		if len(set) == 0 {
+			d.mu.RUnlock()
+			return nil
+		}
+		if smallest == nil || len(set) < len(smallest) {
+			smallest = set
+		}
+	}
+	var matches []string
+	for term := range smallest {
+		if strings.Contains(term, sub) {
+			matches = append(matches, term)
+		}
+	}
+	d.mu.RUnlock()
+
+	sort.Strings(matches)
+	return matches
+}
+
+// merge folds pending terms into the sorted slice and drops removed ones
+func (d *TermDict) merge() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return
+	}
+
+	sort.Strings(d.pending)
+	merged := make([]string, 0, len(d.terms))
+	i, j := 0, 0
+	for i < len(d.sorted) || j < len(d.pending) {
+		var term string
+		if j >= len(d.pending) || (i < len(d.sorted) && d.sorted[i] <= d.pending[j]) {
+			term = d.sorted[i]
+			i++
+		} else {
+			term = d.pending[j]
+			j++
+		}
+		if _, exists := d.terms[term]; !exists {
+			continue
+		}
+		// A term removed and added again can be in both lists
+		if n := len(merged); n > 0 && merged[n-1] == term {
+			continue
+		}
+		merged = append(merged, term)
+	}
+
+	d.sorted = merged
+	d.pending = nil
+	d.dirty = false
+}
+
+// trigrams returns the distinct unpadded trigrams of s; strings shorter than
+// three characters have none
+func trigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < gramSize {
+		return nil
+	}
+	seen := make(map[string]bool)
+	grams := make([]string, 0, len(runes)-gramSize+1)
+	for i := 0; i+gramSize <= len(runes); i++ {
+		gram := string(runes[i : i+gramSize])
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
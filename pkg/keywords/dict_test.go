@@ -0,0 +1,47 @@
+// pkg/keywords/dict_test.go
+package keywords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTermDictLookups(t *testing.T) {
+	d := NewTermDict()
+	for _, term := range []string{"kubernetes", "kube", "cube", "go", "golang", "mongo", "postgres"} {
+		d.Add(term)
+	}
+
+	if got, want := d.Prefix("ku"), []string{"kube", "kubernetes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefix(ku) = %v, want %v", got, want)
+	}
+	if got, want := d.Substring("ube"), []string{"cube", "kube", "kubernetes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Substring(ube) = %v, want %v", got, want)
+	}
+	// Too short for a trigram
+	if got, want := d.Substring("go"), []string{"go", "golang", "mongo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Substring(go) = %v, want %v", got, want)
+	}
+	if got := d.Substring("xyz"); len(got) != 0 {
+		t.Errorf("Substring(xyz) = %v, want none", got)
+	}
+
+	d.Remove("kube")
+	d.Add("kubectl")
+	if got, want := d.Prefix("kube"), []string{"kubectl", "kubernetes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefix(kube) after changes = %v, want %v", got, want)
+	}
+	if got, want := d.Substring("ube"), []string{"cube", "kubectl", "kubernetes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Substring(ube) after changes = %v, want %v", got, want)
+	}
+
+	// Removing and re-adding before the next merge keeps a single copy
+	d.Remove("golang")
+	d.Add("golang")
+	if got, want := d.Prefix("go"), []string{"go", "golang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefix(go) = %v, want %v", got, want)
+	}
+	if d.Len() != 7 || !d.Has("kubectl") || d.Has("kube") {
+		t.Errorf("unexpected dictionary state: len %d", d.Len())
+	}
+}
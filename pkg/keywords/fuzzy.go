@@ -0,0 +1,236 @@
+// pkg/keywords/fuzzy.go
+package keywords
+
+import (
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// gramSize is the n-gram length used by NGramIndex
+const gramSize = 3
+
+// AutoFuzziness returns the number of typos tolerated for a term: none for
+// short terms, one for medium terms and two for long ones
+func AutoFuzziness(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Distance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent characters needed to turn one into the other
+func Distance(a, b string) int {
+	d, _ := boundedDistance([]rune(a), []rune(b), -1)
+	return d
+}
+
+// WithinDistance reports whether a and b are at most maxEdits edits apart. It
+// stops early once the distance is known to exceed maxEdits.
+func WithinDistance(a, b string, maxEdits int) bool {
+	_, ok := boundedDistance([]rune(a), []rune(b), maxEdits)
+	return ok
+}
+
+// boundedDistance computes the OSA distance. With maxEdits >= 0 it gives up as
+// soon as every alignment needs more than maxEdits edits.
+func boundedDistance(a, b []rune, maxEdits int) (int, bool) {
+	if maxEdits >= 0 && abs(len(a)-len(b)) > maxEdits {
+		return maxEdits + 1, false
+	}
+
+	// Three rolling rows: two back for transpositions, previous and current
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < curr[j] {
+				curr[j] = prev2[j-2] + 1
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if maxEdits >= 0 && rowMin > maxEdits {
+			return maxEdits + 1, false
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	d := prev[len(b)]
+	return d, maxEdits < 0 || d <= maxEdits
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// FuzzyMatch is a dictionary term within the requested edit distance
+type FuzzyMatch struct {
+	Term     string
+	Distance int
+}
+
+// NGramIndex is a dictionary of terms indexed by their character trigrams.
+// Lookup uses the trigrams to find candidates sharing enough of them with
+// the query and verifies each candidate with a bounded edit distance, so
+// typo-tolerant lookups do not have to compare against every term.
+type NGramIndex struct {
+	mu    sync.RWMutex
+	grams map[string]map[string]struct{} // trigram -> terms
+	terms map[string]struct{}
+}
+
+// NewNGramIndex creates an empty n-gram index
+func NewNGramIndex() *NGramIndex {
+	return &NGramIndex{
+		grams: make(map[string]map[string]struct{}),
+		terms: make(map[string]struct{}),
+	}
+}
+
+// Add inserts a term into the dictionary
+func (idx *NGramIndex) Add(term string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, exists := idx.terms[term]; exists {
+		return
+	}
+	idx.terms[term] = struct{}{}
+	for _, gram := range ngrams(term) {
+		set := idx.grams[gram]
+		if set == nil {
+			set = make(map[string]struct{})
+			idx.grams[gram] = set
+		}
+		set[term] = struct{}{}
+	}
+}
+
+// Remove deletes a term from the dictionary
+func (idx *NGramIndex) Remove(term string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, exists := idx.terms[term]; !exists {
+		return
+	}
+	delete(idx.terms, term)
+	for _, gram := range ngrams(term) {
+		if set := idx.grams[gram]; set != nil {
+			delete(set, term)
+			if len(set) == 0 {
+				delete(idx.grams, gram)
+			}
+		}
+	}
+}
+
+// Len returns the number of terms in the dictionary
+func (idx *NGramIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.terms)
+}
+
+// Lookup returns the terms within maxEdits of term, closest first. An exact
+// match is included with distance 0.
+func (idx *NGramIndex) Lookup(term string, maxEdits int) []FuzzyMatch {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if maxEdits <= 0 {
+		if _, ok := idx.terms[term]; ok {
+			return []FuzzyMatch{{Term: term}}
+		}
+		return nil
+	}
+
+	// An edit touches at most gramSize grams (one more for a transposition),
+	// so a match must share at least len(grams)-(gramSize+1)*maxEdits of
+	// them. Very short terms would need no shared gram at all; require one
+	// to keep lookups bounded.
+	grams := ngrams(term)
+	required := len(grams) - (gramSize+1)*maxEdits
+	if required < 1 {
+		required = 1
+	}
+
+	shared := make(map[string]int)
+	for _, gram := range grams {
+		for candidate := range idx.grams[gram] {
+			shared[candidate]++
+		}
+	}
+
+	query := []rune(term)
+	var matches []FuzzyMatch
+	for candidate, count := range shared {
+		if count < required {
+			continue
+		}
+		if d, ok := boundedDistance(query, []rune(candidate), maxEdits); ok {
+			matches = append(matches, FuzzyMatch{Term: candidate, Distance: d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Term < matches[j].Term
+	})
+	return matches
+}
+
+// ngrams returns the distinct trigrams of a term padded with boundary
+// markers, so "go" yields "$go", "go$"
+func ngrams(term string) []string {
+	runes := append(append([]rune{'$'}, []rune(term)...), '$')
+	if len(runes) < gramSize {
+		return []string{string(runes)}
+	}
+
+	seen := make(map[string]bool)
+	grams := make([]string, 0, len(runes)-gramSize+1)
+	for i := 0; i+gramSize <= len(runes); i++ {
+		gram := string(runes[i : i+gramSize])
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
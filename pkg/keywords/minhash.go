@@ -0,0 +1,132 @@
+// pkg/keywords/minhash.go
+package keywords
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// MinHasher computes MinHash signatures over word shingles. The fraction of
+// positions where two signatures agree estimates the Jaccard similarity of
+// the two texts' shingle sets, so near-duplicates can be found by comparing
+// a few dozen integers instead of whole texts.
+type MinHasher struct {
+	shingleSize int
+	seeds       []uint64
+}
+
+// Default MinHash parameters
+const (
+	DefaultSignatureSize = 64
+	DefaultShingleSize   = 2
+)
+
+// NewMinHasher creates a MinHasher producing signatures of signatureSize
+// hashes over shingles of shingleSize consecutive words
+func NewMinHasher(signatureSize, shingleSize int) *MinHasher {
+	if signatureSize <= 0 {
+		signatureSize = DefaultSignatureSize
+	}
+	if shingleSize <= 0 {
+		shingleSize = DefaultShingleSize
+	}
+
+	// Fixed seeds keep signatures comparable across runs
+	seeds := make([]uint64, signatureSize)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state = mix64(state + uint64(i))
+		seeds[i] = state
+	}
+	return &MinHasher{shingleSize: shingleSize, seeds: seeds}
+}
+
+// Signature returns the MinHash signature of words, or nil when there are
+// no words. Texts shorter than a shingle are hashed as a single shingle.
+func (h *MinHasher) Signature(words []string) []uint32 {
+	if len(words) == 0 {
+		return nil
+	}
+
+	signature := make([]uint32, len(h.seeds))
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+
+	size := h.shingleSize
+	if len(words) < size {
+		size = len(words)
+	}
+	seen := make(map[uint64]bool)
+	for i := 0; i+size <= len(words); i++ {
+		shingle := hashShingle(words[i : i+size])
+		if seen[shingle] {
+			continue
+		}
+		seen[shingle] = true
+		for j, seed := range h.seeds {
+			if value := uint32(mix64(shingle^seed) >> 32); value < signature[j] {
+				signature[j] = value
+			}
+		}
+	}
+	return signature
+}
+
+// Similarity estimates the Jaccard similarity of the texts behind two
+// signatures made by the same MinHasher
+func Similarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
+
+// Bands splits a signature into bands and returns one key per band for
+// locality-sensitive hashing: similar signatures are likely to share at
+// least one key, dissimilar ones unlikely to share any
+func Bands(signature []uint32, bands int) []string {
+	if len(signature) == 0 || bands <= 0 {
+		return nil
+	}
+	rows := len(signature) / bands
+	if rows == 0 {
+		rows, bands = 1, len(signature)
+	}
+
+	keys := make([]string, bands)
+	buf := make([]byte, 4)
+	for band := 0; band < bands; band++ {
+		hasher := fnv.New64a()
+		for _, value := range signature[band*rows : (band+1)*rows] {
+			binary.LittleEndian.PutUint32(buf, value)
+			hasher.Write(buf)
+		}
+		keys[band] = strconv.Itoa(band) + ":" + strconv.FormatUint(hasher.Sum64(), 16)
+	}
+	return keys
+}
+
+func hashShingle(words []string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(strings.Join(words, " ")))
+	return hasher.Sum64()
+}
+
+// mix64 is the splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,55 @@
+// pkg/keywords/minhash_test.go
+package keywords
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinHashSimilarity(t *testing.T) {
+	h := NewMinHasher(DefaultSignatureSize, DefaultShingleSize)
+	words := func(text string) []string { return strings.Fields(text) }
+
+	original := h.Signature(words("use connection pooling with pgbouncer in front of the postgres primary to cap open connections"))
+	edited := h.Signature(words("use connection pooling with pgbouncer in front of the postgres primary to limit open connections"))
+	unrelated := h.Signature(words("the frontend build uses vite and deploys static assets to the cdn bucket"))
+
+	if got := Similarity(original, original); got != 1 {
+		t.Errorf("Similarity with itself = %v, want 1", got)
+	}
+	if got := Similarity(original, edited); got < 0.6 {
+		t.Errorf("Similarity of a one-word edit = %v, want at least 0.6", got)
+	}
+	if got := Similarity(original, unrelated); got > 0.2 {
+		t.Errorf("Similarity of unrelated texts = %v, want at most 0.2", got)
+	}
+	if got := h.Signature(nil); got != nil {
+		t.Errorf("Signature of no words = %v, want nil", got)
+	}
+
+	// Short texts still get a signature
+	if got := Similarity(h.Signature(words("postgres")), h.Signature(words("postgres"))); got != 1 {
+		t.Errorf("Similarity of identical single words = %v, want 1", got)
+	}
+
+	// Identical signatures share every band; unrelated ones share none
+	shared := func(a, b []uint32) int {
+		keys := make(map[string]bool)
+		for _, key := range Bands(a, 16) {
+			keys[key] = true
+		}
+		n := 0
+		for _, key := range Bands(b, 16) {
+			if keys[key] {
+				n++
+			}
+		}
+		return n
+	}
+	if got := shared(original, original); got != 16 {
+		t.Errorf("Identical signatures share %d bands, want 16", got)
+	}
+	if got := shared(original, unrelated); got != 0 {
+		t.Errorf("Unrelated signatures share %d bands, want 0", got)
+	}
+}
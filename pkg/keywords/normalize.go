@@ -0,0 +1,80 @@
+// pkg/keywords/normalize.go
+package keywords
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldTable maps precomposed and compatibility characters to their ASCII
+// base letters, covering Latin-1 Supplement and Latin Extended-A. Together
+// with dropping combining marks this approximates NFKD followed by accent
+// removal without pulling in golang.org/x/text.
+var foldTable = buildFoldTable(map[string]string{
+	"a":  "àáâãäåāăą",
+	"c":  "çćĉċč",
+	"d":  "ďđð",
+	"e":  "èéêëēĕėęě",
+	"g":  "ĝğġģ",
+	"h":  "ĥħ",
+	"i":  "ìíîïĩīĭįı",
+	"j":  "ĵ",
+	"k":  "ķ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉ",
+	"o":  "òóôõöøōŏő",
+	"r":  "ŕŗř",
+	"s":  "śŝşš",
+	"t":  "ţťŧ",
+	"u":  "ùúûüũūŭůűų",
+	"w":  "ŵ",
+	"y":  "ýÿŷ",
+	"z":  "źżž",
+	"ae": "æ",
+	"oe": "œ",
+	"ss": "ß",
+	"th": "þ",
+	"ij": "ĳ",
+	"ff": "ﬀ",
+	"fi": "ﬁ",
+	"fl": "ﬂ",
+})
+
+func buildFoldTable(groups map[string]string) map[rune]string {
+	table := make(map[rune]string)
+	for base, chars := range groups {
+		for _, r := range chars {
+			table[r] = base
+		}
+	}
+	return table
+}
+
+// Normalize lowercases text, folds accented and compatibility characters to
+// their ASCII equivalents and removes combining marks, so "Café", "CAFE" and
+// "café" all normalize to "cafe"
+func Normalize(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for _, r := range text {
+		switch {
+		case r <= unicode.MaxASCII:
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left over from decomposed input
+		case r >= 0xFF01 && r <= 0xFF5E:
+			// Fullwidth ASCII variants
+			b.WriteRune(unicode.ToLower(r - 0xFF01 + '!'))
+		default:
+			lower := unicode.ToLower(r)
+			if folded, ok := foldTable[lower]; ok {
+				b.WriteString(folded)
+			} else {
+				b.WriteRune(lower)
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,315 @@
+// pkg/keywords/stem.go
+package keywords
+
+// Stem reduces an English word to its stem using the Porter stemming
+// algorithm with the Snowball treatment of a final y, so "deploying",
+// "deployed" and "deployment" all become "deploy". The word must already be
+// lowercase; words with characters outside a-z and words shorter than three
+// letters are returned unchanged.
+func Stem(word string) string {
+	if len(word) < 3 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	p := &porterStemmer{b: []byte(word), k: len(word) - 1}
+	p.step1ab()
+	if p.k > 0 {
+		p.step1c()
+		p.step2()
+		p.step3()
+		p.step4()
+		p.step5()
+	}
+	return string(p.b[:p.k+1])
+}
+
+// porterStemmer holds the word being stemmed in b[0..k]; j marks the end of
+// the stem when testing suffixes
+type porterStemmer struct {
+	b []byte
+	k int
+	j int
+}
+
+// cons reports whether b[i] is a consonant
+func (p *porterStemmer) cons(i int) bool {
+	switch p.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !p.cons(i - 1)
+	}
+	return true
+}
+
+// m measures the number of vowel-consonant sequences in b[0..j]
+func (p *porterStemmer) m() int {
+	n := 0
+	i := 0
+	for {
+		if i > p.j {
+			return n
+		}
+		if !p.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > p.j {
+				return n
+			}
+			if p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > p.j {
+				return n
+			}
+			if !p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem reports whether b[0..j] contains a vowel
+func (p *porterStemmer) vowelInStem() bool {
+	for i := 0; i <= p.j; i++ {
+		if !p.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublec reports whether b[j-1..j] is a double consonant
+func (p *porterStemmer) doublec(j int) bool {
+	if j < 1 || p.b[j] != p.b[j-1] {
+		return false
+	}
+	return p.cons(j)
+}
+
+// cvc reports whether b[i-2..i] is consonant-vowel-consonant and the last
+// consonant is not w, x or y, as in "hop" but not "snow"
+func (p *porterStemmer) cvc(i int) bool {
+	if i < 2 || !p.cons(i) || p.cons(i-1) || !p.cons(i-2) {
+		return false
+	}
+	switch p.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends reports whether b[0..k] ends with s, setting j to the end of the stem
+func (p *porterStemmer) ends(s string) bool {
+	l := len(s)
+	if l > p.k+1 {
+		return false
+	}
+	if string(p.b[p.k-l+1:p.k+1]) != s {
+		return false
+	}
+	p.j = p.k - l
+	return true
+}
+
+// setto replaces b[j+1..k] with s
+func (p *porterStemmer) setto(s string) {
+	p.b = append(p.b[:p.j+1], s...)
+	p.k = p.j + len(s)
+}
+
+// r replaces the suffix with s when the stem has a non-zero measure
+func (p *porterStemmer) r(s string) {
+	if p.m() > 0 {
+		p.setto(s)
+	}
+}
+
+// step1ab removes plurals and -ed or -ing
+func (p *porterStemmer) step1ab() {
+	if p.b[p.k] == 's' {
+		switch {
+		case p.ends("sses"):
+			p.k -= 2
+		case p.ends("ies"):
+			p.setto("i")
+		case p.b[p.k-1] != 's':
+			p.k--
+		}
+	}
+
+	if p.ends("eed") {
+		if p.m() > 0 {
+			p.k--
+		}
+	} else if (p.ends("ed") || p.ends("ing")) && p.vowelInStem() {
+		p.k = p.j
+		switch {
+		case p.ends("at"):
+			p.setto("ate")
+		case p.ends("bl"):
+			p.setto("ble")
+		case p.ends("iz"):
+			p.setto("ize")
+		case p.doublec(p.k):
+			p.k--
+			switch p.b[p.k] {
+			case 'l', 's', 'z':
+				p.k++
+			}
+		default:
+			p.j = p.k
+			if p.m() == 1 && p.cvc(p.k) {
+				p.setto("e")
+			}
+		}
+	}
+}
+
+// step1c turns a terminal y into i after a consonant that is not the first
+// letter. This is the Snowball (Porter2) rule; the original vowel-in-stem
+// test stems "deploying" to "deploi" but "deployment" to "deploy".
+func (p *porterStemmer) step1c() {
+	if p.ends("y") && p.j > 0 && p.cons(p.j) {
+		p.b[p.k] = 'i'
+	}
+}
+
+// step2 maps double suffixes to single ones, e.g. -ization to -ize
+func (p *porterStemmer) step2() {
+	var rules [][2]string
+	switch p.b[p.k-1] {
+	case 'a':
+		rules = [][2]string{{"ational", "ate"}, {"tional", "tion"}}
+	case 'c':
+		rules = [][2]string{{"enci", "ence"}, {"anci", "ance"}}
+	case 'e':
+		rules = [][2]string{{"izer", "ize"}}
+	case 'l':
+		rules = [][2]string{{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}}
+	case 'o':
+		rules = [][2]string{{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}}
+	case 's':
+		rules = [][2]string{{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}}
+	case 't':
+		rules = [][2]string{{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}}
+	case 'g':
+		rules = [][2]string{{"logi", "log"}}
+	}
+	p.applyRules(rules)
+}
+
+// step3 handles -ic-, -full, -ness and similar suffixes
+func (p *porterStemmer) step3() {
+	var rules [][2]string
+	switch p.b[p.k] {
+	case 'e':
+		rules = [][2]string{{"icate", "ic"}, {"ative", ""}, {"alize", "al"}}
+	case 'i':
+		rules = [][2]string{{"iciti", "ic"}}
+	case 'l':
+		rules = [][2]string{{"ical", "ic"}, {"ful", ""}}
+	case 's':
+		rules = [][2]string{{"ness", ""}}
+	}
+	p.applyRules(rules)
+}
+
+// applyRules replaces the first matching suffix. Matching stops at the first
+// suffix found even if the measure condition prevents the replacement.
+func (p *porterStemmer) applyRules(rules [][2]string) {
+	for _, rule := range rules {
+		if p.ends(rule[0]) {
+			p.r(rule[1])
+			return
+		}
+	}
+}
+
+// step4 removes -ant, -ence and similar suffixes from stems with measure > 1
+func (p *porterStemmer) step4() {
+	var suffixes []string
+	switch p.b[p.k-1] {
+	case 'a':
+		suffixes = []string{"al"}
+	case 'c':
+		suffixes = []string{"ance", "ence"}
+	case 'e':
+		suffixes = []string{"er"}
+	case 'i':
+		suffixes = []string{"ic"}
+	case 'l':
+		suffixes = []string{"able", "ible"}
+	case 'n':
+		suffixes = []string{"ant", "ement", "ment", "ent"}
+	case 'o':
+		if p.ends("ion") && p.j >= 0 && (p.b[p.j] == 's' || p.b[p.j] == 't') {
+			break
+		}
+		suffixes = []string{"ou"}
+	case 's':
+		suffixes = []string{"ism"}
+	case 't':
+		suffixes = []string{"ate", "iti"}
+	case 'u':
+		suffixes = []string{"ous"}
+	case 'v':
+		suffixes = []string{"ive"}
+	case 'z':
+		suffixes = []string{"ize"}
+	default:
+		return
+	}
+
+	if suffixes != nil {
+		found := false
+		for _, suffix := range suffixes {
+			if p.ends(suffix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+
+	if p.m() > 1 {
+		p.k = p.j
+	}
+}
+
+// step5 removes a final -e and reduces -ll to -l on longer stems
+func (p *porterStemmer) step5() {
+	p.j = p.k
+	if p.b[p.k] == 'e' {
+		a := p.m()
+		if a > 1 || (a == 1 && !p.cvc(p.k-1)) {
+			p.k--
+		}
+	}
+	if p.b[p.k] == 'l' && p.doublec(p.k) && p.m() > 1 {
+		p.k--
+	}
+}